@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"explorer451/internal/aws"
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+	"explorer451/internal/logger"
+	"explorer451/internal/store"
+
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+// checkTimeout bounds the connectivity checks made by check-config
+const checkTimeout = 30 * time.Second
+
+// checkConfig validates the configuration, including unknown keys, and verifies that
+// the services it points at are reachable. It returns the process exit code.
+func checkConfig() int {
+	cfg, err := config.Check()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	awsCfg, err := aws.LoadConfig(ctx, cfg.AWS.Region)
+	if err != nil {
+		fmt.Printf("aws: failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	bucketClients, err := aws.NewBucketClients(ctx, awsCfg, cfg.AWS.Buckets)
+	if err != nil {
+		fmt.Printf("aws.buckets: %v\n", err)
+		return 1
+	}
+
+	st, err := store.Open(ctx, cfg.Store)
+	if err != nil {
+		fmt.Printf("store: %v\n", err)
+		return 1
+	}
+	if st != nil {
+		defer st.Close()
+	}
+
+	log := logger.New("error", "console")
+	c := core.NewCore(cfg, log, aws.NewS3Client(awsCfg), aws.NewS3Presigner(awsCfg), st)
+	c.BucketClients = bucketClients
+	if err := c.CheckDependencies(ctx); err != nil {
+		fmt.Printf("configuration is valid, but some dependencies are not usable:\n%v\n", err)
+		return 1
+	}
+
+	fmt.Println("configuration OK")
+	return 0
+}
+
+// printConfig prints the effective configuration as YAML with secrets redacted,
+// followed by where each explicitly set value came from. It returns the process exit
+// code.
+func printConfig() int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	out, err := yaml.Parser().Marshal(cfg.Export())
+	if err != nil {
+		fmt.Printf("failed to encode configuration: %v\n", err)
+		return 1
+	}
+	fmt.Print(string(out))
+
+	sources := cfg.Sources()
+	paths := slices.Sorted(maps.Keys(sources))
+	fmt.Println("\n# Where explicitly set values came from; all others are defaults:")
+	for _, path := range paths {
+		fmt.Printf("#   %s: %s\n", path, sources[path])
+	}
+	return 0
+}
@@ -13,9 +13,31 @@ import (
 	"explorer451/internal/config"
 	"explorer451/internal/core"
 	"explorer451/internal/logger"
+	"explorer451/internal/store"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Build information, injected with -ldflags "-X main.version=..." by the Makefile and
+// goreleaser
+var (
+	version = "dev"
+	commit  string
+	date    string
 )
 
 func main() {
+	// `explorer451 check-config` validates the configuration and exits;
+	// `explorer451 print-config` prints the effective configuration and exits
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check-config":
+			os.Exit(checkConfig())
+		case "print-config":
+			os.Exit(printConfig())
+		}
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -36,12 +58,44 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to load AWS configuration")
 	}
 
-	// Create S3 client
-	s3Client := aws.NewS3Client(awsCfg)
+	// Create S3 client, following S3's redirects for buckets in other regions. The
+	// throttle goes first so the regional clients share it.
+	var clientOptions []func(*s3.Options)
+	if cfg.RateLimit.S3Rate > 0 {
+		clientOptions = append(clientOptions, aws.NewThrottle(cfg.RateLimit.S3Rate, cfg.RateLimit.S3Burst).Apply)
+	}
+	redirects := aws.NewRegionRedirects()
+	s3Client := aws.NewS3Client(awsCfg, append(clientOptions, redirects.Apply)...)
 	s3Presigner := aws.NewS3Presigner(awsCfg)
+	bucketClients, err := aws.NewBucketClients(ctx, awsCfg, cfg.AWS.Buckets, clientOptions...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure bucket endpoints")
+	}
+
+	// Open the store before the services restore their data from it
+	st, err := store.Open(ctx, cfg.Store)
+	if err != nil {
+		log.Fatal().Err(err).Str("driver", cfg.Store.Driver).Msg("Failed to open store")
+	}
 
 	// Initialize core service
-	core := core.NewCore(cfg, log, s3Client, s3Presigner)
+	build := core.BuildInfo{Version: version, Commit: commit, Date: date}
+	core := core.NewCore(cfg, log, s3Client, s3Presigner, st)
+	core.Build = build
+	core.BucketClients = bucketClients
+	core.RegionClient = redirects.Client
+	core.KMSClient = aws.NewKMSClient(awsCfg)
+
+	info := core.VersionInfo()
+	log.Info().
+		Str("version", info.Version).
+		Str("commit", info.Commit).
+		Str("built", info.BuildDate).
+		Str("go", info.GoVersion).
+		Str("profile", info.Profile).
+		Str("address", cfg.Server.Address).
+		Interface("features", info.Features).
+		Msg("Starting explorer451")
 
 	// Setup and start HTTP server
 	server := api.NewServer(core)
@@ -63,5 +117,27 @@ func main() {
 		log.Fatal().Err(err).Msg("Server shutdown failed")
 	}
 
+	// No new jobs can be submitted now; give running ones a chance to finish
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Jobs.DrainTimeout)
+	defer cancelDrain()
+
+	if err := core.Jobs.Shutdown(drainCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to save job history")
+	}
+
+	if err := core.Recent.Save(); err != nil {
+		log.Error().Err(err).Msg("Failed to save recent history")
+	}
+
+	if err := core.Shares.Save(); err != nil {
+		log.Error().Err(err).Msg("Failed to save share accesses")
+	}
+
+	if st != nil {
+		if err := st.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close store")
+		}
+	}
+
 	log.Info().Msg("Server gracefully stopped")
 }
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+
+	"explorer451/internal/models"
+)
+
+// parseFields splits a comma-separated ?fields= value into field names.
+// It returns nil when no selection was requested so callers can skip trimming.
+func parseFields(raw string) []string {
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// selectFields trims a JSON-serializable value down to the requested top-level fields.
+// Unknown field names are ignored.
+func selectFields(v interface{}, fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected, nil
+}
+
+// selectListFields applies a field selection to every object of a listing response,
+// keeping the pagination envelope and links intact
+func selectListFields(resp *models.ListObjectsResponse, fields []string) (map[string]interface{}, error) {
+	objects := make([]map[string]json.RawMessage, len(resp.Objects))
+	for i, obj := range resp.Objects {
+		selected, err := selectFields(obj, fields)
+		if err != nil {
+			return nil, err
+		}
+		objects[i] = selected
+	}
+
+	partial := map[string]interface{}{
+		"objects":     objects,
+		"itemsInPage": resp.ItemsInPage,
+		"pagination":  resp.Pagination,
+	}
+	if resp.Links != nil {
+		partial["links"] = resp.Links
+	}
+	return partial, nil
+}
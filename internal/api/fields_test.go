@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	assert.Nil(t, parseFields(""))
+	assert.Nil(t, parseFields(" , "))
+	assert.Equal(t, []string{"key", "size", "lastModified"}, parseFields("key, size,,lastModified"))
+}
+
+func TestSelectListFields(t *testing.T) {
+	resp := &models.ListObjectsResponse{
+		Objects: []models.ObjectInfo{
+			{Key: "a.txt", Size: 10, Type: "file", LastModified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+		ItemsInPage: 1,
+		Pagination:  models.Pagination{PageSize: 100},
+	}
+
+	partial, err := selectListFields(resp, []string{"key", "size", "unknown"})
+	assert.NoError(t, err)
+
+	jsonBytes, err := json.Marshal(partial)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"objects":[{"key":"a.txt","size":10}],
+		"itemsInPage":1,
+		"pagination":{"hasMore":false,"pageSize":100}
+	}`, string(jsonBytes))
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// listRoles handles GET /api/admin/roles
+func (s *Server) listRoles(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.RolesResponse{Roles: s.core.Access.Roles()})
+}
+
+// listUsers handles GET /api/admin/users
+func (s *Server) listUsers(c echo.Context) error {
+	users := slices.DeleteFunc(s.core.Access.Users(), func(user models.User) bool { return !s.allowsUser(c, user.Name) })
+	return c.JSON(http.StatusOK, models.UsersResponse{Users: users})
+}
+
+// createUser handles POST /api/admin/users
+func (s *Server) createUser(c echo.Context) error {
+	var req models.CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	req.Workspace = s.workspaceName(c)
+
+	user, err := s.core.Access.CreateUser(req)
+	if err != nil {
+		return s.accessError(err)
+	}
+
+	s.core.Logger.Info().
+		Str("user", user.Name).
+		Strs("roles", user.Roles).
+		Str("by", s.currentUser(c)).
+		Msg("Created user")
+	return c.JSON(http.StatusCreated, user)
+}
+
+// getUser handles GET /api/admin/users/:user
+func (s *Server) getUser(c echo.Context) error {
+	if !s.allowsUser(c, c.Param("user")) {
+		return s.accessError(core.ErrUserNotFound)
+	}
+	user, err := s.core.Access.User(c.Param("user"))
+	if err != nil {
+		return s.accessError(err)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// updateUser handles PATCH /api/admin/users/:user
+func (s *Server) updateUser(c echo.Context) error {
+	var req models.UpdateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if !s.allowsUser(c, c.Param("user")) {
+		return s.accessError(core.ErrUserNotFound)
+	}
+
+	user, err := s.core.Access.UpdateUser(c.Param("user"), req)
+	if err != nil {
+		return s.accessError(err)
+	}
+
+	s.core.Logger.Info().
+		Str("user", user.Name).
+		Strs("roles", user.Roles).
+		Bool("disabled", user.Disabled).
+		Str("by", s.currentUser(c)).
+		Msg("Updated user")
+	return c.JSON(http.StatusOK, user)
+}
+
+// getEffectivePermissions handles GET /api/admin/users/:user/permissions
+// In a workspace, only the roles that apply there count.
+func (s *Server) getEffectivePermissions(c echo.Context) error {
+	name := c.Param("user")
+	if !s.allowsUser(c, name) {
+		return s.accessError(core.ErrUserNotFound)
+	}
+
+	effective, err := s.effectivePermissions(c, name)
+	if err != nil {
+		return s.accessError(err)
+	}
+	return c.JSON(http.StatusOK, effective)
+}
+
+// effectivePermissions returns a user's permissions in the request's workspace, or
+// across the deployment when there is none
+func (s *Server) effectivePermissions(c echo.Context, name string) (models.EffectivePermissions, error) {
+	if workspace := s.workspace(c); workspace != nil {
+		return s.core.Workspaces.EffectivePermissions(workspace.Name, name)
+	}
+	return s.core.Access.EffectivePermissions(name)
+}
+
+// requireAdmin only serves callers holding the admin permission: users given the admin
+// role, directly or through a team, and those named in server.adminUsers, which is how
+// the first admin gets in. Disabled users are never identified, so never admins.
+func (s *Server) requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := s.currentUser(c)
+		if s.isAdmin(c, user) {
+			return next(c)
+		}
+
+		s.core.Logger.Warn().
+			Str("user", user).
+			Str("path", c.Path()).
+			Msg("Refused admin request")
+		return echo.NewHTTPError(http.StatusForbidden, "The admin role is required")
+	}
+}
+
+// isAdmin reports whether a user is named in server.adminUsers or, with
+// server.adminAccess, holds the admin permission in the request's workspace
+func (s *Server) isAdmin(c echo.Context, user string) bool {
+	if user == anonymousUser {
+		return false
+	}
+	if slices.Contains(s.core.Config.Server.AdminUsers, user) {
+		return true
+	}
+	if !s.core.Config.Server.AdminAccess || s.core.Access == nil {
+		return false
+	}
+	effective, err := s.effectivePermissions(c, user)
+	return err == nil && slices.Contains(effective.Permissions, models.PermissionAdmin)
+}
+
+// listTeams handles GET /api/admin/teams
+func (s *Server) listTeams(c echo.Context) error {
+	teams := slices.DeleteFunc(s.core.Access.Teams(), func(team models.Team) bool { return !s.allowsTeam(c, team) })
+	return c.JSON(http.StatusOK, models.TeamsResponse{Teams: teams})
+}
+
+// createTeam handles POST /api/admin/teams
+func (s *Server) createTeam(c echo.Context) error {
+	var req models.CreateTeamRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := s.checkMembers(c, req.Members); err != nil {
+		return s.accessError(err)
+	}
+	req.Workspace = s.workspaceName(c)
+
+	team, err := s.core.Access.CreateTeam(req)
+	if err != nil {
+		return s.accessError(err)
+	}
+
+	s.core.Logger.Info().
+		Str("team", team.Name).
+		Strs("roles", team.Roles).
+		Str("by", s.currentUser(c)).
+		Msg("Created team")
+	return c.JSON(http.StatusCreated, team)
+}
+
+// getTeam handles GET /api/admin/teams/:team
+func (s *Server) getTeam(c echo.Context) error {
+	team, err := s.visibleTeam(c, c.Param("team"))
+	if err != nil {
+		return s.accessError(err)
+	}
+	return c.JSON(http.StatusOK, team)
+}
+
+// updateTeam handles PATCH /api/admin/teams/:team
+func (s *Server) updateTeam(c echo.Context) error {
+	var req models.UpdateTeamRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if _, err := s.visibleTeam(c, c.Param("team")); err != nil {
+		return s.accessError(err)
+	}
+	if err := s.checkMembers(c, req.AddMembers); err != nil {
+		return s.accessError(err)
+	}
+
+	team, err := s.core.Access.UpdateTeam(c.Param("team"), req)
+	if err != nil {
+		return s.accessError(err)
+	}
+
+	s.core.Logger.Info().
+		Str("team", team.Name).
+		Strs("roles", team.Roles).
+		Strs("members", team.Members).
+		Str("by", s.currentUser(c)).
+		Msg("Updated team")
+	return c.JSON(http.StatusOK, team)
+}
+
+// deleteTeam handles DELETE /api/admin/teams/:team
+func (s *Server) deleteTeam(c echo.Context) error {
+	if _, err := s.visibleTeam(c, c.Param("team")); err != nil {
+		return s.accessError(err)
+	}
+	if err := s.core.Access.DeleteTeam(c.Param("team")); err != nil {
+		return s.accessError(err)
+	}
+
+	s.core.Logger.Info().
+		Str("team", c.Param("team")).
+		Str("by", s.currentUser(c)).
+		Msg("Deleted team")
+	return c.NoContent(http.StatusNoContent)
+}
+
+// visibleTeam returns a team the request may see
+func (s *Server) visibleTeam(c echo.Context, name string) (models.Team, error) {
+	team, err := s.core.Access.Team(name)
+	if err != nil {
+		return models.Team{}, err
+	}
+	if !s.allowsTeam(c, team) {
+		return models.Team{}, core.ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// checkMembers checks that users added to a team are members of the request's
+// workspace, so teams cannot pull in users of other workspaces
+func (s *Server) checkMembers(c echo.Context, members []string) error {
+	for _, member := range members {
+		if !s.allowsUser(c, member) {
+			return fmt.Errorf("%w: %s", core.ErrUserNotFound, member)
+		}
+	}
+	return nil
+}
+
+// accessError maps user and team management errors to HTTP errors
+func (s *Server) accessError(err error) error {
+	switch {
+	case errors.Is(err, core.ErrUserNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, core.ErrTeamNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	case errors.Is(err, core.ErrUserExists), errors.Is(err, core.ErrTeamExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, core.ErrUnknownRole), errors.Is(err, core.ErrInvalidAccessName):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	s.core.Logger.Error().Err(err).Msg("Error saving users and teams")
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save users and teams")
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getObjectACL handles GET /api/buckets/:bucket/objects/*/acl
+func (s *Server) getObjectACL(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	acl, err := s.core.S3Service.GetObjectACL(c.Request().Context(), bucket, key)
+	if err != nil {
+		return s.aclError(err, bucket, key, "Failed to get object ACL")
+	}
+	return c.JSON(http.StatusOK, acl)
+}
+
+// putObjectACL handles PUT /api/buckets/:bucket/objects/*/acl
+func (s *Server) putObjectACL(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.PutObjectACLRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.CannedACL != "" && len(req.Grants) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Give either a canned ACL or grants, not both")
+	}
+
+	acl, err := s.core.S3Service.PutObjectACL(c.Request().Context(), bucket, key, req)
+	if err != nil {
+		return s.aclError(err, bucket, key, "Failed to change object ACL")
+	}
+	return c.JSON(http.StatusOK, acl)
+}
+
+// aclError maps errors from reading or changing an ACL to HTTP errors
+func (s *Server) aclError(err error, bucket, key, message string) error {
+	switch {
+	case errors.Is(err, core.ErrACLsDisabled):
+		return echo.NewHTTPError(http.StatusConflict, "ACLs are disabled by the bucket's object ownership setting")
+	case errors.Is(err, core.ErrInvalidACL):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case isNoSuchBucketError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	case isNoSuchKeyError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+	case isAccessDeniedError(err):
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().
+		Err(err).
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg(message)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getBucketActivity handles GET /api/buckets/:bucket/activity
+func (s *Server) getBucketActivity(c echo.Context) error {
+	activity, err := s.core.Activity.Prefixes(c.Param("bucket"), c.QueryParam("prefix"), c.QueryParam("from"), c.QueryParam("to"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "from and to must be YYYY-MM-DD dates")
+	}
+	return c.JSON(http.StatusOK, activity)
+}
+
+// ingestAccessLogs handles POST /api/activity/ingest
+func (s *Server) ingestAccessLogs(c echo.Context) error {
+	job, err := s.core.Activity.IngestAccessLogs()
+	if err != nil {
+		if errors.Is(err, core.ErrAccessLogsNotConfigured) {
+			return echo.NewHTTPError(http.StatusNotImplemented, "Access logs are not configured on this server")
+		}
+
+		s.core.Logger.Error().Err(err).Msg("Error starting access log ingest")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start access log ingest")
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
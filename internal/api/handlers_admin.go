@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getConfig returns the effective configuration with secrets redacted, along with
+// where each explicitly set value came from
+func (s *Server) getConfig(c echo.Context) error {
+	cfg := s.core.Config
+	return c.JSON(http.StatusOK, models.ConfigExport{
+		Profile: cfg.Profile,
+		Config:  cfg.Export(),
+		Sources: cfg.Sources(),
+	})
+}
+
+// exportData handles GET /api/admin/export, returning the bundle of user data as an
+// attachment
+func (s *Server) exportData(c echo.Context) error {
+	bundle := s.core.ExportBundle()
+	filename := "explorer451-" + bundle.ExportedAt.Format("20060102-150405") + ".json"
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return c.JSON(http.StatusOK, bundle)
+}
+
+// importData handles POST /api/admin/import, merging a bundle written by exportData
+func (s *Server) importData(c echo.Context) error {
+	var bundle models.DataBundle
+	if err := c.Bind(&bundle); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	result, err := s.core.ImportBundle(bundle)
+	if err != nil {
+		if errors.Is(err, core.ErrBundleVersion) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		s.core.Logger.Error().Err(err).Msg("Error importing data bundle")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to import data bundle")
+	}
+
+	s.core.Logger.Info().
+		Int("bookmarks", result.Bookmarks).
+		Int("shares", result.Shares).
+		Int("annotations", result.Annotations).
+		Msg("Imported data bundle")
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// listAnnotations handles GET /api/annotations
+func (s *Server) listAnnotations(c echo.Context) error {
+	bucket := c.QueryParam("bucket")
+	if bucket == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bucket is required")
+	}
+	if !s.allowsBucket(c, bucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	return c.JSON(http.StatusOK, models.AnnotationsResponse{
+		Annotations: s.core.Annotations.List(bucket, c.QueryParam("key"), c.QueryParam("prefix")),
+	})
+}
+
+// searchAnnotations handles GET /api/annotations/search
+func (s *Server) searchAnnotations(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Search query is required")
+	}
+
+	annotations := slices.DeleteFunc(s.core.Annotations.Search(query, c.QueryParam("bucket")), func(annotation models.Annotation) bool {
+		return !s.allowsBucket(c, annotation.Bucket)
+	})
+	return c.JSON(http.StatusOK, models.AnnotationsResponse{Annotations: annotations})
+}
+
+// createAnnotation handles POST /api/annotations
+func (s *Server) createAnnotation(c echo.Context) error {
+	var req models.CreateAnnotationRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Bucket == "" || req.Key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bucket and key are required")
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Text is required")
+	}
+	if !s.allowsBucket(c, req.Bucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	annotation, err := s.core.Annotations.Add(s.currentUser(c), req.Bucket, req.Key, req.Text)
+	if err != nil {
+		if errors.Is(err, core.ErrAnnotationTooLong) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Annotation is too long")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", req.Bucket).Str("key", req.Key).Msg("Error saving annotation")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save annotation")
+	}
+
+	return c.JSON(http.StatusCreated, annotation)
+}
+
+// deleteAnnotation handles DELETE /api/annotations/:id
+func (s *Server) deleteAnnotation(c echo.Context) error {
+	if err := s.core.Annotations.Delete(s.currentUser(c), c.Param("id")); err != nil {
+		if errors.Is(err, core.ErrAnnotationNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Annotation not found")
+		}
+		if errors.Is(err, core.ErrAnnotationNotAuthor) {
+			return echo.NewHTTPError(http.StatusForbidden, "Only the author can delete an annotation")
+		}
+
+		s.core.Logger.Error().Err(err).Str("id", c.Param("id")).Msg("Error deleting annotation")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete annotation")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
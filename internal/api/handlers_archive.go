@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// listArchiveEntries handles GET /api/buckets/:bucket/objects/*/archive
+func (s *Server) listArchiveEntries(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	listing, err := s.core.S3Service.ListArchiveEntries(c.Request().Context(), bucket, key)
+	if err != nil {
+		if errors.Is(err, core.ErrArchiveNotSupported) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Only zip, tar and tar.gz archives can be listed")
+		}
+		if errors.Is(err, core.ErrInvalidArchive) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "Object is not a valid archive")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error listing archive entries")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list archive entries")
+	}
+
+	return c.JSON(http.StatusOK, listing)
+}
+
+// extractArchive handles POST /api/buckets/:bucket/objects/*/extract
+func (s *Server) extractArchive(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ExtractArchiveRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.TargetBucket == "" {
+		req.TargetBucket = bucket
+	}
+	if !s.allowsBucket(c, req.TargetBucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	job, err := s.core.S3Service.ExtractArchive(c.Request().Context(), bucket, key, req.TargetBucket, req.TargetPrefix, req.KMSKeyID)
+	if err != nil {
+		if errors.Is(err, core.ErrArchiveNotSupported) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Only zip, tar and tar.gz archives can be extracted")
+		}
+		if errors.Is(err, core.ErrKMSRequired) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Objects must be encrypted with the required KMS key")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error starting archive extraction")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start archive extraction")
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxBatchOperations caps the number of operations accepted in a single batch request
+const maxBatchOperations = 100
+
+// executeBatch handles POST /api/batch
+func (s *Server) executeBatch(c echo.Context) error {
+	var req models.BatchRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if len(req.Operations) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "At least one operation is required")
+	}
+	if len(req.Operations) > maxBatchOperations {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("A batch may contain at most %d operations", maxBatchOperations))
+	}
+
+	ctx := c.Request().Context()
+	results := make([]models.BatchResult, len(req.Operations))
+	sem := make(chan struct{}, s.core.Config.Listing.Concurrency)
+	var wg sync.WaitGroup
+
+	for i, op := range req.Operations {
+		if op.Bucket != "" && !s.allowsBucket(c, op.Bucket) {
+			results[i] = models.BatchResult{ID: op.ID, Op: op.Op, Status: http.StatusNotFound, Error: "Bucket not found"}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, op models.BatchOperation) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = s.runBatchOperation(ctx, op)
+		}(i, op)
+	}
+	wg.Wait()
+
+	return c.JSON(http.StatusOK, models.BatchResponse{Results: results})
+}
+
+// runBatchOperation executes a single batch operation and converts its outcome into a result
+func (s *Server) runBatchOperation(ctx context.Context, op models.BatchOperation) models.BatchResult {
+	result := models.BatchResult{ID: op.ID, Op: op.Op}
+
+	if op.Bucket == "" {
+		result.Status = http.StatusBadRequest
+		result.Error = "Bucket is required"
+		return result
+	}
+
+	var (
+		data interface{}
+		err  error
+	)
+	switch op.Op {
+	case "head":
+		if op.Key == "" {
+			result.Status = http.StatusBadRequest
+			result.Error = "Key is required"
+			return result
+		}
+		data, err = s.core.S3Service.GetObjectMetadata(ctx, op.Bucket, op.Key, nil)
+	case "list":
+		var objects *models.ListObjectsResponse
+		objects, err = s.core.S3Service.ListObjects(ctx, op.Bucket, op.Prefix, op.Cursor, op.Delimiter, op.PageSize)
+		if err == nil {
+			addListLinks(objects, s.apiRoot(), op.Bucket, op.Prefix, op.Cursor, op.Delimiter)
+			data = objects
+		}
+	case "presign":
+		if op.Key == "" {
+			result.Status = http.StatusBadRequest
+			result.Error = "Key is required"
+			return result
+		}
+		data, err = s.downloadLink(ctx, op.Bucket, op.Key, "", op.ExpiresIn)
+	default:
+		result.Status = http.StatusBadRequest
+		result.Error = "Op must be one of 'head', 'list' or 'presign'"
+		return result
+	}
+
+	if err != nil {
+		result.Status, result.Error = s3ErrorStatus(err)
+		if result.Status == http.StatusInternalServerError {
+			s.core.Logger.Error().
+				Err(err).
+				Str("op", op.Op).
+				Str("bucket", op.Bucket).
+				Str("key", op.Key).
+				Msg("Error executing batch operation")
+		}
+		return result
+	}
+
+	if fields := parseFields(op.Fields); fields != nil {
+		if listing, ok := data.(*models.ListObjectsResponse); ok {
+			data, err = selectListFields(listing, fields)
+		} else {
+			data, err = selectFields(data, fields)
+		}
+		if err != nil {
+			result.Status = http.StatusInternalServerError
+			result.Error = "Operation failed"
+			return result
+		}
+	}
+
+	result.Status = http.StatusOK
+	result.Data = data
+	return result
+}
+
+// s3ErrorStatus maps an S3 error to the HTTP status and message returned to clients
+func s3ErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, core.ErrInvalidCursor):
+		return http.StatusBadRequest, "Invalid cursor"
+	case errors.Is(err, core.ErrObjectInfected):
+		return http.StatusForbidden, "Object is flagged as infected"
+	case errors.Is(err, core.ErrProxyDownloadsDisabled):
+		return http.StatusForbidden, "Downloads of this content type are disabled"
+	case isNoSuchBucketError(err):
+		return http.StatusNotFound, "Bucket not found"
+	case isNoSuchKeyError(err):
+		return http.StatusNotFound, "Object not found"
+	case isAccessDeniedError(err):
+		return http.StatusForbidden, "Access denied"
+	default:
+		return http.StatusInternalServerError, "Operation failed"
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// listBookmarks handles GET /api/bookmarks
+func (s *Server) listBookmarks(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.BookmarksResponse{
+		Bookmarks: s.core.Bookmarks.List(s.currentUser(c)),
+	})
+}
+
+// createBookmark handles POST /api/bookmarks
+func (s *Server) createBookmark(c echo.Context) error {
+	var req models.CreateBookmarkRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Bucket == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bucket is required")
+	}
+
+	bookmark, err := s.core.Bookmarks.Add(s.currentUser(c), req.Bucket, req.Prefix, req.Name)
+	if err != nil {
+		if errors.Is(err, core.ErrTooManyBookmarks) {
+			return echo.NewHTTPError(http.StatusConflict, "Bookmark limit reached; delete some bookmarks first")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", req.Bucket).Msg("Error saving bookmark")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save bookmark")
+	}
+
+	return c.JSON(http.StatusCreated, bookmark)
+}
+
+// deleteBookmark handles DELETE /api/bookmarks/:id
+func (s *Server) deleteBookmark(c echo.Context) error {
+	if err := s.core.Bookmarks.Delete(s.currentUser(c), c.Param("id")); err != nil {
+		if errors.Is(err, core.ErrBookmarkNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bookmark not found")
+		}
+
+		s.core.Logger.Error().Err(err).Str("id", c.Param("id")).Msg("Error deleting bookmark")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete bookmark")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// copyObject handles POST /api/buckets/:bucket/objects/copy
+func (s *Server) copyObject(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.CopyObjectRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Key == "" || req.TargetKey == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Key and target key are required")
+	}
+	if req.TargetBucket != "" && !s.allowsBucket(c, req.TargetBucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	copied, err := s.core.S3Service.CopyObject(c.Request().Context(), bucket, req)
+	if err != nil {
+		return s.copyError(err, bucket, req.Key, "Failed to copy object")
+	}
+
+	s.core.Recent.RecordFile(s.currentUser(c), copied.Bucket, copied.Object.Key, core.RecentUpload)
+	return c.JSON(http.StatusCreated, copied)
+}
+
+// renameObject handles PUT /api/buckets/:bucket/objects/*/rename
+func (s *Server) renameObject(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.RenameObjectRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.TargetKey == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Target key is required")
+	}
+	if req.TargetBucket != "" && !s.allowsBucket(c, req.TargetBucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	renamed, err := s.core.S3Service.RenameObject(c.Request().Context(), bucket, key, req)
+	if err != nil {
+		return s.copyError(err, bucket, key, "Failed to rename object")
+	}
+
+	s.core.Recent.RecordFile(s.currentUser(c), renamed.Bucket, renamed.Object.Key, core.RecentUpload)
+	return c.JSON(http.StatusOK, renamed)
+}
+
+// copyFolder handles POST /api/buckets/:bucket/folders/copy
+func (s *Server) copyFolder(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.CopyFolderRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.TargetBucket != "" && !s.allowsBucket(c, req.TargetBucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	job, err := s.core.S3Service.CopyFolder(c.Request().Context(), bucket, req)
+	if err != nil {
+		if errors.Is(err, core.ErrCopyOntoItself) {
+			return echo.NewHTTPError(http.StatusBadRequest, "A folder cannot be copied onto itself")
+		}
+		return s.copyError(err, bucket, req.Prefix, "Failed to start folder copy")
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// copyError maps errors from copying or renaming an object to HTTP errors
+func (s *Server) copyError(err error, bucket, key, message string) error {
+	switch {
+	case errors.Is(err, core.ErrCopyOntoItself):
+		return echo.NewHTTPError(http.StatusBadRequest, "An object cannot be copied onto itself")
+	case errors.Is(err, core.ErrCrossEndpointCopy):
+		return echo.NewHTTPError(http.StatusBadRequest, "Objects cannot be copied between buckets on different endpoints")
+	case errors.Is(err, core.ErrKMSRequired):
+		return echo.NewHTTPError(http.StatusBadRequest, "Objects must be encrypted with the required KMS key")
+	case errors.Is(err, core.ErrCopyFolderIntoItself):
+		return echo.NewHTTPError(http.StatusBadRequest, "A folder cannot be copied into itself")
+	case errors.Is(err, core.ErrRenameFolder):
+		return echo.NewHTTPError(http.StatusBadRequest, "Folders cannot be renamed; move them with a selection instead")
+	case errors.Is(err, core.ErrInvalidConflict):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrObjectExists):
+		return objectConflictError(err)
+	case errors.Is(err, core.ErrObjectTooLargeToCopy):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Objects over 5 GiB cannot be copied")
+	case errors.Is(err, core.ErrRenameIncomplete):
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg("Error deleting renamed object")
+		return echo.NewHTTPError(http.StatusInternalServerError, "The object was copied to its new key, but the original could not be deleted")
+	case isNoSuchBucketError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	case isNoSuchKeyError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+	case isAccessDeniedError(err):
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg(message)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
+
+// objectConflictError answers a write refused because its target key exists, listing
+// the conflict strategies it could be retried with
+func objectConflictError(err error) error {
+	var conflict *core.ObjectConflictError
+	if !errors.As(err, &conflict) {
+		return echo.NewHTTPError(http.StatusConflict, "An object already exists at the target key")
+	}
+	return echo.NewHTTPError(http.StatusConflict, models.ObjectConflict{
+		Message:      "An object already exists at the target key",
+		Bucket:       conflict.Bucket,
+		Key:          conflict.Key,
+		Alternatives: conflict.Alternatives,
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getObjectContent handles GET /api/buckets/:bucket/objects/*/content
+func (s *Server) getObjectContent(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	content, err := s.core.S3Service.GetObjectContent(c.Request().Context(), bucket, key)
+	if err != nil {
+		return s.editError(err, bucket, key, "Failed to get object content")
+	}
+
+	s.core.Recent.RecordFile(s.currentUser(c), bucket, key, core.RecentUpload)
+
+	c.Response().Header().Set("ETag", content.ETag)
+	return c.JSON(http.StatusOK, content)
+}
+
+// saveObjectContent handles PUT /api/buckets/:bucket/objects/*/content
+func (s *Server) saveObjectContent(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	ifMatch := c.Request().Header.Get("If-Match")
+	if ifMatch == "" {
+		return echo.NewHTTPError(http.StatusPreconditionRequired, "If-Match header with the object's ETag is required")
+	}
+
+	var req models.SaveObjectContentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	content, err := s.core.S3Service.SaveObjectContent(c.Request().Context(), bucket, key, req.Content, ifMatch)
+	if err != nil {
+		return s.editError(err, bucket, key, "Failed to save object content")
+	}
+
+	s.core.Recent.RecordFile(s.currentUser(c), bucket, key, core.RecentUpload)
+
+	c.Response().Header().Set("ETag", content.ETag)
+	return c.JSON(http.StatusOK, content)
+}
+
+// updateObjectMetadata handles PUT /api/buckets/:bucket/objects/*/metadata. If-Match
+// is optional here; without it the change applies to whatever the object holds.
+func (s *Server) updateObjectMetadata(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateObjectMetadataRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	metadata, err := s.core.S3Service.UpdateObjectMetadata(c.Request().Context(), bucket, key, req, c.Request().Header.Get("If-Match"))
+	if err != nil {
+		return s.editError(err, bucket, key, "Failed to update object metadata")
+	}
+
+	c.Response().Header().Set("ETag", metadata.ETag)
+	return c.JSON(http.StatusOK, metadata)
+}
+
+// editError maps errors from loading or saving object content to HTTP errors
+func (s *Server) editError(err error, bucket, key, message string) error {
+	if errors.Is(err, core.ErrETagMismatch) {
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "Object has been modified since it was loaded")
+	}
+	if errors.Is(err, core.ErrEditNotSupported) {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Only UTF-8 text objects can be edited")
+	}
+	if errors.Is(err, core.ErrObjectTooLargeToEdit) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Object is too large to edit")
+	}
+	if errors.Is(err, core.ErrInvalidMetadata) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, core.ErrObjectTooLargeToCopy) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "The metadata of objects over 5 GiB cannot be changed")
+	}
+	if isNoSuchBucketError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+	if isNoSuchKeyError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+	}
+	if isAccessDeniedError(err) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().
+		Err(err).
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg(message)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
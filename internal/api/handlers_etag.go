@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// computeMultipartETag handles POST /api/multipart-etag
+func (s *Server) computeMultipartETag(c echo.Context) error {
+	var req models.MultipartETagRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if len(req.PartMD5s) == 0 && req.Size == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Part MD5s or a size and part size are required")
+	}
+
+	var response models.MultipartETagResponse
+	if req.Size != 0 || req.PartSize != 0 {
+		parts, err := core.ChunkingPlan(req.Size, req.PartSize)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		response.Parts = parts
+		response.PartCount = len(parts)
+	}
+	if len(req.PartMD5s) > 0 {
+		if response.Parts != nil && len(req.PartMD5s) != len(response.Parts) {
+			return echo.NewHTTPError(http.StatusBadRequest, "The number of part MD5s does not match the chunking plan")
+		}
+		etag, err := core.MultipartETag(req.PartMD5s)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		response.ETag = etag
+		response.PartCount = len(req.PartMD5s)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// getObjectParts handles GET /api/buckets/:bucket/objects/*/parts
+func (s *Server) getObjectParts(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	parts, err := s.core.S3Service.GetObjectParts(c.Request().Context(), bucket, key)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting object parts")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get object parts")
+	}
+
+	return c.JSON(http.StatusOK, parts)
+}
+
+// getObjectChecksums handles GET /api/buckets/:bucket/objects/*/checksums. With
+// verify=<algorithm> the object is read on the server and hashed with the algorithm.
+func (s *Server) getObjectChecksums(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+	customerKey, err := customerKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	if c.QueryParam("verify") != "" {
+		liftWriteDeadline(c)
+	}
+
+	checksums, err := s.core.S3Service.GetObjectChecksums(c.Request().Context(), bucket, key, c.QueryParam("versionId"), c.QueryParam("verify"), customerKey)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidChecksumAlgorithm) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Verify must be crc32, crc32c, sha1, sha256 or md5")
+		}
+		if errors.Is(err, core.ErrETagMismatch) {
+			return echo.NewHTTPError(http.StatusConflict, "Object was modified while it was verified")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) || isNoSuchVersionError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting object checksums")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get object checksums")
+	}
+
+	return c.JSON(http.StatusOK, checksums)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultChangesWait is how long a changes request waits for new events by default
+	defaultChangesWait = 20 * time.Second
+	// maxChangesWait caps how long a changes request may wait
+	maxChangesWait = 25 * time.Second
+	// changesWaitMargin keeps long-polls this far below the server's request timeout
+	changesWaitMargin = 5 * time.Second
+)
+
+// getChanges handles GET /api/buckets/:bucket/changes
+func (s *Server) getChanges(c echo.Context) error {
+	bucket := c.Param("bucket")
+	since := c.QueryParam("since")
+
+	// Without a cursor, return the current position so the client can start polling from it
+	if since == "" {
+		_, latest, _ := s.core.Events.Since(bucket, 0)
+		return c.JSON(http.StatusOK, models.ChangesResponse{
+			Events: []models.ChangeEvent{},
+			Cursor: core.EncodeSequenceCursor(latest),
+		})
+	}
+
+	sequence, err := core.DecodeSequenceCursor(since)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+	}
+
+	// Parse wait time in seconds (default 20 seconds, capped below the request timeout)
+	wait := defaultChangesWait
+	if c.QueryParam("wait") != "" {
+		if val, err := strconv.ParseInt(c.QueryParam("wait"), 10, 64); err == nil && val >= 0 {
+			wait = time.Duration(val) * time.Second
+		}
+	}
+	wait = min(wait, maxChangesWait)
+	if timeout := s.routeTimeout(c); timeout > 0 {
+		wait = max(0, min(wait, timeout-changesWaitMargin))
+	}
+
+	events, latest, resync := s.core.Events.Wait(c.Request().Context(), bucket, sequence, wait)
+
+	return c.JSON(http.StatusOK, models.ChangesResponse{
+		Events: events,
+		Cursor: core.EncodeSequenceCursor(latest),
+		Resync: resync,
+	})
+}
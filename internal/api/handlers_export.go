@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+)
+
+// exportContentTypes maps listing export formats to their content types
+var exportContentTypes = map[string]string{
+	core.ExportFormatCSV:    "text/csv; charset=utf-8",
+	core.ExportFormatNDJSON: "application/x-ndjson",
+}
+
+// exportListing handles GET /api/buckets/:bucket/export, streaming a recursive
+// listing of a bucket or prefix as CSV (the default) or NDJSON
+func (s *Server) exportListing(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+	format := c.QueryParam("format")
+	if format == "" {
+		format = core.ExportFormatCSV
+	}
+
+	export, err := s.core.S3Service.PrepareListingExport(c.Request().Context(), bucket, prefix, format)
+	if err != nil {
+		if errors.Is(err, core.ErrExportFormat) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Format must be csv or ndjson")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error exporting listing")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to export listing")
+	}
+
+	filename := strings.TrimSuffix(zipFileName(bucket, prefix), ".zip") + "." + format
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, exportContentTypes[format])
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	liftWriteDeadline(c)
+	c.Response().WriteHeader(http.StatusOK)
+
+	// The status line has been sent, so failures can only be logged
+	_ = s.core.S3Service.WriteListingExport(c.Request().Context(), export, c.Response())
+	return nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fetchObject handles POST /api/buckets/:bucket/objects/fetch
+func (s *Server) fetchObject(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.FetchObjectRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.URL == "" || req.Key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "URL and key are required")
+	}
+
+	job, err := s.core.S3Service.FetchObject(c.Request().Context(), bucket, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrInvalidFetchURL), errors.Is(err, core.ErrConflictingEncryption),
+			errors.Is(err, core.ErrKMSRequired), errors.Is(err, core.ErrInvalidConflict):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, core.ErrFetchHostNotAllowed):
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		case errors.Is(err, core.ErrUploadPolicyRequired):
+			return echo.NewHTTPError(http.StatusBadRequest, "An upload policy is required; imports cannot be restricted by one")
+		case errors.Is(err, core.ErrObjectExists):
+			return objectConflictError(err)
+		case isNoSuchBucketError(err):
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		case isAccessDeniedError(err):
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("key", req.Key).Msg("Error starting import from URL")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start import from URL")
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getObjectHistory handles GET /api/buckets/:bucket/objects/*/history
+func (s *Server) getObjectHistory(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	history, err := s.core.S3Service.ObjectHistory(c.Request().Context(), bucket, key)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting object history")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get object history")
+	}
+
+	// Share tokens are the links themselves, so only their owners see them
+	for i, entry := range history.Entries {
+		if entry.ShareToken == "" {
+			continue
+		}
+		if share, err := s.core.Shares.Get(entry.ShareToken); err != nil || !s.ownsShare(c, share) {
+			history.Entries[i].ShareToken = ""
+		}
+	}
+	return c.JSON(http.StatusOK, history)
+}
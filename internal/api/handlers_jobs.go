@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultJobsPageSize is the number of jobs returned per page when pageSize is not given
+	defaultJobsPageSize = 50
+	// maxJobsPageSize caps the pageSize accepted when listing jobs
+	maxJobsPageSize = 500
+)
+
+// listJobs handles GET /api/jobs
+func (s *Server) listJobs(c echo.Context) error {
+	pageSize := defaultJobsPageSize
+	if val, err := strconv.Atoi(c.QueryParam("pageSize")); err == nil && val > 0 {
+		pageSize = min(val, maxJobsPageSize)
+	}
+
+	offset := 0
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		token, err := core.DecodeCursor(cursor)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+		}
+		offset, err = strconv.Atoi(token)
+		if err != nil || offset < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+		}
+	}
+
+	jobs, total := s.core.Jobs.ListMatching(offset, pageSize, func(job models.Job) bool { return s.allowsJob(c, job) })
+	resp := models.ListJobsResponse{
+		Jobs: jobs,
+		Pagination: models.Pagination{
+			PageSize:    pageSize,
+			MaxPageSize: maxJobsPageSize,
+		},
+	}
+	if next := offset + len(jobs); next < total {
+		resp.Pagination.HasMore = true
+		resp.Pagination.Cursor = core.EncodeCursor(strconv.Itoa(next))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// getJob handles GET /api/jobs/:id
+func (s *Server) getJob(c echo.Context) error {
+	job, err := s.core.Jobs.Get(c.Param("id"))
+	if err != nil || !s.allowsJob(c, job) {
+		return echo.NewHTTPError(http.StatusNotFound, "Job not found")
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// cancelJob handles POST /api/jobs/:id/cancel
+func (s *Server) cancelJob(c echo.Context) error {
+	id := c.Param("id")
+	if job, err := s.core.Jobs.Get(id); err != nil || !s.allowsJob(c, job) {
+		return echo.NewHTTPError(http.StatusNotFound, "Job not found")
+	}
+	if err := s.core.Jobs.Cancel(id); err != nil {
+		if errors.Is(err, core.ErrJobFinished) {
+			return echo.NewHTTPError(http.StatusConflict, "Job already finished")
+		}
+		return echo.NewHTTPError(http.StatusNotFound, "Job not found")
+	}
+
+	job, err := s.core.Jobs.Get(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Job not found")
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// listKMSKeys handles GET /api/kms/keys
+func (s *Server) listKMSKeys(c echo.Context) error {
+	keys, err := s.core.S3Service.ListKMSKeys(c.Request().Context())
+	if err != nil {
+		if errors.Is(err, core.ErrKMSUnavailable) {
+			return echo.NewHTTPError(http.StatusNotImplemented, "KMS is not available")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Msg("Error listing KMS keys")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list KMS keys")
+	}
+
+	return c.JSON(http.StatusOK, models.KMSKeysResponse{Keys: keys})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// legacyPageSize is the page size the original API used by default
+const legacyPageSize = 100
+
+// listBucketsLegacy handles GET /api/buckets in the response format of the original
+// API, for clients that predate the current one (server.legacyRoutes)
+func (s *Server) listBucketsLegacy(c echo.Context) error {
+	buckets, err := s.core.S3Service.ListBuckets(c.Request().Context())
+	if err != nil {
+		s.core.Logger.Error().Err(err).Msg("Error listing buckets")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list buckets")
+	}
+
+	names := make([]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		if s.allowsBucket(c, bucket.Name) {
+			names = append(names, bucket.Name)
+		}
+	}
+	return c.JSON(http.StatusOK, models.LegacyBucketsResponse{Buckets: names})
+}
+
+// listObjectsLegacy handles GET /api/buckets/:bucket/objects in the response format of
+// the original API. Its continuation tokens are the current API's cursors, which old
+// clients pass back unchanged.
+func (s *Server) listObjectsLegacy(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	pageSize := int32(legacyPageSize)
+	if val, err := strconv.ParseInt(c.QueryParam("pageSize"), 10, 32); err == nil {
+		pageSize = int32(val)
+	}
+
+	token := c.QueryParam("continuationToken")
+	objects, err := s.core.S3Service.ListObjects(c.Request().Context(), bucket, prefix, token, "/", pageSize)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidCursor) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid continuation token")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Msg("Error listing objects")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list objects")
+	}
+
+	if token == "" {
+		s.core.Recent.RecordLocation(s.currentUser(c), bucket, prefix)
+	}
+
+	return c.JSON(http.StatusOK, legacyListResponse(objects, pageSize))
+}
+
+// legacyListResponse converts a listing into the original API's format
+func legacyListResponse(objects *models.ListObjectsResponse, pageSize int32) models.LegacyListObjectsResponse {
+	response := models.LegacyListObjectsResponse{
+		Items:       make([]models.LegacyObjectItem, 0, len(objects.Objects)),
+		IsTruncated: objects.Pagination.HasMore,
+		TotalItems:  int32(objects.ItemsInPage),
+		PageSize:    pageSize,
+	}
+	if objects.Pagination.HasMore {
+		response.NextContinuationToken = objects.Pagination.Cursor
+	}
+
+	for _, obj := range objects.Objects {
+		if obj.IsFolder {
+			response.Items = append(response.Items, models.LegacyObjectItem{
+				Key:      strings.TrimSuffix(obj.Key, "/"),
+				IsFolder: true,
+				Type:     "folder",
+			})
+			continue
+		}
+
+		// Folder marker objects were never listed as files
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+
+		contentType := obj.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		item := models.LegacyObjectItem{
+			Key:         obj.Key,
+			Size:        obj.Size,
+			Type:        "file",
+			ContentType: contentType,
+		}
+		if !obj.LastModified.IsZero() {
+			item.LastModified = obj.LastModified.Format(time.RFC3339)
+		}
+		response.Items = append(response.Items, item)
+	}
+
+	return response
+}
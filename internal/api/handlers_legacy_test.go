@@ -0,0 +1,35 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegacyListResponse(t *testing.T) {
+	modified := time.Date(2024, 11, 12, 23, 48, 36, 0, time.UTC)
+	objects := &models.ListObjectsResponse{
+		Objects: []models.ObjectInfo{
+			{Key: "folder1/", IsFolder: true, Type: "folder"},
+			{Key: "docs/", Type: "file"},
+			{Key: "docs/file1.txt", Type: "file", Size: 12, ContentType: "text/plain", LastModified: modified},
+			{Key: "docs/blob", Type: "file"},
+		},
+		ItemsInPage: 4,
+		Pagination:  models.Pagination{Cursor: "abc", HasMore: true, PageSize: 100},
+	}
+
+	response := legacyListResponse(objects, 100)
+
+	assert.Equal(t, []models.LegacyObjectItem{
+		{Key: "folder1", IsFolder: true, Type: "folder"},
+		{Key: "docs/file1.txt", Size: 12, Type: "file", ContentType: "text/plain", LastModified: "2024-11-12T23:48:36Z"},
+		{Key: "docs/blob", Type: "file", ContentType: "application/octet-stream"},
+	}, response.Items)
+	assert.True(t, response.IsTruncated)
+	assert.Equal(t, "abc", response.NextContinuationToken)
+	assert.Equal(t, int32(100), response.PageSize)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// presignUploadManifest handles POST /api/buckets/:bucket/upload-manifest
+func (s *Server) presignUploadManifest(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.UploadManifestRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	customerKey, err := customerKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := s.currentUser(c)
+	manifest, err := s.core.S3Service.PresignUploadManifest(c.Request().Context(), bucket, user, req, customerKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrUploadPolicyNotFound):
+			return echo.NewHTTPError(http.StatusBadRequest, "Upload policy not found")
+		case errors.Is(err, core.ErrUploadPolicyRequired):
+			return echo.NewHTTPError(http.StatusBadRequest, "An upload policy is required")
+		case errors.Is(err, core.ErrUploadContentType):
+			return echo.NewHTTPError(http.StatusBadRequest, "Content type is not allowed by the upload policy")
+		case errors.Is(err, core.ErrInvalidManifest), errors.Is(err, core.ErrUploadTagMissing), errors.Is(err, core.ErrUploadKey),
+			errors.Is(err, core.ErrConflictingEncryption), errors.Is(err, core.ErrKMSRequired), errors.Is(err, core.ErrInvalidConflict):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, core.ErrObjectExists):
+			return objectConflictError(err)
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("prefix", req.Prefix).
+			Msg("Error presigning upload manifest")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to presign upload manifest")
+	}
+
+	for _, target := range manifest.Targets {
+		s.core.Recent.RecordFile(user, bucket, target.Key, core.RecentUpload)
+	}
+	return c.JSON(http.StatusOK, manifest)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getImageMetadata handles GET /api/buckets/:bucket/objects/*/image-metadata
+func (s *Server) getImageMetadata(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	// Clients may ask to leave GPS out, but cannot override a server-wide hideGps
+	includeGPS := !s.core.Config.Images.HideGPS && c.QueryParam("gps") != "false"
+
+	metadata, err := s.core.S3Service.GetImageMetadata(c.Request().Context(), bucket, key, includeGPS)
+	if err != nil {
+		if errors.Is(err, core.ErrNotAnImage) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Object is not a supported image")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting image metadata")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get image metadata")
+	}
+
+	return c.JSON(http.StatusOK, metadata)
+}
+
+// getMediaMetadata handles GET /api/buckets/:bucket/objects/*/media-metadata
+func (s *Server) getMediaMetadata(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := s.core.S3Service.GetMediaMetadata(c.Request().Context(), bucket, key)
+	if err != nil {
+		if errors.Is(err, core.ErrMediaProbeDisabled) {
+			return echo.NewHTTPError(http.StatusNotImplemented, "Media metadata is not enabled on this server")
+		}
+		if errors.Is(err, core.ErrNotMedia) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Object is not audio or video")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting media metadata")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get media metadata")
+	}
+
+	return c.JSON(http.StatusOK, metadata)
+}
+
+// getPDFMetadata handles GET /api/buckets/:bucket/objects/*/pdf-metadata
+func (s *Server) getPDFMetadata(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := s.core.S3Service.GetPDFMetadata(c.Request().Context(), bucket, key)
+	if err != nil {
+		if errors.Is(err, core.ErrPDFDisabled) {
+			return echo.NewHTTPError(http.StatusNotImplemented, "PDF metadata is not enabled on this server")
+		}
+		if errors.Is(err, core.ErrNotPDF) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Object is not a PDF")
+		}
+		if errors.Is(err, core.ErrPDFTooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "PDF is too large to inspect")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting pdf metadata")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get PDF metadata")
+	}
+
+	return c.JSON(http.StatusOK, metadata)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/aws/smithy-go"
+	"github.com/labstack/echo/v4"
+)
+
+// createMultipartUpload handles POST /api/buckets/:bucket/multipart-uploads
+func (s *Server) createMultipartUpload(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.CreateMultipartUploadRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Key is required")
+	}
+
+	upload, err := s.core.S3Service.CreateMultipartUpload(c.Request().Context(), bucket, req)
+	if err != nil {
+		return s.multipartError(err, bucket, req.Key, "Failed to create multipart upload")
+	}
+	return c.JSON(http.StatusCreated, upload)
+}
+
+// presignUploadParts handles POST /api/buckets/:bucket/multipart-uploads/:uploadId/part-urls
+func (s *Server) presignUploadParts(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.PartURLsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Key is required")
+	}
+
+	urls, err := s.core.S3Service.PresignUploadParts(c.Request().Context(), bucket, c.Param("uploadId"), req)
+	if err != nil {
+		return s.multipartError(err, bucket, req.Key, "Failed to presign upload parts")
+	}
+	return c.JSON(http.StatusOK, urls)
+}
+
+// listUploadedParts handles GET /api/buckets/:bucket/multipart-uploads/:uploadId/parts?key=
+func (s *Server) listUploadedParts(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key := c.QueryParam("key")
+	if key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Key is required")
+	}
+
+	parts, err := s.core.S3Service.ListUploadedParts(c.Request().Context(), bucket, key, c.Param("uploadId"))
+	if err != nil {
+		return s.multipartError(err, bucket, key, "Failed to list uploaded parts")
+	}
+	return c.JSON(http.StatusOK, parts)
+}
+
+// completeMultipartUpload handles POST /api/buckets/:bucket/multipart-uploads/:uploadId/complete
+func (s *Server) completeMultipartUpload(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.CompleteMultipartUploadRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Key is required")
+	}
+
+	completed, err := s.core.S3Service.CompleteMultipartUpload(c.Request().Context(), bucket, c.Param("uploadId"), req)
+	if err != nil {
+		return s.multipartError(err, bucket, req.Key, "Failed to complete multipart upload")
+	}
+
+	s.core.Recent.RecordFile(s.currentUser(c), bucket, completed.Key, core.RecentUpload)
+	return c.JSON(http.StatusOK, completed)
+}
+
+// abortMultipartUpload handles DELETE /api/buckets/:bucket/multipart-uploads/:uploadId?key=
+func (s *Server) abortMultipartUpload(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key := c.QueryParam("key")
+	if key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Key is required")
+	}
+
+	if err := s.core.S3Service.AbortMultipartUpload(c.Request().Context(), bucket, key, c.Param("uploadId")); err != nil {
+		return s.multipartError(err, bucket, key, "Failed to abort multipart upload")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// multipartError maps errors from multipart upload operations to HTTP errors
+func (s *Server) multipartError(err error, bucket, key, message string) error {
+	switch {
+	case errors.Is(err, core.ErrInvalidParts), errors.Is(err, core.ErrConflictingEncryption),
+		errors.Is(err, core.ErrKMSRequired):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrUploadPolicyRequired):
+		return echo.NewHTTPError(http.StatusBadRequest, "An upload policy is required; multipart uploads cannot be restricted by one")
+	case errors.Is(err, core.ErrInvalidConflict):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrObjectExists):
+		return objectConflictError(err)
+	case isInvalidPartError(err):
+		return echo.NewHTTPError(http.StatusBadRequest, "Parts are missing, too small or do not match their ETags")
+	case isNoSuchUploadError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Multipart upload not found")
+	case isNoSuchBucketError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	case isAccessDeniedError(err):
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg(message)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
+
+// isNoSuchUploadError checks if the error names an unknown, completed or aborted
+// multipart upload
+func isNoSuchUploadError(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "NoSuchUpload"
+	}
+	return false
+}
+
+// isInvalidPartError checks if S3 refused to assemble the parts of a multipart upload
+func isInvalidPartError(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "InvalidPart", "InvalidPartOrder", "EntityTooSmall":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+)
+
+// testNotifications handles POST /api/buckets/:bucket/notifications/test?prefix=&suffix=
+func (s *Server) testNotifications(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	test, err := s.core.S3Service.TestNotifications(c.Request().Context(), bucket, c.QueryParam("prefix"), c.QueryParam("suffix"))
+	if err != nil {
+		if errors.Is(err, core.ErrKMSRequired) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Msg("Error testing bucket notifications")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to test bucket notifications")
+	}
+	return c.JSON(http.StatusOK, test)
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultPreviewRows is the number of rows returned by a table preview by default
+	defaultPreviewRows = 100
+	// maxPreviewRows is the largest number of rows a client may request
+	maxPreviewRows = 1000
+
+	// defaultInspectBytes is the number of bytes returned by a byte range by default
+	defaultInspectBytes = 256
+	// maxInspectBytes is the largest byte range a client may request
+	maxInspectBytes = 64 * 1024
+)
+
+// previewObject handles GET /api/buckets/:bucket/objects/*/preview
+func (s *Server) previewObject(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	switch c.QueryParam("mode") {
+	case "table":
+		return s.previewTable(c, bucket, key)
+	case "pretty":
+		return s.previewFormatted(c, bucket, key)
+	}
+
+	preview, err := s.core.S3Service.GetObjectPreview(c.Request().Context(), bucket, key, s.previewSizeParam(c), wantsDecompression(c))
+	if err != nil {
+		if errors.Is(err, core.ErrPreviewNotSupported) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Preview is not available for this content type")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting object preview")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get object preview")
+	}
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// tailObject handles GET /api/buckets/:bucket/objects/*/tail
+func (s *Server) tailObject(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	tail, err := s.core.S3Service.GetObjectTail(c.Request().Context(), bucket, key, s.previewSizeParam(c))
+	if err != nil {
+		if errors.Is(err, core.ErrPreviewNotSupported) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Tail is not available for this content type")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting object tail")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get object tail")
+	}
+
+	return c.JSON(http.StatusOK, tail)
+}
+
+// getObjectBytes handles GET /api/buckets/:bucket/objects/*/bytes?offset=&length=&mode=hex|text
+func (s *Server) getObjectBytes(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	if c.QueryParam("offset") != "" {
+		if offset, err = strconv.ParseInt(c.QueryParam("offset"), 10, 64); err != nil || offset < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Offset must be a non-negative number")
+		}
+	}
+	length := int64(defaultInspectBytes)
+	if c.QueryParam("length") != "" {
+		if length, err = strconv.ParseInt(c.QueryParam("length"), 10, 64); err != nil || length <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Length must be a positive number")
+		}
+	}
+	length = min(length, maxInspectBytes)
+
+	customerKey, err := customerKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	chunk, err := s.core.S3Service.GetObjectBytes(c.Request().Context(), bucket, key, c.QueryParam("versionId"), offset, length, c.QueryParam("mode"), customerKey)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidBytesMode) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Mode must be hex or text")
+		}
+		if isInvalidRangeError(err) {
+			return echo.NewHTTPError(http.StatusRequestedRangeNotSatisfiable, "Offset is past the end of the object")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting object bytes")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get object bytes")
+	}
+
+	return c.JSON(http.StatusOK, chunk)
+}
+
+// previewFormatted serves GET /api/buckets/:bucket/objects/*/preview?mode=pretty
+func (s *Server) previewFormatted(c echo.Context, bucket, key string) error {
+	preview, err := s.core.S3Service.GetFormattedPreview(c.Request().Context(), bucket, key, wantsDecompression(c))
+	if err != nil {
+		if errors.Is(err, core.ErrPreviewNotSupported) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "Only JSON and YAML objects can be pretty-printed")
+		}
+		if errors.Is(err, core.ErrPreviewTooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Object is too large to format")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting formatted preview")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to format object")
+	}
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// previewSizeParam parses the sizeKB query parameter, defaulting to preview.defaultBytes
+// and capped at preview.maxBytes
+func (s *Server) previewSizeParam(c echo.Context) int64 {
+	maxBytes := s.core.Config.Preview.DefaultBytes
+	if c.QueryParam("sizeKB") != "" {
+		if val, err := strconv.ParseInt(c.QueryParam("sizeKB"), 10, 64); err == nil && val > 0 {
+			maxBytes = val * 1024
+		}
+	}
+	if maxBytes > s.core.Config.Preview.MaxBytes {
+		maxBytes = s.core.Config.Preview.MaxBytes
+	}
+	return maxBytes
+}
+
+// previewTable serves GET /api/buckets/:bucket/objects/*/preview?mode=table
+func (s *Server) previewTable(c echo.Context, bucket, key string) error {
+	// Parse number of rows (default 100, capped at 1000)
+	rows := defaultPreviewRows
+	if c.QueryParam("rows") != "" {
+		if val, err := strconv.Atoi(c.QueryParam("rows")); err == nil && val > 0 {
+			rows = val
+		}
+	}
+	if rows > maxPreviewRows {
+		rows = maxPreviewRows
+	}
+
+	// An explicit delimiter skips detection; "tab" is accepted for convenience
+	var delimiter rune
+	switch d := c.QueryParam("delimiter"); {
+	case d == "tab" || d == "\t":
+		delimiter = '\t'
+	case utf8.RuneCountInString(d) == 1:
+		delimiter, _ = utf8.DecodeRuneInString(d)
+	case d != "":
+		return echo.NewHTTPError(http.StatusBadRequest, "Delimiter must be a single character")
+	}
+
+	header := c.QueryParam("header") != "false"
+
+	preview, err := s.core.S3Service.GetTablePreview(c.Request().Context(), bucket, key, rows, delimiter, header, wantsDecompression(c))
+	if err != nil {
+		if errors.Is(err, core.ErrPreviewNotSupported) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "Object could not be parsed as delimited text")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error getting table preview")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get table preview")
+	}
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// wantsDecompression reports whether gzip-compressed objects should be served decompressed.
+// This is the default; clients opt out with decompress=false.
+func wantsDecompression(c echo.Context) bool {
+	return c.QueryParam("decompress") != "false"
+}
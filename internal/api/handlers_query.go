@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	"github.com/labstack/echo/v4"
+)
+
+// queryObject handles POST /api/buckets/:bucket/objects/*/query, streaming the records
+// an S3 Select expression returns as
+// {"records":[...],"stats":{...},"truncated":false}. Nothing is written before the
+// first records arrive, so a rejected expression is answered with a plain error; a
+// failure after that ends the document with an "error" field instead.
+func (s *Server) queryObject(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+	var req models.ObjectQueryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	customerKey, err := customerKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	// S3 Select may scan a large object for a long time before the first record
+	liftWriteDeadline(c)
+
+	response := c.Response()
+	count := 0
+	started := false
+	start := func() error {
+		started = true
+		response.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		response.WriteHeader(http.StatusOK)
+		_, err := response.Write([]byte(`{"records":[`))
+		return err
+	}
+	emit := func(records []json.RawMessage) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for _, record := range records {
+			if count > 0 {
+				if _, err := response.Write([]byte(",")); err != nil {
+					return err
+				}
+			}
+			if _, err := response.Write(record); err != nil {
+				return err
+			}
+			count++
+		}
+		response.Flush()
+		return nil
+	}
+
+	stats, truncated, err := s.core.S3Service.QueryObject(c.Request().Context(), bucket, key, req, customerKey, emit)
+	if err != nil && !started {
+		switch {
+		case errors.Is(err, core.ErrInvalidQuery):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case isNoSuchBucketError(err):
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		case isNoSuchKeyError(err):
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		case isAccessDeniedError(err):
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+		// S3 rejects bad SQL and objects that do not match the format with a 400
+		var responseErr *awshttp.ResponseError
+		var apiErr smithy.APIError
+		if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusBadRequest && errors.As(err, &apiErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Query failed: "+apiErr.ErrorMessage())
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error querying object")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to query object")
+	}
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+
+	tail := map[string]any{"stats": stats, "truncated": truncated}
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error streaming query results, response is incomplete")
+		tail["error"] = "Query failed after results were sent"
+	}
+	data, err := json.Marshal(tail)
+	if err != nil {
+		return err
+	}
+	// Splice the stats and flags in after the records array
+	_, err = response.Write(append([]byte("],"), data[1:]...))
+	return err
+}
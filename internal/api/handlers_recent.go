@@ -0,0 +1,12 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getRecent handles GET /api/recent
+func (s *Server) getRecent(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.core.Recent.Get(s.currentUser(c)))
+}
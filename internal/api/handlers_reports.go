@@ -0,0 +1,219 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getCostEstimate handles GET /api/buckets/:bucket/cost
+func (s *Server) getCostEstimate(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	estimate, err := s.core.S3Service.EstimateCost(c.Request().Context(), bucket, prefix)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error estimating storage cost")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to estimate storage cost")
+	}
+
+	return c.JSON(http.StatusOK, estimate)
+}
+
+// getStorageReport handles GET /api/buckets/:bucket/storage-report
+func (s *Server) getStorageReport(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	report, err := s.core.S3Service.GetStorageReport(c.Request().Context(), bucket, prefix)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error generating storage report")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate storage report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// getExposureReport handles GET /api/buckets/:bucket/exposure
+func (s *Server) getExposureReport(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+	objects := c.QueryParam("objects") == "true"
+
+	report, err := s.core.S3Service.GetExposureReport(c.Request().Context(), bucket, prefix, objects)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error generating exposure report")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate exposure report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// getExposureSummary handles GET /api/exposure
+func (s *Server) getExposureSummary(c echo.Context) error {
+	summary, err := s.core.S3Service.GetExposureSummary(c.Request().Context())
+	if err != nil {
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Msg("Error generating exposure summary")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate exposure summary")
+	}
+	summary.Buckets = slices.DeleteFunc(summary.Buckets, func(report models.ExposureReport) bool { return !s.allowsBucket(c, report.Bucket) })
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+// compareBuckets handles GET /api/buckets/:bucket/compare
+func (s *Server) compareBuckets(c echo.Context) error {
+	bucket := c.Param("bucket")
+	target := c.QueryParam("target")
+	if target == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "target is required")
+	}
+	if !s.allowsBucket(c, target) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	comparison, err := s.core.S3Service.CompareBuckets(c.Request().Context(), bucket, target)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("target", target).Msg("Error comparing buckets")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to compare buckets")
+	}
+
+	return c.JSON(http.StatusOK, comparison)
+}
+
+// getDeletePreflight handles GET /api/buckets/:bucket/delete-preflight
+func (s *Server) getDeletePreflight(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	report, err := s.core.S3Service.DeletePreflight(c.Request().Context(), []models.SelectionItem{{Bucket: bucket, Key: prefix}})
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error running delete preflight")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to run delete preflight")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// getGarbageReport handles GET /api/buckets/:bucket/garbage
+func (s *Server) getGarbageReport(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	report, err := s.core.S3Service.GetGarbageReport(c.Request().Context(), bucket, prefix)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error generating garbage report")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate garbage report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// startGarbageCleanup handles POST /api/buckets/:bucket/garbage/cleanup
+func (s *Server) startGarbageCleanup(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	job, err := s.core.S3Service.StartGarbageCleanup(c.Request().Context(), bucket, prefix, s.currentUser(c))
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error starting garbage cleanup")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start garbage cleanup")
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// getRetentionReport handles GET /api/buckets/:bucket/retention-report, answering JSON
+// or, with format=csv, a CSV file of the objects
+func (s *Server) getRetentionReport(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "csv" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Format must be json or csv")
+	}
+
+	report, err := s.core.S3Service.GetRetentionReport(c.Request().Context(), bucket, prefix)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error generating retention report")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate retention report")
+	}
+
+	if format != "csv" {
+		return c.JSON(http.StatusOK, report)
+	}
+	filename := strings.TrimSuffix(zipFileName(bucket, prefix), ".zip") + "-retention.csv"
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, exportContentTypes[core.ExportFormatCSV])
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Response().WriteHeader(http.StatusOK)
+	return core.WriteRetentionReportCSV(report, c.Response())
+}
@@ -1,12 +1,17 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"explorer451/internal/core"
 	"explorer451/internal/models"
 
 	"github.com/aws/smithy-go"
@@ -20,6 +25,7 @@ func (s *Server) listBuckets(c echo.Context) error {
 		s.core.Logger.Error().Err(err).Msg("Error listing buckets")
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list buckets")
 	}
+	buckets = slices.DeleteFunc(buckets, func(bucket models.Bucket) bool { return !s.allowsBucket(c, bucket.Name) })
 
 	return c.JSON(http.StatusOK, buckets)
 }
@@ -49,27 +55,124 @@ func (s *Server) getBucketDetails(c echo.Context) error {
 func (s *Server) listObjects(c echo.Context) error {
 	bucket := c.Param("bucket")
 	prefix := c.QueryParam("prefix")
-	nextToken := c.QueryParam("nextToken")
+	cursor := c.QueryParam("cursor")
 	delimiter := c.QueryParam("delimiter")
 
-	// Parse maxKeys parameter if provided
-	maxKeys := int32(1000) // Default
-	if c.QueryParam("maxKeys") != "" {
-		if val, err := strconv.ParseInt(c.QueryParam("maxKeys"), 10, 32); err == nil {
-			maxKeys = int32(val)
+	// Parse pageSize parameter if provided, accepting the older maxKeys name as well.
+	// Without one the configured default applies.
+	var pageSize int32
+	pageSizeParam := c.QueryParam("pageSize")
+	if pageSizeParam == "" {
+		pageSizeParam = c.QueryParam("maxKeys")
+	}
+	if pageSizeParam != "" {
+		if val, err := strconv.ParseInt(pageSizeParam, 10, 32); err == nil {
+			pageSize = int32(val)
+		}
+	}
+
+	var asOf time.Time
+	if c.QueryParam("asOf") != "" {
+		parsed, err := time.Parse(time.RFC3339, c.QueryParam("asOf"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "asOf must be an RFC 3339 time")
 		}
+		asOf = parsed
 	}
 
-	objects, err := s.core.S3Service.ListObjects(
-		c.Request().Context(),
-		bucket,
-		prefix,
-		nextToken,
-		delimiter,
-		maxKeys,
-	)
+	if wantsAll(c) {
+		if c.QueryParam("fields") != "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Fields cannot be selected in a listing with all=true")
+		}
+		if !asOf.IsZero() {
+			return echo.NewHTTPError(http.StatusBadRequest, "A listing as of a past time cannot be combined with all=true")
+		}
+		err := streamAll(s, c, "objects", func(emit func([]models.ObjectInfo) error) (string, error) {
+			return s.core.S3Service.ListAllObjects(c.Request().Context(), bucket, prefix, cursor, delimiter, func(page *models.ListObjectsResponse) error {
+				return emit(page.Objects)
+			})
+		})
+		if err != nil {
+			return s.listObjectsError(err, bucket)
+		}
+		return nil
+	}
+
+	var objects *models.ListObjectsResponse
+	var err error
+	if asOf.IsZero() {
+		objects, err = s.core.S3Service.ListObjects(
+			c.Request().Context(),
+			bucket,
+			prefix,
+			cursor,
+			delimiter,
+			pageSize,
+		)
+	} else {
+		objects, err = s.core.S3Service.ListObjectsAsOf(c.Request().Context(), bucket, prefix, cursor, delimiter, asOf, pageSize)
+	}
 	if err != nil {
-		// Map common AWS errors to appropriate HTTP status
+		return s.listObjectsError(err, bucket)
+	}
+
+	// Only the first page counts as a visit, not every page scrolled through. Listings
+	// of the past get no links, which would lead to the objects as they are now.
+	if asOf.IsZero() {
+		if cursor == "" {
+			s.core.Recent.RecordLocation(s.currentUser(c), bucket, prefix)
+		}
+		addListLinks(objects, s.apiRoot(), bucket, prefix, cursor, delimiter)
+	}
+
+	if fields := parseFields(c.QueryParam("fields")); fields != nil {
+		partial, err := selectListFields(objects, fields)
+		if err != nil {
+			s.core.Logger.Error().Err(err).Str("bucket", bucket).Msg("Error selecting listing fields")
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list objects")
+		}
+		return c.JSON(http.StatusOK, partial)
+	}
+
+	return c.JSON(http.StatusOK, objects)
+}
+
+// listObjectsError maps errors from listing objects to HTTP errors
+func (s *Server) listObjectsError(err error, bucket string) error {
+	if errors.Is(err, core.ErrInvalidCursor) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+	}
+	// Map common AWS errors to appropriate HTTP status
+	if isNoSuchBucketError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+	if isAccessDeniedError(err) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().Err(err).Str("bucket", bucket).Msg("Error listing objects")
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list objects")
+}
+
+// getTree handles GET /api/buckets/:bucket/tree
+func (s *Server) getTree(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	depth := 1
+	if c.QueryParam("depth") != "" {
+		val, err := strconv.Atoi(c.QueryParam("depth"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Depth must be a number")
+		}
+		depth = val
+	}
+
+	tree, err := s.core.S3Service.GetTree(c.Request().Context(), bucket, prefix, depth)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidTreeDepth) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Depth must be between 0 and %d", core.MaxTreeDepth))
+		}
 		if isNoSuchBucketError(err) {
 			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
 		}
@@ -77,17 +180,41 @@ func (s *Server) listObjects(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 		}
 
-		s.core.Logger.Error().Err(err).Str("bucket", bucket).Msg("Error listing objects")
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list objects")
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error building folder tree")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to build folder tree")
 	}
 
-	return c.JSON(http.StatusOK, objects)
+	return c.JSON(http.StatusOK, tree)
+}
+
+// getBreadcrumbs handles GET /api/buckets/:bucket/breadcrumbs
+func (s *Server) getBreadcrumbs(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	breadcrumbs, err := s.core.S3Service.GetBreadcrumbs(c.Request().Context(), bucket, prefix)
+	if err != nil {
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("prefix", prefix).Msg("Error counting breadcrumbs")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to count breadcrumbs")
+	}
+
+	return c.JSON(http.StatusOK, breadcrumbs)
 }
 
 // getPresignedURL handles GET /api/buckets/:bucket/objects/*
 func (s *Server) getPresignedURL(c echo.Context) error {
 	bucket := c.Param("bucket")
-	key := c.Param("*")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
 
 	// Parse expiration time in seconds (default 15 minutes if not specified)
 	expiresIn := int64(15 * 60)
@@ -97,8 +224,14 @@ func (s *Server) getPresignedURL(c echo.Context) error {
 		}
 	}
 
-	url, err := s.core.S3Service.GetPresignedURL(c.Request().Context(), bucket, key, expiresIn)
+	link, err := s.downloadLink(c.Request().Context(), bucket, key, c.QueryParam("versionId"), expiresIn)
 	if err != nil {
+		if errors.Is(err, core.ErrObjectInfected) {
+			return echo.NewHTTPError(http.StatusForbidden, "Object is flagged as infected")
+		}
+		if errors.Is(err, core.ErrProxyDownloadsDisabled) {
+			return echo.NewHTTPError(http.StatusForbidden, "Downloads of this content type are disabled")
+		}
 		if isNoSuchBucketError(err) {
 			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
 		}
@@ -117,17 +250,86 @@ func (s *Server) getPresignedURL(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate presigned URL")
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"url": url})
+	s.core.Recent.RecordFile(s.currentUser(c), bucket, key, core.RecentDownload)
+	return c.JSON(http.StatusOK, link)
+}
+
+// objectExists handles GET /api/buckets/:bucket/objects/*/exists
+func (s *Server) objectExists(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	existence, err := s.core.S3Service.ObjectExists(c.Request().Context(), bucket, key, c.QueryParam("versionId"))
+	if err != nil {
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg("Error checking object exists")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check object exists")
+	}
+
+	return c.JSON(http.StatusOK, existence)
+}
+
+// getPresignedHeadURL handles GET /api/buckets/:bucket/objects/*/head-url
+func (s *Server) getPresignedHeadURL(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	expiresIn := int64(15 * 60)
+	if c.QueryParam("expiresIn") != "" {
+		if val, err := strconv.ParseInt(c.QueryParam("expiresIn"), 10, 64); err == nil {
+			expiresIn = val
+		}
+	}
+
+	headURL, err := s.core.S3Service.GetPresignedHeadURL(c.Request().Context(), bucket, key, c.QueryParam("versionId"), expiresIn)
+	if err != nil {
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg("Error generating presigned HEAD URL")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate presigned HEAD URL")
+	}
+
+	return c.JSON(http.StatusOK, headURL)
 }
 
-// deleteObject handles DELETE /api/buckets/:bucket/objects/*
+// downloadLink resolves the download policy for an object, pointing proxied
+// downloads at the object's download endpoint
+func (s *Server) downloadLink(ctx context.Context, bucket, key, versionID string, expiresIn int64) (*models.DownloadLink, error) {
+	link, err := s.core.S3Service.GetDownloadLink(ctx, bucket, key, versionID, expiresIn)
+	if err != nil {
+		return nil, err
+	}
+	if link.Mode == core.DownloadModeProxy {
+		link.URL = objectPath(s.apiRoot(), bucket, key) + "/download"
+		if versionID != "" {
+			link.URL += "?versionId=" + url.QueryEscape(versionID)
+		}
+	}
+	return link, nil
+}
+
+// deleteObject handles DELETE /api/buckets/:bucket/objects/*?recursive=&versionId=
 func (s *Server) deleteObject(c echo.Context) error {
 	bucket := c.Param("bucket")
-	key := c.Param("*")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
 	recursive := c.QueryParam("recursive") == "true"
+	versionID := c.QueryParam("versionId")
 
 	// If recursive is true, delete by prefix (folder deletion)
 	if recursive {
+		if versionID != "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "A version ID cannot be combined with a recursive delete")
+		}
 		err := s.core.S3Service.DeleteObjectsByPrefix(c.Request().Context(), bucket, key)
 		if err != nil {
 			if isNoSuchBucketError(err) {
@@ -144,6 +346,36 @@ func (s *Server) deleteObject(c echo.Context) error {
 				Msg("Error deleting objects by prefix")
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete folder")
 		}
+	} else if versionID != "" {
+		// Permanent deletion of one version or delete marker
+		if _, err := s.core.S3Service.DeleteObjectVersion(c.Request().Context(), bucket, key, versionID); err != nil {
+			if isNoSuchBucketError(err) {
+				return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+			}
+			if isNoSuchVersionError(err) {
+				return echo.NewHTTPError(http.StatusNotFound, "Version not found")
+			}
+			if isInvalidArgumentError(err) {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid version ID")
+			}
+			if isAccessDeniedError(err) {
+				return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+			}
+
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", key).
+				Str("versionId", versionID).
+				Msg("Error deleting object version")
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete object version")
+		}
+		s.core.Logger.Info().
+			Str("bucket", bucket).
+			Str("key", key).
+			Str("versionId", versionID).
+			Str("user", s.currentUser(c)).
+			Msg("Object version permanently deleted")
 	} else {
 		// Single object deletion
 		err := s.core.S3Service.DeleteObject(c.Request().Context(), bucket, key)
@@ -229,26 +461,55 @@ func (s *Server) generatePresignedPostURL(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Content type is required")
 	}
 
-	// Set default values if not provided
-	expiresIn := time.Duration(req.ExpiresInSeconds) * time.Second
-	if req.ExpiresInSeconds <= 0 {
-		expiresIn = 15 * time.Minute // Default to 15 minutes
+	customerKey, err := customerKeyParam(c)
+	if err != nil {
+		return err
 	}
 
-	maxSize := req.MaxSizeBytes
-	if maxSize <= 0 {
-		maxSize = 10 * 1024 * 1024 // Default to 10MB
-	}
+	var response *models.PresignedPostURLResponse
+	user := s.currentUser(c)
+	if req.Policy != "" {
+		response, err = s.core.S3Service.GeneratePolicyPostURL(c.Request().Context(), bucket, user, req, customerKey)
+	} else if s.core.S3Service.UploadPolicyRequired() {
+		err = core.ErrUploadPolicyRequired
+	} else {
+		// Set default values if not provided
+		expiresIn := time.Duration(req.ExpiresInSeconds) * time.Second
+		if req.ExpiresInSeconds <= 0 {
+			expiresIn = 15 * time.Minute // Default to 15 minutes
+		}
+
+		maxSize := req.MaxSizeBytes
+		if maxSize <= 0 {
+			maxSize = 10 * 1024 * 1024 // Default to 10MB
+		}
 
-	response, err := s.core.S3Service.GeneratePresignedPostURL(
-		c.Request().Context(),
-		bucket,
-		req.Key,
-		req.ContentType,
-		expiresIn,
-		maxSize,
-	)
+		response, err = s.core.S3Service.GeneratePresignedPostURL(
+			c.Request().Context(),
+			bucket,
+			req.Key,
+			req.ContentType,
+			expiresIn,
+			maxSize,
+			req.KMSKeyID,
+			customerKey,
+			req.Conflict,
+		)
+	}
 	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrUploadPolicyNotFound):
+			return echo.NewHTTPError(http.StatusBadRequest, "Upload policy not found")
+		case errors.Is(err, core.ErrUploadPolicyRequired):
+			return echo.NewHTTPError(http.StatusBadRequest, "An upload policy is required")
+		case errors.Is(err, core.ErrUploadContentType):
+			return echo.NewHTTPError(http.StatusBadRequest, "Content type is not allowed by the upload policy")
+		case errors.Is(err, core.ErrUploadTagMissing), errors.Is(err, core.ErrUploadKey), errors.Is(err, core.ErrConflictingEncryption),
+			errors.Is(err, core.ErrKMSRequired), errors.Is(err, core.ErrInvalidConflict):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, core.ErrObjectExists):
+			return objectConflictError(err)
+		}
 		if isNoSuchBucketError(err) {
 			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
 		}
@@ -264,15 +525,24 @@ func (s *Server) generatePresignedPostURL(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate presigned POST URL")
 	}
 
+	s.core.Recent.RecordFile(user, bucket, response.Fields["key"], core.RecentUpload)
 	return c.JSON(http.StatusOK, response)
 }
 
 // getObjectMetadata handles HEAD /api/buckets/:bucket/objects/*
 func (s *Server) getObjectMetadata(c echo.Context) error {
 	bucket := c.Param("bucket")
-	key := c.Param("*")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	customerKey, err := customerKeyParam(c)
+	if err != nil {
+		return err
+	}
 
-	metadata, err := s.core.S3Service.GetObjectMetadata(c.Request().Context(), bucket, key)
+	metadata, err := s.core.S3Service.GetObjectMetadata(c.Request().Context(), bucket, key, customerKey)
 	if err != nil {
 		if isNoSuchBucketError(err) {
 			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
@@ -306,6 +576,9 @@ func (s *Server) getObjectMetadata(c echo.Context) error {
 	if metadata.VersionId != "" {
 		c.Response().Header().Set("x-amz-version-id", metadata.VersionId)
 	}
+	if metadata.Tags != nil {
+		c.Response().Header().Set("x-amz-tagging-count", strconv.Itoa(len(metadata.Tags)))
+	}
 
 	// Add user metadata headers
 	for k, v := range metadata.UserMetadata {
@@ -324,10 +597,23 @@ func isNoSuchBucketError(err error) bool {
 	return false
 }
 
+// customerKeyHeader carries an SSE-C key, under the same name S3 uses for it
+const customerKeyHeader = "X-Amz-Server-Side-Encryption-Customer-Key"
+
+// customerKeyParam reads the SSE-C key a request supplies, if any
+func customerKeyParam(c echo.Context) (*core.CustomerKey, error) {
+	key, err := core.ParseCustomerKey(c.Request().Header.Get(customerKeyHeader))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Customer key must be a base64-encoded 256-bit key")
+	}
+	return key, nil
+}
+
 func isAccessDeniedError(err error) bool {
 	var apiErr smithy.APIError
 	if errors.As(err, &apiErr) {
-		return apiErr.ErrorCode() == "AccessDenied"
+		// KMS reports AccessDeniedException
+		return apiErr.ErrorCode() == "AccessDenied" || apiErr.ErrorCode() == "AccessDeniedException"
 	}
 	return false
 }
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+)
+
+// scanObject handles POST /api/buckets/:bucket/objects/*/scan
+func (s *Server) scanObject(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	result, job, err := s.core.Antivirus.ScanObject(c.Request().Context(), bucket, key)
+	if err != nil {
+		if errors.Is(err, core.ErrAntivirusDisabled) {
+			return echo.NewHTTPError(http.StatusNotImplemented, "Antivirus scanning is not enabled on this server")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error scanning object")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to scan object")
+	}
+
+	// Large objects are scanned in the background
+	if job != nil {
+		return c.JSON(http.StatusAccepted, job)
+	}
+	return c.JSON(http.StatusOK, result)
+}
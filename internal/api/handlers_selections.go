@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// createSelection handles POST /api/selections
+func (s *Server) createSelection(c echo.Context) error {
+	var req models.CreateSelectionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := s.validateSelectionItems(c, req.Items); err != nil {
+		return err
+	}
+
+	selection, err := s.core.Selections.Create(s.currentUser(c), req.Items)
+	if err != nil {
+		return s.selectionError(err, "")
+	}
+	return c.JSON(http.StatusCreated, selection)
+}
+
+// getSelection handles GET /api/selections/:id
+func (s *Server) getSelection(c echo.Context) error {
+	selection, err := s.core.Selections.Get(s.currentUser(c), c.Param("id"))
+	if err != nil {
+		return s.selectionError(err, c.Param("id"))
+	}
+	return c.JSON(http.StatusOK, selection)
+}
+
+// updateSelection handles PATCH /api/selections/:id
+func (s *Server) updateSelection(c echo.Context) error {
+	var req models.UpdateSelectionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := s.validateSelectionItems(c, req.Add); err != nil {
+		return err
+	}
+
+	selection, err := s.core.Selections.Update(s.currentUser(c), c.Param("id"), req.Add, req.Remove)
+	if err != nil {
+		return s.selectionError(err, c.Param("id"))
+	}
+	return c.JSON(http.StatusOK, selection)
+}
+
+// deleteSelection handles DELETE /api/selections/:id
+func (s *Server) deleteSelection(c echo.Context) error {
+	if err := s.core.Selections.Delete(s.currentUser(c), c.Param("id")); err != nil {
+		return s.selectionError(err, c.Param("id"))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// copySelection handles POST /api/selections/:id/copy
+func (s *Server) copySelection(c echo.Context) error {
+	return s.applyCopySelection(c, false)
+}
+
+// moveSelection handles POST /api/selections/:id/move
+func (s *Server) moveSelection(c echo.Context) error {
+	return s.applyCopySelection(c, true)
+}
+
+// applyCopySelection starts a job copying or moving a selection
+func (s *Server) applyCopySelection(c echo.Context, move bool) error {
+	var req models.CopySelectionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.TargetBucket == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Target bucket is required")
+	}
+	if !s.allowsBucket(c, req.TargetBucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	selection, err := s.core.Selections.Get(s.currentUser(c), c.Param("id"))
+	if err != nil {
+		return s.selectionError(err, c.Param("id"))
+	}
+
+	job, err := s.core.S3Service.CopySelection(c.Request().Context(), selection, req.TargetBucket, req.TargetPrefix, move, req.KMSKeyID, req.Conflict)
+	if err != nil {
+		return s.selectionError(err, selection.ID)
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// deleteSelectedObjects handles POST /api/selections/:id/delete
+func (s *Server) deleteSelectedObjects(c echo.Context) error {
+	selection, err := s.core.Selections.Get(s.currentUser(c), c.Param("id"))
+	if err != nil {
+		return s.selectionError(err, c.Param("id"))
+	}
+
+	job, err := s.core.S3Service.DeleteSelection(c.Request().Context(), selection)
+	if err != nil {
+		return s.selectionError(err, selection.ID)
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// getSelectionDeletePreflight handles GET /api/selections/:id/delete-preflight
+func (s *Server) getSelectionDeletePreflight(c echo.Context) error {
+	selection, err := s.core.Selections.Get(s.currentUser(c), c.Param("id"))
+	if err != nil {
+		return s.selectionError(err, c.Param("id"))
+	}
+
+	report, err := s.core.S3Service.DeletePreflight(c.Request().Context(), selection.Items)
+	if err != nil {
+		return s.selectionError(err, selection.ID)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// downloadSelection handles GET /api/selections/:id/download-zip
+func (s *Server) downloadSelection(c echo.Context) error {
+	selection, err := s.core.Selections.Get(s.currentUser(c), c.Param("id"))
+	if err != nil {
+		return s.selectionError(err, c.Param("id"))
+	}
+
+	manifest, err := s.core.S3Service.PrepareZipFromSelection(c.Request().Context(), selection)
+	if err != nil {
+		if errors.Is(err, core.ErrSelectionEmpty) {
+			return s.selectionError(err, selection.ID)
+		}
+		return s.zipError(err, "")
+	}
+
+	filename := "selection.zip"
+	if manifest.Bucket != "" {
+		filename = zipFileName(manifest.Bucket, "")
+	}
+	return s.streamZip(c, manifest, filename)
+}
+
+// validateSelectionItems checks that every item names a bucket of the request's
+// workspace and a key
+func (s *Server) validateSelectionItems(c echo.Context, items []models.SelectionItem) error {
+	for _, item := range items {
+		if item.Bucket == "" || item.Key == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Every item needs a bucket and a key")
+		}
+		if !s.allowsBucket(c, item.Bucket) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found: "+item.Bucket)
+		}
+	}
+	return nil
+}
+
+// selectionError maps errors from selections and the operations applied to them to
+// HTTP errors
+func (s *Server) selectionError(err error, id string) error {
+	switch {
+	case errors.Is(err, core.ErrSelectionNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "Selection not found")
+	case errors.Is(err, core.ErrSelectionTooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Selection has too many items")
+	case errors.Is(err, core.ErrSelectionEmpty):
+		return echo.NewHTTPError(http.StatusBadRequest, "Selection is empty")
+	case errors.Is(err, core.ErrCrossEndpointCopy):
+		return echo.NewHTTPError(http.StatusBadRequest, "Objects cannot be copied between buckets on different endpoints")
+	case errors.Is(err, core.ErrTargetInsideSelection):
+		return echo.NewHTTPError(http.StatusBadRequest, "A prefix cannot be copied into itself")
+	case errors.Is(err, core.ErrSelectionNameClash), errors.Is(err, core.ErrInvalidConflict):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrKMSRequired):
+		return echo.NewHTTPError(http.StatusBadRequest, "Objects must be encrypted with the required KMS key")
+	case isNoSuchBucketError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	case isAccessDeniedError(err):
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().Err(err).Str("selection", id).Msg("Error applying selection operation")
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to apply selection operation")
+}
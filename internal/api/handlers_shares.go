@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// createShare handles POST /api/shares
+func (s *Server) createShare(c echo.Context) error {
+	var req models.CreateShareRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Bucket == "" || req.Key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bucket and key are required")
+	}
+	if req.ExpiresIn < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "expiresIn must not be negative")
+	}
+	if !s.allowsBucket(c, req.Bucket) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+
+	createdBy := s.currentUser(c)
+	if createdBy == anonymousUser {
+		createdBy = ""
+	}
+	share, err := s.core.Shares.Create(c.Request().Context(), createdBy, req.Bucket, req.Key, req.VersionID, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		if errors.Is(err, core.ErrShareExpiryTooLong) {
+			return echo.NewHTTPError(http.StatusBadRequest, "expiresIn exceeds the maximum of "+s.core.Config.Shares.MaxExpiry.String())
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", req.Bucket).
+			Str("key", req.Key).
+			Msg("Error creating share link")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create share link")
+	}
+
+	return c.JSON(http.StatusCreated, s.withShareURL(c, share))
+}
+
+// listShares handles GET /api/shares, listing the caller's own shares, or every share
+// for admins
+func (s *Server) listShares(c echo.Context) error {
+	shares := slices.DeleteFunc(s.core.Shares.List(), func(share models.Share) bool {
+		return !s.allowsBucket(c, share.Bucket) || !s.ownsShare(c, share)
+	})
+	for i := range shares {
+		shares[i] = s.withShareURL(c, shares[i])
+	}
+	return c.JSON(http.StatusOK, models.SharesResponse{Shares: shares})
+}
+
+// getShare handles GET /api/shares/:token
+func (s *Server) getShare(c echo.Context) error {
+	share, err := s.checkShareOwner(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, s.withShareURL(c, share))
+}
+
+// getShareStats handles GET /api/shares/:token/stats
+func (s *Server) getShareStats(c echo.Context) error {
+	if _, err := s.checkShareOwner(c); err != nil {
+		return err
+	}
+	stats, err := s.core.Shares.Stats(c.Param("token"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Share not found")
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// revokeShare handles DELETE /api/shares/:token
+func (s *Server) revokeShare(c echo.Context) error {
+	if _, err := s.checkShareOwner(c); err != nil {
+		return err
+	}
+	share, err := s.core.Shares.Revoke(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, core.ErrShareNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Share not found")
+		}
+
+		s.core.Logger.Error().Err(err).Str("token", c.Param("token")).Msg("Error revoking share link")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke share link")
+	}
+	return c.JSON(http.StatusOK, s.withShareURL(c, share))
+}
+
+// openShare handles GET /s/:token, redirecting to a freshly presigned URL for the
+// shared object, or to the proxy download for content types that must not be served
+// from the bucket
+func (s *Server) openShare(c echo.Context) error {
+	share, err := s.core.Shares.Resolve(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, core.ErrShareExpired) || errors.Is(err, core.ErrShareRevoked) {
+			return echo.NewHTTPError(http.StatusGone, "This share link is no longer valid")
+		}
+		return echo.NewHTTPError(http.StatusNotFound, "Share not found")
+	}
+
+	expiresIn := int64(s.core.Config.Shares.URLExpiry / time.Second)
+	link, err := s.downloadLink(c.Request().Context(), share.Bucket, share.Key, share.VersionID, expiresIn)
+	if err != nil {
+		status, message := s3ErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			s.core.Logger.Error().
+				Err(err).
+				Str("token", share.Token).
+				Str("bucket", share.Bucket).
+				Str("key", share.Key).
+				Msg("Error resolving share link")
+		}
+		return echo.NewHTTPError(status, message)
+	}
+
+	s.core.Shares.RecordAccess(share.Token, c.RealIP(), c.Request().UserAgent())
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.Redirect(http.StatusFound, link.URL)
+}
+
+// checkShareOwner returns the share named by the request, checking that it is visible
+// to the caller and that they own it
+func (s *Server) checkShareOwner(c echo.Context) (models.Share, error) {
+	share, err := s.core.Shares.Get(c.Param("token"))
+	if err != nil || !s.allowsBucket(c, share.Bucket) {
+		return models.Share{}, echo.NewHTTPError(http.StatusNotFound, "Share not found")
+	}
+	if !s.ownsShare(c, share) {
+		return models.Share{}, echo.NewHTTPError(http.StatusForbidden, "Only the creator of the share link or an admin can do this")
+	}
+	return share, nil
+}
+
+// ownsShare reports whether the caller created a share or is an admin. Shares created
+// without an identified user are anyone's.
+func (s *Server) ownsShare(c echo.Context, share models.Share) bool {
+	user := s.currentUser(c)
+	return share.CreatedBy == "" || share.CreatedBy == user || s.isAdmin(c, user)
+}
+
+// withShareURL fills in the absolute link a share is opened with
+func (s *Server) withShareURL(c echo.Context, share models.Share) models.Share {
+	share.URL = c.Scheme() + "://" + c.Request().Host + s.core.Config.Server.BasePath + "/s/" + share.Token
+	return share
+}
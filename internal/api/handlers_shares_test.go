@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareOwner(t *testing.T) {
+	c := &core.Core{
+		Config: &config.Config{Server: config.ServerConfig{
+			UserHeader:     "X-Forwarded-User",
+			TrustedProxies: []string{"192.0.2.10"},
+			AdminAccess:    true,
+			AdminUsers:     []string{"root"},
+		}, Shares: config.SharesConfig{MaxExpiry: 24 * time.Hour}},
+		Logger: logger.New("error", "json"),
+	}
+	c.Access = core.NewAccessService(c)
+	c.Shares = core.NewShareService(c)
+	s := &Server{core: c}
+
+	expiresAt := time.Now().Add(time.Hour)
+	_, err := c.Shares.Import([]models.Share{
+		{Token: "alices", Bucket: "photos", Key: "cat.jpg", CreatedBy: "alice", ExpiresAt: expiresAt},
+		{Token: "bobs", Bucket: "photos", Key: "dog.jpg", CreatedBy: "bob", ExpiresAt: expiresAt},
+		{Token: "anyones", Bucket: "photos", Key: "cow.jpg", ExpiresAt: expiresAt},
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.GET("/api/shares", s.listShares)
+	e.GET("/api/shares/:token", s.getShare)
+	e.GET("/api/shares/:token/stats", s.getShareStats)
+	e.DELETE("/api/shares/:token", s.revokeShare)
+	request := func(method, path, user string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "192.0.2.10:4000"
+		if user != "" {
+			req.Header.Set("X-Forwarded-User", user)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Others' links are neither listed nor shown
+	list := func(user string) []string {
+		req := httptest.NewRequest(http.MethodGet, "/api/shares", nil)
+		req.RemoteAddr = "192.0.2.10:4000"
+		req.Header.Set("X-Forwarded-User", user)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var response models.SharesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		var tokens []string
+		for _, share := range response.Shares {
+			tokens = append(tokens, share.Token)
+		}
+		slices.Sort(tokens)
+		return tokens
+	}
+	assert.Equal(t, []string{"alices", "anyones"}, list("alice"))
+	assert.Equal(t, []string{"alices", "anyones", "bobs"}, list("root"))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/shares/alices", "alice"))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/shares/alices", "bob"))
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/shares/alices/stats", "alice"))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/shares/alices/stats", "bob"))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/shares/alices/stats", ""))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/api/shares/alices", "bob"))
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/api/shares/alices", "alice"))
+
+	// Admins manage everyone's links, and links created anonymously are anyone's
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/shares/bobs/stats", "root"))
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/api/shares/bobs", "root"))
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/api/shares/anyones", ""))
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/api/shares/unknown", "alice"))
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"explorer451/internal/core"
+
+	"github.com/aws/smithy-go"
+	"github.com/labstack/echo/v4"
+)
+
+// streamObject handles GET /api/buckets/:bucket/objects/*/stream
+func (s *Server) streamObject(c echo.Context) error {
+	return s.serveObject(c, wantsDecompression(c))
+}
+
+// serveObject proxies an object's content to the client, honouring Range requests
+func (s *Server) serveObject(c echo.Context, decompress bool) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	customerKey, err := customerKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	stream, err := s.core.S3Service.GetObjectStream(c.Request().Context(), bucket, key, c.QueryParam("versionId"), c.Request().Header.Get("Range"), decompress, customerKey)
+	if err != nil {
+		if errors.Is(err, core.ErrObjectInfected) {
+			return echo.NewHTTPError(http.StatusForbidden, "Object is flagged as infected")
+		}
+		if isInvalidRangeError(err) {
+			return echo.NewHTTPError(http.StatusRequestedRangeNotSatisfiable, "Requested range not satisfiable")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error streaming object")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to stream object")
+	}
+	defer stream.Body.Close()
+
+	header := c.Response().Header()
+	if stream.Decompressed {
+		// The decompressed length is unknown, so byte ranges cannot be served
+		header.Set("Accept-Ranges", "none")
+	} else {
+		header.Set("Accept-Ranges", "bytes")
+		header.Set("Content-Length", strconv.FormatInt(stream.ContentLength, 10))
+	}
+	if stream.ETag != "" {
+		header.Set("ETag", stream.ETag)
+	}
+	if !stream.LastModified.IsZero() {
+		header.Set("Last-Modified", stream.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	liftWriteDeadline(c)
+
+	status := http.StatusOK
+	if stream.ContentRange != "" {
+		header.Set("Content-Range", stream.ContentRange)
+		status = http.StatusPartialContent
+	}
+
+	return c.Stream(status, stream.ContentType, stream.Body)
+}
+
+// downloadObject handles GET /api/buckets/:bucket/objects/*/download, the proxy used
+// for content types that must not be opened from the bucket's domain. The response is
+// always an attachment and is sandboxed in case a browser renders it anyway.
+func (s *Server) downloadObject(c echo.Context) error {
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set(echo.HeaderContentSecurityPolicy, "sandbox")
+
+	// Objects are downloaded exactly as stored
+	return s.serveObject(c, false)
+}
+
+// isInvalidRangeError reports whether S3 rejected the requested byte range
+func isInvalidRangeError(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "InvalidRange"
+	}
+	return false
+}
+
+// liftWriteDeadline removes the server's write timeout for a long-lived streaming
+// response, which would otherwise cut off large downloads
+func liftWriteDeadline(c echo.Context) {
+	_ = http.NewResponseController(c.Response()).SetWriteDeadline(time.Time{})
+}
+
+// isStreamingRequest reports whether a request is served by a long-lived streaming
+// endpoint that must not be buffered or cut off by the request timeout. Checksum
+// verifications and S3 Select queries count as one, since they read the whole object
+// before answering.
+func isStreamingRequest(c echo.Context) bool {
+	if strings.HasSuffix(c.Path(), "/download-zip") || strings.HasSuffix(c.Path(), apiPrefix+"/buckets/:bucket/export") {
+		return true
+	}
+	if c.Request().Method == http.MethodPost && hasObjectAction(c, "query") {
+		return true
+	}
+	verification := hasObjectAction(c, "checksums") && c.QueryParam("verify") != ""
+	return c.Request().Method == http.MethodGet && (hasObjectAction(c, "stream") || hasObjectAction(c, "download") || verification)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// startBulkTagging handles POST /api/buckets/:bucket/bulk-tagging
+func (s *Server) startBulkTagging(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.BulkTaggingRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	job, err := s.core.S3Service.BulkTag(c.Request().Context(), bucket, req)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidTagging) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().Err(err).Str("bucket", bucket).Msg("Error starting bulk tagging")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start bulk tagging")
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// getObjectTags handles GET /api/buckets/:bucket/objects/*/tags?versionId=
+func (s *Server) getObjectTags(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	tags, err := s.core.S3Service.GetObjectTags(c.Request().Context(), bucket, key, c.QueryParam("versionId"))
+	if err != nil {
+		return s.taggingError(err, bucket, key, "Failed to get object tags")
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// putObjectTags handles PUT /api/buckets/:bucket/objects/*/tags?versionId=
+func (s *Server) putObjectTags(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.PutObjectTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	tags, err := s.core.S3Service.PutObjectTags(c.Request().Context(), bucket, key, c.QueryParam("versionId"), req.Tags)
+	if err != nil {
+		return s.taggingError(err, bucket, key, "Failed to change object tags")
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// deleteObjectTags handles DELETE /api/buckets/:bucket/objects/*/tags?versionId=
+func (s *Server) deleteObjectTags(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	tags, err := s.core.S3Service.DeleteObjectTags(c.Request().Context(), bucket, key, c.QueryParam("versionId"))
+	if err != nil {
+		return s.taggingError(err, bucket, key, "Failed to delete object tags")
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// taggingError maps errors from reading or changing an object's tags to HTTP errors
+func (s *Server) taggingError(err error, bucket, key, message string) error {
+	switch {
+	case errors.Is(err, core.ErrInvalidTagging):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case isNoSuchBucketError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	case isNoSuchKeyError(err):
+		return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+	case isAccessDeniedError(err):
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().
+		Err(err).
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg(message)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
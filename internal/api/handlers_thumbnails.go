@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getThumbnail handles GET /api/buckets/:bucket/objects/*/thumbnail
+func (s *Server) getThumbnail(c echo.Context) error {
+	bucket := c.Param("bucket")
+	key, err := objectKeyParam(c)
+	if err != nil {
+		return err
+	}
+
+	// Parse the requested bounding box size; 0 selects the configured default
+	size := 0
+	if c.QueryParam("size") != "" {
+		val, err := strconv.Atoi(c.QueryParam("size"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Size must be a number")
+		}
+		size = val
+	}
+
+	thumb, err := s.core.Thumbnails.GetThumbnail(c.Request().Context(), bucket, key, size)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidThumbnailSize) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Thumbnail size not allowed")
+		}
+		if errors.Is(err, core.ErrThumbnailNotSupported) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Thumbnails are not available for this content type")
+		}
+		if errors.Is(err, core.ErrThumbnailSourceTooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Image is too large for a thumbnail")
+		}
+		if isNoSuchBucketError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+		}
+		if isNoSuchKeyError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+		}
+		if isAccessDeniedError(err) {
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Error generating thumbnail")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate thumbnail")
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=86400")
+	return c.Blob(http.StatusOK, thumb.ContentType, thumb.Data)
+}
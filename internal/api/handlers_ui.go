@@ -0,0 +1,49 @@
+package api
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// uiHandler serves the embedded single-page frontend. Existing files are served as-is;
+// any other path without a file extension is a client-side route and gets index.html.
+// A `<base href="/">` tag in index.html is rewritten to the base path so the frontend
+// resolves its assets and API calls correctly when served under a prefix.
+func uiHandler(assets fs.FS, basePath string) (echo.HandlerFunc, error) {
+	index, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		return nil, err
+	}
+	index = bytes.Replace(index, []byte(`<base href="/">`), []byte(`<base href="`+basePath+`/">`), 1)
+
+	apiRoot := basePath + apiPrefix
+	return func(c echo.Context) error {
+		// Unknown API routes are not client-side routes
+		if reqPath := c.Request().URL.Path; reqPath == apiRoot || strings.HasPrefix(reqPath, apiRoot+"/") {
+			return echo.ErrNotFound
+		}
+
+		name := path.Clean(strings.TrimPrefix(c.Param("*"), "/"))
+		if name != "." && name != "index.html" {
+			if info, err := fs.Stat(assets, name); err == nil && !info.IsDir() {
+				// Bundlers put content-hashed files under assets/, so they never change
+				if strings.HasPrefix(name, "assets/") {
+					c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+				}
+				http.ServeFileFS(c.Response(), c.Request(), assets, name)
+				return nil
+			}
+			if path.Ext(name) != "" {
+				return echo.ErrNotFound
+			}
+		}
+
+		c.Response().Header().Set("Cache-Control", "no-cache")
+		return c.HTMLBlob(http.StatusOK, index)
+	}, nil
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUIHandler(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html":         {Data: []byte(`<html><head><base href="/"></head></html>`)},
+		"assets/app-1a2b.js": {Data: []byte("console.log(1)")},
+		"favicon.ico":        {Data: []byte("icon")},
+	}
+	handler, err := uiHandler(assets, "/s3-explorer")
+	require.NoError(t, err)
+
+	e := echo.New()
+	root := e.Group("/s3-explorer")
+	root.GET("/api/buckets", func(c echo.Context) error { return c.String(http.StatusOK, "buckets") })
+	root.GET("", handler)
+	root.GET("/*", handler)
+
+	tests := []struct {
+		path     string
+		status   int
+		contains string
+	}{
+		{path: "/s3-explorer", status: http.StatusOK, contains: `<base href="/s3-explorer/">`},
+		{path: "/s3-explorer/buckets/photos", status: http.StatusOK, contains: `<base href="/s3-explorer/">`},
+		{path: "/s3-explorer/assets/app-1a2b.js", status: http.StatusOK, contains: "console.log"},
+		{path: "/s3-explorer/favicon.ico", status: http.StatusOK, contains: "icon"},
+		{path: "/s3-explorer/assets/missing.js", status: http.StatusNotFound},
+		{path: "/s3-explorer/api/unknown", status: http.StatusNotFound},
+		{path: "/s3-explorer/api/buckets", status: http.StatusOK, contains: "buckets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.contains)
+		})
+	}
+}
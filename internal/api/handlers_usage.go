@@ -0,0 +1,45 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getUsage handles GET /api/usage
+func (s *Server) getUsage(c echo.Context) error {
+	usage, err := s.core.Usage.All(c.QueryParam("from"), c.QueryParam("to"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "from and to must be YYYY-MM-DD dates")
+	}
+	usage.Buckets = slices.DeleteFunc(usage.Buckets, func(series models.UsageSeries) bool { return !s.allowsBucket(c, series.Bucket) })
+	return c.JSON(http.StatusOK, usage)
+}
+
+// getBucketUsage handles GET /api/buckets/:bucket/usage
+func (s *Server) getBucketUsage(c echo.Context) error {
+	series, err := s.core.Usage.Series(c.Param("bucket"), c.QueryParam("from"), c.QueryParam("to"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "from and to must be YYYY-MM-DD dates")
+	}
+	return c.JSON(http.StatusOK, series)
+}
+
+// ingestStorageLens handles POST /api/usage/storage-lens
+func (s *Server) ingestStorageLens(c echo.Context) error {
+	job, err := s.core.Usage.IngestStorageLens()
+	if err != nil {
+		if errors.Is(err, core.ErrStorageLensNotConfigured) {
+			return echo.NewHTTPError(http.StatusNotImplemented, "Storage Lens exports are not configured on this server")
+		}
+
+		s.core.Logger.Error().Err(err).Msg("Error starting Storage Lens ingest")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start Storage Lens ingest")
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,13 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getVersion reports the build, enabled features and configured backends so operators
+// can verify what is deployed
+func (s *Server) getVersion(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.core.VersionInfo())
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/aws/smithy-go"
+	"github.com/labstack/echo/v4"
+)
+
+// listObjectVersions handles GET /api/buckets/:bucket/versions?prefix=&delimiter=&cursor=&pageSize=&all=
+func (s *Server) listObjectVersions(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var pageSize int32
+	if val, err := strconv.ParseInt(c.QueryParam("pageSize"), 10, 32); err == nil {
+		pageSize = int32(val)
+	}
+
+	if wantsAll(c) {
+		if c.QueryParam("delimiter") != "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "A delimiter cannot be combined with all=true")
+		}
+		err := streamAll(s, c, "versions", func(emit func([]models.ObjectVersion) error) (string, error) {
+			return s.core.S3Service.ListAllObjectVersions(c.Request().Context(), bucket, c.QueryParam("prefix"), c.QueryParam("cursor"), func(page *models.ListVersionsResponse) error {
+				return emit(page.Versions)
+			})
+		})
+		if err != nil {
+			return s.listVersionsError(err, bucket)
+		}
+		return nil
+	}
+
+	versions, err := s.core.S3Service.ListObjectVersions(
+		c.Request().Context(),
+		bucket,
+		c.QueryParam("prefix"),
+		c.QueryParam("delimiter"),
+		c.QueryParam("cursor"),
+		pageSize,
+	)
+	if err != nil {
+		return s.listVersionsError(err, bucket)
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+// listVersionsError maps errors from listing object versions to HTTP errors
+func (s *Server) listVersionsError(err error, bucket string) error {
+	if errors.Is(err, core.ErrInvalidCursor) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+	}
+	if isNoSuchBucketError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+	if isAccessDeniedError(err) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().Err(err).Str("bucket", bucket).Msg("Error listing object versions")
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list object versions")
+}
+
+// isNoSuchVersionError checks if the error names a version that does not exist
+func isNoSuchVersionError(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "NoSuchVersion"
+	}
+	return false
+}
+
+// isInvalidArgumentError checks if S3 rejected a parameter, such as a malformed
+// version ID
+func isInvalidArgumentError(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "InvalidArgument"
+	}
+	return false
+}
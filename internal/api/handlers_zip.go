@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// downloadZip handles GET /api/buckets/:bucket/download-zip
+func (s *Server) downloadZip(c echo.Context) error {
+	bucket := c.Param("bucket")
+	prefix := c.QueryParam("prefix")
+
+	manifest, err := s.core.S3Service.PrepareZipFromPrefix(c.Request().Context(), bucket, prefix)
+	if err != nil {
+		return s.zipError(err, bucket)
+	}
+
+	return s.streamZip(c, manifest, zipFileName(bucket, prefix))
+}
+
+// downloadSelectionZip handles POST /api/buckets/:bucket/download-zip
+func (s *Server) downloadSelectionZip(c echo.Context) error {
+	bucket := c.Param("bucket")
+
+	var req models.DownloadZipRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if len(req.Keys) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "At least one key is required")
+	}
+
+	manifest, err := s.core.S3Service.PrepareZipFromKeys(c.Request().Context(), bucket, req.Keys)
+	if err != nil {
+		return s.zipError(err, bucket)
+	}
+
+	filename := zipFileName(bucket, "")
+	if req.Name != "" {
+		filename = strings.TrimSuffix(path.Base(req.Name), ".zip") + ".zip"
+	}
+	return s.streamZip(c, manifest, filename)
+}
+
+// streamZip writes a prepared manifest to the response as a ZIP attachment
+func (s *Server) streamZip(c echo.Context, manifest *core.ZipManifest, filename string) error {
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "application/zip")
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	liftWriteDeadline(c)
+	c.Response().WriteHeader(http.StatusOK)
+
+	// The status line has been sent, so failures can only be logged (WriteZip does that)
+	_ = s.core.S3Service.WriteZip(c.Request().Context(), manifest, c.Response())
+	return nil
+}
+
+// zipError maps errors from preparing a ZIP download to HTTP errors
+func (s *Server) zipError(err error, bucket string) error {
+	if errors.Is(err, core.ErrZipTooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Selection exceeds the zip download size limit")
+	}
+	if errors.Is(err, core.ErrZipEmpty) {
+		return echo.NewHTTPError(http.StatusNotFound, "No objects to download")
+	}
+	if isNoSuchBucketError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+	}
+	if isNoSuchKeyError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "Object not found")
+	}
+	if isAccessDeniedError(err) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	s.core.Logger.Error().
+		Err(err).
+		Str("bucket", bucket).
+		Msg("Error preparing zip download")
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to prepare zip download")
+}
+
+// zipFileName names a ZIP download after the last segment of its prefix, or the bucket
+func zipFileName(bucket, prefix string) string {
+	name := path.Base(strings.TrimSuffix(prefix, "/"))
+	if name == "." || name == "/" || name == "" {
+		name = bucket
+	}
+	return name + ".zip"
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"explorer451/internal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// anonymousUser owns per-user data when no user is identified
+const anonymousUser = "anonymous"
+
+// currentUser returns the user a request was made by, as named in the configured user
+// header by a trusted proxy, or anonymousUser. Disabled users are anonymous too.
+func (s *Server) currentUser(c echo.Context) string {
+	cfg := s.core.Config.Server
+	if cfg.UserHeader == "" || !fromTrustedProxy(cfg, c.Request()) {
+		return anonymousUser
+	}
+	user := strings.TrimSpace(c.Request().Header.Get(cfg.UserHeader))
+	if user == "" || (s.core.Access != nil && s.core.Access.Disabled(user)) {
+		return anonymousUser
+	}
+	return user
+}
+
+// fromTrustedProxy reports whether a request comes straight from a proxy trusted to
+// set identity headers, one of the configured proxies. Unlike clientIPExtractor, no
+// address is trusted when none are configured: a user name is a credential, and any
+// host on a private network could otherwise claim one.
+func fromTrustedProxy(cfg config.ServerConfig, req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if ipNet, err := config.ParseTrustedProxy(proxy); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
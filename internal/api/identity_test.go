@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrentUser(t *testing.T) {
+	user := func(cfg config.ServerConfig, remoteAddr, header string) string {
+		s := &Server{core: &core.Core{Config: &config.Config{Server: cfg}}}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if header != "" {
+			req.Header.Set("X-Forwarded-User", header)
+		}
+		return s.currentUser(echo.New().NewContext(req, httptest.NewRecorder()))
+	}
+	withHeader := config.ServerConfig{UserHeader: "X-Forwarded-User"}
+	withProxies := config.ServerConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{"192.0.2.10", "10.0.0.0/8"}}
+
+	assert.Equal(t, "alice", user(withProxies, "10.0.0.5:4000", "alice"))
+	assert.Equal(t, anonymousUser, user(withProxies, "10.0.0.5:4000", ""))
+	assert.Equal(t, anonymousUser, user(config.ServerConfig{}, "10.0.0.5:4000", "alice"))
+
+	// The header is ignored unless a trusted proxy set it
+	assert.Equal(t, anonymousUser, user(withProxies, "198.51.100.1:4000", "mallory"))
+	assert.Equal(t, "alice", user(withProxies, "192.0.2.10:4000", "alice"))
+
+	// Without configured proxies, not even private addresses are trusted
+	assert.Equal(t, anonymousUser, user(withHeader, "10.0.0.5:4000", "mallory"))
+	assert.Equal(t, anonymousUser, user(withHeader, "127.0.0.1:4000", "mallory"))
+}
+
+func TestRequireAdmin(t *testing.T) {
+	c := &core.Core{
+		Config: &config.Config{Server: config.ServerConfig{
+			UserHeader:     "X-Forwarded-User",
+			TrustedProxies: []string{"192.0.2.10"},
+			AdminAccess:    true,
+			AdminUsers:     []string{"root", "dave"},
+		}},
+		Logger: logger.New("error", "json"),
+	}
+	c.Access = core.NewAccessService(c)
+	s := &Server{core: c}
+	for _, user := range []models.CreateUserRequest{
+		{Name: "alice", Roles: []string{"admin"}},
+		{Name: "bob", Roles: []string{"editor"}},
+		{Name: "dave"},
+	} {
+		_, err := c.Access.CreateUser(user)
+		require.NoError(t, err)
+	}
+	disabled := true
+	_, err := c.Access.UpdateUser("dave", models.UpdateUserRequest{Disabled: &disabled})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.GET("/api/admin/users", s.requireAdmin(func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }))
+	request := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		req.RemoteAddr = "192.0.2.10:4000"
+		if user != "" {
+			req.Header.Set("X-Forwarded-User", user)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("alice"))
+	assert.Equal(t, http.StatusNoContent, request("root"), "server.adminUsers need no stored user")
+	assert.Equal(t, http.StatusForbidden, request("bob"))
+	assert.Equal(t, http.StatusForbidden, request(""))
+	// Disabled users are anonymous, even when named in server.adminUsers
+	assert.Equal(t, http.StatusForbidden, request("dave"))
+	assert.Equal(t, anonymousUser, s.currentUser(echo.New().NewContext(func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.10:4000"
+		req.Header.Set("X-Forwarded-User", "dave")
+		return req
+	}(), httptest.NewRecorder())))
+}
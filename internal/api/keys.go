@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// objectKeyContextKey stores a key resolved by objectRouter in the echo context
+const objectKeyContextKey = "objectKey"
+
+// objectActions holds every trailing action segment objectRouter dispatches on, for
+// any method. objectPath escapes the last slash of keys ending in one of them.
+var objectActions = map[string]bool{
+	"preview": true, "thumbnail": true, "stream": true, "download": true, "archive": true,
+	"image-metadata": true, "content": true, "tail": true, "bytes": true, "media-metadata": true,
+	"pdf-metadata": true, "acl": true, "tags": true, "parts": true, "checksums": true,
+	"history": true, "exists": true, "head-url": true, "extract": true, "scan": true,
+	"query": true, "metadata": true, "rename": true,
+}
+
+// objectKeyParam returns the object key captured by a route's trailing wildcard.
+//
+// Keys travel in the path with each segment percent-encoded (see objectPath), so
+// spaces, '+', '%', '?', '#' and unicode survive unchanged and '+' always means a
+// literal plus. Echo routes on the raw path whenever the request contains escapes
+// that do not round-trip (such as %2F), in which case the wildcard value is still
+// escaped and has to be decoded here; otherwise it has already been decoded.
+func objectKeyParam(c echo.Context) (string, error) {
+	if key, ok := c.Get(objectKeyContextKey).(string); ok {
+		return key, nil
+	}
+	return decodeObjectKey(c, c.Param("*"))
+}
+
+// decodeObjectKey decodes a wildcard value taken from the request path
+func decodeObjectKey(c echo.Context, value string) (string, error) {
+	if c.Request().URL.RawPath == "" {
+		return value, nil
+	}
+
+	decoded, err := url.PathUnescape(value)
+	if err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "Invalid object key encoding")
+	}
+	return decoded, nil
+}
+
+// objectRouter dispatches a wildcard object route to the handler registered for a
+// trailing action segment, e.g. /objects/<key>/preview, and falls back to the plain
+// object handler otherwise. A key that itself ends in an action name can still be
+// addressed by encoding its last slash as %2F, as objectPath does; every action must
+// therefore be listed in objectActions.
+func objectRouter(fallback echo.HandlerFunc, actions map[string]echo.HandlerFunc) echo.HandlerFunc {
+	for action := range actions {
+		if !objectActions[action] {
+			panic("api: object action " + action + " is missing from objectActions")
+		}
+	}
+
+	return func(c echo.Context) error {
+		raw := c.Param("*")
+		for action, handler := range actions {
+			if !hasObjectAction(c, action) {
+				continue
+			}
+
+			key, err := decodeObjectKey(c, strings.TrimSuffix(raw, "/"+action))
+			if err != nil {
+				return err
+			}
+			c.Set(objectKeyContextKey, key)
+			return handler(c)
+		}
+
+		return fallback(c)
+	}
+}
+
+// hasObjectAction reports whether a wildcard object route addresses the given action.
+// The action has to follow a literal slash, so ".../docs%2Fhistory" names the key
+// docs/history rather than its history.
+func hasObjectAction(c echo.Context, action string) bool {
+	raw := c.Param("*")
+	suffix := "/" + action
+	return len(raw) > len(suffix) && strings.HasSuffix(raw, suffix)
+}
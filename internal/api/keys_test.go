@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectKeyParam_RoundTrip(t *testing.T) {
+	keys := []string{
+		"plain/file.txt",
+		"with space/my file.txt",
+		"plus+sign/a+b.txt",
+		"percent/100%.txt",
+		"literal-escape/a%2Fb.txt",
+		"query chars/what?#&=.txt",
+		"unicode/été/日本語.txt",
+		"emoji/🚀.png",
+		"double//slash/file.txt",
+		"folder/",
+		"docs/history",
+		"logs/tail",
+		"img/thumbnail",
+		"x/tags",
+	}
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			e := echo.New()
+			var got string
+			e.GET("/api/buckets/:bucket/objects/*", func(c echo.Context) error {
+				var err error
+				got, err = objectKeyParam(c)
+				return err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, objectPath(apiPrefix, "test-bucket", key), nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, key, got)
+		})
+	}
+}
+
+func TestObjectKeyParam_UnencodedClient(t *testing.T) {
+	// Clients that only escape what is strictly required still resolve to the intended key
+	e := echo.New()
+	var got string
+	e.GET("/api/buckets/:bucket/objects/*", func(c echo.Context) error {
+		var err error
+		got, err = objectKeyParam(c)
+		return err
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/buckets/test-bucket/objects/docs/a+b%20c.txt", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "docs/a+b c.txt", got)
+}
+
+func TestObjectRouter(t *testing.T) {
+	var got, handler string
+	record := func(name string) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			handler = name
+			var err error
+			got, err = objectKeyParam(c)
+			return err
+		}
+	}
+
+	e := echo.New()
+	e.GET("/api/buckets/:bucket/objects/*", objectRouter(record("object"), map[string]echo.HandlerFunc{
+		"preview": record("preview"),
+	}))
+
+	tests := []struct {
+		path    string
+		handler string
+		key     string
+	}{
+		{path: "/api/buckets/b/objects/docs/a%20b.txt/preview", handler: "preview", key: "docs/a b.txt"},
+		{path: "/api/buckets/b/objects/docs/a%20b.txt", handler: "object", key: "docs/a b.txt"},
+		{path: "/api/buckets/b/objects/preview", handler: "object", key: "preview"},
+		{path: "/api/buckets/b/objects/docs%2Fpreview", handler: "object", key: "docs/preview"},
+		{path: "/api/buckets/b/objects/a%2Fb/preview", handler: "preview", key: "a/b"},
+		{path: objectPath(apiPrefix, "b", "img/preview"), handler: "object", key: "img/preview"},
+		{path: objectPath(apiPrefix, "b", "img/preview") + "/preview", handler: "preview", key: "img/preview"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.handler, handler)
+			assert.Equal(t, tt.key, got)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"explorer451/internal/models"
+)
+
+// apiPrefix is the path all API routes are registered under, below the configured
+// base path. Link helpers take the resulting API root as their first argument.
+const apiPrefix = "/api"
+
+// bucketPath returns the API path for a bucket
+func bucketPath(apiRoot, bucket string) string {
+	return apiRoot + "/buckets/" + url.PathEscape(bucket)
+}
+
+// objectPath returns the API path for an object, escaping each key segment
+// while keeping the slashes that separate them. objectKeyParam reverses it.
+// When the last segment is an object action, such as docs/history, the slash
+// before it is escaped as well so that objectRouter does not take it for one.
+func objectPath(apiRoot, bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	path := strings.Join(segments, "/")
+	if last := len(segments) - 1; last > 0 && objectActions[segments[last]] {
+		path = strings.Join(segments[:last], "/") + "%2F" + segments[last]
+	}
+	return bucketPath(apiRoot, bucket) + "/objects/" + path
+}
+
+// listPath returns the API path listing the objects under a prefix
+func listPath(apiRoot, bucket, prefix, cursor, delimiter string, pageSize int32) string {
+	query := url.Values{}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if pageSize > 0 {
+		query.Set("pageSize", strconv.FormatInt(int64(pageSize), 10))
+	}
+
+	path := bucketPath(apiRoot, bucket) + "/objects"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
+
+// parentPrefix returns the prefix one level above the given folder prefix.
+// The root prefix has no parent, which is reported through the second return value.
+func parentPrefix(prefix string) (string, bool) {
+	if prefix == "" {
+		return "", false
+	}
+
+	trimmed := strings.TrimSuffix(prefix, "/")
+	idx := strings.LastIndex(trimmed, "/")
+	if idx < 0 {
+		return "", true
+	}
+	return trimmed[:idx+1], true
+}
+
+// addListLinks decorates a listing response and its entries with navigation links
+func addListLinks(resp *models.ListObjectsResponse, apiRoot, bucket, prefix, cursor, delimiter string) {
+	pageSize := int32(resp.Pagination.PageSize)
+	resp.Links = &models.ListLinks{
+		Self: listPath(apiRoot, bucket, prefix, cursor, delimiter, pageSize),
+	}
+	if resp.Pagination.HasMore && resp.Pagination.Cursor != "" {
+		resp.Links.Next = listPath(apiRoot, bucket, prefix, resp.Pagination.Cursor, delimiter, pageSize)
+	}
+	if parent, ok := parentPrefix(prefix); ok {
+		resp.Links.Parent = listPath(apiRoot, bucket, parent, "", delimiter, pageSize)
+	}
+
+	for i := range resp.Objects {
+		obj := &resp.Objects[i]
+		if obj.IsFolder {
+			obj.Links = &models.ObjectLinks{
+				Children: listPath(apiRoot, bucket, obj.Key, "", delimiter, pageSize),
+			}
+			continue
+		}
+
+		path := objectPath(apiRoot, bucket, obj.Key)
+		obj.Links = &models.ObjectLinks{
+			Metadata:     path,
+			PresignedURL: path,
+		}
+	}
+}
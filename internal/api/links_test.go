@@ -0,0 +1,70 @@
+package api
+
+import (
+	"testing"
+
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParentPrefix(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefix    string
+		expected  string
+		hasParent bool
+	}{
+		{name: "root", prefix: "", expected: "", hasParent: false},
+		{name: "top level folder", prefix: "docs/", expected: "", hasParent: true},
+		{name: "nested folder", prefix: "docs/2024/reports/", expected: "docs/2024/", hasParent: true},
+		{name: "partial prefix", prefix: "docs/rep", expected: "docs/", hasParent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, ok := parentPrefix(tt.prefix)
+			assert.Equal(t, tt.hasParent, ok)
+			assert.Equal(t, tt.expected, parent)
+		})
+	}
+}
+
+func TestAddListLinks(t *testing.T) {
+	resp := &models.ListObjectsResponse{
+		Objects: []models.ObjectInfo{
+			{Key: "docs/reports/", IsFolder: true, Type: "folder"},
+			{Key: "docs/my file.txt", Type: "file"},
+		},
+		Pagination: models.Pagination{
+			Cursor:   "abc123",
+			HasMore:  true,
+			PageSize: 100,
+		},
+	}
+
+	addListLinks(resp, apiPrefix, "test-bucket", "docs/", "", "")
+
+	assert.Equal(t, "/api/buckets/test-bucket/objects?pageSize=100&prefix=docs%2F", resp.Links.Self)
+	assert.Equal(t, "/api/buckets/test-bucket/objects?cursor=abc123&pageSize=100&prefix=docs%2F", resp.Links.Next)
+	assert.Equal(t, "/api/buckets/test-bucket/objects?pageSize=100", resp.Links.Parent)
+
+	assert.Equal(t, "/api/buckets/test-bucket/objects?pageSize=100&prefix=docs%2Freports%2F", resp.Objects[0].Links.Children)
+	assert.Empty(t, resp.Objects[0].Links.Metadata)
+
+	assert.Equal(t, "/api/buckets/test-bucket/objects/docs/my%20file.txt", resp.Objects[1].Links.Metadata)
+	assert.Equal(t, "/api/buckets/test-bucket/objects/docs/my%20file.txt", resp.Objects[1].Links.PresignedURL)
+	assert.Empty(t, resp.Objects[1].Links.Children)
+}
+
+func TestAddListLinksBasePath(t *testing.T) {
+	resp := &models.ListObjectsResponse{
+		Objects:    []models.ObjectInfo{{Key: "a.txt", Type: "file"}},
+		Pagination: models.Pagination{PageSize: 100},
+	}
+
+	addListLinks(resp, "/s3-explorer"+apiPrefix, "test-bucket", "", "", "")
+
+	assert.Equal(t, "/s3-explorer/api/buckets/test-bucket/objects?pageSize=100", resp.Links.Self)
+	assert.Equal(t, "/s3-explorer/api/buckets/test-bucket/objects/a.txt", resp.Objects[0].Links.Metadata)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// wantsAll reports whether a listing asks for all=true, every page in one response
+func wantsAll(c echo.Context) bool {
+	return c.QueryParam("all") == "true"
+}
+
+// streamAll streams a listing walked page by page as one JSON document: the entries of
+// every page in an array under field, then a pagination envelope whose cursor resumes
+// the listing when listing.maxAllItems cut it short. Nothing is written before the
+// first page arrives, so an error listing it is returned for the caller to answer;
+// later errors can only be logged, and end the document early.
+func streamAll[T any](s *Server, c echo.Context, field string, walk func(emit func([]T) error) (string, error)) error {
+	response := c.Response()
+	count := 0
+	started := false
+	start := func() error {
+		started = true
+		response.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		response.WriteHeader(http.StatusOK)
+		name, _ := json.Marshal(field)
+		_, err := response.Write([]byte("{" + string(name) + ":["))
+		return err
+	}
+	emit := func(entries []T) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for _, entry := range entries {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				data = append([]byte(","), data...)
+			}
+			if _, err := response.Write(data); err != nil {
+				return err
+			}
+			count++
+		}
+		// Hand each page to the client before listing the next one
+		response.Flush()
+		return nil
+	}
+
+	cursor, err := walk(emit)
+	if err != nil {
+		if !started {
+			return err
+		}
+		s.core.Logger.Error().Err(err).Str("path", c.Path()).Msg("Error streaming listing, response is incomplete")
+		return nil
+	}
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+
+	pagination, err := json.Marshal(models.Pagination{
+		Cursor:      cursor,
+		HasMore:     cursor != "",
+		PageSize:    count,
+		MaxPageSize: s.core.Config.Listing.MaxAllItems,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = response.Write([]byte(`],"pagination":` + string(pagination) + "}"))
+	return err
+}
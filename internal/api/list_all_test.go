@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamAll(t *testing.T) {
+	s := &Server{core: &core.Core{
+		Config: &config.Config{Listing: config.ListingConfig{MaxAllItems: 3}},
+		Logger: logger.New("error", "json"),
+	}}
+	stream := func(walk func(emit func([]models.ObjectInfo) error) (string, error)) (*httptest.ResponseRecorder, error) {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		return rec, streamAll(s, c, "objects", walk)
+	}
+
+	rec, err := stream(func(emit func([]models.ObjectInfo) error) (string, error) {
+		require.NoError(t, emit([]models.ObjectInfo{{Key: "a.txt"}, {Key: "b.txt"}}))
+		require.NoError(t, emit([]models.ObjectInfo{{Key: "c.txt"}}))
+		return "next", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var listing models.ListObjectsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listing))
+	require.Len(t, listing.Objects, 3)
+	assert.Equal(t, "c.txt", listing.Objects[2].Key)
+	assert.True(t, listing.Pagination.HasMore)
+	assert.Equal(t, "next", listing.Pagination.Cursor)
+	assert.Equal(t, 3, listing.Pagination.PageSize)
+
+	// An empty listing is still a document
+	rec, err = stream(func(emit func([]models.ObjectInfo) error) (string, error) { return "", nil })
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"objects":[],"pagination":{"hasMore":false,"pageSize":0,"maxPageSize":3}}`, rec.Body.String())
+
+	// Errors before the first page are left for the handler to answer
+	failed := errors.New("no such bucket")
+	rec, err = stream(func(emit func([]models.ObjectInfo) error) (string, error) { return "", failed })
+	assert.ErrorIs(t, err, failed)
+	assert.Empty(t, rec.Body.String())
+
+	// Later ones end the document early
+	rec, err = stream(func(emit func([]models.ObjectInfo) error) (string, error) {
+		require.NoError(t, emit([]models.ObjectInfo{{Key: "a.txt"}}))
+		return "", failed
+	})
+	require.NoError(t, err)
+	assert.False(t, json.Valid(rec.Body.Bytes()))
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemdFirstFD is the first file descriptor systemd passes with socket activation
+const systemdFirstFD = 3
+
+// errSocketTLS is returned when TLS is combined with a listener that does not support it
+var errSocketTLS = errors.New("TLS is not supported on unix sockets or systemd-activated sockets; terminate TLS in the proxy in front")
+
+// listener returns the listener to serve on when the server does not simply listen on a
+// TCP address: a socket passed by systemd socket activation, or a unix domain socket for
+// addresses of the form unix:/path/to.sock. It returns nil when echo should listen on
+// the address itself.
+func (s *Server) listener(address string) (net.Listener, error) {
+	cfg := s.core.Config.Server
+
+	if l, err := systemdListener(); l != nil || err != nil {
+		return l, err
+	}
+
+	path, ok := strings.CutPrefix(address, "unix:")
+	if !ok {
+		return nil, nil
+	}
+
+	// A socket file left behind by an unclean exit would make Listen fail
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := strconv.ParseUint(cfg.SocketMode, 8, 32)
+	if err == nil {
+		err = os.Chmod(path, os.FileMode(mode))
+	}
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("setting socket mode %s: %w", cfg.SocketMode, err)
+	}
+	return l, nil
+}
+
+// systemdListener returns the socket passed by systemd socket activation, if any
+func systemdListener() (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+	if count > 1 {
+		return nil, fmt.Errorf("systemd passed %d sockets, expected one", count)
+	}
+
+	// Keep the variables from leaking into child processes such as ffprobe
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(systemdFirstFD, "systemd-socket")
+	defer file.Close()
+	return net.FileListener(file)
+}
+
+// notifySystemd sends a state update such as READY=1 to systemd when running as a
+// Type=notify service. It does nothing when NOTIFY_SOCKET is not set.
+func notifySystemd(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading @ denotes a socket in the abstract namespace
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// notifyWhenListening tells systemd the server is ready once echo has opened its
+// listener, or gives up when startup does not get that far
+func (s *Server) notifyWhenListening() {
+	for range 600 {
+		if s.echo.ListenerAddr() != nil || s.echo.TLSListenerAddr() != nil {
+			if err := notifySystemd("READY=1"); err != nil {
+				s.core.Logger.Warn().Err(err).Msg("Failed to notify systemd of readiness")
+			}
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
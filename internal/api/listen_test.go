@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener_UnixSocket(t *testing.T) {
+	s := &Server{core: &core.Core{Config: &config.Config{
+		Server: config.ServerConfig{SocketMode: "0600"},
+	}}}
+	path := filepath.Join(t.TempDir(), "explorer451.sock")
+
+	// A stale socket file from an earlier run is replaced
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	l, err := s.listener("unix:" + path)
+	require.NoError(t, err)
+	defer l.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSocket, info.Mode().Type())
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// TCP addresses are left to echo
+	l, err = s.listener(":8080")
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+}
+
+func TestSystemdListener_OtherProcess(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	l, err := systemdListener()
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+}
+
+func TestNotifySystemd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	require.NoError(t, notifySystemd("READY=1"))
+
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "READY=1", string(buf[:n]))
+
+	// Without NOTIFY_SOCKET there is nothing to notify
+	t.Setenv("NOTIFY_SOCKET", "")
+	assert.NoError(t, notifySystemd("READY=1"))
+}
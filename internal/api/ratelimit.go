@@ -0,0 +1,77 @@
+package api
+
+import (
+	"strings"
+
+	"explorer451/internal/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// clientIPExtractor returns how the client address is determined for logging and
+// rate limiting. Proxy headers are only trusted from the configured proxies, or from
+// loopback, link-local and private addresses when none are configured, so a client
+// connecting directly cannot spoof its address.
+func clientIPExtractor(cfg config.ServerConfig) echo.IPExtractor {
+	var trust []echo.TrustOption
+	if len(cfg.TrustedProxies) > 0 {
+		trust = append(trust, echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false))
+		for _, proxy := range cfg.TrustedProxies {
+			// Entries are checked when the configuration is loaded
+			if ipNet, err := config.ParseTrustedProxy(proxy); err == nil {
+				trust = append(trust, echo.TrustIPRange(ipNet))
+			}
+		}
+	}
+
+	switch strings.ToLower(cfg.ClientIPHeader) {
+	case "x-forwarded-for":
+		return echo.ExtractIPFromXFFHeader(trust...)
+	case "x-real-ip":
+		return echo.ExtractIPFromRealIPHeader(trust...)
+	default:
+		return echo.ExtractIPDirect()
+	}
+}
+
+// rateLimiters returns the configured rate limiting middleware: a global limit shared
+// by all clients, then a per-IP limit. Health checks are never limited.
+func (s *Server) rateLimiters() []echo.MiddlewareFunc {
+	cfg := s.core.Config.RateLimit
+	healthPath := s.core.Config.Server.BasePath + "/health"
+	skipHealth := func(c echo.Context) bool {
+		return c.Path() == healthPath
+	}
+
+	var limiters []echo.MiddlewareFunc
+	if cfg.GlobalRate > 0 {
+		limiters = append(limiters, rateLimiter(cfg.GlobalRate, cfg.GlobalBurst, skipHealth, func(echo.Context) (string, error) {
+			return "global", nil
+		}))
+	}
+	if cfg.PerIPRate > 0 {
+		limiters = append(limiters, rateLimiter(cfg.PerIPRate, cfg.PerIPBurst, skipHealth, func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		}))
+	}
+	return limiters
+}
+
+// rateLimiter limits the requests sharing an identifier to ratePerSecond, answering
+// 429 with a Retry-After hint once the burst is used up
+func rateLimiter(ratePerSecond float64, burst int, skipper middleware.Skipper, identifier middleware.Extractor) echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Skipper:             skipper,
+		IdentifierExtractor: identifier,
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:  rate.Limit(ratePerSecond),
+			Burst: burst,
+		}),
+		DenyHandler: func(c echo.Context, _ string, _ error) error {
+			c.Response().Header().Set("Retry-After", "1")
+			return middleware.ErrRateLimitExceeded
+		},
+	})
+}
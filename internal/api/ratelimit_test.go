@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_PerIP(t *testing.T) {
+	e := echo.New()
+	e.Use(rateLimiter(0.001, 2, middleware.DefaultSkipper, func(c echo.Context) (string, error) {
+		return c.RealIP(), nil
+	}))
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("192.0.2.1:1000").Code)
+	assert.Equal(t, http.StatusOK, request("192.0.2.1:1001").Code)
+
+	rec := request("192.0.2.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	// Other clients have their own budget
+	assert.Equal(t, http.StatusOK, request("192.0.2.2:1000").Code)
+}
+
+func TestClientIPExtractor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+
+	// Forwarded addresses are trusted from a private proxy address only
+	req.RemoteAddr = "10.0.0.5:4000"
+	assert.Equal(t, "203.0.113.7", clientIPExtractor(config.ServerConfig{ClientIPHeader: "X-Forwarded-For"})(req))
+	assert.Equal(t, "10.0.0.5", clientIPExtractor(config.ServerConfig{})(req))
+
+	req.RemoteAddr = "198.51.100.1:4000"
+	assert.Equal(t, "198.51.100.1", clientIPExtractor(config.ServerConfig{ClientIPHeader: "X-Forwarded-For"})(req))
+}
+
+func TestClientIPExtractor_TrustedProxies(t *testing.T) {
+	cfg := config.ServerConfig{ClientIPHeader: "X-Forwarded-For", TrustedProxies: []string{"198.51.100.0/24", "192.0.2.10"}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7, 198.51.100.20")
+
+	// The client is the first address not added by a trusted proxy
+	req.RemoteAddr = "192.0.2.10:4000"
+	assert.Equal(t, "203.0.113.7", clientIPExtractor(cfg)(req))
+
+	// Private addresses are no longer trusted once proxies are configured
+	req.RemoteAddr = "10.0.0.5:4000"
+	assert.Equal(t, "10.0.0.5", clientIPExtractor(cfg)(req))
+
+	cfg.ClientIPHeader = "X-Real-IP"
+	req.Header.Set(echo.HeaderXRealIP, "203.0.113.8")
+	req.RemoteAddr = "198.51.100.3:4000"
+	assert.Equal(t, "203.0.113.8", clientIPExtractor(cfg)(req))
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// recoverMiddleware turns a panicking handler into a logged 500 response instead of a
+// dropped connection
+func (s *Server) recoverMiddleware() echo.MiddlewareFunc {
+	return middleware.RecoverWithConfig(middleware.RecoverConfig{
+		DisableStackAll: true,
+		LogErrorFunc:    s.handlePanic,
+	})
+}
+
+// handlePanic logs a recovered panic with its stack and the request it happened in,
+// then answers with a problem+json error carrying the request ID for reference
+func (s *Server) handlePanic(c echo.Context, err error, stack []byte) error {
+	requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+	s.core.Logger.Error().
+		Err(err).
+		Str("request_id", requestID).
+		Str("user", s.currentUser(c)).
+		Str("method", c.Request().Method).
+		Str("route", c.Path()).
+		Str("uri", c.Request().RequestURI).
+		Str("remote_ip", c.RealIP()).
+		Bytes("stack", stack).
+		Msg("Recovered from panic while handling request")
+
+	// Part of a response already went out, so the best left to do is to end it
+	if c.Response().Committed {
+		return nil
+	}
+
+	body, _ := json.Marshal(models.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(http.StatusInternalServerError),
+		Status:    http.StatusInternalServerError,
+		Detail:    "The server hit an unexpected error while handling the request",
+		Instance:  c.Request().URL.Path,
+		RequestID: requestID,
+	})
+	return c.Blob(http.StatusInternalServerError, models.ProblemContentType, body)
+}
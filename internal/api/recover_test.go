@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	s := &Server{core: &core.Core{
+		Config: &config.Config{Server: config.ServerConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{"10.0.0.0/8"}}},
+		Logger: &logger.Logger{Logger: zerolog.New(&logs)},
+	}}
+
+	e := echo.New()
+	e.Use(s.recoverMiddleware())
+	e.Use(middleware.RequestID())
+	e.GET("/buckets/:bucket", func(echo.Context) error {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/buckets/photos", nil)
+	req.RemoteAddr = "10.0.0.5:4000"
+	req.Header.Set("X-Forwarded-User", "alice")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, models.ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	requestID := rec.Header().Get(echo.HeaderXRequestID)
+	assert.NotEmpty(t, requestID)
+	assert.Equal(t, requestID, problem.RequestID)
+	assert.Equal(t, http.StatusInternalServerError, problem.Status)
+	assert.Equal(t, "/buckets/photos", problem.Instance)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "boom", entry["error"])
+	assert.Equal(t, requestID, entry["request_id"])
+	assert.Equal(t, "/buckets/:bucket", entry["route"])
+	assert.Equal(t, "alice", entry["user"])
+	assert.Contains(t, entry["stack"], "TestRecoverMiddleware")
+}
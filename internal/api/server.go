@@ -2,19 +2,26 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"time"
 
 	"explorer451/internal/core"
+	"explorer451/internal/ui"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 // Server represents the HTTP server
 type Server struct {
 	echo *echo.Echo
 	core *core.Core
+	// redirect serves the optional plain HTTP to HTTPS redirect
+	redirect *http.Server
 }
 
 // NewServer creates a new HTTP server
@@ -24,14 +31,25 @@ func NewServer(core *core.Core) *Server {
 		core: core,
 	}
 
+	cfg := core.Config.Server
+	s.echo.IPExtractor = clientIPExtractor(cfg)
+
 	// Configure middleware
-	s.echo.Use(middleware.Recover())
+	s.echo.Use(s.recoverMiddleware())
 	s.echo.Use(middleware.Logger())
 	s.echo.Use(middleware.CORS())
 	s.echo.Use(middleware.RequestID())
-	s.echo.Use(middleware.TimeoutWithConfig(middleware.TimeoutConfig{
-		Timeout: 30 * time.Second,
-	}))
+	s.echo.Use(s.rateLimiters()...)
+	s.echo.Use(middleware.BodyLimit(fmt.Sprintf("%dB", cfg.MaxBodyBytes)))
+	s.echo.Use(s.timeoutMiddleware())
+
+	for _, server := range []*http.Server{s.echo.Server, s.echo.TLSServer} {
+		server.ReadTimeout = cfg.ReadTimeout
+		server.ReadHeaderTimeout = cfg.ReadHeaderTimeout
+		server.WriteTimeout = cfg.WriteTimeout
+		server.IdleTimeout = cfg.IdleTimeout
+		server.MaxHeaderBytes = cfg.MaxHeaderBytes
+	}
 
 	// Setup routes
 	s.setupRoutes()
@@ -39,33 +57,278 @@ func NewServer(core *core.Core) *Server {
 	return s
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server, serving HTTPS when TLS is configured. The address may
+// name a unix socket, and a socket passed by systemd socket activation is used in
+// preference to it. systemd is notified once the server is listening.
 func (s *Server) Start(address string) error {
-	return s.echo.Start(address)
+	cfg := s.core.Config.Server.TLS
+
+	listener, err := s.listener(address)
+	if err != nil {
+		return err
+	}
+	if listener != nil {
+		if cfg.Enabled() {
+			listener.Close()
+			return errSocketTLS
+		}
+		s.echo.Listener = listener
+	}
+	go s.notifyWhenListening()
+
+	if cfg.Autocert.Enabled {
+		s.echo.AutoTLSManager.Prompt = autocert.AcceptTOS
+		s.echo.AutoTLSManager.HostPolicy = autocert.HostWhitelist(cfg.Autocert.Hosts...)
+		s.echo.AutoTLSManager.Cache = autocert.DirCache(cfg.Autocert.CacheDir)
+		s.echo.AutoTLSManager.Email = cfg.Autocert.Email
+	}
+
+	if cfg.RedirectAddress != "" {
+		s.startRedirect(cfg.RedirectAddress)
+	}
+
+	switch {
+	case cfg.Autocert.Enabled:
+		return s.echo.StartAutoTLS(address)
+	case cfg.CertFile != "":
+		return s.echo.StartTLS(address, cfg.CertFile, cfg.KeyFile)
+	default:
+		return s.echo.Start(address)
+	}
+}
+
+// startRedirect serves plain HTTP on address, redirecting every request to HTTPS.
+// With autocert it also answers ACME HTTP-01 challenges.
+func (s *Server) startRedirect(address string) {
+	var handler http.Handler = http.HandlerFunc(redirectToHTTPS)
+	if s.core.Config.Server.TLS.Autocert.Enabled {
+		handler = s.echo.AutoTLSManager.HTTPHandler(handler)
+	}
+
+	s.redirect = &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := s.redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.core.Logger.Error().Err(err).Str("address", address).Msg("HTTPS redirect server error")
+		}
+	}()
+}
+
+// redirectToHTTPS permanently redirects a request to the same URL over HTTPS
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
 }
 
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
+	if err := notifySystemd("STOPPING=1"); err != nil {
+		s.core.Logger.Warn().Err(err).Msg("Failed to notify systemd of shutdown")
+	}
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			s.core.Logger.Warn().Err(err).Msg("Failed to shut down HTTPS redirect server")
+		}
+	}
 	return s.echo.Shutdown(ctx)
 }
 
+// apiRoot returns the path the API is served under, including the base path
+func (s *Server) apiRoot() string {
+	return s.core.Config.Server.BasePath + apiPrefix
+}
+
+// requireFeature answers 403 instead of calling next when its endpoint group is disabled
+func requireFeature(enabled bool, next echo.HandlerFunc) echo.HandlerFunc {
+	if enabled {
+		return next
+	}
+	return func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusForbidden, "This operation is disabled on this server")
+	}
+}
+
 // setupRoutes configures all API routes
 func (s *Server) setupRoutes() {
+	// Everything is served below the configured base path, empty by default
+	root := s.echo.Group(s.core.Config.Server.BasePath)
+
 	// Health check endpoint
-	s.echo.GET("/health", func(c echo.Context) error {
+	root.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
+	// Share links are short so they can be pasted anywhere
+	root.GET("/s/:token", s.openShare)
+
 	// API endpoints
-	api := s.echo.Group("/api")
+	api := root.Group(apiPrefix)
+	features := s.core.Config.Features
+	// Scope every API request to the caller's workspace, when workspaces are configured
+	if s.core.Workspaces.Enabled() {
+		api.Use(s.workspaceMiddleware())
+	}
+
+	api.GET("/version", s.getVersion)
+	api.GET("/workspaces", s.listWorkspaces)
+	if s.core.Config.Server.ExposeConfig {
+		api.GET("/admin/config", s.requireAdmin(s.getConfig))
+	}
+	if s.core.Config.Server.AdminData {
+		api.GET("/admin/export", s.requireAdmin(s.exportData))
+		api.POST("/admin/import", s.requireAdmin(s.importData))
+	}
+	if s.core.Config.Server.AdminAccess {
+		api.GET("/admin/roles", s.requireAdmin(s.listRoles))
+		api.GET("/admin/users", s.requireAdmin(s.listUsers))
+		api.POST("/admin/users", s.requireAdmin(s.createUser))
+		api.GET("/admin/users/:user", s.requireAdmin(s.getUser))
+		api.PATCH("/admin/users/:user", s.requireAdmin(s.updateUser))
+		api.GET("/admin/users/:user/permissions", s.requireAdmin(s.getEffectivePermissions))
+		api.GET("/admin/teams", s.requireAdmin(s.listTeams))
+		api.POST("/admin/teams", s.requireAdmin(s.createTeam))
+		api.GET("/admin/teams/:team", s.requireAdmin(s.getTeam))
+		api.PATCH("/admin/teams/:team", s.requireAdmin(s.updateTeam))
+		api.DELETE("/admin/teams/:team", s.requireAdmin(s.deleteTeam))
+	}
 
 	// Bucket endpoints
-	api.GET("/buckets", s.listBuckets)
+	// Old clients expect the original response format for these two routes
+	if s.core.Config.Server.LegacyRoutes {
+		api.GET("/buckets", s.listBucketsLegacy)
+		api.GET("/buckets/:bucket/objects", s.listObjectsLegacy)
+	} else {
+		api.GET("/buckets", s.listBuckets)
+		api.GET("/buckets/:bucket/objects", s.listObjects)
+	}
 	api.GET("/buckets/:bucket/details", s.getBucketDetails)
-	api.GET("/buckets/:bucket/objects", s.listObjects)
-	api.GET("/buckets/:bucket/objects/*", s.getPresignedURL)
+	api.GET("/buckets/:bucket/versions", s.listObjectVersions)
+	api.GET("/buckets/:bucket/tree", s.getTree)
+	api.GET("/buckets/:bucket/breadcrumbs", s.getBreadcrumbs)
+	api.GET("/buckets/:bucket/objects/*", objectRouter(s.getPresignedURL, map[string]echo.HandlerFunc{
+		"preview":        s.previewObject,
+		"thumbnail":      s.getThumbnail,
+		"stream":         requireFeature(features.ProxyDownloads, s.streamObject),
+		"download":       requireFeature(features.ProxyDownloads, s.downloadObject),
+		"archive":        s.listArchiveEntries,
+		"image-metadata": s.getImageMetadata,
+		"content":        s.getObjectContent,
+		"tail":           s.tailObject,
+		"bytes":          s.getObjectBytes,
+		"media-metadata": s.getMediaMetadata,
+		"pdf-metadata":   s.getPDFMetadata,
+		"acl":            s.getObjectACL,
+		"tags":           s.getObjectTags,
+		"parts":          s.getObjectParts,
+		"checksums":      s.getObjectChecksums,
+		"history":        s.getObjectHistory,
+		"exists":         s.objectExists,
+		"head-url":       s.getPresignedHeadURL,
+	}))
+	api.POST("/buckets/:bucket/objects/*", objectRouter(echo.NotFoundHandler, map[string]echo.HandlerFunc{
+		"extract": requireFeature(features.Uploads, s.extractArchive),
+		"scan":    s.scanObject,
+		"query":   s.queryObject,
+	}))
+	api.PUT("/buckets/:bucket/objects/*", objectRouter(echo.NotFoundHandler, map[string]echo.HandlerFunc{
+		"content":  requireFeature(features.Edits, s.saveObjectContent),
+		"metadata": requireFeature(features.Edits, s.updateObjectMetadata),
+		"acl":      requireFeature(features.Edits, s.putObjectACL),
+		"tags":     requireFeature(features.Edits, s.putObjectTags),
+		"rename":   requireFeature(features.Uploads && features.Deletes, s.renameObject),
+	}))
 	api.HEAD("/buckets/:bucket/objects/*", s.getObjectMetadata)
-	api.DELETE("/buckets/:bucket/objects/*", s.deleteObject)
-	api.POST("/buckets/:bucket/objects", s.createFolder)
-	api.POST("/buckets/:bucket/presigned-post-url", s.generatePresignedPostURL)
+	api.DELETE("/buckets/:bucket/objects/*", objectRouter(requireFeature(features.Deletes, s.deleteObject), map[string]echo.HandlerFunc{
+		"tags": requireFeature(features.Edits, s.deleteObjectTags),
+	}))
+	api.POST("/buckets/:bucket/objects", requireFeature(features.Uploads, s.createFolder))
+	api.POST("/buckets/:bucket/objects/copy", requireFeature(features.Uploads, s.copyObject))
+	api.POST("/buckets/:bucket/objects/fetch", requireFeature(features.Uploads, s.fetchObject))
+	api.POST("/buckets/:bucket/folders/copy", requireFeature(features.Uploads, s.copyFolder))
+	api.POST("/buckets/:bucket/notifications/test", requireFeature(features.Uploads && features.Deletes, s.testNotifications))
+	api.POST("/buckets/:bucket/bulk-tagging", requireFeature(features.Edits, s.startBulkTagging))
+	api.POST("/buckets/:bucket/presigned-post-url", requireFeature(features.Uploads, s.generatePresignedPostURL))
+	api.POST("/buckets/:bucket/upload-manifest", requireFeature(features.Uploads, s.presignUploadManifest))
+	api.POST("/buckets/:bucket/multipart-uploads", requireFeature(features.Uploads, s.createMultipartUpload))
+	api.POST("/buckets/:bucket/multipart-uploads/:uploadId/part-urls", requireFeature(features.Uploads, s.presignUploadParts))
+	api.GET("/buckets/:bucket/multipart-uploads/:uploadId/parts", requireFeature(features.Uploads, s.listUploadedParts))
+	api.POST("/buckets/:bucket/multipart-uploads/:uploadId/complete", requireFeature(features.Uploads, s.completeMultipartUpload))
+	api.DELETE("/buckets/:bucket/multipart-uploads/:uploadId", requireFeature(features.Uploads, s.abortMultipartUpload))
+	api.GET("/kms/keys", s.listKMSKeys)
+	api.POST("/multipart-etag", s.computeMultipartETag)
+	api.GET("/buckets/:bucket/changes", s.getChanges)
+	api.GET("/buckets/:bucket/cost", s.getCostEstimate)
+	api.GET("/buckets/:bucket/storage-report", s.getStorageReport)
+	api.GET("/buckets/:bucket/exposure", s.getExposureReport)
+	api.GET("/buckets/:bucket/garbage", s.getGarbageReport)
+	api.POST("/buckets/:bucket/garbage/cleanup", requireFeature(features.Deletes, s.startGarbageCleanup))
+	api.GET("/exposure", s.getExposureSummary)
+	api.GET("/buckets/:bucket/compare", s.compareBuckets)
+	api.GET("/buckets/:bucket/delete-preflight", s.getDeletePreflight)
+	api.GET("/buckets/:bucket/retention-report", s.getRetentionReport)
+	api.GET("/buckets/:bucket/usage", s.getBucketUsage)
+	api.GET("/usage", s.getUsage)
+	api.POST("/usage/storage-lens", s.ingestStorageLens)
+	api.GET("/buckets/:bucket/activity", s.getBucketActivity)
+	api.POST("/activity/ingest", s.ingestAccessLogs)
+	api.GET("/buckets/:bucket/export", s.exportListing)
+	api.GET("/buckets/:bucket/download-zip", requireFeature(features.ProxyDownloads, s.downloadZip))
+	api.POST("/buckets/:bucket/download-zip", requireFeature(features.ProxyDownloads, s.downloadSelectionZip))
+
+	// Batch endpoint
+	api.POST("/batch", s.executeBatch)
+
+	// Job endpoints
+	api.GET("/jobs", s.listJobs)
+	api.GET("/jobs/:id", s.getJob)
+	api.POST("/jobs/:id/cancel", s.cancelJob)
+
+	// Share link endpoints
+	api.POST("/shares", s.createShare)
+	api.GET("/shares", s.listShares)
+	api.GET("/shares/:token", s.getShare)
+	api.GET("/shares/:token/stats", s.getShareStats)
+	api.DELETE("/shares/:token", s.revokeShare)
+
+	// Bookmark endpoints, per user
+	api.GET("/bookmarks", s.listBookmarks)
+	api.POST("/bookmarks", s.createBookmark)
+	api.DELETE("/bookmarks/:id", s.deleteBookmark)
+
+	// Selection endpoints, per user
+	api.POST("/selections", s.createSelection)
+	api.GET("/selections/:id", s.getSelection)
+	api.PATCH("/selections/:id", s.updateSelection)
+	api.DELETE("/selections/:id", s.deleteSelection)
+	api.POST("/selections/:id/copy", requireFeature(features.Uploads, s.copySelection))
+	api.POST("/selections/:id/move", requireFeature(features.Uploads && features.Deletes, s.moveSelection))
+	api.POST("/selections/:id/delete", requireFeature(features.Deletes, s.deleteSelectedObjects))
+	api.GET("/selections/:id/delete-preflight", s.getSelectionDeletePreflight)
+	api.GET("/selections/:id/download-zip", requireFeature(features.ProxyDownloads, s.downloadSelection))
+
+	// Annotation endpoints, shared by all users
+	api.GET("/annotations", s.listAnnotations)
+	api.GET("/annotations/search", s.searchAnnotations)
+	api.POST("/annotations", s.createAnnotation)
+	api.DELETE("/annotations/:id", s.deleteAnnotation)
+
+	// Recently visited locations and files, per user
+	api.GET("/recent", s.getRecent)
+
+	// Embedded frontend, when the binary was built with one
+	if assets := ui.Assets(); assets != nil {
+		handler, err := uiHandler(assets, s.core.Config.Server.BasePath)
+		if err != nil {
+			s.core.Logger.Error().Err(err).Msg("Failed to load embedded frontend")
+			return
+		}
+		root.GET("", handler)
+		root.GET("/*", handler)
+	}
 }
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"explorer451/internal/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// objectRoute is the wildcard route object actions are dispatched from
+const objectRoute = "/buckets/:bucket/objects/*"
+
+// routeTimeout returns the time allowed for a request: a configured override for its
+// route, none for streaming endpoints, or the default request timeout. Zero means the
+// request is not timed out.
+func (s *Server) routeTimeout(c echo.Context) time.Duration {
+	cfg := s.core.Config.Server
+	for _, override := range cfg.RouteTimeouts {
+		if matchesRoute(c, s.apiRoot(), override) {
+			return override.Timeout
+		}
+	}
+
+	if isStreamingRequest(c) {
+		return 0
+	}
+	return cfg.RequestTimeout
+}
+
+// matchesRoute reports whether a request was routed to the route of an override
+func matchesRoute(c echo.Context, apiRoot string, override config.RouteTimeout) bool {
+	if override.Method != "" && !strings.EqualFold(override.Method, c.Request().Method) {
+		return false
+	}
+
+	if action, ok := strings.CutPrefix(override.Route, objectRoute+"/"); ok {
+		return c.Path() == apiRoot+objectRoute && hasObjectAction(c, action)
+	}
+	return c.Path() == apiRoot+override.Route
+}
+
+// timeoutMiddleware applies the timeout chosen by routeTimeout to each request
+func (s *Server) timeoutMiddleware() echo.MiddlewareFunc {
+	// One timeout middleware per distinct duration, built once
+	timeouts := map[time.Duration]echo.MiddlewareFunc{}
+	for _, timeout := range s.configuredTimeouts() {
+		timeouts[timeout] = middleware.TimeoutWithConfig(middleware.TimeoutConfig{
+			Timeout: timeout,
+		})
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		handlers := make(map[time.Duration]echo.HandlerFunc, len(timeouts))
+		for timeout, mw := range timeouts {
+			handlers[timeout] = mw(next)
+		}
+
+		return func(c echo.Context) error {
+			if handler, ok := handlers[s.routeTimeout(c)]; ok {
+				return handler(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// configuredTimeouts lists every non-zero timeout routeTimeout can return
+func (s *Server) configuredTimeouts() []time.Duration {
+	cfg := s.core.Config.Server
+	timeouts := []time.Duration{cfg.RequestTimeout}
+	for _, override := range cfg.RouteTimeouts {
+		if override.Timeout > 0 {
+			timeouts = append(timeouts, override.Timeout)
+		}
+	}
+	return timeouts
+}
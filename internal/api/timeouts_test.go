@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	s := &Server{core: &core.Core{Config: &config.Config{
+		Server: config.ServerConfig{
+			RequestTimeout: 30 * time.Second,
+			RouteTimeouts: []config.RouteTimeout{
+				{Route: "/buckets/:bucket/changes", Timeout: 0},
+				{Route: "/buckets/:bucket/objects/*/archive", Timeout: 2 * time.Minute},
+				{Route: "/buckets/:bucket/objects", Method: http.MethodPost, Timeout: 5 * time.Second},
+			},
+		},
+	}}}
+
+	var got time.Duration
+	capture := func(c echo.Context) error {
+		got = s.routeTimeout(c)
+		return nil
+	}
+	e := echo.New()
+	e.GET("/api/buckets/:bucket/changes", capture)
+	e.GET("/api/buckets/:bucket/objects", capture)
+	e.POST("/api/buckets/:bucket/objects", capture)
+	e.GET("/api/buckets/:bucket/objects/*", capture)
+	e.POST("/api/buckets/:bucket/objects/*", capture)
+	e.GET("/api/buckets/:bucket/export", capture)
+	e.GET("/api/selections/:id/download-zip", capture)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected time.Duration
+	}{
+		{http.MethodGet, "/api/buckets/b/changes", 0},
+		{http.MethodGet, "/api/buckets/b/objects/site.zip/archive", 2 * time.Minute},
+		{http.MethodGet, "/api/buckets/b/objects/site.zip/stream", 0},
+		{http.MethodGet, "/api/buckets/b/objects/site.zip/preview", 30 * time.Second},
+		{http.MethodGet, "/api/buckets/b/objects/site.zip/checksums", 30 * time.Second},
+		{http.MethodGet, "/api/buckets/b/objects/site.zip/checksums?verify=sha256", 0},
+		{http.MethodPost, "/api/buckets/b/objects/orders.csv/query", 0},
+		{http.MethodPost, "/api/buckets/b/objects/orders.csv/scan", 30 * time.Second},
+		{http.MethodGet, "/api/buckets/b/objects", 30 * time.Second},
+		{http.MethodPost, "/api/buckets/b/objects", 5 * time.Second},
+		{http.MethodGet, "/api/buckets/b/export", 0},
+		{http.MethodGet, "/api/selections/3f9a/download-zip", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			got = -1
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
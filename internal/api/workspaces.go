@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"explorer451/internal/core"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// workspaceHeader selects the workspace a request is answered in, for users who are
+	// members of several; the workspace query parameter does the same for plain links
+	workspaceHeader = "X-Workspace"
+	// workspaceContextKey holds the request's workspace in the echo context
+	workspaceContextKey = "workspace"
+)
+
+// workspaceExempt lists API routes that need no workspace
+var workspaceExempt = map[string]bool{
+	"/version":    true,
+	"/workspaces": true,
+}
+
+// deploymentWide lists API routes whose data spans every workspace, which are refused
+// while workspaces are configured
+var deploymentWide = map[string]bool{
+	"/admin/config":       true,
+	"/admin/export":       true,
+	"/admin/import":       true,
+	"/usage/storage-lens": true,
+	"/activity/ingest":    true,
+}
+
+// workspaceMiddleware resolves the workspace every API request is answered in and
+// refuses requests for buckets outside it. Buckets of other workspaces answer 404, as
+// buckets that do not exist do.
+func (s *Server) workspaceMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := strings.TrimPrefix(c.Path(), s.apiRoot())
+			if workspaceExempt[route] {
+				return next(c)
+			}
+			if deploymentWide[route] {
+				return echo.NewHTTPError(http.StatusForbidden, "This operation spans all workspaces and is disabled while workspaces are configured")
+			}
+
+			workspace, err := s.core.Workspaces.Resolve(s.currentUser(c), requestedWorkspace(c))
+			if err != nil {
+				if errors.Is(err, core.ErrWorkspaceNotFound) {
+					return echo.NewHTTPError(http.StatusNotFound, "Workspace not found")
+				}
+				return echo.NewHTTPError(http.StatusForbidden, "You are not a member of any workspace")
+			}
+			if bucket := c.Param("bucket"); bucket != "" && !s.core.Workspaces.AllowsBucket(workspace, bucket) {
+				return echo.NewHTTPError(http.StatusNotFound, "Bucket not found")
+			}
+
+			c.Set(workspaceContextKey, workspace)
+			c.Response().Header().Set(workspaceHeader, workspace.Name)
+			return next(c)
+		}
+	}
+}
+
+// requestedWorkspace returns the workspace a request asks for, if any
+func requestedWorkspace(c echo.Context) string {
+	if name := c.Request().Header.Get(workspaceHeader); name != "" {
+		return name
+	}
+	return c.QueryParam("workspace")
+}
+
+// workspace returns the workspace a request is answered in, or nil when no workspaces
+// are configured
+func (s *Server) workspace(c echo.Context) *models.Workspace {
+	workspace, ok := c.Get(workspaceContextKey).(models.Workspace)
+	if !ok {
+		return nil
+	}
+	return &workspace
+}
+
+// workspaceName returns the name of the request's workspace, or "" without workspaces
+func (s *Server) workspaceName(c echo.Context) string {
+	if workspace := s.workspace(c); workspace != nil {
+		return workspace.Name
+	}
+	return ""
+}
+
+// allowsBucket reports whether a request may see a bucket: one of its workspace's, or
+// any bucket when no workspaces are configured
+func (s *Server) allowsBucket(c echo.Context, bucket string) bool {
+	workspace := s.workspace(c)
+	return workspace == nil || s.core.Workspaces.AllowsBucket(*workspace, bucket)
+}
+
+// allowsUser reports whether a request may see a user: a member of its workspace, or
+// any user when no workspaces are configured
+func (s *Server) allowsUser(c echo.Context, user string) bool {
+	workspace := s.workspace(c)
+	return workspace == nil || s.core.Workspaces.HasUser(workspace.Name, user)
+}
+
+// allowsTeam reports whether a request may see a team: one of its workspace's, or any
+// team when no workspaces are configured
+func (s *Server) allowsTeam(c echo.Context, team models.Team) bool {
+	workspace := s.workspace(c)
+	return workspace == nil || s.core.Workspaces.HasTeam(workspace.Name, team)
+}
+
+// allowsJob reports whether a request may see a job: one whose buckets all belong to
+// its workspace, or any job when no workspaces are configured. Jobs that name no
+// bucket span the deployment and are only seen without workspaces.
+func (s *Server) allowsJob(c echo.Context, job models.Job) bool {
+	workspace := s.workspace(c)
+	if workspace == nil {
+		return true
+	}
+	var buckets []string
+	for _, param := range []string{"bucket", "targetBucket"} {
+		if bucket := job.Params[param]; bucket != "" {
+			buckets = append(buckets, bucket)
+		}
+	}
+	if list := job.Params["buckets"]; list != "" {
+		buckets = append(buckets, strings.Split(list, ",")...)
+	}
+	if len(buckets) == 0 {
+		return false
+	}
+	for _, bucket := range buckets {
+		if !s.core.Workspaces.AllowsBucket(*workspace, bucket) {
+			return false
+		}
+	}
+	return true
+}
+
+// listWorkspaces handles GET /api/workspaces, listing the caller's workspaces
+func (s *Server) listWorkspaces(c echo.Context) error {
+	resp := models.WorkspacesResponse{Workspaces: []models.Workspace{}}
+	if !s.core.Workspaces.Enabled() {
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	resp.Workspaces = s.core.Workspaces.For(s.currentUser(c))
+	if workspace, err := s.core.Workspaces.Resolve(s.currentUser(c), requestedWorkspace(c)); err == nil {
+		resp.Current = workspace.Name
+	}
+	return c.JSON(http.StatusOK, resp)
+}
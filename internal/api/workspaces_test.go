@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/core"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspaceMiddleware(t *testing.T) {
+	c := &core.Core{
+		Config: &config.Config{
+			Server: config.ServerConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{"192.0.2.10"}},
+			Workspaces: []config.WorkspaceConfig{
+				{Name: "finance", Buckets: []string{"nb-fin-*"}, Users: []string{"alice"}},
+				{Name: "marketing", Buckets: []string{"nb-mkt-*"}, Users: []string{"alice", "bob"}},
+			},
+		},
+		Logger: logger.New("error", "json"),
+	}
+	c.Access = core.NewAccessService(c)
+	c.Workspaces = core.NewWorkspaceService(c)
+	s := &Server{core: c}
+
+	e := echo.New()
+	api := e.Group(apiPrefix)
+	api.Use(s.workspaceMiddleware())
+	api.GET("/version", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	api.GET("/admin/export", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	api.GET("/buckets/:bucket/details", func(c echo.Context) error { return c.String(http.StatusOK, s.workspaceName(c)) })
+	api.GET("/jobs", func(c echo.Context) error {
+		job := models.Job{Params: map[string]string{"bucket": "nb-fin-prod", "targetBucket": "nb-mkt-web"}}
+		if s.allowsJob(c, job) {
+			return c.NoContent(http.StatusOK)
+		}
+		return c.NoContent(http.StatusNotFound)
+	})
+
+	request := func(path, user, workspace string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.0.2.10:4000"
+		req.Header.Set("X-Forwarded-User", user)
+		if workspace != "" {
+			req.Header.Set(workspaceHeader, workspace)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request("/api/buckets/nb-fin-prod/details", "alice", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "finance", rec.Body.String())
+	assert.Equal(t, http.StatusOK, request("/api/buckets/nb-mkt-web/details", "alice", "marketing").Code)
+
+	// Buckets of other workspaces look like missing buckets
+	assert.Equal(t, http.StatusNotFound, request("/api/buckets/nb-mkt-web/details", "alice", "").Code)
+	assert.Equal(t, http.StatusNotFound, request("/api/buckets/nb-fin-prod/details", "bob", "").Code)
+	assert.Equal(t, http.StatusNotFound, request("/api/buckets/nb-mkt-web/details", "bob", "finance").Code)
+	assert.Equal(t, http.StatusForbidden, request("/api/buckets/nb-fin-prod/details", "mallory", "").Code)
+
+	// Jobs spanning workspaces are hidden from both
+	assert.Equal(t, http.StatusNotFound, request("/api/jobs", "alice", "finance").Code)
+
+	assert.Equal(t, http.StatusNoContent, request("/api/version", "mallory", "").Code)
+	assert.Equal(t, http.StatusForbidden, request("/api/admin/export", "alice", "").Code)
+}
@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	appconfig "explorer451/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyendpoints "github.com/aws/smithy-go/endpoints"
+)
+
+// NewBucketClients creates a client for each bucket configured with its own endpoint,
+// region or credentials. Settings a bucket leaves unset are taken from the default
+// configuration. optFns are applied to every client after the bucket's settings.
+func NewBucketClients(ctx context.Context, base aws.Config, buckets []appconfig.BucketEndpoint, optFns ...func(*s3.Options)) (map[string]*s3.Client, error) {
+	clients := make(map[string]*s3.Client, len(buckets))
+	for _, bucket := range buckets {
+		cfg, err := loadBucketConfig(ctx, base, bucket)
+		if err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", bucket.Name, err)
+		}
+
+		clients[bucket.Name] = s3.NewFromConfig(cfg, append([]func(*s3.Options){func(o *s3.Options) {
+			if bucket.Endpoint != "" {
+				o.BaseEndpoint = aws.String(bucket.Endpoint)
+			}
+			o.UsePathStyle = bucket.UsePathStyle
+			if bucket.ObjectLambdaARN != "" {
+				o.EndpointResolverV2 = accessPointResolver{arn: bucket.ObjectLambdaARN, next: o.EndpointResolverV2}
+			}
+		}}, optFns...)...)
+	}
+	return clients, nil
+}
+
+// loadBucketConfig derives a bucket's AWS configuration from the default one
+func loadBucketConfig(ctx context.Context, base aws.Config, bucket appconfig.BucketEndpoint) (aws.Config, error) {
+	cfg := base.Copy()
+	if bucket.Region != "" {
+		cfg.Region = bucket.Region
+	} else if bucket.ObjectLambdaARN != "" {
+		accessPoint, err := arn.Parse(bucket.ObjectLambdaARN)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		cfg.Region = accessPoint.Region
+	}
+
+	switch {
+	case bucket.Anonymous:
+		cfg.Credentials = aws.AnonymousCredentials{}
+	case bucket.AccessKeyID != "":
+		cfg.Credentials = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(bucket.AccessKeyID, bucket.SecretAccessKey, ""))
+	case bucket.Profile != "":
+		profileCfg, err := config.LoadDefaultConfig(ctx,
+			config.WithRegion(cfg.Region),
+			config.WithSharedConfigProfile(bucket.Profile),
+		)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		cfg.Credentials = profileCfg.Credentials
+	}
+	return cfg, nil
+}
+
+// accessPointResolver sends every request of a client to an access point. Callers keep
+// using the name the access point is configured under; the S3 endpoint rules turn its
+// ARN into the access point's host name and signing service.
+type accessPointResolver struct {
+	arn  string
+	next s3.EndpointResolverV2
+}
+
+// ResolveEndpoint resolves the endpoint with the access point ARN as the bucket
+func (r accessPointResolver) ResolveEndpoint(ctx context.Context, params s3.EndpointParameters) (smithyendpoints.Endpoint, error) {
+	params.Bucket = aws.String(r.arn)
+	return r.next.ResolveEndpoint(ctx, params)
+}
@@ -0,0 +1,42 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appconfig "explorer451/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBucketClients_Anonymous(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	base := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	}
+	clients, err := NewBucketClients(context.Background(), base, []appconfig.BucketEndpoint{
+		{Name: "open-data", Endpoint: server.URL, UsePathStyle: true, Anonymous: true},
+		{Name: "private", Endpoint: server.URL, UsePathStyle: true},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, bucket := range []string{"open-data", "private"} {
+		_, err := clients[bucket].HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String("a.csv")})
+		require.NoError(t, err)
+	}
+	assert.Empty(t, authorization[0], "anonymous requests are not signed")
+	assert.NotEmpty(t, authorization[1])
+}
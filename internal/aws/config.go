@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
@@ -17,11 +18,16 @@ func LoadConfig(ctx context.Context, region string) (aws.Config, error) {
 }
 
 // NewS3Client creates a new S3 client
-func NewS3Client(cfg aws.Config) *s3.Client {
-	return s3.NewFromConfig(cfg)
+func NewS3Client(cfg aws.Config, optFns ...func(*s3.Options)) *s3.Client {
+	return s3.NewFromConfig(cfg, optFns...)
 }
 
 // NewS3Presigner creates a new S3 presigner client
 func NewS3Presigner(cfg aws.Config) *s3.PresignClient {
 	return s3.NewPresignClient(s3.NewFromConfig(cfg))
 }
+
+// NewKMSClient creates a new KMS client
+func NewKMSClient(cfg aws.Config) *kms.Client {
+	return kms.NewFromConfig(cfg)
+}
@@ -0,0 +1,168 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// bucketRegionHeader names the region of a bucket in S3's redirect responses
+const bucketRegionHeader = "X-Amz-Bucket-Region"
+
+// RegionRedirects follows S3's redirects for buckets in another region than the client
+// they are requested with. A request S3 redirects is sent again with a client for the
+// bucket's region, which is remembered so later requests go there directly.
+type RegionRedirects struct {
+	mu sync.Mutex
+	// options configure the client the redirects are followed for
+	options s3.Options
+	// regions maps buckets to the region S3 redirected them to
+	regions map[string]string
+	// clients holds a client per region
+	clients map[string]*s3.Client
+}
+
+// NewRegionRedirects creates an empty set of region redirects
+func NewRegionRedirects() *RegionRedirects {
+	return &RegionRedirects{
+		regions: make(map[string]string),
+		clients: make(map[string]*s3.Client),
+	}
+}
+
+// Apply installs the redirect handling on a client; pass it to s3.NewFromConfig
+func (r *RegionRedirects) Apply(o *s3.Options) {
+	// Regional clients are made from the options as they are now, without the
+	// middleware, so a second redirect is returned rather than followed
+	r.options = o.Copy()
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(r, middleware.Before)
+	})
+}
+
+// Client returns the client for a bucket S3 has redirected to another region
+func (r *RegionRedirects) Client(bucket string) (*s3.Client, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	region, ok := r.regions[bucket]
+	if !ok {
+		return nil, false
+	}
+	return r.clients[region], true
+}
+
+// ID identifies the middleware
+func (r *RegionRedirects) ID() string {
+	return "RegionRedirects"
+}
+
+// HandleInitialize sends a request again with the bucket's regional client when S3
+// answers it with a redirect naming the bucket's region. The first attempt has read an
+// uploaded body, such as that of PutObject or UploadPart, so a body is rewound to where
+// it started; one that cannot be rewound is not sent again, and the redirect is
+// returned, though the region is still remembered for later requests.
+func (r *RegionRedirects) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	body := bodyParameter(in.Parameters)
+	seeker, seekable := body.(io.Seeker)
+	var start int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	if err == nil {
+		return out, metadata, err
+	}
+
+	bucket := bucketParameter(in.Parameters)
+	region := redirectRegion(err)
+	if bucket == "" || region == "" || region == r.options.Region {
+		return out, metadata, err
+	}
+
+	client := r.remember(bucket, region)
+	if body != nil {
+		if !seekable {
+			return out, metadata, err
+		}
+		if _, seekErr := seeker.Seek(start, io.SeekStart); seekErr != nil {
+			return out, metadata, err
+		}
+	}
+	method := reflect.ValueOf(client).MethodByName(middleware.GetOperationName(ctx))
+	if !method.IsValid() {
+		return out, metadata, err
+	}
+	results := method.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(in.Parameters)})
+	if retryErr, _ := results[1].Interface().(error); retryErr != nil {
+		return middleware.InitializeOutput{}, metadata, retryErr
+	}
+	return middleware.InitializeOutput{Result: results[0].Interface()}, metadata, nil
+}
+
+// remember records a bucket's region and returns the client for it
+func (r *RegionRedirects) remember(bucket, region string) *s3.Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.regions[bucket] = region
+	client, ok := r.clients[region]
+	if !ok {
+		options := r.options.Copy()
+		options.Region = region
+		client = s3.New(options)
+		r.clients[region] = client
+	}
+	return client
+}
+
+// bucketParameter returns the bucket an operation's input names, if any
+func bucketParameter(params any) string {
+	return stringParameter(params, "Bucket")
+}
+
+// bodyParameter returns the body an operation's input uploads, if any
+func bodyParameter(params any) io.Reader {
+	value := reflect.ValueOf(params)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	field := value.Elem().FieldByName("Body")
+	if !field.IsValid() {
+		return nil
+	}
+	body, _ := field.Interface().(io.Reader)
+	return body
+}
+
+// redirectRegion returns the region S3 named when it refused a request for being sent
+// to the wrong region: with a redirect, or, for some operations, a malformed
+// authorization error
+func redirectRegion(err error) string {
+	var responseErr *awshttp.ResponseError
+	if !errors.As(err, &responseErr) || responseErr.Response == nil {
+		return ""
+	}
+
+	var apiErr smithy.APIError
+	switch {
+	case responseErr.HTTPStatusCode() == http.StatusMovedPermanently,
+		responseErr.HTTPStatusCode() == http.StatusTemporaryRedirect:
+	case errors.As(err, &apiErr) && apiErr.ErrorCode() == "AuthorizationHeaderMalformed":
+	default:
+		return ""
+	}
+	return responseErr.Response.Header.Get(bucketRegionHeader)
+}
@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionRedirects(t *testing.T) {
+	var redirected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The bucket lives in eu-west-1; requests signed for another region are redirected
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/") {
+			redirected.Add(1)
+			w.Header().Set("X-Amz-Bucket-Region", "eu-west-1")
+			w.WriteHeader(http.StatusMovedPermanently)
+			if r.Method != http.MethodHead {
+				_, _ = io.WriteString(w, `<Error><Code>PermanentRedirect</Code><Message>Use the eu-west-1 endpoint</Message></Error>`)
+			}
+			return
+		}
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	redirects := NewRegionRedirects()
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	}, redirects.Apply)
+	ctx := context.Background()
+
+	_, ok := redirects.Client("eu-bucket")
+	assert.False(t, ok)
+
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("eu-bucket"), Key: aws.String("a.txt")})
+	require.NoError(t, err)
+	body, err := io.ReadAll(output.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, int32(1), redirected.Load())
+
+	regional, ok := redirects.Client("eu-bucket")
+	require.True(t, ok)
+	assert.Equal(t, "eu-west-1", regional.Options().Region)
+
+	_, err = regional.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("eu-bucket"), Key: aws.String("a.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), redirected.Load(), "the regional client goes to the right region directly")
+
+	// Head requests carry no error body, only the region header
+	_, err = client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("other-bucket"), Key: aws.String("a.txt")})
+	require.NoError(t, err)
+	_, ok = redirects.Client("other-bucket")
+	assert.True(t, ok)
+}
+
+func TestRegionRedirectsUpload(t *testing.T) {
+	var uploaded []string
+	// Over TLS, bodies that cannot be rewound are sent unsigned
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/") {
+			w.Header().Set("X-Amz-Bucket-Region", "eu-west-1")
+			w.WriteHeader(http.StatusMovedPermanently)
+			_, _ = io.WriteString(w, `<Error><Code>PermanentRedirect</Code><Message>Use the eu-west-1 endpoint</Message></Error>`)
+			return
+		}
+		uploaded = append(uploaded, string(body))
+	}))
+	defer server.Close()
+
+	redirects := NewRegionRedirects()
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		HTTPClient:   server.Client(),
+	}, redirects.Apply)
+	ctx := context.Background()
+
+	// The retry sends the whole body again, not what the first attempt left of it
+	body := strings.NewReader("the body")
+	_, err := client.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String("eu-bucket"), Key: aws.String("a.txt"), Body: body})
+	require.NoError(t, err)
+	require.Len(t, uploaded, 1)
+	assert.Contains(t, uploaded[0], "the body")
+
+	// A body that cannot be rewound is not sent again, but the region is remembered
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String("other-bucket"),
+		Key:    aws.String("a.txt"),
+		Body:   io.MultiReader(strings.NewReader("the body")),
+	})
+	require.Error(t, err)
+	assert.Len(t, uploaded, 1)
+	_, ok := redirects.Client("other-bucket")
+	assert.True(t, ok)
+}
@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// minThrottleRate is the slowest SlowDown responses bring a partition down to, in
+	// requests per second
+	minThrottleRate = 1
+	// throttleRecoveryInterval is how often a slowed partition speeds up again while S3
+	// accepts its requests, by a tenth of the configured rate each time
+	throttleRecoveryInterval = time.Second
+	// maxIdleThrottles is how many partitions are tracked before idle ones running at
+	// the configured rate are forgotten
+	maxIdleThrottles = 1000
+)
+
+// throttleKey carries the partition of an S3 request from the initialize step, where
+// its parameters are known, to the finalize step, where each attempt is sent
+type throttleKey struct{}
+
+// Throttle limits the requests sent to S3 for each partition, a bucket and the first
+// segment of the key or prefix, since S3 scales its request rates per prefix. When S3
+// answers a request with SlowDown, the partition's rate is halved; it then recovers
+// gradually to the configured rate while requests succeed. Large jobs thereby back off
+// before they get the whole account throttled.
+type Throttle struct {
+	rate  float64
+	burst int
+
+	mu         sync.Mutex
+	partitions map[string]*partitionThrottle
+	now        func() time.Time
+}
+
+// partitionThrottle is the token bucket of one partition
+type partitionThrottle struct {
+	limiter   *rate.Limiter
+	recovered time.Time
+	used      time.Time
+}
+
+// NewThrottle creates a throttle allowing ratePerSecond requests per partition with
+// bursts of burst requests, which defaults to the rate
+func NewThrottle(ratePerSecond float64, burst int) *Throttle {
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(ratePerSecond)))
+	}
+	return &Throttle{
+		rate:       ratePerSecond,
+		burst:      burst,
+		partitions: make(map[string]*partitionThrottle),
+		now:        time.Now,
+	}
+}
+
+// Apply installs the throttle on a client; pass it to s3.NewFromConfig before
+// RegionRedirects.Apply so regional clients share it
+func (t *Throttle) Apply(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		if err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ThrottlePartition", t.partition), middleware.Before); err != nil {
+			return err
+		}
+		// After the retry middleware, so every attempt waits for a token and every
+		// SlowDown answer slows the partition
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("Throttle", t.throttle), "Retry", middleware.After)
+	})
+}
+
+// partition records the partition an operation's input addresses
+func (t *Throttle) partition(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	bucket := bucketParameter(in.Parameters)
+	if bucket != "" {
+		key := stringParameter(in.Parameters, "Key")
+		if key == "" {
+			key = stringParameter(in.Parameters, "Prefix")
+		}
+		segment, _, _ := strings.Cut(key, "/")
+		ctx = middleware.WithStackValue(ctx, throttleKey{}, bucket+"/"+segment)
+	}
+	return next.HandleInitialize(ctx, in)
+}
+
+// throttle waits for a token of the request's partition before sending it, and slows
+// the partition down when S3 answers SlowDown
+func (t *Throttle) throttle(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	partition, _ := middleware.GetStackValue(ctx, throttleKey{}).(string)
+	if partition == "" {
+		return next.HandleFinalize(ctx, in)
+	}
+
+	limiter := t.limiter(partition)
+	if err := limiter.Wait(ctx); err != nil {
+		return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+	}
+	out, metadata, err := next.HandleFinalize(ctx, in)
+	if isSlowDown(err) {
+		t.slowDown(partition)
+	} else if err == nil {
+		t.recover(partition)
+	}
+	return out, metadata, err
+}
+
+// limiter returns the token bucket of a partition, creating it at the configured rate
+func (t *Throttle) limiter(partition string) *rate.Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	p, ok := t.partitions[partition]
+	if !ok {
+		if len(t.partitions) >= maxIdleThrottles {
+			t.forgetIdle(now)
+		}
+		p = &partitionThrottle{limiter: rate.NewLimiter(rate.Limit(t.rate), t.burst)}
+		t.partitions[partition] = p
+	}
+	p.used = now
+	return p.limiter
+}
+
+// forgetIdle drops partitions that run at the configured rate and have not been used
+// for a minute; they would be created again as they were
+func (t *Throttle) forgetIdle(now time.Time) {
+	for partition, p := range t.partitions {
+		if float64(p.limiter.Limit()) >= t.rate && now.Sub(p.used) > time.Minute {
+			delete(t.partitions, partition)
+		}
+	}
+}
+
+// slowDown halves a partition's rate, down to minThrottleRate
+func (t *Throttle) slowDown(partition string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[partition]
+	if !ok {
+		return
+	}
+	now := t.now()
+	p.limiter.SetLimitAt(now, max(p.limiter.Limit()/2, minThrottleRate))
+	p.recovered = now
+}
+
+// recover speeds a slowed partition up by a tenth of the configured rate, at most once
+// per throttleRecoveryInterval
+func (t *Throttle) recover(partition string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[partition]
+	if !ok || float64(p.limiter.Limit()) >= t.rate {
+		return
+	}
+	now := t.now()
+	if now.Sub(p.recovered) < throttleRecoveryInterval {
+		return
+	}
+	p.limiter.SetLimitAt(now, rate.Limit(min(float64(p.limiter.Limit())+t.rate/10, t.rate)))
+	p.recovered = now
+}
+
+// Rate returns the current rate of a partition, in requests per second
+func (t *Throttle) Rate(bucket, segment string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.partitions[bucket+"/"+segment]; ok {
+		return float64(p.limiter.Limit())
+	}
+	return t.rate
+}
+
+// isSlowDown reports whether S3 refused a request for exceeding its request rate
+func isSlowDown(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "SlowDown"
+}
+
+// stringParameter returns a string field of an operation's input, if it has one
+func stringParameter(params any, name string) string {
+	value := reflect.ValueOf(params)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	field := value.Elem().FieldByName(name)
+	if !field.IsValid() {
+		return ""
+	}
+	s, _ := field.Interface().(*string)
+	return aws.ToString(s)
+}
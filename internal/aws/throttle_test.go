@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	var slowDowns atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first two attempts on logs/ are refused for going too fast
+		if r.URL.Path == "/data/logs/a.txt" && slowDowns.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`)
+			return
+		}
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	throttle := NewThrottle(100, 0)
+	throttle.now = func() time.Time { return now }
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		}),
+	}, throttle.Apply)
+	ctx := context.Background()
+
+	// Retries follow SlowDown answers, each attempt halving the partition's rate
+	_, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("data"), Key: aws.String("logs/a.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, throttle.Rate("data", "logs"))
+	// Other partitions keep the configured rate
+	_, err = client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("data"), Key: aws.String("images/b.png")})
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, throttle.Rate("data", "images"))
+
+	// Successful requests speed the partition up again, once per interval
+	_, err = client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("data"), Key: aws.String("logs/a.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, throttle.Rate("data", "logs"))
+	for range 10 {
+		now = now.Add(throttleRecoveryInterval)
+		_, err = client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("data"), Prefix: aws.String("logs/2024/")})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 100.0, throttle.Rate("data", "logs"))
+}
+
+func TestThrottle_Floor(t *testing.T) {
+	throttle := NewThrottle(4, 0)
+	throttle.limiter("data/logs")
+	for range 5 {
+		throttle.slowDown("data/logs")
+	}
+	assert.Equal(t, float64(minThrottleRate), throttle.Rate("data", "logs"))
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Redacted replaces the values of settings tagged secret:"true" in Export
+const Redacted = "[redacted]"
+
+// Export returns the effective configuration as nested maps keyed like config.yml, with
+// defaults applied and secrets redacted
+func (c *Config) Export() map[string]any {
+	return exportStruct(reflect.ValueOf(*c))
+}
+
+// Sources maps the path of every setting that was set explicitly, such as
+// server.address, to where its value came from: config.yml, a profile or the
+// environment. Settings that are not listed have their default value.
+func (c *Config) Sources() map[string]string {
+	paths := make(map[string]string)
+	collectPaths(reflect.TypeOf(Config{}), "", paths)
+
+	sources := make(map[string]string, len(c.sources))
+	for key, source := range c.sources {
+		// Report the path as written in config.yml rather than lowercased
+		if path, ok := paths[key]; ok {
+			key = path
+		}
+		sources[key] = source
+	}
+	return sources
+}
+
+// collectPaths maps the lowercased path of every setting to its path in config.yml.
+// Sections are included too, since an empty YAML section loads as a null leaf.
+func collectPaths(t reflect.Type, prefix string, paths map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("koanf")
+		if tag == "" {
+			continue
+		}
+
+		path := prefix + tag
+		paths[strings.ToLower(path)] = path
+		if field.Type.Kind() == reflect.Struct {
+			collectPaths(field.Type, path+".", paths)
+		}
+	}
+}
+
+func exportStruct(v reflect.Value) map[string]any {
+	out := make(map[string]any)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("koanf")
+		if tag == "" {
+			continue
+		}
+
+		if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+			out[tag] = Redacted
+			continue
+		}
+		out[tag] = exportValue(v.Field(i))
+	}
+	return out
+}
+
+func exportValue(v reflect.Value) any {
+	// Durations read better as they are written in config.yml
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return exportStruct(v)
+	case reflect.Slice:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = exportValue(v.Index(i))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{Address: ":8080", RequestTimeout: 30 * time.Second},
+		AWS: AWSConfig{Buckets: []BucketEndpoint{
+			{Name: "legacy-data", AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI"},
+			{Name: "archive", Profile: "archive"},
+		}},
+	}
+
+	exported := cfg.Export()
+
+	server := exported["server"].(map[string]any)
+	assert.Equal(t, ":8080", server["address"])
+	assert.Equal(t, "30s", server["requestTimeout"])
+
+	buckets := exported["aws"].(map[string]any)["buckets"].([]any)
+	legacy := buckets[0].(map[string]any)
+	assert.Equal(t, "legacy-data", legacy["name"])
+	assert.Equal(t, Redacted, legacy["accessKeyId"])
+	assert.Equal(t, Redacted, legacy["secretAccessKey"])
+	// Unset secrets are shown as unset
+	assert.Equal(t, "", buckets[1].(map[string]any)["secretAccessKey"])
+}
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(`
+server:
+  address: ":8080"
+  requestTimeout: 20s
+profiles:
+  prod:
+    server:
+      requestTimeout: 45s
+`), 0o644))
+	t.Chdir(dir)
+	t.Setenv("EXPLORER451_PROFILE", "prod")
+	t.Setenv("EXPLORER451_SERVER_ADDRESS", ":9000")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"profile":               "environment",
+		"server.address":        "environment",
+		"server.requestTimeout": "profile prod",
+	}, cfg.Sources())
+}
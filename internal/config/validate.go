@@ -0,0 +1,374 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/knadh/koanf/v2"
+)
+
+var (
+	logLevels  = []string{"debug", "info", "warn", "error"}
+	logFormats = []string{"json", "console"}
+	// storeDrivers are the drivers of the store package
+	storeDrivers = []string{"sqlite", "postgres", "none"}
+	// sseAlgorithms are the server-side encryption settings an upload policy may
+	// require, the first being none
+	sseAlgorithms = []string{"", "AES256", "aws:kms", "aws:kms:dsse"}
+	// conflictStrategies are what writes may do with a target key that exists, the
+	// first being the default
+	conflictStrategies = []string{"", "overwrite", "fail", "rename"}
+	// uploadPlaceholder matches placeholders in upload policy key prefixes
+	uploadPlaceholder = regexp.MustCompile(`\{[^}]*\}`)
+)
+
+// Validate reports every setting that is invalid or conflicts with another one.
+// It runs on the values as loaded, so zero still means "use the default" while
+// negative limits and durations are rejected rather than silently replaced.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if strings.ContainsAny(c.Server.BasePath, "?#:* ") {
+		add("server.basePath: %q must be a plain path such as /s3-explorer", c.Server.BasePath)
+	}
+
+	if c.Server.SocketMode != "" {
+		if mode, err := strconv.ParseUint(c.Server.SocketMode, 8, 32); err != nil || mode > 0o777 {
+			add("server.socketMode: %q is not an octal permission mode such as 0660", c.Server.SocketMode)
+		}
+	}
+	if strings.HasPrefix(c.Server.Address, "unix:") && c.Server.TLS.Enabled() {
+		add("server.tls: TLS is not supported on a unix socket; terminate TLS in the proxy in front")
+	}
+
+	seenBuckets := map[string]bool{}
+	for i, bucket := range c.AWS.Buckets {
+		switch {
+		case bucket.Name == "":
+			add("aws.buckets[%d]: name is required", i)
+		case seenBuckets[bucket.Name]:
+			add("aws.buckets[%d]: bucket %q is configured more than once", i, bucket.Name)
+		}
+		seenBuckets[bucket.Name] = true
+
+		if bucket.Endpoint != "" {
+			if u, err := url.Parse(bucket.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+				add("aws.buckets[%d].endpoint: %q is not a URL such as https://minio.internal:9000", i, bucket.Endpoint)
+			}
+		}
+		if (bucket.AccessKeyID == "") != (bucket.SecretAccessKey == "") {
+			add("aws.buckets[%d]: accessKeyId and secretAccessKey must be set together", i)
+		}
+		if bucket.AccessKeyID != "" && bucket.Profile != "" {
+			add("aws.buckets[%d]: set either profile or static credentials, not both", i)
+		}
+		if bucket.Anonymous && (bucket.AccessKeyID != "" || bucket.Profile != "") {
+			add("aws.buckets[%d].anonymous: anonymous buckets are read without credentials; remove profile and accessKeyId", i)
+		}
+		if bucket.ObjectLambdaARN != "" {
+			if a, err := arn.Parse(bucket.ObjectLambdaARN); err != nil || a.Service != "s3-object-lambda" || !strings.HasPrefix(a.Resource, "accesspoint/") {
+				add("aws.buckets[%d].objectLambdaArn: %q is not an Object Lambda access point ARN", i, bucket.ObjectLambdaARN)
+			} else if bucket.Region != "" && bucket.Region != a.Region {
+				add("aws.buckets[%d].region: %q does not match the access point's region %q", i, bucket.Region, a.Region)
+			}
+			if bucket.UsePathStyle {
+				add("aws.buckets[%d].usePathStyle: access points cannot be addressed in the path", i)
+			}
+		}
+	}
+
+	for i, info := range c.Buckets {
+		if info.Name == "" {
+			add("buckets[%d]: name is required", i)
+		} else if _, err := path.Match(info.Name, ""); err != nil {
+			add("buckets[%d]: %q is not a valid pattern", i, info.Name)
+		}
+	}
+
+	workspaceNames := make(map[string]bool)
+	for i, workspace := range c.Workspaces {
+		switch {
+		case workspace.Name == "":
+			add("workspaces[%d]: name is required", i)
+		case workspaceNames[workspace.Name]:
+			add("workspaces[%d]: name %q is used twice", i, workspace.Name)
+		}
+		workspaceNames[workspace.Name] = true
+		for _, pattern := range workspace.Buckets {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				add("workspaces[%d].buckets: %q is not a valid pattern", i, pattern)
+			}
+		}
+	}
+	if len(c.Workspaces) > 0 && c.Server.UserHeader == "" {
+		add("workspaces: members are identified by server.userHeader, which is not set")
+	}
+
+	if c.Log.Level != "" && !slices.Contains(logLevels, c.Log.Level) {
+		add("log.level: unknown level %q (use one of %s)", c.Log.Level, strings.Join(logLevels, ", "))
+	}
+	if c.Log.Format != "" && !slices.Contains(logFormats, c.Log.Format) {
+		add("log.format: unknown format %q (use one of %s)", c.Log.Format, strings.Join(logFormats, ", "))
+	}
+
+	if c.Store.Driver != "" && !slices.Contains(storeDrivers, c.Store.Driver) {
+		add("store.driver: unknown driver %q (use one of %s)", c.Store.Driver, strings.Join(storeDrivers, ", "))
+	}
+	if c.Store.Driver == "postgres" && c.Store.DSN == "" {
+		add("store.dsn: a connection string is required for the postgres driver")
+	}
+
+	for _, size := range c.Thumbnails.Sizes {
+		if size <= 0 {
+			add("thumbnails.sizes: %d is not a positive pixel size", size)
+		}
+	}
+	if c.Thumbnails.CacheDir != "" && c.Thumbnails.CachePrefix != "" {
+		add("thumbnails: set either cacheDir or cachePrefix, not both")
+	}
+
+	for name, value := range map[string]int64{
+		"server.maxHeaderBytes":        int64(c.Server.MaxHeaderBytes),
+		"server.maxBodyBytes":          c.Server.MaxBodyBytes,
+		"preview.defaultBytes":         c.Preview.DefaultBytes,
+		"preview.maxBytes":             c.Preview.MaxBytes,
+		"preview.maxFormatBytes":       c.Preview.MaxFormatBytes,
+		"thumbnails.maxSourceBytes":    c.Thumbnails.MaxSourceBytes,
+		"pdf.maxSourceBytes":           c.PDF.MaxSourceBytes,
+		"antivirus.syncMaxBytes":       c.Antivirus.SyncMaxBytes,
+		"jobs.maxConcurrent":           int64(c.Jobs.MaxConcurrent),
+		"jobs.retention":               int64(c.Jobs.Retention),
+		"jobs.copyConcurrency":         int64(c.Jobs.CopyConcurrency),
+		"extract.maxEntries":           int64(c.Extract.MaxEntries),
+		"extract.maxEntryBytes":        c.Extract.MaxEntryBytes,
+		"extract.maxTotalBytes":        c.Extract.MaxTotalBytes,
+		"download.maxZipBytes":         c.Download.MaxZipBytes,
+		"download.maxZipObjects":       int64(c.Download.MaxZipObjects),
+		"download.zipConcurrency":      int64(c.Download.ZipConcurrency),
+		"fetch.maxBytes":               c.Fetch.MaxBytes,
+		"recent.size":                  int64(c.Recent.Size),
+		"listing.defaultPageSize":      int64(c.Listing.DefaultPageSize),
+		"listing.maxAllItems":          int64(c.Listing.MaxAllItems),
+		"listing.concurrency":          int64(c.Listing.Concurrency),
+		"listing.treeMaxFolders":       int64(c.Listing.TreeMaxFolders),
+		"listing.treeMaxFolderEntries": int64(c.Listing.TreeMaxFolderEntries),
+		"reports.maxObjects":           int64(c.Reports.MaxObjects),
+		"reports.maxAclChecks":         int64(c.Reports.MaxACLChecks),
+		"reports.maxLockChecks":        int64(c.Reports.MaxLockChecks),
+		"usage.retentionDays":          int64(c.Usage.RetentionDays),
+		"activity.prefixDepth":         int64(c.Activity.PrefixDepth),
+		"activity.retentionDays":       int64(c.Activity.RetentionDays),
+	} {
+		if value < 0 {
+			add("%s: must not be negative (got %d; leave unset for the default)", name, value)
+		}
+	}
+
+	if c.Preview.MaxBytes > 0 && c.Preview.DefaultBytes > c.Preview.MaxBytes {
+		add("preview.defaultBytes: must not exceed preview.maxBytes (got %d > %d)", c.Preview.DefaultBytes, c.Preview.MaxBytes)
+	}
+	if c.Listing.MaxPageSize < 0 || c.Listing.MaxPageSize > 1000 {
+		add("listing.maxPageSize: must be between 1 and 1000, the most S3 returns per page (got %d)", c.Listing.MaxPageSize)
+	}
+	if maxPageSize := cmp.Or(c.Listing.MaxPageSize, 1000); c.Listing.DefaultPageSize > maxPageSize {
+		add("listing.defaultPageSize: must not exceed listing.maxPageSize (got %d > %d)", c.Listing.DefaultPageSize, maxPageSize)
+	}
+
+	for i, days := range c.Reports.AgeDays {
+		if days <= 0 || (i > 0 && days <= c.Reports.AgeDays[i-1]) {
+			add("reports.ageDays: must be positive and ascending (got %v)", c.Reports.AgeDays)
+			break
+		}
+	}
+
+	if err := ValidatePrices(c.Pricing.StorageClasses); err != nil {
+		add("pricing.storageClasses: %s", err)
+	}
+
+	for name, value := range map[string]time.Duration{
+		"server.requestTimeout":    c.Server.RequestTimeout,
+		"server.readTimeout":       c.Server.ReadTimeout,
+		"server.readHeaderTimeout": c.Server.ReadHeaderTimeout,
+		"server.writeTimeout":      c.Server.WriteTimeout,
+		"server.idleTimeout":       c.Server.IdleTimeout,
+		"media.ffprobe.timeout":    c.Media.FFprobe.Timeout,
+		"pdf.timeout":              c.PDF.Timeout,
+		"antivirus.timeout":        c.Antivirus.Timeout,
+		"fetch.timeout":            c.Fetch.Timeout,
+		"jobs.drainTimeout":        c.Jobs.DrainTimeout,
+		"shares.defaultExpiry":     c.Shares.DefaultExpiry,
+		"shares.maxExpiry":         c.Shares.MaxExpiry,
+		"shares.urlExpiry":         c.Shares.URLExpiry,
+		"reports.sizeCacheTtl":     c.Reports.SizeCacheTTL,
+		"reports.garbageAge":       c.Reports.GarbageAge,
+		"store.refreshInterval":    c.Store.RefreshInterval,
+	} {
+		if value < 0 {
+			add("%s: must not be negative (got %s; leave unset for the default)", name, value)
+		}
+	}
+
+	if c.Shares.DefaultExpiry > 0 && c.Shares.MaxExpiry > 0 && c.Shares.DefaultExpiry > c.Shares.MaxExpiry {
+		add("shares.defaultExpiry: %s is longer than shares.maxExpiry (%s)", c.Shares.DefaultExpiry, c.Shares.MaxExpiry)
+	}
+
+	switch strings.ToLower(c.Server.ClientIPHeader) {
+	case "", "x-forwarded-for", "x-real-ip":
+	default:
+		add("server.clientIpHeader: %q is not supported (use X-Forwarded-For or X-Real-IP)", c.Server.ClientIPHeader)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, err := ParseTrustedProxy(proxy); err != nil {
+			add("server.trustedProxies: %q is not an IP address or CIDR range", proxy)
+		}
+	}
+	if c.Server.AdminAccess && c.Server.UserHeader == "" {
+		add("server.adminAccess: admins are identified by server.userHeader, which is not set")
+	}
+	if len(c.Server.AdminUsers) > 0 && c.Server.UserHeader == "" {
+		add("server.adminUsers: admins are identified by server.userHeader, which is not set")
+	}
+	if c.Server.ExposeConfig && !c.Server.AdminAccess && len(c.Server.AdminUsers) == 0 {
+		add("server.exposeConfig: only admins may use it; name them in server.adminUsers or enable server.adminAccess")
+	}
+	if c.Server.AdminData && !c.Server.AdminAccess && len(c.Server.AdminUsers) == 0 {
+		add("server.adminData: only admins may use it; name them in server.adminUsers or enable server.adminAccess")
+	}
+	if c.Server.UserHeader != "" && len(c.Server.TrustedProxies) == 0 {
+		add("server.userHeader: requires server.trustedProxies, the proxies allowed to set it")
+	}
+	if len(c.Server.TrustedProxies) > 0 && c.Server.ClientIPHeader == "" && c.Server.UserHeader == "" {
+		add("server.trustedProxies: has no effect without server.clientIpHeader or server.userHeader")
+	}
+
+	for name, value := range map[string]float64{
+		"rateLimit.globalRate":  c.RateLimit.GlobalRate,
+		"rateLimit.globalBurst": float64(c.RateLimit.GlobalBurst),
+		"rateLimit.perIpRate":   c.RateLimit.PerIPRate,
+		"rateLimit.perIpBurst":  float64(c.RateLimit.PerIPBurst),
+		"rateLimit.s3Rate":      c.RateLimit.S3Rate,
+		"rateLimit.s3Burst":     float64(c.RateLimit.S3Burst),
+	} {
+		if value < 0 {
+			add("%s: must not be negative (use 0 to disable the limit)", name)
+		}
+	}
+
+	for i, route := range c.Server.RouteTimeouts {
+		if !strings.HasPrefix(route.Route, "/") {
+			add("server.routeTimeouts[%d].route: %q must be a path below the API root, such as /buckets/:bucket/changes", i, route.Route)
+		}
+		if route.Timeout < 0 {
+			add("server.routeTimeouts[%d].timeout: must not be negative (use 0 to disable the timeout)", i)
+		}
+	}
+
+	for _, contentType := range c.Download.ProxyContentTypes {
+		if strings.HasSuffix(contentType, "/*") {
+			continue
+		}
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			add("download.proxyContentTypes: %q is not a content type", contentType)
+		}
+	}
+
+	for _, host := range c.Fetch.AllowedHosts {
+		if host == "" || strings.ContainsAny(host, "/:") {
+			add("fetch.allowedHosts: %q is not a host name", host)
+		}
+	}
+
+	if c.Uploads.RequirePolicy && len(c.Uploads.Policies) == 0 {
+		add("uploads.requirePolicy: no uploads.policies are defined")
+	}
+	if c.Uploads.KMSKeyID != "" && !c.Uploads.RequireKMS {
+		add("uploads.kmsKeyId: has no effect without uploads.requireKms")
+	}
+	if !slices.Contains(conflictStrategies, c.Uploads.Conflict) {
+		add("uploads.conflict: unknown strategy %q (use one of %s)", c.Uploads.Conflict, strings.Join(conflictStrategies[1:], ", "))
+	}
+	policyNames := make(map[string]bool)
+	for i, policy := range c.Uploads.Policies {
+		switch {
+		case policy.Name == "":
+			add("uploads.policies[%d]: name is required", i)
+		case policyNames[policy.Name]:
+			add("uploads.policies[%d]: policy %q is defined more than once", i, policy.Name)
+		}
+		policyNames[policy.Name] = true
+
+		for _, contentType := range policy.ContentTypes {
+			if strings.HasSuffix(contentType, "/*") {
+				continue
+			}
+			if _, _, err := mime.ParseMediaType(contentType); err != nil || !strings.Contains(contentType, "/") {
+				add("uploads.policies[%d].contentTypes: %q is not a content type", i, contentType)
+			}
+		}
+		if policy.MaxSizeBytes < 0 {
+			add("uploads.policies[%d].maxSizeBytes: must not be negative", i)
+		}
+		if policy.Expiry < 0 {
+			add("uploads.policies[%d].expiry: must not be negative", i)
+		}
+		for _, placeholder := range uploadPlaceholder.FindAllString(policy.KeyPrefix, -1) {
+			if placeholder != "{user}" && placeholder != "{date}" {
+				add("uploads.policies[%d].keyPrefix: unknown placeholder %s (use {user} or {date})", i, placeholder)
+			}
+		}
+		if !slices.Contains(sseAlgorithms, policy.SSE) {
+			add("uploads.policies[%d].sse: unknown algorithm %q (use one of %s)", i, policy.SSE, strings.Join(sseAlgorithms[1:], ", "))
+		}
+		if policy.KMSKeyID != "" && !strings.HasPrefix(policy.SSE, "aws:kms") {
+			add("uploads.policies[%d].kmsKeyId: requires sse aws:kms or aws:kms:dsse", i)
+		}
+		if c.Uploads.RequireKMS {
+			if policy.SSE != "" && !strings.HasPrefix(policy.SSE, "aws:kms") {
+				add("uploads.policies[%d].sse: uploads.requireKms allows only aws:kms or aws:kms:dsse", i)
+			}
+			if policy.KMSKeyID != "" && c.Uploads.KMSKeyID != "" && policy.KMSKeyID != c.Uploads.KMSKeyID {
+				add("uploads.policies[%d].kmsKeyId: differs from uploads.kmsKeyId", i)
+			}
+		}
+	}
+
+	if err := c.Server.TLS.validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Sort for stable output, since the limit checks iterate over maps
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// unknownKeys returns the loaded keys that do not correspond to any config field,
+// which are usually typos. Keys are compared case-insensitively since keys set
+// through environment variables are lower case.
+func unknownKeys(k *koanf.Koanf) []string {
+	known := make(map[string]string)
+	collectPaths(reflect.TypeOf(Config{}), "", known)
+
+	var unknown []string
+	for _, key := range k.Keys() {
+		if _, ok := known[strings.ToLower(key)]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
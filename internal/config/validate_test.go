@@ -0,0 +1,265 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, (&Config{}).Validate())
+
+	cfg := &Config{
+		Log:        LogConfig{Level: "verbose"},
+		Thumbnails: ThumbnailConfig{Sizes: []int{128, -1}},
+		Jobs:       JobsConfig{MaxConcurrent: -2},
+		PDF:        PDFConfig{Timeout: -time.Second},
+	}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `log.level: unknown level "verbose"`)
+	assert.Contains(t, err.Error(), "thumbnails.sizes: -1")
+	assert.Contains(t, err.Error(), "jobs.maxConcurrent: must not be negative")
+	assert.Contains(t, err.Error(), "pdf.timeout: must not be negative")
+
+	cfg = &Config{Server: ServerConfig{
+		Address:    "unix:/run/explorer451.sock",
+		SocketMode: "0999",
+		TLS:        TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key"},
+	}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `server.socketMode: "0999"`)
+	assert.Contains(t, err.Error(), "server.tls: TLS is not supported on a unix socket")
+
+	cfg = &Config{Server: ServerConfig{TrustedProxies: []string{"10.0.0.0/8", "proxy.internal"}}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `server.trustedProxies: "proxy.internal" is not an IP address`)
+	assert.Contains(t, err.Error(), "server.trustedProxies: has no effect without server.clientIpHeader or server.userHeader")
+
+	cfg = &Config{Server: ServerConfig{UserHeader: "X-Forwarded-User"}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.userHeader: requires server.trustedProxies")
+
+	cfg = &Config{Server: ServerConfig{AdminAccess: true}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.adminAccess: admins are identified by server.userHeader, which is not set")
+
+	cfg = &Config{Server: ServerConfig{AdminUsers: []string{"root"}}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.adminUsers: admins are identified by server.userHeader, which is not set")
+
+	cfg = &Config{Server: ServerConfig{AdminData: true, ExposeConfig: true}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.adminData: only admins may use it")
+	assert.Contains(t, err.Error(), "server.exposeConfig: only admins may use it")
+
+	cfg = &Config{Buckets: []BucketInfo{{Name: "logs-*", Group: "Logs"}, {Group: "Other"}, {Name: "data-[a"}}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "buckets[1]: name is required")
+	assert.Contains(t, err.Error(), `buckets[2]: "data-[a" is not a valid pattern`)
+
+	cfg = &Config{Preview: PreviewConfig{DefaultBytes: 2 << 20, MaxBytes: 1 << 20}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "preview.defaultBytes: must not exceed preview.maxBytes (got 2097152 > 1048576)")
+
+	cfg = &Config{Reports: ReportsConfig{AgeDays: []int{30, 30, 90}}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reports.ageDays: must be positive and ascending (got [30 30 90])")
+
+	cfg = &Config{Pricing: PricingConfig{StorageClasses: []StorageClassPrice{
+		{StorageClass: "STANDARD", PerGBMonth: 0.023},
+		{StorageClass: "standard", PerGBMonth: 0.02},
+	}}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pricing.storageClasses: STANDARD is listed more than once")
+
+	cfg = &Config{Uploads: UploadsConfig{Policies: []UploadPolicy{
+		{Name: "images", ContentTypes: []string{"image/*"}, KeyPrefix: "img/{user}/", SSE: "AES256"},
+		{Name: "images", KeyPrefix: "{bucket}/", SSE: "aws:kms:dsse"},
+		{ContentTypes: []string{"pdf"}, SSE: "AES256", KMSKeyID: "alias/uploads"},
+		{Name: "plain", SSE: "none"},
+	}}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "uploads.policies[0]")
+	assert.Contains(t, err.Error(), `uploads.policies[1]: policy "images" is defined more than once`)
+	assert.Contains(t, err.Error(), "uploads.policies[1].keyPrefix: unknown placeholder {bucket}")
+	assert.Contains(t, err.Error(), "uploads.policies[2]: name is required")
+	assert.Contains(t, err.Error(), `uploads.policies[2].contentTypes: "pdf" is not a content type`)
+	assert.Contains(t, err.Error(), "uploads.policies[2].kmsKeyId: requires sse aws:kms or aws:kms:dsse")
+	assert.Contains(t, err.Error(), `uploads.policies[3].sse: unknown algorithm "none"`)
+
+	cfg = &Config{Uploads: UploadsConfig{RequirePolicy: true}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "uploads.requirePolicy: no uploads.policies are defined")
+
+	cfg = &Config{Uploads: UploadsConfig{RequireKMS: true, KMSKeyID: "alias/uploads", Policies: []UploadPolicy{
+		{Name: "kms", SSE: "aws:kms"},
+		{Name: "s3", SSE: "AES256"},
+		{Name: "other", SSE: "aws:kms", KMSKeyID: "alias/other"},
+	}}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "uploads.policies[0]")
+	assert.Contains(t, err.Error(), "uploads.policies[1].sse: uploads.requireKms allows only aws:kms or aws:kms:dsse")
+	assert.Contains(t, err.Error(), "uploads.policies[2].kmsKeyId: differs from uploads.kmsKeyId")
+
+	cfg = &Config{Uploads: UploadsConfig{KMSKeyID: "alias/uploads"}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "uploads.kmsKeyId: has no effect without uploads.requireKms")
+
+	cfg = &Config{Uploads: UploadsConfig{Conflict: "skip"}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `uploads.conflict: unknown strategy "skip" (use one of overwrite, fail, rename)`)
+}
+
+func TestValidateBuckets(t *testing.T) {
+	cfg := &Config{AWS: AWSConfig{Buckets: []BucketEndpoint{
+		{Name: "legacy-data", Endpoint: "https://minio.internal:9000", UsePathStyle: true, AccessKeyID: "key", SecretAccessKey: "secret"},
+		{Name: "archive", Region: "eu-west-1", Profile: "archive"},
+		{Name: "redacted", ObjectLambdaARN: "arn:aws:s3-object-lambda:eu-west-1:123456789012:accesspoint/redacted"},
+	}}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.AWS.Buckets = append(cfg.AWS.Buckets,
+		BucketEndpoint{Name: "legacy-data"},
+		BucketEndpoint{Endpoint: "minio.internal"},
+		BucketEndpoint{Name: "half", AccessKeyID: "key"},
+		BucketEndpoint{Name: "lambda", ObjectLambdaARN: "arn:aws:s3:eu-west-1:123456789012:accesspoint/plain"},
+		BucketEndpoint{Name: "moved", Region: "us-east-1", UsePathStyle: true,
+			ObjectLambdaARN: "arn:aws:s3-object-lambda:eu-west-1:123456789012:accesspoint/redacted"},
+	)
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `aws.buckets[3]: bucket "legacy-data" is configured more than once`)
+	assert.Contains(t, err.Error(), "aws.buckets[4]: name is required")
+	assert.Contains(t, err.Error(), `aws.buckets[4].endpoint: "minio.internal" is not a URL`)
+	assert.Contains(t, err.Error(), "aws.buckets[5]: accessKeyId and secretAccessKey must be set together")
+	assert.Contains(t, err.Error(), "aws.buckets[6].objectLambdaArn: ")
+	assert.Contains(t, err.Error(), `aws.buckets[7].region: "us-east-1" does not match the access point's region "eu-west-1"`)
+	assert.Contains(t, err.Error(), "aws.buckets[7].usePathStyle: ")
+}
+
+func TestValidateTLS(t *testing.T) {
+	tests := map[string]TLSConfig{
+		"cert without key":   {CertFile: "tls.crt"},
+		"autocert and files": {CertFile: "tls.crt", KeyFile: "tls.key", Autocert: AutocertConfig{Enabled: true, Hosts: []string{"a"}}},
+		"autocert no hosts":  {Autocert: AutocertConfig{Enabled: true}},
+		"redirect no tls":    {RedirectAddress: ":80"},
+	}
+	for name, tlsCfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, tlsCfg.validate())
+		})
+	}
+
+	assert.NoError(t, TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", RedirectAddress: ":80"}.validate())
+}
+
+func TestUnknownKeys(t *testing.T) {
+	k := koanf.New(".")
+	require.NoError(t, k.Set("server.address", ":8080"))
+	require.NoError(t, k.Set("server.tls.autocert.hosts", []string{"example.com"}))
+	require.NoError(t, k.Set("thumbnails.maxsourcebytes", 1024)) // from the environment
+	require.NoError(t, k.Set("thumbnails.cachedirectory", "/tmp"))
+	require.NoError(t, k.Set("logging.level", "debug"))
+
+	assert.Equal(t, []string{"logging.level", "thumbnails.cachedirectory"}, unknownKeys(k))
+}
+
+func TestLoadFeatures(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte("features:\n  deletes: false\n"), 0o644))
+	t.Chdir(dir)
+	t.Setenv("EXPLORER451_FEATURES_PROXYDOWNLOADS", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Features.Uploads)
+	assert.True(t, cfg.Features.Edits)
+	assert.False(t, cfg.Features.Deletes)
+	assert.False(t, cfg.Features.ProxyDownloads)
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(`
+server:
+  address: ":8080"
+log:
+  level: info
+profile: dev
+profiles:
+  dev:
+    log:
+      level: debug
+  prod:
+    server:
+      address: ":9000"
+    log:
+      level: warn
+  staging:
+    log:
+      levle: warn
+`), 0o644))
+	t.Chdir(dir)
+
+	// The file selects a default profile
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "dev", cfg.Profile)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, ":8080", cfg.Server.Address)
+
+	// The environment selects another, and its variables override the profile
+	t.Setenv("EXPLORER451_PROFILE", "prod")
+	t.Setenv("EXPLORER451_SERVER_ADDRESS", ":9100")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cfg.Profile)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, ":9100", cfg.Server.Address)
+
+	// Typos in any profile are reported by check-config
+	_, err = Check()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "profiles.staging.log.levle: unknown setting")
+
+	t.Setenv("EXPLORER451_PROFILE", "qa")
+	_, err = Load()
+	assert.ErrorContains(t, err, `profile "qa" is not defined`)
+}
+
+func TestValidateStore(t *testing.T) {
+	cfg := &Config{Store: StoreConfig{Driver: "postgres", DSN: "postgres://db.internal/explorer451"}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Store.DSN = ""
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "store.dsn: a connection string is required")
+
+	cfg.Store.Driver = "mysql"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `store.driver: unknown driver "mysql"`)
+}
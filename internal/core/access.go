@@ -0,0 +1,470 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+var (
+	// ErrUserNotFound is returned for unknown user names
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned when creating a user that already exists
+	ErrUserExists = errors.New("user already exists")
+	// ErrTeamNotFound is returned for unknown team names
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrTeamExists is returned when creating a team that already exists
+	ErrTeamExists = errors.New("team already exists")
+	// ErrUnknownRole is returned when assigning a role that does not exist
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrInvalidAccessName is returned for empty or overlong user and team names
+	ErrInvalidAccessName = errors.New("invalid name")
+)
+
+// maxAccessNameLength caps the length of user and team names
+const maxAccessNameLength = 256
+
+// roles are the roles that can be assigned to users and teams
+var roles = []models.Role{
+	{
+		Name:        "viewer",
+		Description: "Browse, preview and download objects",
+		Permissions: []string{models.PermissionRead},
+	},
+	{
+		Name:        "contributor",
+		Description: "Viewer, plus upload objects and create share links",
+		Permissions: []string{models.PermissionRead, models.PermissionUpload, models.PermissionShare},
+	},
+	{
+		Name:        "editor",
+		Description: "Contributor, plus edit and delete objects",
+		Permissions: []string{models.PermissionRead, models.PermissionUpload, models.PermissionShare, models.PermissionEdit, models.PermissionDelete},
+	},
+	{
+		Name:        "admin",
+		Description: "Everything, including managing users and teams",
+		Permissions: []string{models.PermissionRead, models.PermissionUpload, models.PermissionShare, models.PermissionEdit, models.PermissionDelete, models.PermissionAdmin},
+	},
+}
+
+// AccessService keeps the users and teams known to the explorer and the roles assigned
+// to them
+type AccessService struct {
+	core *Core
+
+	mu    sync.Mutex
+	users map[string]models.User
+	teams map[string]models.Team
+}
+
+// accessState is how users and teams are saved
+type accessState struct {
+	Users []models.User `json:"users"`
+	Teams []models.Team `json:"teams"`
+}
+
+// NewAccessService creates a new AccessService, restoring the users and teams saved by
+// the previous run
+func NewAccessService(core *Core) *AccessService {
+	a := &AccessService{core: core}
+
+	var state accessState
+	if err := core.restoreState("access", "", &state); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore users and teams")
+	}
+	a.setLocked(state)
+	return a
+}
+
+// Roles returns the roles that can be assigned
+func (a *AccessService) Roles() []models.Role {
+	return slices.Clone(roles)
+}
+
+// Users returns every user, sorted by name
+func (a *AccessService) Users() []models.User {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	users := make([]models.User, 0, len(a.users))
+	for _, name := range slices.Sorted(maps.Keys(a.users)) {
+		users = append(users, a.userLocked(name))
+	}
+	return users
+}
+
+// User returns one user
+func (a *AccessService) User(name string) (models.User, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	if _, ok := a.users[name]; !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return a.userLocked(name), nil
+}
+
+// Disabled reports whether a user was disabled; unknown users are not
+func (a *AccessService) Disabled(name string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	return a.users[name].Disabled
+}
+
+// CreateUser adds a user
+func (a *AccessService) CreateUser(req models.CreateUserRequest) (models.User, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := validateAccessName(name); err != nil {
+		return models.User{}, err
+	}
+	assigned, err := validateRoles(req.Roles)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	if _, ok := a.users[name]; ok {
+		return models.User{}, ErrUserExists
+	}
+	now := time.Now().UTC()
+	a.users[name] = models.User{
+		Name:        name,
+		DisplayName: req.DisplayName,
+		Roles:       assigned,
+		Workspace:   req.Workspace,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := a.saveLocked(); err != nil {
+		delete(a.users, name)
+		return models.User{}, err
+	}
+	return a.userLocked(name), nil
+}
+
+// UpdateUser changes a user's display name, roles or disabled flag
+func (a *AccessService) UpdateUser(name string, req models.UpdateUserRequest) (models.User, error) {
+	var assigned []string
+	if req.Roles != nil {
+		var err error
+		if assigned, err = validateRoles(*req.Roles); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	previous, ok := a.users[name]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	user := previous
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+	if req.Roles != nil {
+		user.Roles = assigned
+	}
+	user.UpdatedAt = time.Now().UTC()
+
+	a.users[name] = user
+	if err := a.saveLocked(); err != nil {
+		a.users[name] = previous
+		return models.User{}, err
+	}
+	return a.userLocked(name), nil
+}
+
+// Teams returns every team, sorted by name
+func (a *AccessService) Teams() []models.Team {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	teams := make([]models.Team, 0, len(a.teams))
+	for _, name := range slices.Sorted(maps.Keys(a.teams)) {
+		teams = append(teams, a.teams[name])
+	}
+	return teams
+}
+
+// Team returns one team
+func (a *AccessService) Team(name string) (models.Team, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	team, ok := a.teams[name]
+	if !ok {
+		return models.Team{}, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// CreateTeam adds a team. Its members must be existing users.
+func (a *AccessService) CreateTeam(req models.CreateTeamRequest) (models.Team, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := validateAccessName(name); err != nil {
+		return models.Team{}, err
+	}
+	assigned, err := validateRoles(req.Roles)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	if _, ok := a.teams[name]; ok {
+		return models.Team{}, ErrTeamExists
+	}
+	members, err := a.addMembersLocked(nil, req.Members)
+	if err != nil {
+		return models.Team{}, err
+	}
+	now := time.Now().UTC()
+	team := models.Team{
+		Name:        name,
+		Description: req.Description,
+		Members:     members,
+		Roles:       assigned,
+		Workspace:   req.Workspace,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	a.teams[name] = team
+	if err := a.saveLocked(); err != nil {
+		delete(a.teams, name)
+		return models.Team{}, err
+	}
+	return team, nil
+}
+
+// UpdateTeam changes a team's description or roles and adds and removes members
+func (a *AccessService) UpdateTeam(name string, req models.UpdateTeamRequest) (models.Team, error) {
+	var assigned []string
+	if req.Roles != nil {
+		var err error
+		if assigned, err = validateRoles(*req.Roles); err != nil {
+			return models.Team{}, err
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	previous, ok := a.teams[name]
+	if !ok {
+		return models.Team{}, ErrTeamNotFound
+	}
+	team := previous
+	if req.Description != nil {
+		team.Description = *req.Description
+	}
+	if req.Roles != nil {
+		team.Roles = assigned
+	}
+	members, err := a.addMembersLocked(team.Members, req.AddMembers)
+	if err != nil {
+		return models.Team{}, err
+	}
+	team.Members = slices.DeleteFunc(members, func(member string) bool {
+		return slices.Contains(req.RemoveMembers, member)
+	})
+	team.UpdatedAt = time.Now().UTC()
+
+	a.teams[name] = team
+	if err := a.saveLocked(); err != nil {
+		a.teams[name] = previous
+		return models.Team{}, err
+	}
+	return team, nil
+}
+
+// DeleteTeam removes a team; its members lose the roles it gave them
+func (a *AccessService) DeleteTeam(name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	team, ok := a.teams[name]
+	if !ok {
+		return ErrTeamNotFound
+	}
+	delete(a.teams, name)
+	if err := a.saveLocked(); err != nil {
+		a.teams[name] = team
+		return err
+	}
+	return nil
+}
+
+// EffectivePermissions returns the roles a user holds, directly or through teams, and
+// the permissions they add up to. Disabled users hold roles but have no permissions.
+func (a *AccessService) EffectivePermissions(name string) (models.EffectivePermissions, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+
+	user, ok := a.users[name]
+	if !ok {
+		return models.EffectivePermissions{}, ErrUserNotFound
+	}
+
+	effective := models.EffectivePermissions{
+		User:        name,
+		Disabled:    user.Disabled,
+		Roles:       []models.RoleGrant{},
+		Permissions: []string{},
+	}
+	for _, role := range user.Roles {
+		effective.Roles = append(effective.Roles, models.RoleGrant{Role: role, Via: "user"})
+	}
+	for _, team := range a.teamsOfLocked(name) {
+		for _, role := range a.teams[team].Roles {
+			effective.Roles = append(effective.Roles, models.RoleGrant{Role: role, Via: "team:" + team})
+		}
+	}
+	if !user.Disabled {
+		effective.Permissions = permissionsOf(effective.Roles)
+	}
+	return effective, nil
+}
+
+// permissionsOf returns the permissions granted by roles, sorted
+func permissionsOf(grants []models.RoleGrant) []string {
+	permissions := []string{}
+	for _, grant := range grants {
+		for _, role := range roles {
+			if role.Name != grant.Role {
+				continue
+			}
+			for _, permission := range role.Permissions {
+				if !slices.Contains(permissions, permission) {
+					permissions = append(permissions, permission)
+				}
+			}
+		}
+	}
+	slices.Sort(permissions)
+	return permissions
+}
+
+// userLocked returns a user with their teams filled in
+func (a *AccessService) userLocked(name string) models.User {
+	user := a.users[name]
+	user.Roles = slices.Clone(user.Roles)
+	user.Teams = a.teamsOfLocked(name)
+	return user
+}
+
+// teamsOfLocked returns the names of the teams a user is a member of, sorted
+func (a *AccessService) teamsOfLocked(user string) []string {
+	teams := []string{}
+	for _, name := range slices.Sorted(maps.Keys(a.teams)) {
+		if slices.Contains(a.teams[name].Members, user) {
+			teams = append(teams, name)
+		}
+	}
+	return teams
+}
+
+// addMembersLocked returns members with the given users added, each once. Every user
+// must exist.
+func (a *AccessService) addMembersLocked(members, add []string) ([]string, error) {
+	members = slices.Clone(members)
+	if members == nil {
+		members = []string{}
+	}
+	for _, user := range add {
+		if _, ok := a.users[user]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, user)
+		}
+		if !slices.Contains(members, user) {
+			members = append(members, user)
+		}
+	}
+	return members, nil
+}
+
+// refreshLocked reloads the users and teams when another instance changed them, so a
+// user disabled there is disabled here too
+func (a *AccessService) refreshLocked() {
+	var state accessState
+	reloaded, err := a.core.refreshState("access", "", &state)
+	if err != nil {
+		a.core.Logger.Warn().
+			Err(err).
+			Msg("Failed to refresh users and teams")
+		return
+	}
+	if reloaded {
+		a.setLocked(state)
+	}
+}
+
+// setLocked replaces the users and teams with saved ones
+func (a *AccessService) setLocked(state accessState) {
+	a.users = make(map[string]models.User, len(state.Users))
+	for _, user := range state.Users {
+		a.users[user.Name] = user
+	}
+	a.teams = make(map[string]models.Team, len(state.Teams))
+	for _, team := range state.Teams {
+		a.teams[team.Name] = team
+	}
+}
+
+// saveLocked persists the users and teams
+func (a *AccessService) saveLocked() error {
+	state := accessState{
+		Users: slices.Collect(maps.Values(a.users)),
+		Teams: slices.Collect(maps.Values(a.teams)),
+	}
+	return a.core.persistState("access", "", state)
+}
+
+// validateAccessName checks a user or team name
+func validateAccessName(name string) error {
+	if name == "" || len(name) > maxAccessNameLength {
+		return fmt.Errorf("%w: names must be 1 to %d characters", ErrInvalidAccessName, maxAccessNameLength)
+	}
+	return nil
+}
+
+// validateRoles checks that every role exists and returns them without duplicates
+func validateRoles(assigned []string) ([]string, error) {
+	valid := []string{}
+	for _, name := range assigned {
+		if !slices.ContainsFunc(roles, func(role models.Role) bool { return role.Name == name }) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRole, name)
+		}
+		if !slices.Contains(valid, name) {
+			valid = append(valid, name)
+		}
+	}
+	return valid, nil
+}
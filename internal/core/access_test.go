@@ -0,0 +1,72 @@
+package core
+
+import (
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAccessService() *AccessService {
+	return NewAccessService(&Core{
+		Config: &config.Config{},
+		Logger: logger.New("error", "json"),
+	})
+}
+
+func TestAccessService(t *testing.T) {
+	access := newTestAccessService()
+
+	alice, err := access.CreateUser(models.CreateUserRequest{Name: " alice ", Roles: []string{"viewer", "viewer"}})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", alice.Name)
+	assert.Equal(t, []string{"viewer"}, alice.Roles)
+	assert.Empty(t, alice.Teams)
+
+	_, err = access.CreateUser(models.CreateUserRequest{Name: "alice"})
+	assert.ErrorIs(t, err, ErrUserExists)
+	_, err = access.CreateUser(models.CreateUserRequest{Name: "bob", Roles: []string{"root"}})
+	assert.ErrorIs(t, err, ErrUnknownRole)
+	_, err = access.CreateUser(models.CreateUserRequest{Name: "  "})
+	assert.ErrorIs(t, err, ErrInvalidAccessName)
+
+	_, err = access.CreateTeam(models.CreateTeamRequest{Name: "finance", Members: []string{"carol"}})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	team, err := access.CreateTeam(models.CreateTeamRequest{Name: "finance", Members: []string{"alice"}, Roles: []string{"contributor"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, team.Members)
+
+	alice, err = access.User("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"finance"}, alice.Teams)
+
+	effective, err := access.EffectivePermissions("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []models.RoleGrant{{Role: "viewer", Via: "user"}, {Role: "contributor", Via: "team:finance"}}, effective.Roles)
+	assert.Equal(t, []string{"read", "share", "upload"}, effective.Permissions)
+
+	// Disabled users keep their roles but lose every permission
+	disabled := true
+	_, err = access.UpdateUser("alice", models.UpdateUserRequest{Disabled: &disabled})
+	require.NoError(t, err)
+	effective, err = access.EffectivePermissions("alice")
+	require.NoError(t, err)
+	assert.True(t, effective.Disabled)
+	assert.Len(t, effective.Roles, 2)
+	assert.Empty(t, effective.Permissions)
+	assert.True(t, access.Disabled("alice"))
+	assert.False(t, access.Disabled("nobody"))
+
+	team, err = access.UpdateTeam("finance", models.UpdateTeamRequest{RemoveMembers: []string{"alice"}})
+	require.NoError(t, err)
+	assert.Empty(t, team.Members)
+
+	require.NoError(t, access.DeleteTeam("finance"))
+	assert.ErrorIs(t, access.DeleteTeam("finance"), ErrTeamNotFound)
+	_, err = access.EffectivePermissions("nobody")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
@@ -0,0 +1,468 @@
+package core
+
+import (
+	"bufio"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// JobTypeIngestAccessLogs is the job type of access log ingestion
+const JobTypeIngestAccessLogs = "ingest-access-logs"
+
+// accessLogTimeLayout is the format of request times in S3 server access logs
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+var (
+	// ErrAccessLogsNotConfigured is returned when ingesting access logs without
+	// activity.logBucket set
+	ErrAccessLogsNotConfigured = errors.New("access logs are not configured")
+	// ErrInvalidActivityRange is returned for from and to dates that are not YYYY-MM-DD
+	ErrInvalidActivityRange = errors.New("invalid activity date range")
+
+	// errInvalidAccessLog is recorded for log files that cannot be read
+	errInvalidAccessLog = errors.New("not an S3 access log or CloudTrail log")
+)
+
+// Operations counted as reads and writes: S3 server access log operations and
+// CloudTrail event names
+var (
+	accessLogReads = []string{
+		"REST.GET.OBJECT", "REST.COPY.OBJECT_GET",
+		"GetObject",
+	}
+	accessLogWrites = []string{
+		"REST.PUT.OBJECT", "REST.POST.UPLOAD", "REST.COPY.OBJECT", "REST.DELETE.OBJECT", "BATCH.DELETE.OBJECT",
+		"PutObject", "CopyObject", "CompleteMultipartUpload", "DeleteObject",
+	}
+)
+
+// ActivityService counts the reads and writes of objects per prefix and day, from S3
+// server access logs or CloudTrail logs with S3 data events, so owners can find data
+// that is no longer used
+type ActivityService struct {
+	core *Core
+
+	mu    sync.Mutex
+	state activityState
+}
+
+// activityState is the ingested counts, as kept in the state file
+type activityState struct {
+	// Watermark is the last-modified time of the newest log file ingested; only
+	// files delivered after it are read by the next ingest
+	Watermark time.Time `json:"watermark"`
+	// Counts maps buckets to prefixes to their points by date
+	Counts map[string]map[string]map[string]models.ActivityPoint `json:"counts"`
+}
+
+// accessRequest is a read or write of an object found in a log
+type accessRequest struct {
+	bucket string
+	key    string
+	date   string
+	write  bool
+}
+
+// NewActivityService creates a new ActivityService, restoring the counts saved by the
+// previous run
+func NewActivityService(core *Core) *ActivityService {
+	a := &ActivityService{core: core}
+
+	if err := core.restoreState("activity", core.Config.Activity.StateFile, &a.state); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore access activity")
+	}
+	if a.state.Counts == nil {
+		a.state.Counts = make(map[string]map[string]map[string]models.ActivityPoint)
+	}
+	return a
+}
+
+// Prefixes returns the request history of the prefixes of a bucket under prefix, with
+// totals between from and to, inclusive; either may be empty to leave that end open.
+// Prefixes are ordered by their last read, never-read and least recently read first,
+// so the coldest data comes first.
+func (a *ActivityService) Prefixes(bucket, prefix, from, to string) (models.ActivityResponse, error) {
+	if err := validDateRange(ErrInvalidActivityRange, from, to); err != nil {
+		return models.ActivityResponse{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	response := models.ActivityResponse{
+		Bucket:   bucket,
+		Prefix:   prefix,
+		From:     from,
+		To:       to,
+		Prefixes: []models.PrefixActivity{},
+	}
+	if !a.state.Watermark.IsZero() {
+		response.IngestedThrough = a.state.Watermark.UTC().Format(time.RFC3339)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(a.state.Counts[bucket])) {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		activity := models.PrefixActivity{Prefix: name, Points: []models.ActivityPoint{}}
+		days := a.state.Counts[bucket][name]
+		// Dates in YYYY-MM-DD order as strings
+		for _, date := range slices.Sorted(maps.Keys(days)) {
+			point := days[date]
+			if point.Reads > 0 {
+				activity.LastRead = date
+			}
+			if point.Writes > 0 {
+				activity.LastWrite = date
+			}
+			if (from != "" && date < from) || (to != "" && date > to) {
+				continue
+			}
+			activity.Reads += point.Reads
+			activity.Writes += point.Writes
+			activity.Points = append(activity.Points, point)
+		}
+		response.Prefixes = append(response.Prefixes, activity)
+	}
+
+	slices.SortStableFunc(response.Prefixes, func(x, y models.PrefixActivity) int {
+		return cmp.Compare(x.LastRead, y.LastRead)
+	})
+	return response, nil
+}
+
+// IngestAccessLogs starts a job reading the logs under activity.logPrefix that were
+// delivered since the previous ingest
+func (a *ActivityService) IngestAccessLogs() (models.Job, error) {
+	bucket := a.core.Config.Activity.LogBucket
+	prefix := a.core.Config.Activity.LogPrefix
+	if bucket == "" {
+		return models.Job{}, ErrAccessLogsNotConfigured
+	}
+
+	params := map[string]string{"bucket": bucket, "prefix": prefix}
+	return a.core.Jobs.Submit(JobTypeIngestAccessLogs, params, func(ctx context.Context, job *Job) error {
+		return a.ingestAccessLogs(ctx, job, bucket, prefix)
+	}), nil
+}
+
+// ingestAccessLogs counts the requests in every log file delivered after the watermark,
+// oldest first. Files that cannot be read are reported and not retried.
+func (a *ActivityService) ingestAccessLogs(ctx context.Context, job *Job, bucket, prefix string) error {
+	a.mu.Lock()
+	watermark := a.state.Watermark
+	a.mu.Unlock()
+
+	type logFile struct {
+		key      string
+		modified time.Time
+	}
+	var files []logFile
+	paginator := s3.NewListObjectsV2Paginator(a.core.Client(bucket), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			a.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("prefix", prefix).
+				Msg("Failed to list access logs")
+			return err
+		}
+		for _, obj := range page.Contents {
+			if modified := aws.ToTime(obj.LastModified); modified.After(watermark) {
+				files = append(files, logFile{key: aws.ToString(obj.Key), modified: modified})
+			}
+		}
+	}
+	slices.SortStableFunc(files, func(x, y logFile) int {
+		return x.modified.Compare(y.modified)
+	})
+	job.AddTotal(int64(len(files)))
+
+	depth := a.core.Config.Activity.PrefixDepth
+	counts := make(map[string]map[string]map[string]models.ActivityPoint)
+	var requests int
+	// Whatever was read is kept when the job is cancelled
+	defer func() {
+		a.merge(counts, watermark)
+		a.core.Logger.Info().
+			Str("bucket", bucket).
+			Str("prefix", prefix).
+			Int("requests", requests).
+			Msg("Ingested access logs")
+	}()
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		size, err := a.readAccessLog(ctx, bucket, file.key, func(req accessRequest) {
+			requests++
+			addActivity(counts, req, depth)
+		})
+		watermark = file.modified
+		if err != nil {
+			job.ItemFailed(file.key, err)
+			continue
+		}
+		job.ItemSucceeded(file.key, size)
+	}
+	return nil
+}
+
+// readAccessLog calls fn for each successful read or write of an object in a log file,
+// and returns the file's size. Files ending in .json or .json.gz are read as CloudTrail
+// logs, all others as S3 server access logs.
+func (a *ActivityService) readAccessLog(ctx context.Context, bucket, key string, fn func(accessRequest)) (int64, error) {
+	output, err := a.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer output.Body.Close()
+
+	var body io.Reader = output.Body
+	if isGzipObject(aws.ToString(output.ContentEncoding), aws.ToString(output.ContentType), key) {
+		gzipReader, err := gzip.NewReader(output.Body)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", errInvalidAccessLog, err)
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(key), ".gz")
+	if strings.HasSuffix(name, ".json") {
+		err = readCloudTrailLog(body, fn)
+	} else {
+		err = readServerAccessLog(body, fn)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return aws.ToInt64(output.ContentLength), nil
+}
+
+// merge adds ingested counts and moves the watermark up to the newest file read
+func (a *ActivityService) merge(counts map[string]map[string]map[string]models.ActivityPoint, watermark time.Time) {
+	oldest := time.Now().UTC().AddDate(0, 0, -a.core.Config.Activity.RetentionDays).Format(usageDateLayout)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for bucket, prefixes := range counts {
+		for prefix, days := range prefixes {
+			for date, point := range days {
+				if date < oldest {
+					continue
+				}
+				stored := a.state.Counts[bucket][prefix][date]
+				point.Reads += stored.Reads
+				point.Writes += stored.Writes
+				setActivity(a.state.Counts, bucket, prefix, point)
+			}
+		}
+	}
+	if watermark.After(a.state.Watermark) {
+		a.state.Watermark = watermark
+	}
+
+	// Drop days past the retention
+	for bucket, prefixes := range a.state.Counts {
+		for prefix, days := range prefixes {
+			maps.DeleteFunc(days, func(date string, _ models.ActivityPoint) bool {
+				return date < oldest
+			})
+			if len(days) == 0 {
+				delete(prefixes, prefix)
+			}
+		}
+		if len(prefixes) == 0 {
+			delete(a.state.Counts, bucket)
+		}
+	}
+
+	if err := a.core.persistState("activity", a.core.Config.Activity.StateFile, a.state); err != nil {
+		a.core.Logger.Warn().
+			Err(err).
+			Msg("Failed to save access activity")
+	}
+}
+
+// addActivity counts a request towards its prefix
+func addActivity(counts map[string]map[string]map[string]models.ActivityPoint, req accessRequest, depth int) {
+	prefix := activityPrefix(req.key, depth)
+	point := counts[req.bucket][prefix][req.date]
+	point.Date = req.date
+	if req.write {
+		point.Writes++
+	} else {
+		point.Reads++
+	}
+	setActivity(counts, req.bucket, prefix, point)
+}
+
+// setActivity stores a point, creating the maps it goes in
+func setActivity(counts map[string]map[string]map[string]models.ActivityPoint, bucket, prefix string, point models.ActivityPoint) {
+	prefixes, ok := counts[bucket]
+	if !ok {
+		prefixes = make(map[string]map[string]models.ActivityPoint)
+		counts[bucket] = prefixes
+	}
+	days, ok := prefixes[prefix]
+	if !ok {
+		days = make(map[string]models.ActivityPoint)
+		prefixes[prefix] = days
+	}
+	days[point.Date] = point
+}
+
+// activityPrefix returns the folder of a key, cut to its first depth levels, or "" for
+// objects at the top of the bucket
+func activityPrefix(key string, depth int) string {
+	folders := strings.Split(key, "/")
+	folders = folders[:len(folders)-1]
+	if len(folders) > depth {
+		folders = folders[:depth]
+	}
+	if len(folders) == 0 {
+		return ""
+	}
+	return strings.Join(folders, "/") + "/"
+}
+
+// accessRequestFor classifies an operation on a key, reporting false for operations that
+// are neither reads nor writes of an object
+func accessRequestFor(bucket, key, operation string, at time.Time) (accessRequest, bool) {
+	req := accessRequest{bucket: bucket, key: key, date: at.UTC().Format(usageDateLayout)}
+	switch {
+	case bucket == "" || key == "" || key == "-":
+		return req, false
+	case slices.Contains(accessLogReads, operation):
+		return req, true
+	case slices.Contains(accessLogWrites, operation):
+		req.write = true
+		return req, true
+	}
+	return req, false
+}
+
+// readServerAccessLog reads the successful object requests in an S3 server access log
+func readServerAccessLog(r io.Reader, fn func(accessRequest)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// bucket owner, bucket, [time], remote IP, requester, request ID, operation, key,
+		// "request URI", HTTP status, ...
+		fields := splitAccessLogLine(line)
+		if len(fields) < 10 {
+			return fmt.Errorf("%w: line with %d fields", errInvalidAccessLog, len(fields))
+		}
+		at, err := time.Parse(accessLogTimeLayout, fields[2])
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidAccessLog, err)
+		}
+		status, err := strconv.Atoi(fields[9])
+		if err != nil || status < 200 || status > 299 {
+			continue
+		}
+		key, err := url.QueryUnescape(fields[7])
+		if err != nil {
+			key = fields[7]
+		}
+		if req, ok := accessRequestFor(fields[1], key, fields[6], at); ok {
+			fn(req)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidAccessLog, err)
+	}
+	return nil
+}
+
+// splitAccessLogLine splits a server access log line into its fields, keeping
+// bracketed times and quoted strings whole and without their delimiters
+func splitAccessLogLine(line string) []string {
+	var fields []string
+	for line != "" {
+		var field string
+		switch line[0] {
+		case '[', '"':
+			closing := "]"
+			if line[0] == '"' {
+				closing = `"`
+			}
+			end := strings.Index(line[1:], closing)
+			if end < 0 {
+				return append(fields, line[1:])
+			}
+			field, line = line[1:end+1], line[end+2:]
+		default:
+			field, line, _ = strings.Cut(line, " ")
+		}
+		fields = append(fields, field)
+		line = strings.TrimLeft(line, " ")
+	}
+	return fields
+}
+
+// cloudTrailLog is the part of a CloudTrail log file read for activity
+type cloudTrailLog struct {
+	Records []struct {
+		EventSource       string    `json:"eventSource"`
+		EventName         string    `json:"eventName"`
+		EventTime         time.Time `json:"eventTime"`
+		ErrorCode         string    `json:"errorCode"`
+		RequestParameters struct {
+			BucketName string `json:"bucketName"`
+			Key        string `json:"key"`
+		} `json:"requestParameters"`
+	} `json:"Records"`
+}
+
+// readCloudTrailLog reads the successful S3 object data events in a CloudTrail log
+func readCloudTrailLog(r io.Reader, fn func(accessRequest)) error {
+	var log cloudTrailLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidAccessLog, err)
+	}
+	for _, record := range log.Records {
+		if record.EventSource != "s3.amazonaws.com" || record.ErrorCode != "" {
+			continue
+		}
+		params := record.RequestParameters
+		if req, ok := accessRequestFor(params.BucketName, params.Key, record.EventName, record.EventTime); ok {
+			fn(req)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogFile is a log file in the fake log bucket
+type testLogFile struct {
+	modified string
+	body     []byte
+}
+
+// newTestActivityCore fakes a bucket of access logs under logs/
+func newTestActivityCore(t *testing.T, files map[string]testLogFile) *Core {
+	cfg := &config.Config{
+		Jobs:     config.JobsConfig{MaxConcurrent: 1, Retention: 10},
+		Activity: config.ActivityConfig{LogBucket: "access-logs", LogPrefix: "logs/", PrefixDepth: 2, RetentionDays: 10000},
+	}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/access-logs/")
+		if r.URL.Query().Has("list-type") {
+			w.Header().Set("Content-Type", "application/xml")
+			var contents strings.Builder
+			for name, file := range files {
+				fmt.Fprintf(&contents, `<Contents><Key>%s</Key><LastModified>%s</LastModified></Contents>`, name, file.modified)
+			}
+			fmt.Fprintf(w, `<ListBucketResult><IsTruncated>false</IsTruncated>%s</ListBucketResult>`, contents.String())
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(files[key].body)))
+		_, _ = w.Write(files[key].body)
+	})
+	c.Jobs = NewJobManager(c)
+	c.Activity = NewActivityService(c)
+	return c
+}
+
+func TestActivityPrefix(t *testing.T) {
+	assert.Equal(t, "", activityPrefix("readme.txt", 2))
+	assert.Equal(t, "logs/", activityPrefix("logs/app.log", 2))
+	assert.Equal(t, "logs/2024/", activityPrefix("logs/2024/01/app.log", 2))
+	assert.Equal(t, "logs/", activityPrefix("logs/2024/01/app.log", 1))
+}
+
+func TestIngestAccessLogs(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte(`{"Records": [
+		{"eventSource": "s3.amazonaws.com", "eventName": "GetObject", "eventTime": "2024-03-02T10:00:00Z",
+		 "requestParameters": {"bucketName": "docs", "key": "reports/2023/q4.pdf"}},
+		{"eventSource": "s3.amazonaws.com", "eventName": "GetObject", "eventTime": "2024-03-02T10:00:00Z", "errorCode": "AccessDenied",
+		 "requestParameters": {"bucketName": "docs", "key": "reports/2023/q4.pdf"}},
+		{"eventSource": "s3.amazonaws.com", "eventName": "ListObjects", "eventTime": "2024-03-02T10:00:00Z",
+		 "requestParameters": {"bucketName": "docs"}}
+	]}`))
+	require.NoError(t, gz.Close())
+
+	c := newTestActivityCore(t, map[string]testLogFile{
+		"logs/2024-03-01-00-00-00-A": {modified: "2024-03-01T01:00:00Z", body: []byte(
+			`owner docs [01/Mar/2024:00:10:00 +0000] 192.0.2.3 arn:aws:iam::123:user/a 1 REST.PUT.OBJECT reports/2023/q4.pdf "PUT /docs/reports/2023/q4.pdf HTTP/1.1" 200 - 0 10 5 4 "-" "curl" -` + "\n" +
+				`owner docs [01/Mar/2024:00:11:00 +0000] 192.0.2.3 arn:aws:iam::123:user/a 2 REST.GET.OBJECT archive/old%20file.txt "GET /docs/archive/old%20file.txt HTTP/1.1" 404 NoSuchKey 0 - 5 4 "-" "curl" -` + "\n" +
+				`owner docs [01/Mar/2024:00:12:00 +0000] 192.0.2.3 arn:aws:iam::123:user/a 3 REST.PUT.OBJECT archive/old%20file.txt "PUT /docs/archive/old%20file.txt HTTP/1.1" 200 - 0 10 5 4 "-" "curl" -` + "\n" +
+				`owner docs [01/Mar/2024:00:13:00 +0000] 192.0.2.3 arn:aws:iam::123:user/a 4 REST.GET.BUCKET - "GET /docs?list-type=2 HTTP/1.1" 200 - 100 - 5 4 "-" "curl" -` + "\n"),
+		},
+		"logs/cloudtrail/2024-03-02.json.gz": {modified: "2024-03-02T11:00:00Z", body: compressed.Bytes()},
+		"logs/2024-03-03-00-00-00-B":         {modified: "2024-03-03T01:00:00Z", body: []byte("not a log\n")},
+	})
+
+	submitted, err := c.Activity.IngestAccessLogs()
+	require.NoError(t, err)
+	job := waitForJob(t, c.Jobs, submitted.ID)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, int64(3), job.Progress.Total)
+	assert.Equal(t, int64(1), job.Progress.Failed)
+
+	activity, err := c.Activity.Prefixes("docs", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-03T01:00:00Z", activity.IngestedThrough)
+	assert.Equal(t, []models.PrefixActivity{
+		{
+			Prefix: "archive/", Writes: 1, LastWrite: "2024-03-01",
+			Points: []models.ActivityPoint{{Date: "2024-03-01", Writes: 1}},
+		},
+		{
+			Prefix: "reports/2023/", Reads: 1, Writes: 1, LastRead: "2024-03-02", LastWrite: "2024-03-01",
+			Points: []models.ActivityPoint{{Date: "2024-03-01", Writes: 1}, {Date: "2024-03-02", Reads: 1}},
+		},
+	}, activity.Prefixes, "never-read prefixes come first")
+
+	activity, err = c.Activity.Prefixes("docs", "reports/", "2024-03-02", "")
+	require.NoError(t, err)
+	require.Len(t, activity.Prefixes, 1)
+	assert.Equal(t, int64(0), activity.Prefixes[0].Writes)
+	assert.Equal(t, "2024-03-01", activity.Prefixes[0].LastWrite)
+
+	// Files already ingested are not counted again
+	submitted, err = c.Activity.IngestAccessLogs()
+	require.NoError(t, err)
+	job = waitForJob(t, c.Jobs, submitted.ID)
+	assert.Equal(t, int64(0), job.Progress.Total)
+
+	_, err = c.Activity.Prefixes("docs", "", "March", "")
+	assert.ErrorIs(t, err, ErrInvalidActivityRange)
+
+	c.Config.Activity.LogBucket = ""
+	_, err = c.Activity.IngestAccessLogs()
+	assert.ErrorIs(t, err, ErrAccessLogsNotConfigured)
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"explorer451/internal/models"
+)
+
+var (
+	// ErrAnnotationNotFound is returned for unknown annotation IDs
+	ErrAnnotationNotFound = errors.New("annotation not found")
+	// ErrAnnotationNotAuthor is returned when deleting someone else's annotation
+	ErrAnnotationNotAuthor = errors.New("annotation belongs to another user")
+	// ErrAnnotationTooLong is returned for annotations longer than maxAnnotationLength
+	ErrAnnotationTooLong = errors.New("annotation too long")
+)
+
+const (
+	// maxAnnotationLength caps the characters in one annotation
+	maxAnnotationLength = 4000
+	// maxAnnotationSearchResults caps the annotations returned by a search
+	maxAnnotationSearchResults = 200
+)
+
+// AnnotationService keeps notes that users attach to objects and prefixes. Annotations
+// are shared by all users, unlike bookmarks, and are stored by the explorer rather
+// than as object metadata, so they work for objects the user may not modify.
+type AnnotationService struct {
+	core *Core
+
+	mu sync.Mutex
+	// annotations holds all annotations, oldest first
+	annotations []models.Annotation
+}
+
+// NewAnnotationService creates a new AnnotationService, restoring the annotations
+// saved by the previous run
+func NewAnnotationService(core *Core) *AnnotationService {
+	a := &AnnotationService{core: core}
+
+	if err := core.restoreState("annotations", core.Config.Annotations.StateFile, &a.annotations); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore annotations")
+	}
+	return a
+}
+
+// List returns the annotations of an object or prefix when key is set, or of
+// everything under prefix otherwise, oldest first
+func (a *AnnotationService) List(bucket, key, prefix string) []models.Annotation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	matches := []models.Annotation{}
+	for _, annotation := range a.annotations {
+		if annotation.Bucket != bucket {
+			continue
+		}
+		if key != "" && annotation.Key != key {
+			continue
+		}
+		if !strings.HasPrefix(annotation.Key, prefix) {
+			continue
+		}
+		matches = append(matches, annotation)
+	}
+	return matches
+}
+
+// Search returns the annotations whose text or key contains query, ignoring case,
+// newest first. An empty bucket searches all buckets.
+func (a *AnnotationService) Search(query, bucket string) []models.Annotation {
+	query = strings.ToLower(query)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	matches := []models.Annotation{}
+	for i := len(a.annotations) - 1; i >= 0 && len(matches) < maxAnnotationSearchResults; i-- {
+		annotation := a.annotations[i]
+		if bucket != "" && annotation.Bucket != bucket {
+			continue
+		}
+		if strings.Contains(strings.ToLower(annotation.Text), query) || strings.Contains(strings.ToLower(annotation.Key), query) {
+			matches = append(matches, annotation)
+		}
+	}
+	return matches
+}
+
+// Add attaches a note to an object, or to a prefix when key ends in a slash
+func (a *AnnotationService) Add(user, bucket, key, text string) (models.Annotation, error) {
+	if utf8.RuneCountInString(text) > maxAnnotationLength {
+		return models.Annotation{}, ErrAnnotationTooLong
+	}
+
+	annotation := models.Annotation{
+		ID:        newAnnotationID(),
+		Bucket:    bucket,
+		Key:       strings.TrimPrefix(key, "/"),
+		Text:      text,
+		Author:    user,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.annotations = append(a.annotations, annotation)
+	if err := a.saveLocked(); err != nil {
+		a.annotations = a.annotations[:len(a.annotations)-1]
+		return models.Annotation{}, err
+	}
+	return annotation, nil
+}
+
+// Delete removes an annotation. Only its author may delete it.
+func (a *AnnotationService) Delete(user, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, annotation := range a.annotations {
+		if annotation.ID != id {
+			continue
+		}
+		if annotation.Author != user {
+			return ErrAnnotationNotAuthor
+		}
+
+		existing := a.annotations
+		a.annotations = append(append([]models.Annotation{}, existing[:i]...), existing[i+1:]...)
+		if err := a.saveLocked(); err != nil {
+			a.annotations = existing
+			return err
+		}
+		return nil
+	}
+	return ErrAnnotationNotFound
+}
+
+// All returns every annotation, oldest first
+func (a *AnnotationService) All() []models.Annotation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]models.Annotation{}, a.annotations...)
+}
+
+// Import adds annotations exported from another deployment, skipping IDs that already
+// exist, and returns the number added
+func (a *AnnotationService) Import(annotations []models.Annotation) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	existing := a.annotations
+	for _, annotation := range annotations {
+		if annotation.ID == "" || slices.ContainsFunc(a.annotations, func(e models.Annotation) bool { return e.ID == annotation.ID }) {
+			continue
+		}
+		a.annotations = append(a.annotations, annotation)
+	}
+	added := len(a.annotations) - len(existing)
+	if added > 0 {
+		a.annotations = slices.Clone(a.annotations)
+		slices.SortStableFunc(a.annotations, func(x, y models.Annotation) int { return x.CreatedAt.Compare(y.CreatedAt) })
+	}
+	if err := a.saveLocked(); err != nil {
+		a.annotations = existing
+		return 0, err
+	}
+	return added, nil
+}
+
+// saveLocked persists the annotations
+func (a *AnnotationService) saveLocked() error {
+	return a.core.persistState("annotations", a.core.Config.Annotations.StateFile, a.annotations)
+}
+
+// newAnnotationID generates a random annotation identifier
+func newAnnotationID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAnnotationService(stateFile string) *AnnotationService {
+	return NewAnnotationService(&Core{
+		Config: &config.Config{Annotations: config.AnnotationsConfig{StateFile: stateFile}},
+		Logger: logger.New("error", "json"),
+	})
+}
+
+func TestAnnotationService(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "annotations.json")
+	annotations := newTestAnnotationService(stateFile)
+
+	folder, err := annotations.Add("alice", "data", "reports/2024/", "Owned by Finance")
+	require.NoError(t, err)
+	file, err := annotations.Add("bob", "data", "/reports/2024/q1.csv", "Restated in May")
+	require.NoError(t, err)
+	assert.Equal(t, "reports/2024/q1.csv", file.Key)
+	_, err = annotations.Add("bob", "logs", "finance/", "Raw exports")
+	require.NoError(t, err)
+
+	_, err = annotations.Add("bob", "data", "x", strings.Repeat("a", maxAnnotationLength+1))
+	assert.ErrorIs(t, err, ErrAnnotationTooLong)
+
+	assert.Len(t, annotations.List("data", "", "reports/"), 2)
+	assert.Equal(t, []string{folder.ID}, annotationIDs(annotations.List("data", "reports/2024/", "")))
+
+	// Search covers text and keys across buckets, newest first
+	assert.Len(t, annotations.Search("FINANCE", ""), 2)
+	assert.Equal(t, []string{folder.ID}, annotationIDs(annotations.Search("finance", "data")))
+
+	// Only the author may delete an annotation
+	assert.ErrorIs(t, annotations.Delete("bob", folder.ID), ErrAnnotationNotAuthor)
+	assert.ErrorIs(t, annotations.Delete("bob", "unknown"), ErrAnnotationNotFound)
+	require.NoError(t, annotations.Delete("bob", file.ID))
+
+	// Annotations survive a restart
+	restored := newTestAnnotationService(stateFile)
+	assert.Equal(t, []string{folder.ID}, annotationIDs(restored.List("data", "", "")))
+}
+
+func annotationIDs(annotations []models.Annotation) []string {
+	ids := make([]string, 0, len(annotations))
+	for _, annotation := range annotations {
+		ids = append(ids, annotation.ID)
+	}
+	return ids
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// anonymousBucket reports whether a bucket is configured to be read without credentials
+func (c *Core) anonymousBucket(bucket string) bool {
+	for _, endpoint := range c.Config.AWS.Buckets {
+		if endpoint.Name == bucket {
+			return endpoint.Anonymous
+		}
+	}
+	return false
+}
+
+// unsignedPresignClient presigns requests for a public bucket as plain URLs. The SDK
+// refuses to presign without credentials, so placeholder ones get past it and the
+// presigner leaves the request unsigned.
+func unsignedPresignClient(client *s3.Client) *s3.PresignClient {
+	return s3.NewPresignClient(client, func(o *s3.PresignOptions) {
+		o.Presigner = unsignedPresigner{}
+		o.ClientOptions = append(o.ClientOptions, func(o *s3.Options) {
+			o.Credentials = credentials.NewStaticCredentialsProvider("anonymous", "anonymous", "")
+		})
+	})
+}
+
+// unsignedPresigner returns requests' URLs without signing them
+type unsignedPresigner struct{}
+
+// PresignHTTP returns the request's URL, dropping the presigning parameters the SDK has
+// already added, such as X-Amz-Expires, as they mean nothing without a signature
+func (unsignedPresigner) PresignHTTP(ctx context.Context, credentials aws.Credentials, r *http.Request, payloadHash, service, region string, signingTime time.Time, optFns ...func(*v4.SignerOptions)) (string, http.Header, error) {
+	u := *r.URL
+	query := u.Query()
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), http.Header{}, nil
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// JobTypeScanObject identifies antivirus scan jobs
+	JobTypeScanObject = "scan-object"
+
+	// Object tags recording the outcome of a scan
+	scanStatusTag    = "scan-status"
+	scanSignatureTag = "scan-signature"
+	scanTimeTag      = "scan-time"
+
+	// ScanStatusClean marks an object in which no malware was found
+	ScanStatusClean = "clean"
+	// ScanStatusInfected marks an object in which malware was found
+	ScanStatusInfected = "infected"
+)
+
+var (
+	// ErrAntivirusDisabled is returned when scanning is requested but not configured
+	ErrAntivirusDisabled = errors.New("antivirus scanning is disabled")
+	// ErrObjectInfected is returned when downloading an object tagged as infected is blocked
+	ErrObjectInfected = errors.New("object is flagged as infected")
+)
+
+// AntivirusService scans objects with ClamAV and records the results as object tags
+type AntivirusService struct {
+	core  *Core
+	clamd *ClamAVClient
+}
+
+// NewAntivirusService creates a new AntivirusService
+func NewAntivirusService(core *Core) *AntivirusService {
+	cfg := core.Config.Antivirus
+	return &AntivirusService{
+		core:  core,
+		clamd: NewClamAVClient(cfg.Address, cfg.Timeout),
+	}
+}
+
+// ScanObject scans an object. Objects up to the configured size are scanned right away
+// and the result is returned; larger ones are scanned by a background job, which is
+// returned instead.
+func (a *AntivirusService) ScanObject(ctx context.Context, bucket, key string) (*models.ScanResult, *models.Job, error) {
+	if !a.core.Config.Antivirus.Enabled {
+		return nil, nil, ErrAntivirusDisabled
+	}
+
+	head, err := a.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		a.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for scan")
+		return nil, nil, err
+	}
+
+	if aws.ToInt64(head.ContentLength) <= a.core.Config.Antivirus.SyncMaxBytes {
+		result, err := a.scan(ctx, bucket, key)
+		return result, nil, err
+	}
+
+	params := map[string]string{
+		"bucket": bucket,
+		"key":    key,
+	}
+	job := a.core.Jobs.Submit(JobTypeScanObject, params, func(ctx context.Context, job *Job) error {
+		job.AddTotal(1)
+		result, err := a.scan(ctx, bucket, key)
+		if err != nil {
+			job.ItemFailed(key, err)
+			return err
+		}
+		if result.Status == ScanStatusInfected {
+			job.ItemFailed(key, ErrObjectInfected)
+			return nil
+		}
+		job.ItemSucceeded(key, aws.ToInt64(head.ContentLength))
+		return nil
+	})
+	return nil, &job, nil
+}
+
+// scan streams an object through clamd and tags it with the result
+func (a *AntivirusService) scan(ctx context.Context, bucket, key string) (*models.ScanResult, error) {
+	a.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Scanning object")
+
+	output, err := a.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		a.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object for scan")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	clean, signature, err := a.clamd.Scan(ctx, output.Body)
+	if err != nil {
+		a.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to scan object")
+		return nil, err
+	}
+
+	result := &models.ScanResult{
+		Key:       key,
+		Status:    ScanStatusClean,
+		Signature: signature,
+		ScannedAt: time.Now().UTC(),
+	}
+	if !clean {
+		result.Status = ScanStatusInfected
+		a.core.Logger.Warn().
+			Str("bucket", bucket).
+			Str("key", key).
+			Str("signature", signature).
+			Msg("Infected object detected")
+	}
+
+	if err := a.tag(ctx, bucket, key, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// tag records a scan result on the object, keeping its other tags
+func (a *AntivirusService) tag(ctx context.Context, bucket, key string, result *models.ScanResult) error {
+	existing, err := a.core.Client(bucket).GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+
+	tags := make([]s3Types.Tag, 0, len(existing.TagSet)+3)
+	for _, tag := range existing.TagSet {
+		switch aws.ToString(tag.Key) {
+		case scanStatusTag, scanSignatureTag, scanTimeTag:
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	tags = append(tags,
+		s3Types.Tag{Key: aws.String(scanStatusTag), Value: aws.String(result.Status)},
+		s3Types.Tag{Key: aws.String(scanTimeTag), Value: aws.String(result.ScannedAt.Format(time.RFC3339))},
+	)
+	if result.Signature != "" {
+		tags = append(tags, s3Types.Tag{Key: aws.String(scanSignatureTag), Value: aws.String(result.Signature)})
+	}
+
+	_, err = a.core.Client(bucket).PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &s3Types.Tagging{TagSet: tags},
+	})
+	if err != nil {
+		a.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to tag scanned object")
+	}
+	return err
+}
+
+// CheckDownload returns ErrObjectInfected when blocking is enabled and the object has
+// been tagged as infected
+func (a *AntivirusService) CheckDownload(ctx context.Context, bucket, key string) error {
+	cfg := a.core.Config.Antivirus
+	if !cfg.Enabled || !cfg.BlockInfected {
+		return nil
+	}
+
+	output, err := a.core.Client(bucket).GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range output.TagSet {
+		if aws.ToString(tag.Key) == scanStatusTag && aws.ToString(tag.Value) == ScanStatusInfected {
+			return ErrObjectInfected
+		}
+	}
+	return nil
+}
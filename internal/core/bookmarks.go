@@ -0,0 +1,182 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+var (
+	// ErrBookmarkNotFound is returned for unknown bookmark IDs
+	ErrBookmarkNotFound = errors.New("bookmark not found")
+	// ErrTooManyBookmarks is returned when a user already has maxBookmarks bookmarks
+	ErrTooManyBookmarks = errors.New("too many bookmarks")
+)
+
+// maxBookmarks caps the bookmarks kept per user
+const maxBookmarks = 500
+
+// BookmarkService keeps each user's bookmarked buckets and prefixes
+type BookmarkService struct {
+	core *Core
+
+	mu sync.Mutex
+	// bookmarks maps user names to their bookmarks, oldest first
+	bookmarks map[string][]models.Bookmark
+}
+
+// NewBookmarkService creates a new BookmarkService, restoring the bookmarks saved by
+// the previous run
+func NewBookmarkService(core *Core) *BookmarkService {
+	b := &BookmarkService{
+		core:      core,
+		bookmarks: make(map[string][]models.Bookmark),
+	}
+
+	if err := core.restoreState("bookmarks", core.Config.Bookmarks.StateFile, &b.bookmarks); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore bookmarks")
+	}
+	return b
+}
+
+// List returns a user's bookmarks, oldest first
+func (b *BookmarkService) List(user string) []models.Bookmark {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]models.Bookmark{}, b.bookmarks[user]...)
+}
+
+// Add bookmarks a bucket, or a prefix within it, for a user. Bookmarking a location
+// twice returns the existing bookmark.
+func (b *BookmarkService) Add(user, bucket, prefix, name string) (models.Bookmark, error) {
+	if prefix = strings.TrimPrefix(prefix, "/"); prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if name == "" {
+		name = bucket
+		if prefix != "" {
+			name = path.Base(prefix)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	existing := b.bookmarks[user]
+	for _, bookmark := range existing {
+		if bookmark.Bucket == bucket && bookmark.Prefix == prefix {
+			return bookmark, nil
+		}
+	}
+	if len(existing) >= maxBookmarks {
+		return models.Bookmark{}, ErrTooManyBookmarks
+	}
+
+	bookmark := models.Bookmark{
+		ID:        newBookmarkID(),
+		Bucket:    bucket,
+		Prefix:    prefix,
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+	b.bookmarks[user] = append(existing, bookmark)
+	if err := b.saveLocked(); err != nil {
+		b.bookmarks[user] = existing
+		return models.Bookmark{}, err
+	}
+	return bookmark, nil
+}
+
+// Delete removes one of a user's bookmarks
+func (b *BookmarkService) Delete(user, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	existing := b.bookmarks[user]
+	for i, bookmark := range existing {
+		if bookmark.ID != id {
+			continue
+		}
+
+		kept := append(append([]models.Bookmark{}, existing[:i]...), existing[i+1:]...)
+		if len(kept) == 0 {
+			delete(b.bookmarks, user)
+		} else {
+			b.bookmarks[user] = kept
+		}
+		if err := b.saveLocked(); err != nil {
+			b.bookmarks[user] = existing
+			return err
+		}
+		return nil
+	}
+	return ErrBookmarkNotFound
+}
+
+// All returns every user's bookmarks, keyed by user name
+func (b *BookmarkService) All() map[string][]models.Bookmark {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	all := make(map[string][]models.Bookmark, len(b.bookmarks))
+	for user, bookmarks := range b.bookmarks {
+		all[user] = append([]models.Bookmark{}, bookmarks...)
+	}
+	return all
+}
+
+// Import adds bookmarks exported from another deployment, skipping locations a user
+// has already bookmarked, and returns the number added
+func (b *BookmarkService) Import(bookmarks map[string][]models.Bookmark) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous := make(map[string][]models.Bookmark, len(b.bookmarks))
+	for user, existing := range b.bookmarks {
+		previous[user] = existing
+	}
+
+	added := 0
+	for user, imported := range bookmarks {
+		existing := slices.Clone(b.bookmarks[user])
+		for _, bookmark := range imported {
+			if slices.ContainsFunc(existing, func(e models.Bookmark) bool {
+				return e.Bucket == bookmark.Bucket && e.Prefix == bookmark.Prefix
+			}) || len(existing) >= maxBookmarks {
+				continue
+			}
+			existing = append(existing, bookmark)
+			added++
+		}
+		if len(existing) > 0 {
+			b.bookmarks[user] = existing
+		}
+	}
+	if err := b.saveLocked(); err != nil {
+		b.bookmarks = previous
+		return 0, err
+	}
+	return added, nil
+}
+
+// saveLocked persists the bookmarks
+func (b *BookmarkService) saveLocked() error {
+	return b.core.persistState("bookmarks", b.core.Config.Bookmarks.StateFile, b.bookmarks)
+}
+
+// newBookmarkID generates a random bookmark identifier
+func newBookmarkID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
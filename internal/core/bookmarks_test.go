@@ -0,0 +1,60 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBookmarkService(stateFile string) *BookmarkService {
+	return NewBookmarkService(&Core{
+		Config: &config.Config{Bookmarks: config.BookmarksConfig{StateFile: stateFile}},
+		Logger: logger.New("error", "json"),
+	})
+}
+
+func TestBookmarkService(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "bookmarks.json")
+	bookmarks := newTestBookmarkService(stateFile)
+
+	reports, err := bookmarks.Add("alice", "data", "reports/2024", "")
+	require.NoError(t, err)
+	assert.Equal(t, "reports/2024/", reports.Prefix)
+	assert.Equal(t, "2024", reports.Name)
+
+	root, err := bookmarks.Add("alice", "data", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "data", root.Name)
+
+	// Bookmarking the same location again returns the existing bookmark
+	again, err := bookmarks.Add("alice", "data", "reports/2024/", "Reports")
+	require.NoError(t, err)
+	assert.Equal(t, reports.ID, again.ID)
+
+	_, err = bookmarks.Add("bob", "logs", "app/", "")
+	require.NoError(t, err)
+
+	// Bookmarks are per user and survive a restart
+	restored := newTestBookmarkService(stateFile)
+	assert.Equal(t, []string{reports.ID, root.ID}, bookmarkIDs(restored.List("alice")))
+	assert.Len(t, restored.List("bob"), 1)
+	assert.Empty(t, restored.List("carol"))
+
+	assert.ErrorIs(t, restored.Delete("bob", reports.ID), ErrBookmarkNotFound)
+	require.NoError(t, restored.Delete("alice", reports.ID))
+	assert.Equal(t, []string{root.ID}, bookmarkIDs(restored.List("alice")))
+}
+
+func bookmarkIDs(bookmarks []models.Bookmark) []string {
+	ids := make([]string, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.ID
+	}
+	return ids
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+// bundleVersion is the format version of data bundles written by ExportBundle
+const bundleVersion = 1
+
+// ErrBundleVersion is returned when importing a bundle of an unknown format version
+var ErrBundleVersion = errors.New("unsupported bundle version")
+
+// ExportBundle collects the data users have created in the explorer (bookmarks, share
+// links and annotations) for backup or migration to another deployment
+func (c *Core) ExportBundle() models.DataBundle {
+	return models.DataBundle{
+		Version:     bundleVersion,
+		ExportedAt:  time.Now().UTC(),
+		Bookmarks:   c.Bookmarks.All(),
+		Shares:      c.Shares.List(),
+		Annotations: c.Annotations.All(),
+	}
+}
+
+// ImportBundle merges a bundle written by ExportBundle into this deployment's data.
+// Entries that already exist are kept as they are, so importing the same bundle twice
+// adds nothing the second time.
+func (c *Core) ImportBundle(bundle models.DataBundle) (models.ImportResult, error) {
+	var result models.ImportResult
+	if bundle.Version != bundleVersion {
+		return result, fmt.Errorf("%w %d (expected %d)", ErrBundleVersion, bundle.Version, bundleVersion)
+	}
+
+	var err error
+	if result.Bookmarks, err = c.Bookmarks.Import(bundle.Bookmarks); err != nil {
+		return result, fmt.Errorf("importing bookmarks: %w", err)
+	}
+	if result.Shares, err = c.Shares.Import(bundle.Shares); err != nil {
+		return result, fmt.Errorf("importing shares: %w", err)
+	}
+	if result.Annotations, err = c.Annotations.Import(bundle.Annotations); err != nil {
+		return result, fmt.Errorf("importing annotations: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBundleCore() *Core {
+	c := &Core{
+		Config: &config.Config{Shares: config.SharesConfig{MaxExpiry: 24 * time.Hour}},
+		Logger: logger.New("error", "json"),
+	}
+	c.Bookmarks = NewBookmarkService(c)
+	c.Shares = NewShareService(c)
+	c.Annotations = NewAnnotationService(c)
+	return c
+}
+
+func TestBundle_RoundTrip(t *testing.T) {
+	source := newTestBundleCore()
+	_, err := source.Bookmarks.Add("alice", "data", "reports/", "")
+	require.NoError(t, err)
+	_, err = source.Annotations.Add("bob", "data", "reports/", "Owned by finance")
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	source.Shares.shares["tok"] = &shareRecord{Share: models.Share{Token: "tok", Bucket: "data", Key: "a.txt", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, Accesses: 3}
+
+	bundle := source.ExportBundle()
+	assert.Equal(t, bundleVersion, bundle.Version)
+
+	target := newTestBundleCore()
+	_, err = target.Bookmarks.Add("alice", "data", "reports/", "Reports")
+	require.NoError(t, err)
+
+	result, err := target.ImportBundle(bundle)
+	require.NoError(t, err)
+	// alice already had the bookmark
+	assert.Equal(t, models.ImportResult{Bookmarks: 0, Shares: 1, Annotations: 1}, result)
+
+	share, err := target.Shares.Resolve("tok")
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", share.Key)
+	assert.Len(t, target.Annotations.List("data", "reports/", ""), 1)
+
+	// Importing again adds nothing
+	result, err = target.ImportBundle(bundle)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{}, result)
+
+	bundle.Version = 2
+	_, err = target.ImportBundle(bundle)
+	assert.ErrorIs(t, err, ErrBundleVersion)
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// CheckDependencies verifies that everything the configuration points at is usable:
+// S3 is reachable with the loaded credentials, enabled integrations can reach their
+// daemons and binaries, and configured files and directories can be used. Every
+// problem found is reported rather than just the first.
+func (c *Core) CheckDependencies(ctx context.Context) error {
+	cfg := c.Config
+	var errs []error
+
+	if _, err := c.S3Client.ListBuckets(ctx, &s3.ListBucketsInput{}); err != nil {
+		errs = append(errs, fmt.Errorf("aws: cannot list buckets in %s (check credentials and network access): %w", cfg.AWS.Region, err))
+	}
+	for _, bucket := range cfg.AWS.Buckets {
+		if _, err := c.Client(bucket.Name).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket.Name)}); err != nil {
+			errs = append(errs, fmt.Errorf("aws.buckets: cannot reach bucket %s (check its endpoint and credentials): %w", bucket.Name, err))
+		}
+	}
+
+	if cfg.Antivirus.Enabled {
+		if err := c.Antivirus.clamd.Ping(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("antivirus.address: clamd at %s is not responding: %w", cfg.Antivirus.Address, err))
+		}
+	}
+
+	binaries := map[string]string{}
+	if cfg.Media.FFprobe.Enabled {
+		binaries["media.ffprobe.path"] = cfg.Media.FFprobe.Path
+	}
+	if cfg.PDF.Enabled {
+		binaries["pdf.pdfinfoPath"] = cfg.PDF.PdfinfoPath
+		binaries["pdf.pdftoppmPath"] = cfg.PDF.PdftoppmPath
+	}
+	for setting, path := range binaries {
+		if _, err := exec.LookPath(path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q is not an executable: %w", setting, path, err))
+		}
+	}
+
+	tlsCfg := cfg.Server.TLS
+	if tlsCfg.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("server.tls: cannot load certificate: %w", err))
+		}
+	}
+
+	dirs := map[string]string{}
+	if cfg.Thumbnails.CachePrefix == "" {
+		dirs["thumbnails.cacheDir"] = cfg.Thumbnails.CacheDir
+	}
+	if tlsCfg.Autocert.Enabled {
+		dirs["server.tls.autocert.cacheDir"] = tlsCfg.Autocert.CacheDir
+	}
+	for setting, dir := range dirs {
+		if err := checkWritableDir(dir); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q is not writable: %w", setting, dir, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkWritableDir creates dir if needed and verifies files can be written in it
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, ".check-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClamdReply(t *testing.T) {
+	clean, signature, err := parseClamdReply("stream: OK\x00")
+	assert.NoError(t, err)
+	assert.True(t, clean)
+	assert.Empty(t, signature)
+
+	clean, signature, err = parseClamdReply("stream: Win.Test.EICAR_HDB-1 FOUND\x00")
+	assert.NoError(t, err)
+	assert.False(t, clean)
+	assert.Equal(t, "Win.Test.EICAR_HDB-1", signature)
+
+	_, _, err = parseClamdReply("INSTREAM size limit exceeded. ERROR\x00")
+	assert.Error(t, err)
+}
+
+func TestClamAVClient_Scan(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// A fake clamd that reassembles the INSTREAM chunks and flags a marker string
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		command := make([]byte, len("zINSTREAM\x00"))
+		_, _ = io.ReadFull(conn, command)
+
+		var data bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(conn, binary.BigEndian, &size); err != nil || size == 0 {
+				break
+			}
+			_, _ = io.CopyN(&data, conn, int64(size))
+		}
+		received <- data.Bytes()
+
+		if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+			_, _ = conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+		} else {
+			_, _ = conn.Write([]byte("stream: OK\x00"))
+		}
+	}()
+
+	client := NewClamAVClient(listener.Addr().String(), time.Second)
+	payload := bytes.Repeat([]byte("a"), clamdChunkSize+10)
+	payload = append(payload, "EICAR"...)
+
+	clean, signature, err := client.Scan(context.Background(), bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.False(t, clean)
+	assert.Equal(t, "Eicar-Signature", signature)
+	assert.Equal(t, payload, <-received)
+}
+
+func TestClamAVClient_Ping(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		command := make([]byte, len("zPING\x00"))
+		_, _ = io.ReadFull(conn, command)
+		_, _ = conn.Write([]byte("PONG\x00"))
+	}()
+
+	client := NewClamAVClient(listener.Addr().String(), time.Second)
+	assert.NoError(t, client.Ping(context.Background()))
+}
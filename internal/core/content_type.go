@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// sniffLength is how many leading bytes are read to sniff a content type, as many as
+// http.DetectContentType looks at
+const sniffLength = 512
+
+// genericContentType is the content type of objects whose type is not known
+const genericContentType = "application/octet-stream"
+
+// contentTypes maps extensions to content types. It takes precedence over
+// mime.TypeByExtension, whose answers depend on the host's mime.types files, so the
+// common types come out the same everywhere.
+var contentTypes = map[string]string{
+	".txt":     "text/plain",
+	".log":     "text/plain",
+	".md":      "text/markdown",
+	".csv":     "text/csv",
+	".tsv":     "text/tab-separated-values",
+	".html":    "text/html",
+	".htm":     "text/html",
+	".css":     "text/css",
+	".js":      "application/javascript",
+	".mjs":     "application/javascript",
+	".json":    "application/json",
+	".ndjson":  "application/x-ndjson",
+	".jsonl":   "application/x-ndjson",
+	".xml":     "application/xml",
+	".yaml":    "application/yaml",
+	".yml":     "application/yaml",
+	".jpg":     "image/jpeg",
+	".jpeg":    "image/jpeg",
+	".png":     "image/png",
+	".gif":     "image/gif",
+	".webp":    "image/webp",
+	".avif":    "image/avif",
+	".svg":     "image/svg+xml",
+	".tif":     "image/tiff",
+	".tiff":    "image/tiff",
+	".pdf":     "application/pdf",
+	".zip":     "application/zip",
+	".gz":      "application/gzip",
+	".tar":     "application/x-tar",
+	".parquet": "application/vnd.apache.parquet",
+	".wasm":    "application/wasm",
+	".mp4":     "video/mp4",
+	".m4v":     "video/mp4",
+	".webm":    "video/webm",
+	".mov":     "video/quicktime",
+	".mp3":     "audio/mpeg",
+	".m4a":     "audio/mp4",
+	".wav":     "audio/wav",
+	".ogg":     "audio/ogg",
+	".oga":     "audio/ogg",
+	".flac":    "audio/flac",
+}
+
+// detectContentType detects the content type of a file based on its extension
+func detectContentType(filename string) string {
+	if contentType, ok := extensionContentType(filename); ok {
+		return contentType
+	}
+	return genericContentType
+}
+
+// extensionContentType returns the content type a file's extension implies, without
+// parameters, reporting false for files without a known extension
+func extensionContentType(filename string) (string, bool) {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == "" {
+		return "", false
+	}
+	if contentType, ok := contentTypes[ext]; ok {
+		return contentType, true
+	}
+	mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(ext))
+	if err != nil {
+		return "", false
+	}
+	return mediaType, true
+}
+
+// isGenericContentType reports whether a stored content type says nothing about the
+// content, as with objects uploaded without one
+func isGenericContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "" || mediaType == genericContentType || mediaType == "binary/octet-stream"
+}
+
+// objectContentType returns the content type to show for an object: the stored one
+// unless it is missing or generic, otherwise the one the key's extension implies and,
+// for keys without a known extension and with listing.sniffContentTypes on, the one
+// the object's first bytes suggest. Listings, which have no stored type, and metadata
+// thereby agree for objects stored without a type.
+func (s *S3Service) objectContentType(ctx context.Context, bucket, key, stored string, size int64) string {
+	if !isGenericContentType(stored) {
+		return stored
+	}
+	if contentType, ok := extensionContentType(key); ok {
+		return contentType
+	}
+	if s.core.Config.Listing.SniffContentTypes && size > 0 {
+		return s.sniffContentType(ctx, bucket, key)
+	}
+	return genericContentType
+}
+
+// sniffContentType reads the first bytes of an object with a ranged GET and tells its
+// content type from them. Objects that cannot be read are reported as generic.
+func (s *S3Service) sniffContentType(ctx context.Context, bucket, key string) string {
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", sniffLength-1)),
+	})
+	if err != nil {
+		s.core.Logger.Debug().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to read object to sniff its content type")
+		return genericContentType
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(output.Body, sniffLength))
+	if err != nil {
+		return genericContentType
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+// sniffListedContentTypes sniffs the content types of listed objects without a known
+// extension, listing.concurrency at a time, when listing.sniffContentTypes is on
+func (s *S3Service) sniffListedContentTypes(ctx context.Context, bucket string, objects []models.ObjectInfo) {
+	if !s.core.Config.Listing.SniffContentTypes {
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.core.Config.Listing.Concurrency)
+	for i, object := range objects {
+		if object.IsFolder || object.Size == 0 || object.ContentType != genericContentType {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			objects[i].ContentType = s.sniffContentType(ctx, bucket, object.Key)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContentType(t *testing.T) {
+	assert.Equal(t, "text/plain", detectContentType("notes.TXT"))
+	assert.Equal(t, "application/yaml", detectContentType("config/app.yml"))
+	assert.Equal(t, "image/svg+xml", detectContentType("logo.svg"))
+	assert.Equal(t, "text/csv", detectContentType("data/export.csv"))
+	assert.Equal(t, "application/octet-stream", detectContentType("README"))
+	assert.Equal(t, "application/octet-stream", detectContentType("archive.unknownext"))
+	assert.Equal(t, "application/octet-stream", detectContentType("folder.d/README"))
+}
+
+func TestIsGenericContentType(t *testing.T) {
+	assert.True(t, isGenericContentType(""))
+	assert.True(t, isGenericContentType("binary/octet-stream"))
+	assert.True(t, isGenericContentType("application/octet-stream"))
+	assert.False(t, isGenericContentType("text/plain; charset=utf-8"))
+}
+
+// newTestSniffCore fakes a bucket "docs" with a PNG image and a text file stored
+// without extensions or content types
+func newTestSniffCore(t *testing.T) *Core {
+	objects := map[string]string{
+		"image": "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16),
+		"notes": "plain text notes",
+	}
+	cfg := &config.Config{Listing: config.ListingConfig{
+		DefaultPageSize: 100, MaxPageSize: 1000, Concurrency: 2, SniffContentTypes: true,
+	}}
+	return newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/docs/")
+		switch {
+		case r.URL.Query().Get("list-type") == "2":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`+
+				`<Contents><Key>image</Key><Size>24</Size></Contents><Contents><Key>notes</Key><Size>16</Size></Contents>`+
+				`<Contents><Key>empty</Key><Size>0</Size></Contents><Contents><Key>a.csv</Key><Size>9</Size></Contents></ListBucketResult>`)
+		case r.URL.Query().Has("tagging"):
+			fmt.Fprint(w, `<Tagging><TagSet></TagSet></Tagging>`)
+		case r.URL.Query().Has("acl"):
+			fmt.Fprint(w, `<AccessControlPolicy><AccessControlList></AccessControlList></AccessControlPolicy>`)
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Type", "binary/octet-stream")
+			w.Header().Set("Content-Length", fmt.Sprint(len(objects[key])))
+		default:
+			assert.Equal(t, "bytes=0-511", r.Header.Get("Range"))
+			w.Header().Set("Content-Length", fmt.Sprint(len(objects[key])))
+			fmt.Fprint(w, objects[key])
+		}
+	})
+}
+
+func TestSniffContentTypes(t *testing.T) {
+	c := newTestSniffCore(t)
+	ctx := context.Background()
+
+	listing, err := c.S3Service.ListObjects(ctx, "docs", "", "", "", 0)
+	require.NoError(t, err)
+	types := make(map[string]string)
+	for _, object := range listing.Objects {
+		types[object.Key] = object.ContentType
+	}
+	assert.Equal(t, map[string]string{
+		"image": "image/png",
+		"notes": "text/plain",
+		"empty": "application/octet-stream",
+		"a.csv": "text/csv",
+	}, types)
+
+	// Metadata agrees with the listing for objects stored without a type
+	metadata, err := c.S3Service.GetObjectMetadata(ctx, "docs", "image", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", metadata.ContentType)
+
+	c.Config.Listing.SniffContentTypes = false
+	metadata, err = c.S3Service.GetObjectMetadata(ctx, "docs", "image", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "application/octet-stream", metadata.ContentType)
+}
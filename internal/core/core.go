@@ -1,9 +1,14 @@
 package core
 
 import (
+	"sync"
+
 	"explorer451/internal/config"
 	"explorer451/internal/logger"
+	"explorer451/internal/store"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
@@ -14,6 +19,37 @@ type Core struct {
 	S3Client    *s3.Client
 	S3Presigner *s3.PresignClient
 	S3Service   *S3Service
+	Thumbnails  *ThumbnailService
+	Events      *EventFeed
+	Jobs        *JobManager
+	Antivirus   *AntivirusService
+	Shares      *ShareService
+	Bookmarks   *BookmarkService
+	Recent      *RecentService
+	Annotations *AnnotationService
+	Access      *AccessService
+	Workspaces  *WorkspaceService
+	Usage       *UsageService
+	Activity    *ActivityService
+	Selections  *SelectionService
+	Pricing     *PricingService
+	// Build identifies the running binary; set by main
+	Build BuildInfo
+	// BucketClients holds clients for buckets configured with their own endpoint,
+	// region or credentials in aws.buckets; set by main
+	BucketClients map[string]*s3.Client
+	// RegionClient returns the client for a bucket S3 redirected to another region than
+	// the default client's; set by main
+	RegionClient func(bucket string) (*s3.Client, bool)
+	// KMSClient lists the KMS keys offered for encrypting uploads; set by main
+	KMSClient *kms.Client
+	// Store keeps the explorer's own data; nil keeps it in memory only
+	Store store.Store
+
+	// statesMu guards states, the versions of the documents read from and written to
+	// Store
+	statesMu sync.Mutex
+	states   map[string]*storedState
 }
 
 // NewCore creates a new Core instance with all dependencies
@@ -22,16 +58,85 @@ func NewCore(
 	logger *logger.Logger,
 	s3Client *s3.Client,
 	s3Presigner *s3.PresignClient,
+	st store.Store,
 ) *Core {
 	core := &Core{
 		Config:      cfg,
 		Logger:      logger,
 		S3Client:    s3Client,
 		S3Presigner: s3Presigner,
+		Events:      NewEventFeed(defaultEventFeedCapacity),
+		Store:       st,
 	}
 
 	// Initialize services
 	core.S3Service = NewS3Service(core)
+	core.Thumbnails = NewThumbnailService(core)
+	core.Jobs = NewJobManager(core)
+	core.Antivirus = NewAntivirusService(core)
+	core.Shares = NewShareService(core)
+	core.Bookmarks = NewBookmarkService(core)
+	core.Recent = NewRecentService(core)
+	core.Annotations = NewAnnotationService(core)
+	core.Access = NewAccessService(core)
+	core.Workspaces = NewWorkspaceService(core)
+	core.Usage = NewUsageService(core)
+	core.Activity = NewActivityService(core)
+	core.Selections = NewSelectionService(core)
+	core.Pricing = NewPricingService(core)
 
 	return core
 }
+
+// Client returns the S3 client for a bucket: its own when the bucket is configured in
+// aws.buckets, one for its region when S3 redirected it there, otherwise the default
+// client
+func (c *Core) Client(bucket string) *s3.Client {
+	if client, ok := c.BucketClients[bucket]; ok {
+		return client
+	}
+	if c.RegionClient != nil {
+		if client, ok := c.RegionClient(bucket); ok {
+			return client
+		}
+	}
+	return c.S3Client
+}
+
+// sameEndpoint reports whether two buckets are reached through the same endpoint and
+// credentials, so that S3 can copy between them server-side. Clients for buckets S3
+// redirected to another region do not count as another endpoint.
+func (c *Core) sameEndpoint(a, b string) bool {
+	clientA, configuredA := c.BucketClients[a]
+	clientB, configuredB := c.BucketClients[b]
+	return configuredA == configuredB && clientA == clientB
+}
+
+// objectLambdaRegion returns the region of the Object Lambda access point a bucket name
+// is configured for, and whether it is one
+func (c *Core) objectLambdaRegion(bucket string) (string, bool) {
+	for _, endpoint := range c.Config.AWS.Buckets {
+		if endpoint.Name == bucket && endpoint.ObjectLambdaARN != "" {
+			accessPoint, err := arn.Parse(endpoint.ObjectLambdaARN)
+			return accessPoint.Region, err == nil
+		}
+	}
+	return "", false
+}
+
+// Presigner returns the presign client for a bucket, following the same rules as Client
+func (c *Core) Presigner(bucket string) *s3.PresignClient {
+	if client, ok := c.BucketClients[bucket]; ok {
+		// Clients for anonymous buckets have their credentials cleared
+		if client.Options().Credentials == nil {
+			return unsignedPresignClient(client)
+		}
+		return s3.NewPresignClient(client)
+	}
+	if c.RegionClient != nil {
+		if client, ok := c.RegionClient(bucket); ok {
+			return s3.NewPresignClient(client)
+		}
+	}
+	return c.S3Presigner
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_BucketOverrides(t *testing.T) {
+	defaultClient := s3.New(s3.Options{Region: "us-east-1"})
+	legacyClient := s3.New(s3.Options{Region: "eu-west-1", BaseEndpoint: aws.String("https://minio.internal:9000")})
+	c := &Core{
+		S3Client:      defaultClient,
+		S3Presigner:   s3.NewPresignClient(defaultClient),
+		BucketClients: map[string]*s3.Client{"legacy-data": legacyClient},
+	}
+
+	assert.Same(t, legacyClient, c.Client("legacy-data"))
+	assert.Same(t, defaultClient, c.Client("photos"))
+	assert.Same(t, c.S3Presigner, c.Presigner("photos"))
+	assert.NotSame(t, c.S3Presigner, c.Presigner("legacy-data"))
+}
+
+func TestDescribeBucket(t *testing.T) {
+	c := &Core{Config: &config.Config{Buckets: []config.BucketInfo{
+		{Name: "nb-fin-prod-7f3a", DisplayName: "Finance", Group: "Finance", Tags: []string{"prod"}},
+		{Name: "nb-fin-*", Group: "Finance"},
+		{Name: "*-logs", Group: "Logs", Description: "Access logs"},
+	}}}
+	s := NewS3Service(c)
+
+	bucket := models.Bucket{Name: "nb-fin-prod-7f3a"}
+	s.describeBucket(&bucket)
+	assert.Equal(t, models.Bucket{Name: "nb-fin-prod-7f3a", DisplayName: "Finance", Group: "Finance", Tags: []string{"prod"}}, bucket)
+
+	// The first matching entry wins
+	bucket = models.Bucket{Name: "nb-fin-logs"}
+	s.describeBucket(&bucket)
+	assert.Equal(t, "Finance", bucket.Group)
+	assert.Empty(t, bucket.Description)
+
+	bucket = models.Bucket{Name: "cdn-logs"}
+	s.describeBucket(&bucket)
+	assert.Equal(t, "Access logs", bucket.Description)
+
+	bucket = models.Bucket{Name: "scratch"}
+	s.describeBucket(&bucket)
+	assert.Equal(t, models.Bucket{Name: "scratch"}, bucket)
+}
+
+func TestGetBucketDetails_ObjectLambda(t *testing.T) {
+	c := &Core{Config: &config.Config{AWS: config.AWSConfig{Buckets: []config.BucketEndpoint{
+		{Name: "legacy-data", Endpoint: "https://minio.internal:9000"},
+		{Name: "redacted", ObjectLambdaARN: "arn:aws:s3-object-lambda:eu-west-1:123456789012:accesspoint/redact"},
+	}}}, Logger: logger.New("error", "json")}
+	s := NewS3Service(c)
+
+	details, err := s.GetBucketDetails(context.Background(), "redacted")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BucketDetail{Name: "redacted", Region: "eu-west-1", ObjectLambda: true}, details)
+
+	_, ok := c.objectLambdaRegion("legacy-data")
+	assert.False(t, ok)
+}
+
+func TestAnonymousBucket(t *testing.T) {
+	public := s3.New(s3.Options{Region: "us-west-2", Credentials: aws.AnonymousCredentials{}})
+	c := &Core{
+		Config: &config.Config{AWS: config.AWSConfig{Buckets: []config.BucketEndpoint{
+			{Name: "open-data", Region: "us-west-2", Anonymous: true},
+		}}},
+		Logger:        logger.New("error", "json"),
+		BucketClients: map[string]*s3.Client{"open-data": public},
+	}
+	s := NewS3Service(c)
+
+	details, err := s.GetBucketDetails(context.Background(), "open-data")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BucketDetail{Name: "open-data", Region: "us-west-2", Anonymous: true}, details)
+
+	presigned, err := c.Presigner("open-data").PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String("open-data"),
+		Key:    aws.String("2024/data.csv"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://open-data.s3.us-west-2.amazonaws.com/2024/data.csv?x-id=GetObject", presigned.URL)
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorPayload is the data carried inside an opaque pagination cursor
+type cursorPayload struct {
+	Token string `json:"t"`
+}
+
+// EncodeCursor wraps a backend-specific continuation token into an opaque cursor.
+// An empty token yields an empty cursor, meaning there are no more pages.
+func EncodeCursor(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	data, _ := json.Marshal(cursorPayload{Token: token})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor unwraps a cursor produced by EncodeCursor into its continuation token
+func DecodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.Token == "" {
+		return "", ErrInvalidCursor
+	}
+
+	return payload.Token, nil
+}
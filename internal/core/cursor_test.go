@@ -0,0 +1,33 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	token := "1ueGcxLPRx1Tr/XYExHnhbYLgveDs2J/wm36Hy4vbOwM="
+
+	cursor := EncodeCursor(token)
+	assert.NotEmpty(t, cursor)
+	assert.NotContains(t, cursor, token)
+
+	decoded, err := DecodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, token, decoded)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	decoded, err := DecodeCursor("")
+	assert.NoError(t, err)
+	assert.Empty(t, decoded)
+
+	assert.Empty(t, EncodeCursor(""))
+
+	_, err = DecodeCursor("not a cursor!")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = DecodeCursor("e30") // base64 of "{}"
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
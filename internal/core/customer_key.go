@@ -0,0 +1,68 @@
+package core
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+var (
+	// ErrInvalidCustomerKey is returned for SSE-C keys that are not base64-encoded
+	// 256-bit keys
+	ErrInvalidCustomerKey = errors.New("customer key must be a base64-encoded 256-bit key")
+	// ErrConflictingEncryption is returned when a write asks for both a KMS key and a
+	// customer key
+	ErrConflictingEncryption = errors.New("a KMS key and a customer key cannot be combined")
+)
+
+// customerKeyAlgorithm is the only algorithm S3 supports for SSE-C
+const customerKeyAlgorithm = "AES256"
+
+// CustomerKey is an SSE-C key supplied with a single request. It is passed on to S3 and
+// never stored or logged; S3 keeps only a salted hash to check later requests against.
+// A nil key means the object is not encrypted with a customer key.
+type CustomerKey struct {
+	encoded string
+	md5     string
+}
+
+// ParseCustomerKey parses a base64-encoded 256-bit SSE-C key. An empty string gives a
+// nil key.
+func ParseCustomerKey(encoded string) (*CustomerKey, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidCustomerKey
+	}
+	sum := md5.Sum(key)
+	return &CustomerKey{
+		encoded: encoded,
+		md5:     base64.StdEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// params returns the algorithm, key and key MD5 request parameters, all nil for a nil
+// key
+func (k *CustomerKey) params() (algorithm, key, keyMD5 *string) {
+	if k == nil {
+		return nil, nil, nil
+	}
+	return aws.String(customerKeyAlgorithm), aws.String(k.encoded), aws.String(k.md5)
+}
+
+// fields returns the presigned POST form fields for encrypting an upload with the key
+func (k *CustomerKey) fields() map[string]string {
+	if k == nil {
+		return nil
+	}
+	return map[string]string{
+		"x-amz-server-side-encryption-customer-algorithm": customerKeyAlgorithm,
+		"x-amz-server-side-encryption-customer-key":       k.encoded,
+		"x-amz-server-side-encryption-customer-key-MD5":   k.md5,
+	}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCustomerKey(t *testing.T) {
+	key, err := ParseCustomerKey("")
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	_, err = ParseCustomerKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+	assert.ErrorIs(t, err, ErrInvalidCustomerKey)
+	_, err = ParseCustomerKey("not base64!")
+	assert.ErrorIs(t, err, ErrInvalidCustomerKey)
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	key, err = ParseCustomerKey(encoded)
+	require.NoError(t, err)
+	algorithm, value, md5 := key.params()
+	assert.Equal(t, "AES256", *algorithm)
+	assert.Equal(t, encoded, *value)
+	assert.Equal(t, "mT2HRsMGJ5IX5C+0rreZ8Q==", *md5)
+}
+
+func TestGeneratePresignedPostURL_CustomerKey(t *testing.T) {
+	c := newTestUploadCore()
+	key, err := ParseCustomerKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	require.NoError(t, err)
+
+	resp, err := c.S3Service.GeneratePresignedPostURL(context.Background(), "docs", "a.txt", "text/plain", 0, 0, "", key, "")
+	require.NoError(t, err)
+	assert.Equal(t, "AES256", resp.Fields["x-amz-server-side-encryption-customer-algorithm"])
+	assert.Equal(t, "mT2HRsMGJ5IX5C+0rreZ8Q==", resp.Fields["x-amz-server-side-encryption-customer-key-MD5"])
+
+	_, err = c.S3Service.GeneratePresignedPostURL(context.Background(), "docs", "a.txt", "text/plain", 0, 0, "alias/uploads", key, "")
+	assert.ErrorIs(t, err, ErrConflictingEncryption)
+}
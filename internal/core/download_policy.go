@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrProxyDownloadsDisabled is returned when an object may only be downloaded through
+// the proxy but proxy downloads are turned off
+var ErrProxyDownloadsDisabled = errors.New("proxy downloads are disabled")
+
+const (
+	// DownloadModePresigned means the client downloads straight from S3 with a presigned URL
+	DownloadModePresigned = "presigned"
+	// DownloadModeProxy means the client downloads through the explorer's own endpoint
+	DownloadModeProxy = "proxy"
+)
+
+// GetDownloadLink decides how an object should be downloaded. Content types listed in
+// download.proxyContentTypes (HTML and SVG by default) are served through the proxy,
+// so scriptable content is never rendered from the bucket's domain; everything else
+// gets a presigned URL. With download.proxyAll every object is proxied, for clients
+// that cannot reach the S3 endpoint. In proxy mode the returned URL is empty and is filled in by
+// the API layer, which owns its routes. A versionID selects a specific version.
+func (s *S3Service) GetDownloadLink(ctx context.Context, bucket, key, versionID string, expiresIn int64) (*models.DownloadLink, error) {
+	if s.core.Config.Download.ProxyAll {
+		return s.proxyDownloadLink(ctx, bucket, key)
+	}
+
+	patterns := s.core.Config.Download.ProxyContentTypes
+	if len(patterns) > 0 {
+		input := &s3.HeadObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		}
+		if versionID != "" {
+			input.VersionId = aws.String(versionID)
+		}
+		head, err := s.core.Client(bucket).HeadObject(ctx, input)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", key).
+				Msg("Failed to get object metadata for download policy")
+			return nil, err
+		}
+
+		// Judge by both the stored type and the extension, since either may be what a browser uses
+		mediaType, _, _ := mime.ParseMediaType(aws.ToString(head.ContentType))
+		if matchesContentType(patterns, mediaType) || matchesContentType(patterns, detectContentType(key)) {
+			return s.proxyDownloadLink(ctx, bucket, key)
+		}
+	}
+
+	url, err := s.GetPresignedURL(ctx, bucket, key, versionID, expiresIn)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DownloadLink{URL: url, Mode: DownloadModePresigned}, nil
+}
+
+// proxyDownloadLink returns a proxy mode link, refusing objects that cannot be downloaded
+// through the explorer
+func (s *S3Service) proxyDownloadLink(ctx context.Context, bucket, key string) (*models.DownloadLink, error) {
+	if !s.core.Config.Features.ProxyDownloads {
+		return nil, ErrProxyDownloadsDisabled
+	}
+	if err := s.core.Antivirus.CheckDownload(ctx, bucket, key); err != nil {
+		return nil, err
+	}
+	return &models.DownloadLink{Mode: DownloadModeProxy}, nil
+}
+
+// matchesContentType reports whether a media type matches any pattern, where a pattern
+// is either an exact type or a wildcard such as "text/*"
+func matchesContentType(patterns []string, mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	if mediaType == "" {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
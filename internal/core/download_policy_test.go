@@ -0,0 +1,33 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDownloadLinkProxyAll(t *testing.T) {
+	c := &Core{
+		Config: &config.Config{
+			Download: config.DownloadConfig{ProxyAll: true},
+			Features: config.FeaturesConfig{ProxyDownloads: true},
+		},
+		Logger: logger.New("error", "json"),
+	}
+	c.S3Service = NewS3Service(c)
+	c.Antivirus = NewAntivirusService(c)
+
+	// No S3 requests are made, as there is no client
+	link, err := c.S3Service.GetDownloadLink(context.Background(), "docs", "report.pdf", "", 900)
+	require.NoError(t, err)
+	assert.Equal(t, DownloadModeProxy, link.Mode)
+
+	c.Config.Features.ProxyDownloads = false
+	_, err = c.S3Service.GetDownloadLink(context.Background(), "docs", "report.pdf", "", 900)
+	assert.ErrorIs(t, err, ErrProxyDownloadsDisabled)
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+const (
+	// EventObjectCreated is published when an object is written through the explorer
+	EventObjectCreated = "ObjectCreated"
+	// EventObjectRemoved is published when an object is deleted through the explorer
+	EventObjectRemoved = "ObjectRemoved"
+
+	// defaultEventFeedCapacity is the number of events retained for long-polling clients
+	defaultEventFeedCapacity = 10000
+)
+
+// EventFeed keeps a bounded, ordered history of object changes and wakes up
+// long-polling clients when new events arrive
+type EventFeed struct {
+	mu       sync.Mutex
+	events   []models.ChangeEvent
+	capacity int
+	sequence int64
+	notify   chan struct{}
+}
+
+// NewEventFeed creates a new EventFeed retaining at most capacity events
+func NewEventFeed(capacity int) *EventFeed {
+	if capacity <= 0 {
+		capacity = defaultEventFeedCapacity
+	}
+
+	return &EventFeed{
+		events:   make([]models.ChangeEvent, 0, capacity),
+		capacity: capacity,
+		notify:   make(chan struct{}),
+	}
+}
+
+// Publish records a change event and wakes up any waiting clients
+func (f *EventFeed) Publish(eventType, bucket, key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sequence++
+	if len(f.events) == f.capacity {
+		copy(f.events, f.events[1:])
+		f.events = f.events[:len(f.events)-1]
+	}
+	f.events = append(f.events, models.ChangeEvent{
+		Sequence: f.sequence,
+		Type:     eventType,
+		Bucket:   bucket,
+		Key:      key,
+		Time:     time.Now().UTC(),
+	})
+
+	close(f.notify)
+	f.notify = make(chan struct{})
+}
+
+// Since returns the events for a bucket published after the given sequence number,
+// the latest sequence number, and whether events after since have already been dropped
+func (f *EventFeed) Since(bucket string, since int64) ([]models.ChangeEvent, int64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.since(bucket, since)
+}
+
+func (f *EventFeed) since(bucket string, since int64) ([]models.ChangeEvent, int64, bool) {
+	events := make([]models.ChangeEvent, 0)
+	resync := len(f.events) > 0 && f.events[0].Sequence > since+1
+
+	for _, event := range f.events {
+		if event.Sequence > since && event.Bucket == bucket {
+			events = append(events, event)
+		}
+	}
+
+	return events, f.sequence, resync
+}
+
+// ForObject returns the retained events for one object, oldest first
+func (f *EventFeed) ForObject(bucket, key string) []models.ChangeEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var events []models.ChangeEvent
+	for _, event := range f.events {
+		if event.Bucket == bucket && event.Key == key {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Wait blocks until events for the bucket are published after since, the timeout
+// elapses, or the context is cancelled. It returns the same values as Since.
+func (f *EventFeed) Wait(ctx context.Context, bucket string, since int64, timeout time.Duration) ([]models.ChangeEvent, int64, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		f.mu.Lock()
+		events, latest, resync := f.since(bucket, since)
+		notify := f.notify
+		f.mu.Unlock()
+
+		if len(events) > 0 || resync {
+			return events, latest, resync
+		}
+
+		select {
+		case <-notify:
+			// Something was published, possibly for another bucket; check again
+		case <-timer.C:
+			return events, latest, false
+		case <-ctx.Done():
+			return events, latest, false
+		}
+	}
+}
+
+// EncodeSequenceCursor converts a feed sequence number into an opaque cursor
+func EncodeSequenceCursor(sequence int64) string {
+	return EncodeCursor(strconv.FormatInt(sequence, 10))
+}
+
+// DecodeSequenceCursor converts a cursor produced by EncodeSequenceCursor back into a sequence number
+func DecodeSequenceCursor(cursor string) (int64, error) {
+	token, err := DecodeCursor(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	sequence, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return sequence, nil
+}
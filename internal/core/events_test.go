@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFeed_Since(t *testing.T) {
+	feed := NewEventFeed(10)
+	feed.Publish(EventObjectCreated, "bucket-a", "a.txt")
+	feed.Publish(EventObjectCreated, "bucket-b", "b.txt")
+	feed.Publish(EventObjectRemoved, "bucket-a", "a.txt")
+
+	events, latest, resync := feed.Since("bucket-a", 1)
+	assert.Equal(t, int64(3), latest)
+	assert.False(t, resync)
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventObjectRemoved, events[0].Type)
+	assert.Equal(t, int64(3), events[0].Sequence)
+}
+
+func TestEventFeed_Resync(t *testing.T) {
+	feed := NewEventFeed(2)
+	feed.Publish(EventObjectCreated, "bucket", "1")
+	feed.Publish(EventObjectCreated, "bucket", "2")
+	feed.Publish(EventObjectCreated, "bucket", "3")
+
+	events, _, resync := feed.Since("bucket", 0)
+	assert.True(t, resync, "event 1 was dropped and the client must re-list")
+	assert.Len(t, events, 2)
+
+	_, _, resync = feed.Since("bucket", 1)
+	assert.False(t, resync)
+}
+
+func TestEventFeed_Wait(t *testing.T) {
+	feed := NewEventFeed(10)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		feed.Publish(EventObjectCreated, "other", "x")
+		feed.Publish(EventObjectCreated, "bucket", "y")
+	}()
+
+	events, latest, _ := feed.Wait(context.Background(), "bucket", 0, time.Second)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "y", events[0].Key)
+	assert.Equal(t, int64(2), latest)
+
+	events, _, _ = feed.Wait(context.Background(), "bucket", latest, 10*time.Millisecond)
+	assert.Empty(t, events)
+}
+
+func TestSequenceCursor(t *testing.T) {
+	seq, err := DecodeSequenceCursor(EncodeSequenceCursor(42))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), seq)
+
+	_, err = DecodeSequenceCursor(EncodeCursor("not-a-number"))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
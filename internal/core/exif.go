@@ -0,0 +1,243 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+// EXIF tags read by parseExif
+const (
+	exifTagMake             = 0x010f
+	exifTagModel            = 0x0110
+	exifTagOrientation      = 0x0112
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagGPSIFD           = 0x8825
+	exifTagDateTimeOriginal = 0x9003
+
+	gpsTagLatitudeRef  = 0x0001
+	gpsTagLatitude     = 0x0002
+	gpsTagLongitudeRef = 0x0003
+	gpsTagLongitude    = 0x0004
+
+	// exifDateLayout is the timestamp format used by EXIF, which carries no time zone
+	exifDateLayout = "2006:01:02 15:04:05"
+)
+
+// errNoExif is returned when an image does not carry EXIF data
+var errNoExif = errors.New("no exif data")
+
+// exifTypeSizes holds the size in bytes of each TIFF field type
+var exifTypeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// exifData holds the EXIF fields surfaced in image metadata
+type exifData struct {
+	make        string
+	model       string
+	takenAt     *time.Time
+	orientation int
+	gps         *models.GPSCoordinates
+}
+
+// exifEntry is a single IFD entry
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// tiffReader reads IFDs from a TIFF structure with bounds checking
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// findJPEGExif returns the TIFF payload of a JPEG's EXIF APP1 segment
+func findJPEGExif(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, errNoExif
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xff {
+			return nil, errNoExif
+		}
+		marker := data[pos+1]
+		// Start of scan or end of image: no more metadata segments follow
+		if marker == 0xda || marker == 0xd9 {
+			return nil, errNoExif
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errNoExif
+		}
+
+		segment := data[pos+4 : end]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+		pos = end
+	}
+
+	return nil, errNoExif
+}
+
+// parseExif extracts the fields of interest from a TIFF-formatted EXIF payload
+func parseExif(tiff []byte) (*exifData, error) {
+	if len(tiff) < 8 {
+		return nil, errNoExif
+	}
+
+	r := &tiffReader{data: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, errNoExif
+	}
+	if r.order.Uint16(tiff[2:]) != 42 {
+		return nil, errNoExif
+	}
+
+	ifd0, err := r.readIFD(r.order.Uint32(tiff[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &exifData{
+		make:        r.ascii(ifd0[exifTagMake]),
+		model:       r.ascii(ifd0[exifTagModel]),
+		orientation: int(r.uint(ifd0[exifTagOrientation])),
+	}
+
+	dateTime := r.ascii(ifd0[exifTagDateTime])
+	if entry, ok := ifd0[exifTagExifIFD]; ok {
+		if exifIFD, err := r.readIFD(r.uint(entry)); err == nil {
+			if original := r.ascii(exifIFD[exifTagDateTimeOriginal]); original != "" {
+				dateTime = original
+			}
+		}
+	}
+	if t, err := time.Parse(exifDateLayout, dateTime); err == nil {
+		result.takenAt = &t
+	}
+
+	if entry, ok := ifd0[exifTagGPSIFD]; ok {
+		if gpsIFD, err := r.readIFD(r.uint(entry)); err == nil {
+			result.gps = r.gps(gpsIFD)
+		}
+	}
+
+	return result, nil
+}
+
+// readIFD reads the entries of the IFD at offset
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]exifEntry, error) {
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return nil, errNoExif
+	}
+
+	count := uint32(r.order.Uint16(r.data[offset:]))
+	start := offset + 2
+	if uint64(start)+uint64(count)*12 > uint64(len(r.data)) {
+		return nil, errNoExif
+	}
+
+	entries := make(map[uint16]exifEntry, count)
+	for i := uint32(0); i < count; i++ {
+		raw := r.data[start+i*12 : start+i*12+12]
+		typ := r.order.Uint16(raw[2:])
+		size, ok := exifTypeSizes[typ]
+		if !ok {
+			continue
+		}
+
+		entryCount := r.order.Uint32(raw[4:])
+		total := uint64(size) * uint64(entryCount)
+		value := raw[8:12]
+		if total > 4 {
+			valueOffset := uint64(r.order.Uint32(raw[8:]))
+			if valueOffset+total > uint64(len(r.data)) {
+				continue
+			}
+			value = r.data[valueOffset : valueOffset+total]
+		} else {
+			value = value[:total]
+		}
+
+		entries[r.order.Uint16(raw)] = exifEntry{typ: typ, count: entryCount, value: value}
+	}
+
+	return entries, nil
+}
+
+// ascii decodes an ASCII entry, trimming padding and the NUL terminator
+func (r *tiffReader) ascii(entry exifEntry) string {
+	if entry.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(entry.value), "\x00"))
+}
+
+// uint decodes a SHORT or LONG entry
+func (r *tiffReader) uint(entry exifEntry) uint32 {
+	switch {
+	case entry.typ == 3 && len(entry.value) >= 2:
+		return uint32(r.order.Uint16(entry.value))
+	case entry.typ == 4 && len(entry.value) >= 4:
+		return r.order.Uint32(entry.value)
+	default:
+		return 0
+	}
+}
+
+// rationals decodes an unsigned RATIONAL entry
+func (r *tiffReader) rationals(entry exifEntry) []float64 {
+	if entry.typ != 5 {
+		return nil
+	}
+
+	values := make([]float64, 0, entry.count)
+	for i := 0; i+8 <= len(entry.value); i += 8 {
+		numerator := r.order.Uint32(entry.value[i:])
+		denominator := r.order.Uint32(entry.value[i+4:])
+		if denominator == 0 {
+			return nil
+		}
+		values = append(values, float64(numerator)/float64(denominator))
+	}
+	return values
+}
+
+// gps converts the degrees/minutes/seconds GPS tags into decimal coordinates
+func (r *tiffReader) gps(ifd map[uint16]exifEntry) *models.GPSCoordinates {
+	latitude := r.rationals(ifd[gpsTagLatitude])
+	longitude := r.rationals(ifd[gpsTagLongitude])
+	if len(latitude) != 3 || len(longitude) != 3 {
+		return nil
+	}
+
+	coords := &models.GPSCoordinates{
+		Latitude:  latitude[0] + latitude[1]/60 + latitude[2]/3600,
+		Longitude: longitude[0] + longitude[1]/60 + longitude[2]/3600,
+	}
+	if r.ascii(ifd[gpsTagLatitudeRef]) == "S" {
+		coords.Latitude = -coords.Latitude
+	}
+	if r.ascii(ifd[gpsTagLongitudeRef]) == "W" {
+		coords.Longitude = -coords.Longitude
+	}
+	return coords
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tiffBuilder assembles a little-endian TIFF structure for tests
+type tiffBuilder struct {
+	buf bytes.Buffer
+}
+
+type testIFDEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiEntry(tag uint16, s string) testIFDEntry {
+	return testIFDEntry{tag: tag, typ: 2, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func longEntry(tag uint16, v uint32) testIFDEntry {
+	data := binary.LittleEndian.AppendUint32(nil, v)
+	return testIFDEntry{tag: tag, typ: 4, count: 1, data: data}
+}
+
+func rationalEntry(tag uint16, values ...[2]uint32) testIFDEntry {
+	var data []byte
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v[0])
+		data = binary.LittleEndian.AppendUint32(data, v[1])
+	}
+	return testIFDEntry{tag: tag, typ: 5, count: uint32(len(values)), data: data}
+}
+
+// ifdSize returns the number of bytes an IFD and its out-of-line values occupy
+func ifdSize(entries []testIFDEntry) uint32 {
+	size := uint32(2 + 12*len(entries) + 4)
+	for _, e := range entries {
+		if len(e.data) > 4 {
+			size += uint32(len(e.data))
+		}
+	}
+	return size
+}
+
+// writeIFD appends an IFD at the current offset
+func (b *tiffBuilder) writeIFD(entries []testIFDEntry) {
+	le := binary.LittleEndian
+	offset := uint32(b.buf.Len())
+	valueOffset := offset + uint32(2+12*len(entries)+4)
+
+	var values []byte
+	b.buf.Write(le.AppendUint16(nil, uint16(len(entries))))
+	for _, e := range entries {
+		b.buf.Write(le.AppendUint16(nil, e.tag))
+		b.buf.Write(le.AppendUint16(nil, e.typ))
+		b.buf.Write(le.AppendUint32(nil, e.count))
+		if len(e.data) > 4 {
+			b.buf.Write(le.AppendUint32(nil, valueOffset+uint32(len(values))))
+			values = append(values, e.data...)
+		} else {
+			inline := make([]byte, 4)
+			copy(inline, e.data)
+			b.buf.Write(inline)
+		}
+	}
+	b.buf.Write(make([]byte, 4))
+	b.buf.Write(values)
+}
+
+func buildTestExif() []byte {
+	gps := []testIFDEntry{
+		asciiEntry(gpsTagLatitudeRef, "N"),
+		rationalEntry(gpsTagLatitude, [2]uint32{52, 1}, [2]uint32{30, 1}, [2]uint32{0, 1}),
+		asciiEntry(gpsTagLongitudeRef, "W"),
+		rationalEntry(gpsTagLongitude, [2]uint32{13, 1}, [2]uint32{15, 1}, [2]uint32{36, 1}),
+	}
+	exif := []testIFDEntry{
+		asciiEntry(exifTagDateTimeOriginal, "2024:05:01 10:20:30"),
+	}
+
+	ifd0 := []testIFDEntry{
+		asciiEntry(exifTagMake, "Canon"),
+		asciiEntry(exifTagModel, "EOS R6"),
+		{tag: exifTagOrientation, typ: 3, count: 1, data: []byte{6, 0}},
+		asciiEntry(exifTagDateTime, "2024:05:02 00:00:00"),
+		longEntry(exifTagExifIFD, 0),
+		longEntry(exifTagGPSIFD, 0),
+	}
+	exifOffset := 8 + ifdSize(ifd0)
+	ifd0[4] = longEntry(exifTagExifIFD, exifOffset)
+	ifd0[5] = longEntry(exifTagGPSIFD, exifOffset+ifdSize(exif))
+
+	b := &tiffBuilder{}
+	b.buf.WriteString("II")
+	b.buf.Write(binary.LittleEndian.AppendUint16(nil, 42))
+	b.buf.Write(binary.LittleEndian.AppendUint32(nil, 8))
+	b.writeIFD(ifd0)
+	b.writeIFD(exif)
+	b.writeIFD(gps)
+	return b.buf.Bytes()
+}
+
+func TestParseExif(t *testing.T) {
+	tiff := buildTestExif()
+
+	// Wrap the TIFF payload in a minimal JPEG APP1 segment
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	jpeg := []byte{0xff, 0xd8, 0xff, 0xe1}
+	jpeg = binary.BigEndian.AppendUint16(jpeg, uint16(len(segment)+2))
+	jpeg = append(jpeg, segment...)
+	jpeg = append(jpeg, 0xff, 0xd9)
+
+	payload, err := findJPEGExif(jpeg)
+	require.NoError(t, err)
+
+	exif, err := parseExif(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "Canon", exif.make)
+	assert.Equal(t, "EOS R6", exif.model)
+	assert.Equal(t, 6, exif.orientation)
+	require.NotNil(t, exif.takenAt)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC), *exif.takenAt, "DateTimeOriginal wins over DateTime")
+	require.NotNil(t, exif.gps)
+	assert.InDelta(t, 52.5, exif.gps.Latitude, 1e-9)
+	assert.InDelta(t, -13.26, exif.gps.Longitude, 1e-9)
+}
+
+func TestParseExif_Invalid(t *testing.T) {
+	_, err := findJPEGExif([]byte("\x89PNG\r\n"))
+	assert.ErrorIs(t, err, errNoExif)
+
+	_, err = parseExif([]byte("II*\x00\xff\xff\xff\xff"))
+	assert.ErrorIs(t, err, errNoExif)
+
+	// Truncated payloads must not panic
+	tiff := buildTestExif()
+	for i := range tiff {
+		_, _ = parseExif(tiff[:i])
+	}
+}
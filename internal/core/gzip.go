@@ -0,0 +1,75 @@
+package core
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+)
+
+// isGzipObject reports whether an object's stored bytes are gzip-compressed, judged by
+// its Content-Encoding, its content type or a .gz extension
+func isGzipObject(contentEncoding, contentType, key string) bool {
+	if strings.EqualFold(strings.TrimSpace(contentEncoding), "gzip") {
+		return true
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "application/gzip" || mediaType == "application/x-gzip" {
+		return true
+	}
+
+	lower := strings.ToLower(key)
+	return strings.HasSuffix(lower, ".gz") && !strings.HasSuffix(lower, ".tar.gz")
+}
+
+// trimGzipExtension returns the key without a trailing .gz, so that the content type of
+// the decompressed data can be derived from the remaining extension
+func trimGzipExtension(key string) string {
+	if strings.HasSuffix(strings.ToLower(key), ".gz") {
+		return key[:len(key)-3]
+	}
+	return key
+}
+
+// readGzipPrefix decompresses up to maxBytes from r. It reports whether more data
+// followed; a stream that ends early, e.g. a partly uploaded log, is returned as-is.
+func readGzipPrefix(r io.Reader, maxBytes int64) ([]byte, bool, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, false, err
+	}
+	defer gzipReader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(gzipReader, maxBytes+1))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, false, err
+	}
+
+	truncated := int64(len(data)) > maxBytes || errors.Is(err, io.ErrUnexpectedEOF)
+	if int64(len(data)) > maxBytes {
+		data = data[:maxBytes]
+	}
+	return data, truncated, nil
+}
+
+// gzipReadCloser decompresses an object body and closes the body along with the reader
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// newGzipReadCloser wraps a compressed object body
+func newGzipReadCloser(body io.ReadCloser) (io.ReadCloser, error) {
+	gzipReader, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipReadCloser{Reader: gzipReader, body: body}, nil
+}
+
+// Close closes both the gzip reader and the underlying body
+func (g *gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
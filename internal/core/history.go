@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// historyMatchWindow is how far apart the records of the same change by different
+// sources may be, e.g. a user's recent upload and the event it published
+const historyMatchWindow = 5 * time.Second
+
+// ObjectHistory collects what the explorer knows about an object: the changes made
+// through it, who uploaded or downloaded it, its share links and their accesses, and
+// its last modification in S3. Users are matched to the changes they made by time.
+// Sources only go back as far as they are kept, so the history is not complete.
+func (s *S3Service) ObjectHistory(ctx context.Context, bucket, key string) (*models.ObjectHistory, error) {
+	history := &models.ObjectHistory{Bucket: bucket, Key: key, Entries: []models.ObjectHistoryEntry{}}
+
+	for _, event := range s.core.Events.ForObject(bucket, key) {
+		action := models.HistoryUploaded
+		if event.Type == EventObjectRemoved {
+			action = models.HistoryDeleted
+		}
+		history.Entries = append(history.Entries, models.ObjectHistoryEntry{
+			Time:   event.Time,
+			Action: action,
+			Source: models.HistorySourceEvents,
+		})
+	}
+
+	for user, file := range s.core.Recent.FileUses(bucket, key) {
+		if file.Action == RecentUpload {
+			if i := matchHistoryEntry(history.Entries, models.HistoryUploaded, file.At, true); i >= 0 {
+				history.Entries[i].User = user
+				continue
+			}
+		}
+		action := models.HistoryDownloaded
+		if file.Action == RecentUpload {
+			action = models.HistoryUploaded
+		}
+		history.Entries = append(history.Entries, models.ObjectHistoryEntry{
+			Time:   file.At,
+			Action: action,
+			User:   user,
+			Source: models.HistorySourceRecent,
+		})
+	}
+
+	for _, share := range s.core.Shares.forObject(bucket, key) {
+		history.Entries = append(history.Entries, models.ObjectHistoryEntry{
+			Time:       share.CreatedAt,
+			Action:     models.HistoryShared,
+			ShareToken: share.Token,
+			Source:     models.HistorySourceShares,
+		})
+		for _, access := range share.Recent {
+			history.Entries = append(history.Entries, models.ObjectHistoryEntry{
+				Time:       access.Time,
+				Action:     models.HistoryDownloaded,
+				ShareToken: share.Token,
+				IP:         access.IP,
+				Source:     models.HistorySourceShares,
+			})
+		}
+	}
+
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	switch {
+	case err == nil:
+		history.Exists = true
+		// Changes made outside the explorer only show in the object's own metadata
+		modified := aws.ToTime(head.LastModified)
+		if matchHistoryEntry(history.Entries, models.HistoryUploaded, modified, false) < 0 {
+			history.Entries = append(history.Entries, models.ObjectHistoryEntry{
+				Time:   modified,
+				Action: models.HistoryModified,
+				Source: models.HistorySourceS3,
+			})
+		}
+	case hasErrorCode(err, "NotFound") && len(history.Entries) > 0:
+		// The history of a deleted object is still worth showing
+	default:
+		return nil, err
+	}
+
+	slices.SortStableFunc(history.Entries, func(a, b models.ObjectHistoryEntry) int {
+		return b.Time.Compare(a.Time)
+	})
+	return history, nil
+}
+
+// matchHistoryEntry returns the index of an entry for action within historyMatchWindow
+// of at, without a user when unattributed is set, or -1
+func matchHistoryEntry(entries []models.ObjectHistoryEntry, action string, at time.Time, unattributed bool) int {
+	for i, entry := range entries {
+		if entry.Action != action || (unattributed && entry.User != "") {
+			continue
+		}
+		if entry.Time.Sub(at).Abs() <= historyMatchWindow {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectHistory(t *testing.T) {
+	modified := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	cfg := &config.Config{
+		Shares: config.SharesConfig{DefaultExpiry: time.Hour, MaxExpiry: 24 * time.Hour},
+		Recent: config.RecentConfig{Size: 10},
+	}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photos/cat.jpg":
+			w.Header().Set("Content-Length", "2048")
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		case "/photos/dog.jpg":
+			w.Header().Set("Content-Length", "10")
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	c.Shares = NewShareService(c)
+	c.Recent = NewRecentService(c)
+	ctx := context.Background()
+
+	// Changed outside the explorer, then downloaded by ann and through a share link
+	c.Recent.RecordFile("ann", "photos", "cat.jpg", RecentDownload)
+	share, err := c.Shares.Create(ctx, "alice", "photos", "cat.jpg", "", 0)
+	require.NoError(t, err)
+	c.Shares.RecordAccess(share.Token, "203.0.113.7", "curl")
+
+	history, err := c.S3Service.ObjectHistory(ctx, "photos", "cat.jpg")
+	require.NoError(t, err)
+	assert.True(t, history.Exists)
+	require.Len(t, history.Entries, 4)
+	assert.Equal(t, models.HistoryModified, history.Entries[3].Action)
+	assert.Equal(t, models.HistorySourceS3, history.Entries[3].Source)
+	assert.ElementsMatch(t, []models.ObjectHistoryEntry{
+		{Action: models.HistoryDownloaded, User: "ann", Source: models.HistorySourceRecent},
+		{Action: models.HistoryShared, ShareToken: share.Token, Source: models.HistorySourceShares},
+		{Action: models.HistoryDownloaded, ShareToken: share.Token, IP: "203.0.113.7", Source: models.HistorySourceShares},
+	}, withoutTimes(history.Entries[:3]))
+
+	// Uploaded through the explorer: the event, ann's upload and S3's timestamp agree
+	c.Events.Publish(EventObjectCreated, "photos", "dog.jpg")
+	c.Recent.RecordFile("ann", "photos", "dog.jpg", RecentUpload)
+	history, err = c.S3Service.ObjectHistory(ctx, "photos", "dog.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []models.ObjectHistoryEntry{
+		{Action: models.HistoryUploaded, User: "ann", Source: models.HistorySourceEvents},
+	}, withoutTimes(history.Entries))
+
+	// Deleted objects keep their history
+	c.Events.Publish(EventObjectCreated, "photos", "gone.jpg")
+	c.Events.Publish(EventObjectRemoved, "photos", "gone.jpg")
+	history, err = c.S3Service.ObjectHistory(ctx, "photos", "gone.jpg")
+	require.NoError(t, err)
+	assert.False(t, history.Exists)
+	assert.Len(t, history.Entries, 2)
+
+	_, err = c.S3Service.ObjectHistory(ctx, "photos", "never.jpg")
+	assert.True(t, hasErrorCode(err, "NotFound"))
+}
+
+// withoutTimes clears the times of history entries, for comparing the rest
+func withoutTimes(entries []models.ObjectHistoryEntry) []models.ObjectHistoryEntry {
+	cleared := make([]models.ObjectHistoryEntry, len(entries))
+	for i, entry := range entries {
+		entry.Time = time.Time{}
+		cleared[i] = entry
+	}
+	return cleared
+}
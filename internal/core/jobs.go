@@ -0,0 +1,411 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+// maxJobResults caps the per-item results kept for a single job
+const maxJobResults = 1000
+
+var (
+	// ErrJobNotFound is returned when a job ID is unknown
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobFinished is returned when cancelling a job that has already finished
+	ErrJobFinished = errors.New("job already finished")
+
+	// errJobInterrupted is the cancellation cause of jobs stopped by a shutdown
+	errJobInterrupted = errors.New("interrupted by server shutdown")
+)
+
+// JobFunc performs the work of a job, reporting progress through the job handle
+type JobFunc func(ctx context.Context, job *Job) error
+
+// Job is the handle a running job uses to report its progress
+type Job struct {
+	mu     sync.Mutex
+	state  models.Job
+	cancel context.CancelCauseFunc
+}
+
+// AddTotal increases the number of items the job expects to process
+func (j *Job) AddTotal(n int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state.Progress.Total += n
+}
+
+// ItemSucceeded records a successfully processed item
+func (j *Job) ItemSucceeded(key string, bytes int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state.Progress.Completed++
+	j.state.Progress.Bytes += bytes
+}
+
+// ItemReported records a successfully processed item and lists it in the job's results,
+// for jobs whose callers want an outcome for every item
+func (j *Job) ItemReported(key string, bytes int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state.Progress.Completed++
+	j.state.Progress.Bytes += bytes
+	if len(j.state.Results) < maxJobResults {
+		j.state.Results = append(j.state.Results, models.JobItemResult{
+			Key:    key,
+			Status: models.JobStatusSucceeded,
+		})
+	}
+}
+
+// ItemFailed records an item that could not be processed
+func (j *Job) ItemFailed(key string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state.Progress.Failed++
+	if len(j.state.Results) < maxJobResults {
+		j.state.Results = append(j.state.Results, models.JobItemResult{
+			Key:    key,
+			Status: models.JobStatusFailed,
+			Error:  err.Error(),
+		})
+	}
+}
+
+// Snapshot returns a copy of the job's current state
+func (j *Job) Snapshot() models.Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	snapshot := j.state
+	snapshot.Results = append([]models.JobItemResult(nil), j.state.Results...)
+	return snapshot
+}
+
+// finished reports whether the job has reached a terminal status
+func (j *Job) finished() bool {
+	switch j.state.Status {
+	case models.JobStatusSucceeded, models.JobStatusFailed, models.JobStatusCancelled, models.JobStatusInterrupted:
+		return true
+	default:
+		return false
+	}
+}
+
+// JobManager runs background jobs with bounded concurrency and keeps their status
+type JobManager struct {
+	core *Core
+	sem  chan struct{}
+
+	// running tracks job goroutines so shutdown can wait for them
+	running sync.WaitGroup
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	order []string
+}
+
+// NewJobManager creates a new JobManager, restoring the job history saved by the
+// previous run
+func NewJobManager(core *Core) *JobManager {
+	m := &JobManager{
+		core: core,
+		sem:  make(chan struct{}, max(1, core.Config.Jobs.MaxConcurrent)),
+		jobs: make(map[string]*Job),
+	}
+
+	if err := m.load(); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore job history")
+	}
+	return m
+}
+
+// Submit queues a job for execution and returns its initial state
+func (m *JobManager) Submit(jobType string, params map[string]string, fn JobFunc) models.Job {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	job := &Job{
+		state: models.Job{
+			ID:        newJobID(),
+			Type:      jobType,
+			Status:    models.JobStatusPending,
+			Params:    params,
+			CreatedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	m.jobs[job.state.ID] = job
+	m.order = append(m.order, job.state.ID)
+	m.pruneLocked()
+	m.mu.Unlock()
+
+	m.core.Logger.Info().
+		Str("jobId", job.state.ID).
+		Str("type", jobType).
+		Msg("Job submitted")
+
+	m.running.Add(1)
+	go m.run(ctx, job, fn)
+
+	return job.Snapshot()
+}
+
+// run waits for a free slot and executes a job
+func (m *JobManager) run(ctx context.Context, job *Job, fn JobFunc) {
+	defer m.running.Done()
+	defer job.cancel(nil)
+
+	select {
+	case m.sem <- struct{}{}:
+		defer func() { <-m.sem }()
+	case <-ctx.Done():
+		m.finish(job, context.Cause(ctx))
+		return
+	}
+
+	job.mu.Lock()
+	started := time.Now().UTC()
+	job.state.Status = models.JobStatusRunning
+	job.state.StartedAt = &started
+	job.mu.Unlock()
+
+	err := fn(ctx, job)
+	if ctx.Err() != nil && (err == nil || errors.Is(err, context.Canceled)) {
+		err = context.Cause(ctx)
+	}
+	m.finish(job, err)
+}
+
+// finish moves a job into its terminal status
+func (m *JobManager) finish(job *Job, err error) {
+	job.mu.Lock()
+	finished := time.Now().UTC()
+	job.state.FinishedAt = &finished
+	switch {
+	case errors.Is(err, errJobInterrupted):
+		job.state.Status = models.JobStatusInterrupted
+		job.state.Error = err.Error()
+	case errors.Is(err, context.Canceled):
+		job.state.Status = models.JobStatusCancelled
+	case err != nil:
+		job.state.Status = models.JobStatusFailed
+		job.state.Error = err.Error()
+	default:
+		job.state.Status = models.JobStatusSucceeded
+	}
+	state := job.state
+	job.mu.Unlock()
+
+	event := m.core.Logger.Info()
+	if err != nil && state.Status == models.JobStatusFailed {
+		event = m.core.Logger.Error().Err(err)
+	}
+	event.
+		Str("jobId", state.ID).
+		Str("type", state.Type).
+		Str("status", state.Status).
+		Int64("completed", state.Progress.Completed).
+		Int64("failed", state.Progress.Failed).
+		Msg("Job finished")
+}
+
+// Get returns the current state of a job
+func (m *JobManager) Get(id string) (models.Job, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+
+	if !ok {
+		return models.Job{}, ErrJobNotFound
+	}
+	return job.Snapshot(), nil
+}
+
+// List returns jobs newest first, starting at offset and returning at most limit jobs,
+// together with the total number of jobs
+func (m *JobManager) List(offset, limit int) ([]models.Job, int) {
+	return m.ListMatching(offset, limit, nil)
+}
+
+// ListMatching is List restricted to the jobs keep accepts, such as those of a
+// workspace's buckets; offset and the total count only the jobs kept. A nil keep keeps
+// every job.
+func (m *JobManager) ListMatching(offset, limit int, keep func(models.Job) bool) ([]models.Job, int) {
+	m.mu.Lock()
+	ids := make([]string, len(m.order))
+	copy(ids, m.order)
+	jobs := make([]*Job, len(ids))
+	for i, id := range ids {
+		jobs[len(ids)-1-i] = m.jobs[id]
+	}
+	m.mu.Unlock()
+
+	result := make([]models.Job, 0, limit)
+	total := 0
+	for _, job := range jobs {
+		snapshot := job.Snapshot()
+		if keep != nil && !keep(snapshot) {
+			continue
+		}
+		if total >= offset && len(result) < limit {
+			result = append(result, snapshot)
+		}
+		total++
+	}
+	return result, total
+}
+
+// Cancel requests cancellation of a pending or running job
+func (m *JobManager) Cancel(id string) error {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	job.mu.Lock()
+	finished := job.finished()
+	job.mu.Unlock()
+	if finished {
+		return ErrJobFinished
+	}
+
+	job.cancel(context.Canceled)
+	return nil
+}
+
+// Shutdown waits for pending and running jobs to finish until ctx is done, then
+// interrupts the remaining ones. The job history is saved to the state file, if
+// configured, so the outcome of every job can still be queried after a restart.
+func (m *JobManager) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.mu.Lock()
+		jobs := make([]*Job, 0, len(m.jobs))
+		for _, job := range m.jobs {
+			jobs = append(jobs, job)
+		}
+		m.mu.Unlock()
+
+		interrupted := 0
+		for _, job := range jobs {
+			job.mu.Lock()
+			finished := job.finished()
+			job.mu.Unlock()
+			if !finished {
+				job.cancel(errJobInterrupted)
+				interrupted++
+			}
+		}
+		m.core.Logger.Warn().
+			Int("jobs", interrupted).
+			Msg("Interrupting background jobs that did not finish in time")
+
+		// Cancelled jobs stop at their next context check; don't wait on one that ignores it
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}
+
+	return m.save()
+}
+
+// save persists every known job, oldest first. Jobs still running at this point are
+// recorded as interrupted.
+func (m *JobManager) save() error {
+	m.mu.Lock()
+	jobs := make([]models.Job, 0, len(m.order))
+	for _, id := range m.order {
+		jobs = append(jobs, m.jobs[id].Snapshot())
+	}
+	m.mu.Unlock()
+
+	for i := range jobs {
+		markInterrupted(&jobs[i])
+	}
+
+	return m.core.persistState("jobs", m.core.Config.Jobs.StateFile, jobs)
+}
+
+// load restores the jobs saved by a previous run. Their work cannot be resumed, so
+// jobs that had not finished are marked as interrupted.
+func (m *JobManager) load() error {
+	var jobs []models.Job
+	if err := m.core.restoreState("jobs", m.core.Config.Jobs.StateFile, &jobs); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, state := range jobs {
+		markInterrupted(&state)
+		m.jobs[state.ID] = &Job{
+			state:  state,
+			cancel: func(error) {},
+		}
+		m.order = append(m.order, state.ID)
+	}
+	m.pruneLocked()
+	return nil
+}
+
+// markInterrupted moves a job that has not finished into the interrupted status
+func markInterrupted(state *models.Job) {
+	switch state.Status {
+	case models.JobStatusPending, models.JobStatusRunning:
+		now := time.Now().UTC()
+		state.Status = models.JobStatusInterrupted
+		state.Error = errJobInterrupted.Error()
+		state.FinishedAt = &now
+	}
+}
+
+// pruneLocked drops the oldest finished jobs beyond the retention limit. m.mu must be held.
+func (m *JobManager) pruneLocked() {
+	excess := len(m.order) - max(1, m.core.Config.Jobs.Retention)
+	if excess <= 0 {
+		return
+	}
+
+	kept := m.order[:0]
+	for _, id := range m.order {
+		job := m.jobs[id]
+		job.mu.Lock()
+		finished := job.finished()
+		job.mu.Unlock()
+
+		if excess > 0 && finished {
+			delete(m.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	m.order = kept
+}
+
+// newJobID generates a random job identifier
+func newJobID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJobManager() *JobManager {
+	return NewJobManager(&Core{
+		Config: &config.Config{Jobs: config.JobsConfig{MaxConcurrent: 1, Retention: 2}},
+		Logger: logger.New("error", "json"),
+	})
+}
+
+// waitForJob polls until a job reaches a terminal status
+func waitForJob(t *testing.T, m *JobManager, id string) models.Job {
+	t.Helper()
+	var job models.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(id)
+		require.NoError(t, err)
+		return job.FinishedAt != nil
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestJobManager_Lifecycle(t *testing.T) {
+	m := newTestJobManager()
+
+	submitted := m.Submit("test", nil, func(ctx context.Context, job *Job) error {
+		job.AddTotal(2)
+		job.ItemSucceeded("a", 10)
+		job.ItemFailed("b", errors.New("boom"))
+		return nil
+	})
+	assert.Equal(t, models.JobStatusPending, submitted.Status)
+
+	job := waitForJob(t, m, submitted.ID)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, models.JobProgress{Total: 2, Completed: 1, Failed: 1, Bytes: 10}, job.Progress)
+	require.Len(t, job.Results, 1)
+	assert.Equal(t, "b", job.Results[0].Key)
+
+	failed := m.Submit("test", nil, func(ctx context.Context, job *Job) error {
+		return errors.New("broken")
+	})
+	job = waitForJob(t, m, failed.ID)
+	assert.Equal(t, models.JobStatusFailed, job.Status)
+	assert.Equal(t, "broken", job.Error)
+
+	assert.ErrorIs(t, m.Cancel(failed.ID), ErrJobFinished)
+	_, err := m.Get("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestJobManager_Cancel(t *testing.T) {
+	m := newTestJobManager()
+
+	submitted := m.Submit("test", nil, func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, m.Cancel(submitted.ID))
+
+	job := waitForJob(t, m, submitted.ID)
+	assert.Equal(t, models.JobStatusCancelled, job.Status)
+}
+
+func TestJobManager_ListAndRetention(t *testing.T) {
+	m := newTestJobManager()
+
+	var ids []string
+	for range 3 {
+		job := m.Submit("test", nil, func(ctx context.Context, job *Job) error { return nil })
+		waitForJob(t, m, job.ID)
+		ids = append(ids, job.ID)
+	}
+
+	// Submitting a fourth job prunes the oldest finished ones down to the retention limit
+	latest := m.Submit("test", nil, func(ctx context.Context, job *Job) error { return nil })
+	waitForJob(t, m, latest.ID)
+
+	jobs, total := m.List(0, 10)
+	assert.Equal(t, 2, total)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, latest.ID, jobs[0].ID, "newest job comes first")
+	assert.Equal(t, ids[2], jobs[1].ID)
+
+	jobs, _ = m.List(1, 10)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, ids[2], jobs[0].ID)
+}
+
+func TestSafeArchivePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		ok       bool
+	}{
+		{"docs/readme.txt", "docs/readme.txt", true},
+		{"./docs//readme.txt", "docs/readme.txt", true},
+		{"docs/../readme.txt", "readme.txt", true},
+		{"docs\\readme.txt", "docs/readme.txt", true},
+		{"../evil.txt", "", false},
+		{"docs/../../evil.txt", "", false},
+		{"..\\evil.txt", "", false},
+		{"/etc/passwd", "", false},
+		{"..", "", false},
+		{".", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := safeArchivePath(tt.name)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestJobManager_ShutdownDrainsAndInterrupts(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "jobs.json")
+	newManager := func() *JobManager {
+		return NewJobManager(&Core{
+			Config: &config.Config{Jobs: config.JobsConfig{MaxConcurrent: 2, Retention: 10, StateFile: statePath}},
+			Logger: logger.New("error", "json"),
+		})
+	}
+	m := newManager()
+
+	quick := m.Submit("test", nil, func(ctx context.Context, job *Job) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	stuck := m.Submit("test", nil, func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.NoError(t, m.Shutdown(ctx))
+
+	job, err := m.Get(quick.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+
+	job, err = m.Get(stuck.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusInterrupted, job.Status)
+
+	// The history survives a restart
+	restored := newManager()
+	job, err = restored.Get(stuck.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusInterrupted, job.Status)
+	jobs, total := restored.List(0, 10)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, stuck.ID, jobs[0].ID)
+}
+
+func TestMarkInterrupted(t *testing.T) {
+	running := models.Job{Status: models.JobStatusRunning}
+	markInterrupted(&running)
+	assert.Equal(t, models.JobStatusInterrupted, running.Status)
+	assert.NotNil(t, running.FinishedAt)
+
+	succeeded := models.Job{Status: models.JobStatusSucceeded}
+	markInterrupted(&succeeded)
+	assert.Equal(t, models.JobStatusSucceeded, succeeded.Status)
+}
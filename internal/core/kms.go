@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+var (
+	// ErrKMSUnavailable is returned when no KMS client is configured
+	ErrKMSUnavailable = errors.New("KMS is not available")
+	// ErrKMSRequired is returned for writes that do not comply with uploads.requireKms
+	ErrKMSRequired = errors.New("objects must be encrypted with the required KMS key")
+)
+
+// ListKMSKeys lists the KMS key aliases uploads can be encrypted with, in the default
+// region. Aliases that do not point at a key are left out.
+func (s *S3Service) ListKMSKeys(ctx context.Context) ([]models.KMSKey, error) {
+	if s.core.KMSClient == nil {
+		return nil, ErrKMSUnavailable
+	}
+
+	keys := []models.KMSKey{}
+	paginator := kms.NewListAliasesPaginator(s.core.KMSClient, &kms.ListAliasesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Msg("Failed to list KMS aliases")
+			return nil, err
+		}
+
+		for _, alias := range page.Aliases {
+			if alias.TargetKeyId == nil {
+				continue
+			}
+			name := aws.ToString(alias.AliasName)
+			keys = append(keys, models.KMSKey{
+				Alias:      name,
+				KeyID:      aws.ToString(alias.TargetKeyId),
+				ARN:        aws.ToString(alias.AliasArn),
+				AWSManaged: strings.HasPrefix(name, "alias/aws/"),
+			})
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b models.KMSKey) int {
+		return strings.Compare(a.Alias, b.Alias)
+	})
+	return keys, nil
+}
+
+// sseKMS is the SSE-KMS setting of a write. The zero value leaves encryption to the
+// bucket default; enabled with an empty keyID uses the AWS managed key.
+type sseKMS struct {
+	enabled bool
+	keyID   string
+}
+
+// kmsFor decides the SSE-KMS setting of a write that asks for keyID, or for
+// customerKey, applying uploads.requireKms
+func (s *S3Service) kmsFor(keyID string, customerKey *CustomerKey) (sseKMS, error) {
+	if keyID != "" && customerKey != nil {
+		return sseKMS{}, ErrConflictingEncryption
+	}
+
+	uploads := s.core.Config.Uploads
+	if !uploads.RequireKMS {
+		return sseKMS{enabled: keyID != "", keyID: keyID}, nil
+	}
+	if customerKey != nil || (keyID != "" && uploads.KMSKeyID != "" && keyID != uploads.KMSKeyID) {
+		return sseKMS{}, ErrKMSRequired
+	}
+	if keyID == "" {
+		keyID = uploads.KMSKeyID
+	}
+	return sseKMS{enabled: true, keyID: keyID}, nil
+}
+
+// keptEncryption returns the server-side encryption a rewrite of an object keeps: its
+// own, unless uploads.requireKms applies to an object not encrypted with KMS
+func (s *S3Service) keptEncryption(head *s3.HeadObjectOutput) (s3Types.ServerSideEncryption, *string, *bool) {
+	kms, _ := s.kmsFor("", nil)
+	if kms.enabled && !strings.HasPrefix(string(head.ServerSideEncryption), string(s3Types.ServerSideEncryptionAwsKms)) {
+		sse, keyID := kms.params()
+		return sse, keyID, nil
+	}
+	return head.ServerSideEncryption, head.SSEKMSKeyId, head.BucketKeyEnabled
+}
+
+// params returns the encryption parameters of a PutObject or CopyObject request
+func (e sseKMS) params() (s3Types.ServerSideEncryption, *string) {
+	if !e.enabled {
+		return "", nil
+	}
+	if e.keyID == "" {
+		return s3Types.ServerSideEncryptionAwsKms, nil
+	}
+	return s3Types.ServerSideEncryptionAwsKms, aws.String(e.keyID)
+}
+
+// fields returns the presigned POST form fields for the setting
+func (e sseKMS) fields() map[string]string {
+	if !e.enabled {
+		return nil
+	}
+	fields := map[string]string{"x-amz-server-side-encryption": string(s3Types.ServerSideEncryptionAwsKms)}
+	if e.keyID != "" {
+		fields["x-amz-server-side-encryption-aws-kms-key-id"] = e.keyID
+	}
+	return fields
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListKMSKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_, _ = w.Write([]byte(`{"Aliases":[
+			{"AliasName":"alias/uploads","AliasArn":"arn:aws:kms:us-east-1:1:alias/uploads","TargetKeyId":"k1"},
+			{"AliasName":"alias/unused","AliasArn":"arn:aws:kms:us-east-1:1:alias/unused"},
+			{"AliasName":"alias/aws/s3","AliasArn":"arn:aws:kms:us-east-1:1:alias/aws/s3","TargetKeyId":"k2"}
+		]}`))
+	}))
+	defer server.Close()
+
+	c := &Core{Config: &config.Config{}, Logger: logger.New("error", "json")}
+	c.S3Service = NewS3Service(c)
+	_, err := c.S3Service.ListKMSKeys(context.Background())
+	assert.ErrorIs(t, err, ErrKMSUnavailable)
+
+	c.KMSClient = kms.New(kms.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	keys, err := c.S3Service.ListKMSKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []models.KMSKey{
+		{Alias: "alias/aws/s3", KeyID: "k2", ARN: "arn:aws:kms:us-east-1:1:alias/aws/s3", AWSManaged: true},
+		{Alias: "alias/uploads", KeyID: "k1", ARN: "arn:aws:kms:us-east-1:1:alias/uploads"},
+	}, keys)
+}
+
+func TestGeneratePresignedPostURL_KMSKey(t *testing.T) {
+	c := newTestUploadCore(config.UploadPolicy{Name: "fixed", SSE: "AES256"}, config.UploadPolicy{Name: "open"})
+	ctx := context.Background()
+
+	resp, err := c.S3Service.GeneratePresignedPostURL(ctx, "docs", "a.txt", "text/plain", 0, 0, "alias/uploads", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "aws:kms", resp.Fields["x-amz-server-side-encryption"])
+	assert.Equal(t, "alias/uploads", resp.Fields["x-amz-server-side-encryption-aws-kms-key-id"])
+
+	resp, err = c.S3Service.GeneratePolicyPostURL(ctx, "docs", "ann", models.PresignedPostURLRequest{
+		Key: "a.txt", ContentType: "text/plain", Policy: "open", KMSKeyID: "alias/uploads",
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "alias/uploads", resp.Fields["x-amz-server-side-encryption-aws-kms-key-id"])
+
+	// The policy's encryption wins over the client's choice
+	resp, err = c.S3Service.GeneratePolicyPostURL(ctx, "docs", "ann", models.PresignedPostURLRequest{
+		Key: "a.txt", ContentType: "text/plain", Policy: "fixed", KMSKeyID: "alias/uploads",
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "AES256", resp.Fields["x-amz-server-side-encryption"])
+	assert.NotContains(t, resp.Fields, "x-amz-server-side-encryption-aws-kms-key-id")
+}
+
+func TestKMSFor(t *testing.T) {
+	c := newTestUploadCore()
+	customerKey, err := ParseCustomerKey(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+
+	kms, err := c.S3Service.kmsFor("", nil)
+	require.NoError(t, err)
+	assert.Nil(t, kms.fields())
+
+	_, err = c.S3Service.kmsFor("alias/uploads", customerKey)
+	assert.ErrorIs(t, err, ErrConflictingEncryption)
+
+	// Writes without a key get the required one; other keys and SSE-C are refused
+	c.Config.Uploads = config.UploadsConfig{RequireKMS: true, KMSKeyID: "alias/required"}
+	kms, err = c.S3Service.kmsFor("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"x-amz-server-side-encryption":                "aws:kms",
+		"x-amz-server-side-encryption-aws-kms-key-id": "alias/required",
+	}, kms.fields())
+	_, err = c.S3Service.kmsFor("alias/required", nil)
+	assert.NoError(t, err)
+	_, err = c.S3Service.kmsFor("alias/other", nil)
+	assert.ErrorIs(t, err, ErrKMSRequired)
+	_, err = c.S3Service.kmsFor("", customerKey)
+	assert.ErrorIs(t, err, ErrKMSRequired)
+
+	// Without a required key any KMS key will do, the AWS managed one by default
+	c.Config.Uploads.KMSKeyID = ""
+	kms, err = c.S3Service.kmsFor("", nil)
+	require.NoError(t, err)
+	sse, keyID := kms.params()
+	assert.Equal(t, "aws:kms", string(sse))
+	assert.Nil(t, keyID)
+	_, err = c.S3Service.kmsFor("alias/other", nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/config"
+)
+
+// PricingService provides the storage prices used for cost estimates, picking up
+// changes to the configured pricing file
+type PricingService struct {
+	core *Core
+
+	mu sync.Mutex
+	// modTime is the modification time of the pricing file when it was last read
+	modTime  time.Time
+	currency string
+	prices   map[string]float64
+}
+
+// NewPricingService creates a new PricingService starting from the configured prices
+func NewPricingService(core *Core) *PricingService {
+	p := &PricingService{core: core}
+	p.set(core.Config.Pricing.Currency, core.Config.Pricing.StorageClasses)
+	return p
+}
+
+// Prices returns the currency and the monthly price per GB of each storage class,
+// keyed by upper-case storage class name
+func (p *PricingService) Prices() (string, map[string]float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if path := p.core.Config.Pricing.File; path != "" {
+		if err := p.reloadLocked(path); err != nil {
+			// Keep estimating with the prices we have rather than failing every request
+			p.core.Logger.Warn().
+				Err(err).
+				Str("path", path).
+				Msg("Failed to read pricing file")
+		}
+	}
+	return p.currency, p.prices
+}
+
+// reloadLocked reads the pricing file if it changed since it was last read
+func (p *PricingService) reloadLocked(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(p.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var table struct {
+		Currency       string                     `json:"currency"`
+		StorageClasses []config.StorageClassPrice `json:"storageClasses"`
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		return err
+	}
+	if err := config.ValidatePrices(table.StorageClasses); err != nil {
+		return fmt.Errorf("storageClasses: %w", err)
+	}
+	if table.Currency == "" {
+		table.Currency = p.core.Config.Pricing.Currency
+	}
+
+	p.set(table.Currency, table.StorageClasses)
+	p.modTime = info.ModTime()
+	p.core.Logger.Info().Str("path", path).Msg("Loaded pricing file")
+	return nil
+}
+
+// set replaces the price table. The map is never modified afterwards, so callers may
+// keep reading it after a reload.
+func (p *PricingService) set(currency string, prices []config.StorageClassPrice) {
+	p.currency = currency
+	p.prices = make(map[string]float64, len(prices))
+	for _, price := range prices {
+		p.prices[strings.ToUpper(price.StorageClass)] = price.PerGBMonth
+	}
+}
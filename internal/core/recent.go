@@ -0,0 +1,143 @@
+package core
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+// Actions recorded for recently used files
+const (
+	RecentDownload = "download"
+	RecentUpload   = "upload"
+)
+
+// RecentService keeps each user's recently visited prefixes and recently downloaded
+// or uploaded objects. History is held in memory and only written to the state file on
+// shutdown, as it changes with every listing.
+type RecentService struct {
+	core *Core
+
+	mu      sync.Mutex
+	history map[string]*recentHistory
+}
+
+// recentHistory is one user's history, most recent first
+type recentHistory struct {
+	Locations []models.RecentLocation `json:"locations"`
+	Files     []models.RecentFile     `json:"files"`
+}
+
+// NewRecentService creates a new RecentService, restoring the history saved by the
+// previous run when a state file is configured
+func NewRecentService(core *Core) *RecentService {
+	r := &RecentService{
+		core:    core,
+		history: make(map[string]*recentHistory),
+	}
+
+	if err := core.restoreState("recent", core.Config.Recent.StateFile, &r.history); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore recent history")
+	}
+	return r
+}
+
+// RecordLocation notes that a user visited a bucket, or a prefix within it
+func (r *RecentService) RecordLocation(user, bucket, prefix string) {
+	if prefix = strings.TrimPrefix(prefix, "/"); prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.userHistory(user)
+	location := models.RecentLocation{Bucket: bucket, Prefix: prefix, VisitedAt: time.Now().UTC()}
+	h.Locations = pushRecent(h.Locations, location, r.core.Config.Recent.Size, func(l models.RecentLocation) bool {
+		return l.Bucket == bucket && l.Prefix == prefix
+	})
+}
+
+// RecordFile notes that a user downloaded or uploaded an object
+func (r *RecentService) RecordFile(user, bucket, key, action string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.userHistory(user)
+	file := models.RecentFile{Bucket: bucket, Key: key, Action: action, At: time.Now().UTC()}
+	h.Files = pushRecent(h.Files, file, r.core.Config.Recent.Size, func(f models.RecentFile) bool {
+		return f.Bucket == bucket && f.Key == key
+	})
+}
+
+// FileUses returns, per user, the latest download or upload of an object still in their
+// recent files
+func (r *RecentService) FileUses(bucket, key string) map[string]models.RecentFile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	uses := make(map[string]models.RecentFile)
+	for user, h := range r.history {
+		for _, file := range h.Files {
+			if file.Bucket == bucket && file.Key == key {
+				uses[user] = file
+				break
+			}
+		}
+	}
+	return uses
+}
+
+// Get returns a user's recent locations and files, most recent first
+func (r *RecentService) Get(user string) models.RecentResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	response := models.RecentResponse{
+		Locations: []models.RecentLocation{},
+		Files:     []models.RecentFile{},
+	}
+	if h, ok := r.history[user]; ok {
+		response.Locations = append(response.Locations, h.Locations...)
+		response.Files = append(response.Files, h.Files...)
+	}
+	return response
+}
+
+// Save persists the history
+func (r *RecentService) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.core.persistState("recent", r.core.Config.Recent.StateFile, r.history)
+}
+
+// userHistory returns a user's history, creating it on first use
+func (r *RecentService) userHistory(user string) *recentHistory {
+	h, ok := r.history[user]
+	if !ok {
+		h = &recentHistory{}
+		r.history[user] = h
+	}
+	return h
+}
+
+// pushRecent puts an entry at the front of a history list, dropping an older entry for
+// the same item and trimming the list to size entries
+func pushRecent[T any](list []T, entry T, size int, same func(T) bool) []T {
+	updated := make([]T, 0, min(len(list)+1, size))
+	updated = append(updated, entry)
+	for _, existing := range list {
+		if len(updated) >= size {
+			break
+		}
+		if !same(existing) {
+			updated = append(updated, existing)
+		}
+	}
+	return updated
+}
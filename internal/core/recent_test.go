@@ -0,0 +1,66 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRecentService(size int, stateFile string) *RecentService {
+	return NewRecentService(&Core{
+		Config: &config.Config{Recent: config.RecentConfig{Size: size, StateFile: stateFile}},
+		Logger: logger.New("error", "json"),
+	})
+}
+
+func TestRecentService(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "recent.json")
+	recent := newTestRecentService(3, stateFile)
+
+	recent.RecordLocation("alice", "data", "")
+	recent.RecordLocation("alice", "data", "reports")
+	recent.RecordLocation("alice", "logs", "app/")
+	// Visiting a location again moves it to the front instead of repeating it
+	recent.RecordLocation("alice", "data", "reports/")
+	recent.RecordLocation("bob", "logs", "")
+
+	assert.Equal(t, []string{"data/reports/", "logs/app/", "data/"}, recentLocations(recent.Get("alice")))
+	assert.Equal(t, []string{"logs/"}, recentLocations(recent.Get("bob")))
+
+	for _, key := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		recent.RecordFile("alice", "data", key, RecentDownload)
+	}
+	recent.RecordFile("alice", "data", "c.txt", RecentUpload)
+
+	// Only the newest size entries are kept
+	files := recent.Get("alice").Files
+	require.Len(t, files, 3)
+	assert.Equal(t, "c.txt", files[0].Key)
+	assert.Equal(t, RecentUpload, files[0].Action)
+	assert.Equal(t, "d.txt", files[1].Key)
+	assert.Equal(t, "b.txt", files[2].Key)
+
+	// Unknown users get empty lists rather than nulls
+	empty := recent.Get("carol")
+	assert.NotNil(t, empty.Locations)
+	assert.NotNil(t, empty.Files)
+
+	// History survives a restart once saved
+	require.NoError(t, recent.Save())
+	restored := newTestRecentService(3, stateFile)
+	assert.Equal(t, recent.Get("alice"), restored.Get("alice"))
+}
+
+func recentLocations(recent models.RecentResponse) []string {
+	locations := make([]string, 0, len(recent.Locations))
+	for _, location := range recent.Locations {
+		locations = append(locations, location.Bucket+"/"+location.Prefix)
+	}
+	return locations
+}
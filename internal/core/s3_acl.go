@@ -0,0 +1,200 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+var (
+	// ErrACLsDisabled is returned when changing an ACL in a bucket whose object
+	// ownership setting disables ACLs
+	ErrACLsDisabled = errors.New("ACLs are disabled for this bucket")
+	// ErrInvalidACL is returned for unknown canned ACLs, permissions or grantees
+	ErrInvalidACL = errors.New("invalid ACL")
+)
+
+// Predefined groups that make an object public when granted access
+const (
+	allUsersGroup           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsersGroup = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// GetObjectACL returns an object's ACL, with warnings for grants that make it public
+func (s *S3Service) GetObjectACL(ctx context.Context, bucket, key string) (*models.ObjectACL, error) {
+	acl, err := s.objectACL(ctx, bucket, key)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object ACL")
+		return nil, err
+	}
+	acl.ACLsEnabled = s.aclsEnabled(ctx, bucket)
+	return acl, nil
+}
+
+// objectACL reads an object's owner and grants, with warnings for grants that make it
+// public
+func (s *S3Service) objectACL(ctx context.Context, bucket, key string) (*models.ObjectACL, error) {
+	output, err := s.core.Client(bucket).GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	acl := &models.ObjectACL{
+		Grants:   make([]models.ACLGrant, 0, len(output.Grants)),
+		Warnings: []string{},
+	}
+	if output.Owner != nil {
+		acl.Owner = models.ACLGrantee{
+			Type:        string(s3Types.TypeCanonicalUser),
+			ID:          aws.ToString(output.Owner.ID),
+			DisplayName: aws.ToString(output.Owner.DisplayName),
+		}
+	}
+	for _, grant := range output.Grants {
+		if grant.Grantee == nil {
+			continue
+		}
+		acl.Grants = append(acl.Grants, models.ACLGrant{
+			Grantee: models.ACLGrantee{
+				Type:        string(grant.Grantee.Type),
+				ID:          aws.ToString(grant.Grantee.ID),
+				DisplayName: aws.ToString(grant.Grantee.DisplayName),
+				URI:         aws.ToString(grant.Grantee.URI),
+				Email:       aws.ToString(grant.Grantee.EmailAddress),
+			},
+			Permission: string(grant.Permission),
+		})
+	}
+	acl.Warnings = publicGrantWarnings(acl.Grants)
+	return acl, nil
+}
+
+// PutObjectACL replaces an object's ACL with a canned ACL or a list of grants, keeping
+// the owner, and returns the new ACL
+func (s *S3Service) PutObjectACL(ctx context.Context, bucket, key string, req models.PutObjectACLRequest) (*models.ObjectACL, error) {
+	if !s.aclsEnabled(ctx, bucket) {
+		return nil, ErrACLsDisabled
+	}
+
+	input := &s3.PutObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if req.CannedACL != "" {
+		canned := s3Types.ObjectCannedACL(req.CannedACL)
+		if !slices.Contains(canned.Values(), canned) {
+			return nil, fmt.Errorf("%w: unknown canned ACL %q", ErrInvalidACL, req.CannedACL)
+		}
+		input.ACL = canned
+	} else {
+		current, err := s.GetObjectACL(ctx, bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		grants, err := aclGrants(req.Grants)
+		if err != nil {
+			return nil, err
+		}
+		input.AccessControlPolicy = &s3Types.AccessControlPolicy{
+			Owner:  &s3Types.Owner{ID: aws.String(current.Owner.ID)},
+			Grants: grants,
+		}
+	}
+
+	if _, err := s.core.Client(bucket).PutObjectAcl(ctx, input); err != nil {
+		if isACLNotSupported(err) {
+			return nil, ErrACLsDisabled
+		}
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to put object ACL")
+		return nil, err
+	}
+
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("cannedAcl", req.CannedACL).
+		Int("grants", len(req.Grants)).
+		Msg("Changed object ACL")
+	return s.GetObjectACL(ctx, bucket, key)
+}
+
+// aclsEnabled reports whether a bucket's object ownership setting allows ACLs. Buckets
+// without ownership controls predate the setting and use ACLs; when the setting cannot
+// be read, ACLs are assumed to work and S3 has the final word.
+func (s *S3Service) aclsEnabled(ctx context.Context, bucket string) bool {
+	output, err := s.core.Client(bucket).GetBucketOwnershipControls(ctx, &s3.GetBucketOwnershipControlsInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil || output.OwnershipControls == nil {
+		return true
+	}
+	for _, rule := range output.OwnershipControls.Rules {
+		if rule.ObjectOwnership == s3Types.ObjectOwnershipBucketOwnerEnforced {
+			return false
+		}
+	}
+	return true
+}
+
+// aclGrants converts requested grants, telling the grantee type from the field set
+func aclGrants(requested []models.ACLGrant) ([]s3Types.Grant, error) {
+	grants := make([]s3Types.Grant, 0, len(requested))
+	for _, grant := range requested {
+		permission := s3Types.Permission(grant.Permission)
+		// WRITE has no meaning on objects
+		if permission == s3Types.PermissionWrite || !slices.Contains(permission.Values(), permission) {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidACL, grant.Permission)
+		}
+
+		grantee := &s3Types.Grantee{}
+		switch {
+		case grant.Grantee.ID != "":
+			grantee.Type = s3Types.TypeCanonicalUser
+			grantee.ID = aws.String(grant.Grantee.ID)
+		case grant.Grantee.URI != "":
+			grantee.Type = s3Types.TypeGroup
+			grantee.URI = aws.String(grant.Grantee.URI)
+		case grant.Grantee.Email != "":
+			grantee.Type = s3Types.TypeAmazonCustomerByEmail
+			grantee.EmailAddress = aws.String(grant.Grantee.Email)
+		default:
+			return nil, fmt.Errorf("%w: grantee needs an id, uri or email", ErrInvalidACL)
+		}
+		grants = append(grants, s3Types.Grant{Grantee: grantee, Permission: permission})
+	}
+	return grants, nil
+}
+
+// publicGrantWarnings describes grants to everyone or to every AWS account
+func publicGrantWarnings(grants []models.ACLGrant) []string {
+	warnings := []string{}
+	for _, grant := range grants {
+		if isPublicGroup(grant.Grantee.URI) {
+			warnings = append(warnings, fmt.Sprintf("%s is granted to %s", grant.Permission, groupName(grant.Grantee.URI)))
+		}
+	}
+	return warnings
+}
+
+// isACLNotSupported reports whether S3 refused an ACL because the bucket disables them
+func isACLNotSupported(err error) bool {
+	return hasErrorCode(err, "AccessControlListNotSupported")
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestACLCore(t *testing.T, ownership string) *Core {
+	return newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		switch {
+		case r.URL.Query().Has("ownershipControls"):
+			fmt.Fprintf(w, `<OwnershipControls><Rule><ObjectOwnership>%s</ObjectOwnership></Rule></OwnershipControls>`, ownership)
+		case r.URL.Query().Has("acl") && r.Method == http.MethodGet:
+			fmt.Fprint(w, `<AccessControlPolicy><Owner><ID>owner-id</ID><DisplayName>ann</DisplayName></Owner><AccessControlList>`+
+				`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>owner-id</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant>`+
+				`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ</Permission></Grant>`+
+				`</AccessControlList></AccessControlPolicy>`)
+		case r.URL.Query().Has("acl"):
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Length", "3")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestGetObjectACL(t *testing.T) {
+	c := newTestACLCore(t, "ObjectWriter")
+
+	acl, err := c.S3Service.GetObjectACL(context.Background(), "data", "a.txt")
+	require.NoError(t, err)
+	assert.True(t, acl.ACLsEnabled)
+	assert.Equal(t, "owner-id", acl.Owner.ID)
+	require.Len(t, acl.Grants, 2)
+	assert.Equal(t, "Group", acl.Grants[1].Grantee.Type)
+	assert.Equal(t, []string{"READ is granted to everyone on the internet"}, acl.Warnings)
+}
+
+func TestObjectMetadataGrants(t *testing.T) {
+	c := newTestACLCore(t, "ObjectWriter")
+
+	metadata, err := c.S3Service.GetObjectMetadata(context.Background(), "data", "a.txt", nil)
+	require.NoError(t, err)
+	require.Len(t, metadata.Grants, 2)
+	assert.Equal(t, "FULL_CONTROL", metadata.Grants[0].Permission)
+	assert.True(t, metadata.Public)
+}
+
+func TestPutObjectACL(t *testing.T) {
+	ctx := context.Background()
+	c := newTestACLCore(t, "BucketOwnerPreferred")
+
+	_, err := c.S3Service.PutObjectACL(ctx, "data", "a.txt", models.PutObjectACLRequest{CannedACL: "public-read"})
+	require.NoError(t, err)
+
+	_, err = c.S3Service.PutObjectACL(ctx, "data", "a.txt", models.PutObjectACLRequest{CannedACL: "world-writable"})
+	assert.ErrorIs(t, err, ErrInvalidACL)
+
+	_, err = c.S3Service.PutObjectACL(ctx, "data", "a.txt", models.PutObjectACLRequest{Grants: []models.ACLGrant{
+		{Grantee: models.ACLGrantee{URI: allUsersGroup}, Permission: "WRITE"},
+	}})
+	assert.ErrorIs(t, err, ErrInvalidACL)
+
+	_, err = c.S3Service.PutObjectACL(ctx, "data", "a.txt", models.PutObjectACLRequest{Grants: []models.ACLGrant{
+		{Grantee: models.ACLGrantee{Email: "bob@example.com"}, Permission: "READ"},
+	}})
+	require.NoError(t, err)
+
+	c = newTestACLCore(t, "BucketOwnerEnforced")
+	_, err = c.S3Service.PutObjectACL(ctx, "data", "a.txt", models.PutObjectACLRequest{CannedACL: "private"})
+	assert.ErrorIs(t, err, ErrACLsDisabled)
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// maxArchiveEntries caps the number of entries returned for a single archive
+	maxArchiveEntries = 10000
+	// maxTarScanBytes bounds how much compressed data is streamed to list a tarball
+	maxTarScanBytes = 512 * 1024 * 1024
+)
+
+var (
+	// ErrArchiveNotSupported is returned for objects that are not a supported archive format
+	ErrArchiveNotSupported = errors.New("archive format not supported")
+	// ErrInvalidArchive is returned when an archive cannot be parsed
+	ErrInvalidArchive = errors.New("invalid archive")
+)
+
+// archiveFormat returns the archive format implied by a key's extension
+func archiveFormat(key string) string {
+	lower := strings.ToLower(key)
+	switch {
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".jar"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	default:
+		return ""
+	}
+}
+
+// ListArchiveEntries lists the files inside a zip or tar archive object. Zip files are
+// read through ranged requests for their central directory only; tarballs have to be
+// streamed, but entry contents are skipped rather than buffered.
+func (s *S3Service) ListArchiveEntries(ctx context.Context, bucket, key string) (*models.ArchiveListing, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Listing archive entries")
+
+	format := archiveFormat(key)
+	if format == "" {
+		return nil, ErrArchiveNotSupported
+	}
+
+	var (
+		listing *models.ArchiveListing
+		err     error
+	)
+	if format == "zip" {
+		listing, err = s.listZipEntries(ctx, bucket, key)
+	} else {
+		listing, err = s.listTarEntries(ctx, bucket, key, format == "tar.gz")
+	}
+	if err != nil {
+		if !errors.Is(err, ErrInvalidArchive) {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", key).
+				Msg("Failed to list archive entries")
+		}
+		return nil, err
+	}
+
+	listing.Key = key
+	listing.Format = format
+	listing.EntryCount = len(listing.Entries)
+	return listing, nil
+}
+
+// listZipEntries reads a zip file's central directory through ranged reads
+func (s *S3Service) listZipEntries(ctx context.Context, bucket, key string) (*models.ArchiveListing, error) {
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	readerAt := newObjectReaderAt(ctx, s.core.Client(bucket), bucket, key, aws.ToInt64(head.ContentLength))
+	zipReader, err := zip.NewReader(readerAt, readerAt.Size())
+	if err != nil {
+		if errors.Is(err, zip.ErrFormat) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrInvalidArchive
+		}
+		return nil, err
+	}
+
+	listing := &models.ArchiveListing{
+		Entries: make([]models.ArchiveEntry, 0, min(len(zipReader.File), maxArchiveEntries)),
+	}
+	for _, file := range zipReader.File {
+		if len(listing.Entries) == maxArchiveEntries {
+			listing.Truncated = true
+			break
+		}
+
+		listing.Entries = append(listing.Entries, models.ArchiveEntry{
+			Name:           file.Name,
+			Size:           int64(file.UncompressedSize64),
+			CompressedSize: int64(file.CompressedSize64),
+			Modified:       file.Modified,
+			IsDir:          file.FileInfo().IsDir(),
+		})
+	}
+
+	return listing, nil
+}
+
+// listTarEntries streams a tarball and collects its entry headers
+func (s *S3Service) listTarEntries(ctx context.Context, bucket, key string, gzipped bool) (*models.ArchiveListing, error) {
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	var reader io.Reader = io.LimitReader(output.Body, maxTarScanBytes)
+	if gzipped {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, ErrInvalidArchive
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	listing := &models.ArchiveListing{
+		Entries: make([]models.ArchiveEntry, 0),
+	}
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Hitting the scan limit part-way through is reported as a truncated listing
+			if len(listing.Entries) > 0 && errors.Is(err, io.ErrUnexpectedEOF) {
+				listing.Truncated = true
+				break
+			}
+			return nil, ErrInvalidArchive
+		}
+
+		if len(listing.Entries) == maxArchiveEntries {
+			listing.Truncated = true
+			break
+		}
+
+		listing.Entries = append(listing.Entries, models.ArchiveEntry{
+			Name:     header.Name,
+			Size:     header.Size,
+			Modified: header.ModTime,
+			IsDir:    header.Typeflag == tar.TypeDir,
+		})
+	}
+
+	return listing, nil
+}
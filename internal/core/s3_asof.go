@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+// maxAsOfPages bounds the pages of versions read for one page of a listing as of a past
+// time, so a prefix with a long history answers in time; the listing then continues
+// from a cursor, with fewer entries in the page than asked for
+const maxAsOfPages = 10
+
+// afterFolder sorts after every key under a folder, so a version listing can skip the
+// rest of a folder already listed
+const afterFolder = "\U0010FFFF"
+
+// ListObjectsAsOf lists the objects and folders under a prefix as they were at a past
+// time, in the shape of ListObjects. Each key is listed with its newest version
+// modified at or before asOf, unless that version is a delete marker; keys created
+// later are left out. Folders are those holding at least one such key. The versions
+// are walked in key order, so a page costs as many version pages as the history of its
+// keys takes, up to maxAsOfPages.
+func (s *S3Service) ListObjectsAsOf(ctx context.Context, bucket, prefix, cursor, delimiter string, asOf time.Time, maxKeys int32) (*models.ListObjectsResponse, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Str("cursor", cursor).
+		Time("asOf", asOf).
+		Msg("Listing objects as of a past time")
+
+	if delimiter == "" {
+		delimiter = "/"
+	}
+	maxPageSize := int32(s.core.Config.Listing.MaxPageSize)
+	if maxKeys <= 0 {
+		maxKeys = int32(s.core.Config.Listing.DefaultPageSize)
+	}
+	maxKeys = min(maxKeys, maxPageSize)
+
+	var folders, files []models.ObjectInfo
+	// current is the key whose versions are being read, decided once its version at
+	// asOf was found; lastFolder is the folder listed last, whose keys are skipped
+	current, lastFolder := "", ""
+	decided := false
+	// after returns the cursor continuing past a key, and past the folder listed last
+	// when the key is in it
+	after := func(key string) string {
+		if lastFolder != "" && strings.HasPrefix(key, lastFolder) {
+			key = lastFolder + afterFolder
+		}
+		marker, _ := json.Marshal(versionsCursor{KeyMarker: key})
+		return EncodeCursor(string(marker))
+	}
+
+	next := cursor
+	resume := ""
+scan:
+	for pages := 0; ; pages++ {
+		if pages == maxAsOfPages {
+			// Versions of an undecided key were all newer than asOf, so the listing can
+			// continue within the key
+			resume = next
+			if decided {
+				resume = after(current)
+			}
+			break
+		}
+		page, err := s.ListObjectVersions(ctx, bucket, prefix, "", next, maxPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range page.Versions {
+			if version.Key != current {
+				inFolder := lastFolder != "" && strings.HasPrefix(version.Key, lastFolder)
+				if current != "" && !inFolder && len(folders)+len(files) >= int(maxKeys) {
+					// The keys before this one are all read and the page is full
+					resume = after(current)
+					break scan
+				}
+				current, decided = version.Key, inFolder
+			}
+			if decided || version.LastModified.After(asOf) {
+				continue
+			}
+			decided = true
+			if version.DeleteMarker || version.Key == prefix {
+				continue
+			}
+
+			if i := strings.Index(version.Key[len(prefix):], delimiter); i >= 0 {
+				lastFolder = version.Key[:len(prefix)+i+len(delimiter)]
+				folders = append(folders, models.ObjectInfo{Key: lastFolder, IsFolder: true, Type: "folder"})
+				continue
+			}
+			contentType := ""
+			if !strings.HasSuffix(version.Key, "/") {
+				contentType = detectContentType(version.Key)
+			}
+			files = append(files, models.ObjectInfo{
+				Key:          version.Key,
+				VersionID:    version.VersionID,
+				Type:         "file",
+				Size:         version.Size,
+				ContentType:  contentType,
+				LastModified: version.LastModified,
+				StorageClass: version.StorageClass,
+				ETag:         version.ETag,
+			})
+		}
+
+		if !page.Pagination.HasMore || page.Pagination.Cursor == "" {
+			break
+		}
+		next = page.Pagination.Cursor
+		if decided && lastFolder != "" && strings.HasPrefix(current, lastFolder) {
+			// Skip the rest of a folder already listed
+			next = after(current)
+		}
+	}
+
+	response := &models.ListObjectsResponse{
+		Objects: slices.Concat(folders, files),
+		Pagination: models.Pagination{
+			Cursor:      resume,
+			HasMore:     resume != "",
+			PageSize:    int(maxKeys),
+			MaxPageSize: int(maxPageSize),
+		},
+	}
+	if response.Objects == nil {
+		response.Objects = []models.ObjectInfo{}
+	}
+	response.ItemsInPage = len(response.Objects)
+	if cursor == "" && resume == "" {
+		total := int64(response.ItemsInPage)
+		response.Pagination.TotalEstimate = &total
+	}
+	return response, nil
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListObjectsAsOf(t *testing.T) {
+	// The history of the bucket, in S3's order: by key, newest version first
+	history := []struct {
+		key, id, modified string
+		deleteMarker      bool
+	}{
+		{"docs/a.txt", "a2", "2024-04-01", false},
+		{"docs/a.txt", "a1", "2024-01-01", false},
+		{"docs/b.txt", "b2", "2024-02-01", true},
+		{"docs/b.txt", "b1", "2024-01-01", false},
+		{"docs/c.txt", "c1", "2024-05-01", false},
+		{"docs/old/x.txt", "x1", "2024-01-01", false},
+		{"docs/old/y.txt", "y1", "2024-01-01", false},
+		{"docs/z.txt", "z2", "2024-04-01", true},
+		{"docs/z.txt", "z1", "2024-01-15", false},
+	}
+	var markers []string
+	c := newTestS3Core(t, &config.Config{Listing: config.ListingConfig{DefaultPageSize: 1, MaxPageSize: 2}}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		markers = append(markers, query.Get("key-marker"))
+		maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
+
+		// Continue after the marker key, or after the marker version within it
+		marker, versionMarker := query.Get("key-marker"), query.Get("version-id-marker")
+		start := 0
+		for start < len(history) && (history[start].key < marker || (history[start].key == marker && versionMarker == "")) {
+			start++
+		}
+		for versionMarker != "" && start < len(history) && history[start].key == marker {
+			start++
+			if history[start-1].id == versionMarker {
+				break
+			}
+		}
+		end := min(start+maxKeys, len(history))
+
+		var body strings.Builder
+		for _, version := range history[start:end] {
+			element := "Version"
+			if version.deleteMarker {
+				element = "DeleteMarker"
+			}
+			fmt.Fprintf(&body, `<%[1]s><Key>%[2]s</Key><VersionId>%[3]s</VersionId><LastModified>%[4]sT00:00:00Z</LastModified>`+
+				`<Size>1</Size></%[1]s>`, element, version.key, version.id, version.modified)
+		}
+		if end < len(history) {
+			fmt.Fprintf(&body, `<NextKeyMarker>%s</NextKeyMarker><NextVersionIdMarker>%s</NextVersionIdMarker>`,
+				history[end-1].key, history[end-1].id)
+		}
+		fmt.Fprintf(w, `<ListVersionsResult><IsTruncated>%t</IsTruncated>%s</ListVersionsResult>`, end < len(history), body.String())
+	})
+	ctx := context.Background()
+	asOf := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	var listed []models.ObjectInfo
+	cursor := ""
+	for range 5 {
+		page, err := c.S3Service.ListObjectsAsOf(ctx, "docs", "docs/", cursor, "", asOf, 0)
+		require.NoError(t, err)
+		listed = append(listed, page.Objects...)
+		if cursor = page.Pagination.Cursor; !page.Pagination.HasMore {
+			break
+		}
+	}
+	require.Empty(t, cursor)
+
+	// b.txt was deleted and c.txt not yet created; z.txt was deleted later
+	require.Len(t, listed, 3)
+	assert.Equal(t, "docs/a.txt", listed[0].Key)
+	assert.Equal(t, "a1", listed[0].VersionID)
+	assert.Equal(t, "docs/old/", listed[1].Key)
+	assert.True(t, listed[1].IsFolder)
+	assert.Equal(t, "docs/z.txt", listed[2].Key)
+	assert.Equal(t, "z1", listed[2].VersionID)
+	assert.Equal(t, "2024-01-15T00:00:00Z", listed[2].LastModified.Format(time.RFC3339))
+
+	// The rest of a folder already listed is skipped
+	assert.Contains(t, markers, "docs/old/"+afterFolder)
+
+	page, err := c.S3Service.ListObjectsAsOf(ctx, "docs", "docs/", "", "", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 10)
+	require.NoError(t, err)
+	assert.Empty(t, page.Objects)
+	assert.False(t, page.Pagination.HasMore)
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+// prefixSize is the size of a prefix as counted by a report
+type prefixSize struct {
+	objects   int64
+	bytes     int64
+	countedAt time.Time
+}
+
+// GetBreadcrumbs returns each segment of prefix, from the bucket down, with the folders
+// and objects directly inside it and, when a recent report counted it, its total size
+func (s *S3Service) GetBreadcrumbs(ctx context.Context, bucket, prefix string) (*models.BreadcrumbsResponse, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Msg("Counting breadcrumbs")
+
+	if prefix = strings.TrimPrefix(prefix, "/"); prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	// The segments are counted like one level of a folder tree
+	nodes := []*models.TreeNode{{Prefix: "", Name: bucket}}
+	for i, r := range prefix {
+		if r == '/' {
+			segment := prefix[:i+1]
+			nodes = append(nodes, &models.TreeNode{
+				Prefix: segment,
+				Name:   path.Base(segment[:i]),
+			})
+		}
+	}
+	if _, err := s.listTreeLevel(ctx, bucket, nodes, false); err != nil {
+		return nil, err
+	}
+
+	response := &models.BreadcrumbsResponse{
+		Bucket:      bucket,
+		Prefix:      prefix,
+		Breadcrumbs: make([]models.Breadcrumb, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		crumb := models.Breadcrumb{
+			Prefix:    node.Prefix,
+			Name:      node.Name,
+			Folders:   node.Folders,
+			Objects:   node.Objects,
+			Truncated: node.Truncated,
+		}
+		if size, ok := s.cachedSize(bucket, node.Prefix); ok {
+			crumb.Size = &models.PrefixSize{Objects: size.objects, Bytes: size.bytes, CountedAt: size.countedAt}
+		}
+		response.Breadcrumbs = append(response.Breadcrumbs, crumb)
+	}
+	return response, nil
+}
+
+// cacheSize records the size of a prefix counted in full by a report. Prefixes that do
+// not end at a folder boundary are skipped, as they also count sibling folders. The size
+// of a whole bucket is also added to its usage history.
+func (s *S3Service) cacheSize(bucket, prefix string, objects, bytes int64) {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		return
+	}
+	if prefix == "" && s.core.Usage != nil {
+		s.core.Usage.Record(bucket, objects, bytes)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sizes[bucket+"/"+prefix] = prefixSize{objects: objects, bytes: bytes, countedAt: time.Now().UTC()}
+}
+
+// cachedSize returns the size of a prefix if it was counted within reports.sizeCacheTtl
+func (s *S3Service) cachedSize(bucket, prefix string) (prefixSize, bool) {
+	key := bucket + "/" + prefix
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	size, ok := s.sizes[key]
+	if !ok {
+		return prefixSize{}, false
+	}
+	if time.Since(size.countedAt) > s.core.Config.Reports.SizeCacheTTL {
+		delete(s.sizes, key)
+		return prefixSize{}, false
+	}
+	return size, true
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBreadcrumbs(t *testing.T) {
+	c := newTestTreeCore(t)
+	c.Config.Reports.SizeCacheTTL = time.Hour
+	c.Config.Reports.MaxObjects = 100
+	ctx := context.Background()
+
+	_, err := c.S3Service.GetStorageReport(ctx, "files", "a/x/")
+	require.NoError(t, err)
+
+	resp, err := c.S3Service.GetBreadcrumbs(ctx, "files", "/a/x")
+	require.NoError(t, err)
+	assert.Equal(t, "a/x/", resp.Prefix)
+	require.Len(t, resp.Breadcrumbs, 3)
+
+	root, a, x := resp.Breadcrumbs[0], resp.Breadcrumbs[1], resp.Breadcrumbs[2]
+	assert.Equal(t, "files", root.Name)
+	assert.Equal(t, 2, root.Folders)
+	assert.Nil(t, root.Size)
+	assert.Equal(t, "a", a.Name)
+	assert.Equal(t, "a/", a.Prefix)
+	assert.Equal(t, 2, a.Objects)
+	assert.Equal(t, "x", x.Name)
+	assert.Equal(t, 1, x.Objects)
+	require.NotNil(t, x.Size)
+	assert.Equal(t, int64(1), x.Size.Objects)
+}
+
+func TestCachedSize(t *testing.T) {
+	c := newTestTreeCore(t)
+	c.Config.Reports.SizeCacheTTL = time.Minute
+
+	c.S3Service.cacheSize("files", "a", 1, 10)
+	_, ok := c.S3Service.cachedSize("files", "a")
+	assert.False(t, ok, "partial prefixes are not cached")
+
+	c.S3Service.cacheSize("files", "a/", 1, 10)
+	size, ok := c.S3Service.cachedSize("files", "a/")
+	require.True(t, ok)
+	assert.Equal(t, int64(10), size.bytes)
+
+	c.S3Service.sizes["files/a/"] = prefixSize{countedAt: time.Now().Add(-2 * time.Minute)}
+	_, ok = c.S3Service.cachedSize("files", "a/")
+	assert.False(t, ok)
+}
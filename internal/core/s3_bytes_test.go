@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetObjectBytes(t *testing.T) {
+	content := "%PDF-1.7\n%\xe2\xe3\xcf\xd3\nendobj"
+	var requested string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		requested = r.Header.Get("Range")
+		var start, end int
+		fmt.Sscanf(requested, "bytes=%d-%d", &start, &end)
+		if start >= len(content) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			fmt.Fprint(w, `<Error><Code>InvalidRange</Code></Error>`)
+			return
+		}
+		end = min(end, len(content)-1)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, content[start:end+1])
+	})
+	ctx := context.Background()
+
+	chunk, err := c.S3Service.GetObjectBytes(ctx, "docs", "a.pdf", "", 0, 8, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "bytes=0-7", requested)
+	assert.Equal(t, int64(len(content)), chunk.Size)
+	assert.Equal(t, int64(8), chunk.Length)
+	assert.Equal(t, BytesModeHex, chunk.Mode)
+	assert.Equal(t, "application/pdf", chunk.DetectedType)
+	assert.Equal(t, "00000000  25 50 44 46 2d 31 2e 37                           |%PDF-1.7|\n", chunk.Content)
+
+	// Ranges past the end return what there is, and text mode replaces invalid UTF-8
+	chunk, err = c.S3Service.GetObjectBytes(ctx, "docs", "a.pdf", "", 9, 100, BytesModeText, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)-9), chunk.Length)
+	assert.Empty(t, chunk.DetectedType)
+	assert.Equal(t, "%\uFFFD\nendobj", chunk.Content)
+
+	_, err = c.S3Service.GetObjectBytes(ctx, "docs", "a.pdf", "", 100, 8, "", nil)
+	assert.True(t, hasErrorCode(err, "InvalidRange"))
+
+	_, err = c.S3Service.GetObjectBytes(ctx, "docs", "a.pdf", "", 0, 8, "base64", nil)
+	assert.ErrorIs(t, err, ErrInvalidBytesMode)
+}
+
+func TestHexDump(t *testing.T) {
+	data := []byte("0123456789abcdef\x00\x01")
+	assert.Equal(t,
+		"00000100  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n"+
+			"00000110  00 01                                             |..|\n",
+		hexDump(data, 256))
+}
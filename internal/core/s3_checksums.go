@@ -0,0 +1,310 @@
+package core
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Checksum algorithms an object can be verified with
+const (
+	ChecksumCRC32  = "crc32"
+	ChecksumCRC32C = "crc32c"
+	ChecksumSHA1   = "sha1"
+	ChecksumSHA256 = "sha256"
+	// ChecksumMD5 is compared with the ETag, which is the MD5 of objects that are not
+	// encrypted with SSE-KMS or SSE-C
+	ChecksumMD5 = "md5"
+)
+
+// maxAttributeParts is the most parts GetObjectAttributes lists per request
+const maxAttributeParts = 1000
+
+// ErrInvalidChecksumAlgorithm is returned for algorithms objects cannot be verified with
+var ErrInvalidChecksumAlgorithm = errors.New("algorithm must be crc32, crc32c, sha1, sha256 or md5")
+
+// GetObjectChecksums returns the checksums S3 stores for an object, or one version of
+// it. When verify names an algorithm, the object is also read on the server and hashed
+// with it, so its integrity can be checked without downloading it. Multipart uploads
+// are hashed part by part where S3 stored a checksum of the parts' checksums; md5 is
+// compared with the ETag the same way. The read fails with ErrETagMismatch should the
+// object be overwritten meanwhile.
+func (s *S3Service) GetObjectChecksums(ctx context.Context, bucket, key, versionID, verify string, customerKey *CustomerKey) (*models.ObjectChecksums, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("verify", verify).
+		Msg("Getting object checksums")
+
+	if verify != "" {
+		if _, ok := newChecksumHash(verify); !ok {
+			return nil, ErrInvalidChecksumAlgorithm
+		}
+	}
+
+	checksums, parts, err := s.objectAttributes(ctx, bucket, key, versionID, customerKey)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object attributes")
+		return nil, err
+	}
+	if verify == "" {
+		return checksums, nil
+	}
+
+	verification, err := s.verifyChecksum(ctx, bucket, key, versionID, verify, checksums, parts, customerKey)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return nil, ErrETagMismatch
+		}
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to verify object checksum")
+		return nil, err
+	}
+	checksums.Verification = verification
+
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("algorithm", verify).
+		Bool("match", aws.ToBool(verification.Match)).
+		Int64("bytes", verification.BytesRead).
+		Msg("Object checksum verified")
+
+	return checksums, nil
+}
+
+// objectAttributes reads an object's checksums with GetObjectAttributes, along with
+// every part S3 lists for it
+func (s *S3Service) objectAttributes(ctx context.Context, bucket, key, versionID string, customerKey *CustomerKey) (*models.ObjectChecksums, []s3Types.ObjectPart, error) {
+	input := &s3.GetObjectAttributesInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		ObjectAttributes: []s3Types.ObjectAttributes{
+			s3Types.ObjectAttributesEtag,
+			s3Types.ObjectAttributesChecksum,
+			s3Types.ObjectAttributesObjectParts,
+			s3Types.ObjectAttributesObjectSize,
+		},
+		MaxParts: aws.Int32(maxAttributeParts),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = customerKey.params()
+
+	output, err := s.core.Client(bucket).GetObjectAttributes(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	checksums := &models.ObjectChecksums{
+		Key:       key,
+		VersionID: aws.ToString(output.VersionId),
+		ETag:      `"` + normalizeETag(aws.ToString(output.ETag)) + `"`,
+		Size:      aws.ToInt64(output.ObjectSize),
+	}
+	if checksum := output.Checksum; checksum != nil {
+		checksums.Type = string(checksum.ChecksumType)
+		checksums.CRC32 = aws.ToString(checksum.ChecksumCRC32)
+		checksums.CRC32C = aws.ToString(checksum.ChecksumCRC32C)
+		checksums.CRC64NVME = aws.ToString(checksum.ChecksumCRC64NVME)
+		checksums.SHA1 = aws.ToString(checksum.ChecksumSHA1)
+		checksums.SHA256 = aws.ToString(checksum.ChecksumSHA256)
+	}
+
+	var parts []s3Types.ObjectPart
+	for output.ObjectParts != nil {
+		checksums.PartCount = int(aws.ToInt32(output.ObjectParts.TotalPartsCount))
+		parts = append(parts, output.ObjectParts.Parts...)
+		if !aws.ToBool(output.ObjectParts.IsTruncated) {
+			break
+		}
+		input.PartNumberMarker = output.ObjectParts.NextPartNumberMarker
+		if output, err = s.core.Client(bucket).GetObjectAttributes(ctx, input); err != nil {
+			return nil, nil, err
+		}
+	}
+	return checksums, parts, nil
+}
+
+// verifyChecksum reads an object and hashes it with an algorithm. Composite checksums
+// and multipart ETags are the hash of the parts' hashes followed by the part count, so
+// for those the object is hashed part by part.
+func (s *S3Service) verifyChecksum(ctx context.Context, bucket, key, versionID, algorithm string, checksums *models.ObjectChecksums, parts []s3Types.ObjectPart, customerKey *CustomerKey) (*models.ChecksumVerification, error) {
+	verification := &models.ChecksumVerification{
+		Algorithm: algorithm,
+		Stored:    storedChecksum(checksums, algorithm),
+	}
+
+	var sizes []int64
+	composite := checksums.PartCount > 0 && verification.Stored != "" &&
+		(algorithm == ChecksumMD5 || checksums.Type != string(s3Types.ChecksumTypeFullObject))
+	if composite {
+		sizes = s.partSizes(ctx, bucket, key, versionID, checksums, parts, customerKey)
+		if sizes == nil {
+			verification.Reason = "The part sizes of the multipart upload are unknown, so the whole object was hashed"
+		}
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		IfMatch: aws.String(checksums.ETag),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = customerKey.params()
+
+	output, err := s.core.Client(bucket).GetObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	if algorithm == ChecksumMD5 && (strings.HasPrefix(string(output.ServerSideEncryption), "aws:kms") || output.SSECustomerAlgorithm != nil) {
+		verification.Stored = ""
+		verification.Reason = "The ETag of an object encrypted with SSE-KMS or SSE-C is not an MD5"
+	}
+
+	digest, _ := newChecksumHash(algorithm)
+	if sizes == nil {
+		verification.BytesRead, err = io.Copy(digest, output.Body)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, size := range sizes {
+			part, _ := newChecksumHash(algorithm)
+			n, err := io.CopyN(part, output.Body, size)
+			verification.BytesRead += n
+			if err != nil {
+				return nil, err
+			}
+			digest.Write(part.Sum(nil))
+		}
+	}
+
+	if algorithm == ChecksumMD5 {
+		verification.Computed = hex.EncodeToString(digest.Sum(nil))
+	} else {
+		verification.Computed = base64.StdEncoding.EncodeToString(digest.Sum(nil))
+	}
+	if sizes != nil {
+		verification.Computed += fmt.Sprintf("-%d", len(sizes))
+	}
+	if algorithm == ChecksumMD5 {
+		verification.Computed = `"` + verification.Computed + `"`
+	}
+
+	switch {
+	case verification.Stored != "" && verification.Reason == "":
+		match := sameChecksum(verification.Stored, verification.Computed)
+		verification.Match = &match
+	case verification.Reason == "":
+		verification.Reason = fmt.Sprintf("The object has no stored %s checksum", algorithm)
+	}
+	return verification, nil
+}
+
+// partSizes returns the size of every part of a multipart upload: those listed by
+// GetObjectAttributes, which only lists parts uploaded with checksums, or else those
+// of a fixed part size read from the first part. It returns nil when neither matches
+// the part count.
+func (s *S3Service) partSizes(ctx context.Context, bucket, key, versionID string, checksums *models.ObjectChecksums, parts []s3Types.ObjectPart, customerKey *CustomerKey) []int64 {
+	if len(parts) == checksums.PartCount {
+		sizes := make([]int64, 0, len(parts))
+		for _, part := range parts {
+			sizes = append(sizes, aws.ToInt64(part.Size))
+		}
+		return sizes
+	}
+
+	input := &s3.HeadObjectInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		PartNumber: aws.Int32(1),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = customerKey.params()
+
+	output, err := s.core.Client(bucket).HeadObject(ctx, input)
+	if err != nil {
+		return nil
+	}
+	plan, err := ChunkingPlan(checksums.Size, aws.ToInt64(output.ContentLength))
+	if err != nil || len(plan) != checksums.PartCount {
+		return nil
+	}
+	sizes := make([]int64, 0, len(plan))
+	for _, part := range plan {
+		sizes = append(sizes, part.Size)
+	}
+	return sizes
+}
+
+// newChecksumHash returns a hash computing a checksum algorithm
+func newChecksumHash(algorithm string) (hash.Hash, bool) {
+	switch algorithm {
+	case ChecksumCRC32:
+		return crc32.NewIEEE(), true
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), true
+	case ChecksumSHA1:
+		return sha1.New(), true
+	case ChecksumSHA256:
+		return sha256.New(), true
+	case ChecksumMD5:
+		return md5.New(), true
+	}
+	return nil, false
+}
+
+// storedChecksum returns the checksum S3 stores for an object with an algorithm
+func storedChecksum(checksums *models.ObjectChecksums, algorithm string) string {
+	switch algorithm {
+	case ChecksumCRC32:
+		return checksums.CRC32
+	case ChecksumCRC32C:
+		return checksums.CRC32C
+	case ChecksumSHA1:
+		return checksums.SHA1
+	case ChecksumSHA256:
+		return checksums.SHA256
+	case ChecksumMD5:
+		return checksums.ETag
+	}
+	return ""
+}
+
+// sameChecksum compares a stored checksum with a computed one. S3 leaves the part
+// count off some composite checksums, so only the digests are compared.
+func sameChecksum(stored, computed string) bool {
+	stored, _, _ = strings.Cut(normalizeETag(stored), "-")
+	computed, _, _ = strings.Cut(normalizeETag(computed), "-")
+	return stored == computed
+}
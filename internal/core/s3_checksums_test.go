@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetObjectChecksums(t *testing.T) {
+	single := "hello world"
+	sha := sha256.Sum256([]byte(single))
+	etag := md5.Sum([]byte(single))
+
+	// A two-part upload with CRC32 checksums: the checksum of the parts' checksums
+	multi := "hello world"
+	crc := func(s string) []byte {
+		return binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE([]byte(s)))
+	}
+	composite := base64.StdEncoding.EncodeToString(crc(string(append(crc(multi[:6]), crc(multi[6:])...))))
+
+	var ifMatch string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("attributes") && r.URL.Path == "/docs/single.txt":
+			fmt.Fprintf(w, `<GetObjectAttributesResponse><ETag>%s</ETag><Checksum><ChecksumSHA256>%s</ChecksumSHA256>`+
+				`<ChecksumType>FULL_OBJECT</ChecksumType></Checksum><ObjectSize>%d</ObjectSize></GetObjectAttributesResponse>`,
+				hex.EncodeToString(etag[:]), base64.StdEncoding.EncodeToString(sha[:]), len(single))
+		case r.URL.Query().Has("attributes") && r.URL.Path == "/docs/multi.bin":
+			fmt.Fprintf(w, `<GetObjectAttributesResponse><ETag>abc-2</ETag><Checksum><ChecksumCRC32>%s-2</ChecksumCRC32>`+
+				`<ChecksumType>COMPOSITE</ChecksumType></Checksum><ObjectParts><PartsCount>2</PartsCount>`+
+				`<IsTruncated>false</IsTruncated><Part><PartNumber>1</PartNumber><Size>6</Size></Part>`+
+				`<Part><PartNumber>2</PartNumber><Size>5</Size></Part></ObjectParts><ObjectSize>%d</ObjectSize>`+
+				`</GetObjectAttributesResponse>`, composite, len(multi))
+		case r.URL.Query().Has("attributes"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+		default:
+			ifMatch = r.Header.Get("If-Match")
+			fmt.Fprint(w, single)
+		}
+	})
+	ctx := context.Background()
+
+	checksums, err := c.S3Service.GetObjectChecksums(ctx, "docs", "single.txt", "", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `"`+hex.EncodeToString(etag[:])+`"`, checksums.ETag)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(sha[:]), checksums.SHA256)
+	assert.Equal(t, "FULL_OBJECT", checksums.Type)
+	assert.Nil(t, checksums.Verification)
+
+	checksums, err = c.S3Service.GetObjectChecksums(ctx, "docs", "single.txt", "", ChecksumSHA256, nil)
+	require.NoError(t, err)
+	require.NotNil(t, checksums.Verification)
+	assert.Equal(t, checksums.ETag, ifMatch)
+	assert.Equal(t, int64(len(single)), checksums.Verification.BytesRead)
+	assert.True(t, *checksums.Verification.Match)
+
+	// The ETag of an object uploaded in one part is its MD5
+	checksums, err = c.S3Service.GetObjectChecksums(ctx, "docs", "single.txt", "", ChecksumMD5, nil)
+	require.NoError(t, err)
+	assert.True(t, *checksums.Verification.Match)
+
+	// Without a stored checksum there is nothing to compare with
+	checksums, err = c.S3Service.GetObjectChecksums(ctx, "docs", "single.txt", "", ChecksumCRC32C, nil)
+	require.NoError(t, err)
+	assert.Nil(t, checksums.Verification.Match)
+	assert.Equal(t, "The object has no stored crc32c checksum", checksums.Verification.Reason)
+
+	// Composite checksums are recomputed part by part
+	checksums, err = c.S3Service.GetObjectChecksums(ctx, "docs", "multi.bin", "", ChecksumCRC32, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, checksums.PartCount)
+	assert.Equal(t, composite+"-2", checksums.Verification.Computed)
+	assert.True(t, *checksums.Verification.Match)
+
+	// A checksum the content does not have is reported as a mismatch
+	checksums, err = c.S3Service.GetObjectChecksums(ctx, "docs", "multi.bin", "", ChecksumMD5, nil)
+	require.NoError(t, err)
+	assert.False(t, *checksums.Verification.Match)
+
+	_, err = c.S3Service.GetObjectChecksums(ctx, "docs", "single.txt", "", "blake3", nil)
+	assert.ErrorIs(t, err, ErrInvalidChecksumAlgorithm)
+
+	_, err = c.S3Service.GetObjectChecksums(ctx, "docs", "missing.txt", "", "", nil)
+	assert.True(t, hasErrorCode(err, "NoSuchKey"))
+}
+
+func TestSameChecksum(t *testing.T) {
+	assert.True(t, sameChecksum("AAAA-3", "AAAA-3"))
+	assert.True(t, sameChecksum("AAAA", "AAAA-3"))
+	assert.True(t, sameChecksum(`"9b2c"`, "9b2c"))
+	assert.False(t, sameChecksum("AAAA", "AAAB"))
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// bucketSetting reads one configuration setting of a bucket, returning nil when it is
+// not configured
+type bucketSetting struct {
+	name        string
+	description string
+	read        func(ctx context.Context, client *s3.Client, bucket string) (any, error)
+}
+
+// bucketSettings are the settings compared between buckets, in response order
+var bucketSettings = []bucketSetting{
+	{"versioning", "versioning configuration", readVersioningSetting},
+	{"encryption", "default encryption", readEncryptionSetting},
+	{"policy", "bucket policy", readPolicySetting},
+	{"lifecycle", "lifecycle configuration", readLifecycleSetting},
+	{"tags", "bucket tags", readTagsSetting},
+}
+
+// settingValue is a setting as read from one bucket
+type settingValue struct {
+	value any
+	err   error
+}
+
+// CompareBuckets compares the versioning, default encryption, policy, lifecycle rules
+// and tags of two buckets. Settings that cannot be read are reported rather than
+// failing the comparison.
+func (s *S3Service) CompareBuckets(ctx context.Context, source, target string) (*models.BucketComparison, error) {
+	s.core.Logger.Debug().
+		Str("source", source).
+		Str("target", target).
+		Msg("Comparing buckets")
+
+	// Fail early on a missing or inaccessible bucket; the settings below only note errors
+	for _, bucket := range []string{source, target} {
+		if _, err := s.core.Client(bucket).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		wg           sync.WaitGroup
+		sourceValues []settingValue
+		targetValues []settingValue
+	)
+	wg.Add(2)
+	go func() { defer wg.Done(); sourceValues = s.readBucketSettings(ctx, source) }()
+	go func() { defer wg.Done(); targetValues = s.readBucketSettings(ctx, target) }()
+	wg.Wait()
+
+	comparison := &models.BucketComparison{
+		Source:      source,
+		Target:      target,
+		Identical:   true,
+		Settings:    make([]models.SettingComparison, 0, len(bucketSettings)),
+		GeneratedAt: time.Now().UTC(),
+	}
+	for i, setting := range bucketSettings {
+		result := models.SettingComparison{
+			Setting:     setting.name,
+			Source:      sourceValues[i].value,
+			Target:      targetValues[i].value,
+			Differences: []models.SettingDifference{},
+		}
+		if err := sourceValues[i].err; err != nil {
+			result.SourceError = unreadable(setting.description, err)
+		}
+		if err := targetValues[i].err; err != nil {
+			result.TargetError = unreadable(setting.description, err)
+		}
+		if result.SourceError == "" && result.TargetError == "" {
+			diffSetting(setting.name, result.Source, result.Target, &result.Differences)
+			result.Equal = len(result.Differences) == 0
+		}
+		comparison.Identical = comparison.Identical && result.Equal
+		comparison.Settings = append(comparison.Settings, result)
+	}
+	return comparison, nil
+}
+
+// readBucketSettings reads every compared setting of a bucket
+func (s *S3Service) readBucketSettings(ctx context.Context, bucket string) []settingValue {
+	client := s.core.Client(bucket)
+	values := make([]settingValue, len(bucketSettings))
+	for i, setting := range bucketSettings {
+		value, err := setting.read(ctx, client, bucket)
+		if err == nil {
+			value, err = normalizeSetting(value)
+		}
+		if err != nil {
+			s.core.Logger.Debug().
+				Err(err).
+				Str("bucket", bucket).
+				Str("setting", setting.name).
+				Msg("Failed to read bucket setting")
+		}
+		values[i] = settingValue{value: value, err: err}
+	}
+	return values
+}
+
+func readVersioningSetting(ctx context.Context, client *s3.Client, bucket string) (any, error) {
+	output, err := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return nil, err
+	}
+	// Buckets that never had versioning enabled report no status
+	status := string(output.Status)
+	if status == "" {
+		status = "Disabled"
+	}
+	return map[string]any{"status": status, "mfaDelete": string(output.MFADelete)}, nil
+}
+
+func readEncryptionSetting(ctx context.Context, client *s3.Client, bucket string) (any, error) {
+	output, err := client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(bucket)})
+	if hasErrorCode(err, "ServerSideEncryptionConfigurationNotFoundError") {
+		return nil, nil
+	}
+	if err != nil || output.ServerSideEncryptionConfiguration == nil {
+		return nil, err
+	}
+	return output.ServerSideEncryptionConfiguration.Rules, nil
+}
+
+func readPolicySetting(ctx context.Context, client *s3.Client, bucket string) (any, error) {
+	output, err := client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(bucket)})
+	if hasErrorCode(err, "NoSuchBucketPolicy") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var policy any
+	if err := json.Unmarshal([]byte(aws.ToString(output.Policy)), &policy); err != nil {
+		return nil, fmt.Errorf("invalid bucket policy: %w", err)
+	}
+	return policy, nil
+}
+
+// readLifecycleSetting returns the lifecycle rules by ID, so rules are matched up
+// regardless of their order
+func readLifecycleSetting(ctx context.Context, client *s3.Client, bucket string) (any, error) {
+	output, err := client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucket)})
+	if hasErrorCode(err, "NoSuchLifecycleConfiguration") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules := make(map[string]any, len(output.Rules))
+	for i, rule := range output.Rules {
+		id := aws.ToString(rule.ID)
+		if id == "" {
+			id = fmt.Sprintf("rule-%d", i+1)
+		}
+		rules[id] = rule
+	}
+	return rules, nil
+}
+
+func readTagsSetting(ctx context.Context, client *s3.Client, bucket string) (any, error) {
+	output, err := client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: aws.String(bucket)})
+	if hasErrorCode(err, "NoSuchTagSet") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]any, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// normalizeSetting converts a setting to plain JSON values without null or empty
+// fields, so that settings read from the SDK compare by content alone
+func normalizeSetting(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var plain any
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return nil, err
+	}
+	return pruneSetting(plain), nil
+}
+
+// pruneSetting drops null and empty values from maps
+func pruneSetting(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			field = pruneSetting(field)
+			if field == nil || field == "" {
+				delete(v, key)
+				continue
+			}
+			v[key] = field
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = pruneSetting(item)
+		}
+	}
+	return value
+}
+
+// diffSetting records the values that differ between two settings. Objects are
+// compared field by field and lists of equal length item by item; anything else
+// that differs is recorded whole.
+func diffSetting(path string, source, target any, diffs *[]models.SettingDifference) {
+	sourceMap, sourceIsMap := source.(map[string]any)
+	targetMap, targetIsMap := target.(map[string]any)
+	if sourceIsMap && targetIsMap {
+		keys := slices.AppendSeq(slices.Collect(maps.Keys(sourceMap)), maps.Keys(targetMap))
+		slices.Sort(keys)
+		for _, key := range slices.Compact(keys) {
+			diffSetting(path+"."+key, sourceMap[key], targetMap[key], diffs)
+		}
+		return
+	}
+
+	sourceList, sourceIsList := source.([]any)
+	targetList, targetIsList := target.([]any)
+	if sourceIsList && targetIsList && len(sourceList) == len(targetList) {
+		for i := range sourceList {
+			diffSetting(fmt.Sprintf("%s[%d]", path, i), sourceList[i], targetList[i], diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(source, target) {
+		*diffs = append(*diffs, models.SettingDifference{Path: path, Source: source, Target: target})
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCompareCore fakes a bucket "source" and a replica that differs in its
+// lifecycle rules and tags, and whose policy cannot be read
+func newTestCompareCore(t *testing.T) *Core {
+	lifecycleRule := func(id string, days int) string {
+		return fmt.Sprintf(`<Rule><ID>%s</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter>`+
+			`<Expiration><Days>%d</Days></Expiration></Rule>`, id, days)
+	}
+	return newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		replica := strings.HasPrefix(r.URL.Path, "/replica")
+		query := r.URL.Query()
+		switch {
+		case query.Has("versioning"):
+			fmt.Fprint(w, `<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`)
+		case query.Has("encryption"):
+			fmt.Fprint(w, `<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault>`+
+				`<SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`)
+		case query.Has("policy") && replica:
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code></Error>`)
+		case query.Has("policy"):
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"Version":"2012-10-17","Statement":[]}`)
+		case query.Has("lifecycle") && replica:
+			fmt.Fprintf(w, `<LifecycleConfiguration>%s%s</LifecycleConfiguration>`, lifecycleRule("tmp", 1), lifecycleRule("logs", 90))
+		case query.Has("lifecycle"):
+			fmt.Fprintf(w, `<LifecycleConfiguration>%s%s</LifecycleConfiguration>`, lifecycleRule("logs", 30), lifecycleRule("tmp", 1))
+		case query.Has("tagging") && replica:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchTagSet</Code></Error>`)
+		case query.Has("tagging"):
+			fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>team</Key><Value>data</Value></Tag></TagSet></Tagging>`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+func TestCompareBuckets(t *testing.T) {
+	c := newTestCompareCore(t)
+
+	comparison, err := c.S3Service.CompareBuckets(context.Background(), "source", "replica")
+	require.NoError(t, err)
+	assert.False(t, comparison.Identical)
+	require.Len(t, comparison.Settings, 5)
+
+	settings := make(map[string]models.SettingComparison)
+	for _, setting := range comparison.Settings {
+		settings[setting.Setting] = setting
+	}
+	assert.True(t, settings["versioning"].Equal)
+	assert.True(t, settings["encryption"].Equal)
+
+	assert.False(t, settings["policy"].Equal)
+	assert.Empty(t, settings["policy"].SourceError)
+	assert.Equal(t, "The bucket policy could not be read: AccessDenied", settings["policy"].TargetError)
+
+	// Rules are matched by ID, not position
+	assert.Equal(t, []models.SettingDifference{
+		{Path: "lifecycle.logs.Expiration.Days", Source: float64(30), Target: float64(90)},
+	}, settings["lifecycle"].Differences)
+
+	assert.Equal(t, []models.SettingDifference{
+		{Path: "tags", Source: map[string]any{"team": "data"}, Target: nil},
+	}, settings["tags"].Differences)
+
+	comparison, err = c.S3Service.CompareBuckets(context.Background(), "source", "source")
+	require.NoError(t, err)
+	assert.True(t, comparison.Identical)
+}
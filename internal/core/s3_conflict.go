@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"explorer451/internal/models"
+)
+
+// Conflict strategies for writes whose target key already exists
+const (
+	ConflictOverwrite = "overwrite"
+	ConflictFail      = "fail"
+	ConflictRename    = "rename"
+)
+
+// maxConflictRenames caps the numbered suffixes tried when looking for a free key
+const maxConflictRenames = 100
+
+var (
+	// ErrObjectExists is returned for writes refused because their target key exists
+	ErrObjectExists = errors.New("an object already exists at the target key")
+	// ErrInvalidConflict is returned for unknown conflict strategies
+	ErrInvalidConflict = errors.New("conflict must be fail, overwrite or rename")
+)
+
+// ObjectConflictError is returned for writes refused because their target key exists.
+// It matches ErrObjectExists, and lists the strategies the write could be retried
+// with.
+type ObjectConflictError struct {
+	Bucket       string
+	Key          string
+	Alternatives []models.ConflictAlternative
+}
+
+func (e *ObjectConflictError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrObjectExists, e.Key)
+}
+
+func (e *ObjectConflictError) Unwrap() error {
+	return ErrObjectExists
+}
+
+// resolveConflict returns the key a write to key should go to under a conflict
+// strategy, which defaults to uploads.conflict. Overwriting needs no check; otherwise
+// the key is checked with a HEAD request before the write, so a write racing another
+// to the same key can still overwrite it.
+func (s *S3Service) resolveConflict(ctx context.Context, bucket, key, conflict string) (string, error) {
+	conflict, err := s.conflictStrategy(conflict)
+	if err != nil || conflict == ConflictOverwrite {
+		return key, err
+	}
+
+	existence, err := s.ObjectExists(ctx, bucket, key, "")
+	if err != nil || !existence.Exists {
+		return key, err
+	}
+
+	renamed, err := s.freeKey(ctx, bucket, key)
+	if err != nil {
+		return "", err
+	}
+	if conflict == ConflictRename && renamed != "" {
+		s.core.Logger.Debug().
+			Str("bucket", bucket).
+			Str("key", key).
+			Str("renamed", renamed).
+			Msg("Target key exists, writing to a renamed key")
+		return renamed, nil
+	}
+
+	conflictErr := &ObjectConflictError{
+		Bucket:       bucket,
+		Key:          key,
+		Alternatives: []models.ConflictAlternative{{Conflict: ConflictOverwrite, Key: key}},
+	}
+	if renamed != "" {
+		conflictErr.Alternatives = append(conflictErr.Alternatives, models.ConflictAlternative{Conflict: ConflictRename, Key: renamed})
+	}
+	return "", conflictErr
+}
+
+// conflictStrategy returns the strategy a write asking for conflict uses, which
+// defaults to uploads.conflict, so jobs can refuse unknown ones before they start
+func (s *S3Service) conflictStrategy(conflict string) (string, error) {
+	if conflict == "" {
+		conflict = s.core.Config.Uploads.Conflict
+	}
+	switch conflict {
+	case "", ConflictOverwrite:
+		return ConflictOverwrite, nil
+	case ConflictFail, ConflictRename:
+		return conflict, nil
+	}
+	return "", ErrInvalidConflict
+}
+
+// freeKey returns the first key with a numbered suffix that does not exist yet, or ""
+// when maxConflictRenames are all taken
+func (s *S3Service) freeKey(ctx context.Context, bucket, key string) (string, error) {
+	for n := 1; n <= maxConflictRenames; n++ {
+		candidate := suffixedKey(key, n)
+		existence, err := s.ObjectExists(ctx, bucket, candidate, "")
+		if err != nil {
+			return "", err
+		}
+		if !existence.Exists {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// suffixedKey numbers a key before its extension: reports/q1.csv becomes
+// reports/q1-2.csv. Names that are all extension, such as .env, are numbered at the end.
+func suffixedKey(key string, n int) string {
+	dir, name := path.Split(key)
+	ext := path.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	return fmt.Sprintf("%s%s-%d%s", dir, strings.TrimSuffix(name, ext), n, ext)
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuffixedKey(t *testing.T) {
+	assert.Equal(t, "reports/q1-1.csv", suffixedKey("reports/q1.csv", 1))
+	assert.Equal(t, "reports/q1.tar-2.gz", suffixedKey("reports/q1.tar.gz", 2))
+	assert.Equal(t, "notes-3", suffixedKey("notes", 3))
+	assert.Equal(t, "config/.env-1", suffixedKey("config/.env", 1))
+}
+
+func TestResolveConflict(t *testing.T) {
+	existing := map[string]bool{"/docs/q1.csv": true, "/docs/q1-1.csv": true, "/docs/report.pdf": true}
+	var copiedTo string
+	c := newTestS3Core(t, &config.Config{Uploads: config.UploadsConfig{Conflict: ConflictFail}}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			copiedTo = r.URL.Path
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"new"</ETag></CopyObjectResult>`)
+		case existing[r.URL.Path] || r.URL.Path == copiedTo:
+			w.Header().Set("Content-Length", "42")
+			w.Header().Set("ETag", `"new"`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	key, err := c.S3Service.resolveConflict(ctx, "docs", "q2.csv", "")
+	require.NoError(t, err)
+	assert.Equal(t, "q2.csv", key)
+
+	// The configured strategy refuses the write and offers the alternatives
+	_, err = c.S3Service.resolveConflict(ctx, "docs", "q1.csv", "")
+	require.ErrorIs(t, err, ErrObjectExists)
+	var conflict *ObjectConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, []models.ConflictAlternative{
+		{Conflict: ConflictOverwrite, Key: "q1.csv"},
+		{Conflict: ConflictRename, Key: "q1-2.csv"},
+	}, conflict.Alternatives)
+
+	key, err = c.S3Service.resolveConflict(ctx, "docs", "q1.csv", ConflictRename)
+	require.NoError(t, err)
+	assert.Equal(t, "q1-2.csv", key)
+
+	key, err = c.S3Service.resolveConflict(ctx, "docs", "q1.csv", ConflictOverwrite)
+	require.NoError(t, err)
+	assert.Equal(t, "q1.csv", key)
+
+	_, err = c.S3Service.resolveConflict(ctx, "docs", "q1.csv", "skip")
+	assert.ErrorIs(t, err, ErrInvalidConflict)
+
+	// Copies write to the renamed key
+	copied, err := c.S3Service.CopyObject(ctx, "docs", models.CopyObjectRequest{Key: "q1.csv", TargetKey: "report.pdf", Conflict: ConflictRename})
+	require.NoError(t, err)
+	assert.Equal(t, "/docs/report-1.pdf", copiedTo)
+	assert.Equal(t, "report-1.pdf", copied.Object.Key)
+
+	_, err = c.S3Service.CopyObject(ctx, "docs", models.CopyObjectRequest{Key: "q1.csv", TargetKey: "report.pdf"})
+	assert.ErrorIs(t, err, ErrObjectExists)
+}
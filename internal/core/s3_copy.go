@@ -0,0 +1,213 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxCopyObjectBytes is the largest object a single CopyObject request can copy
+const maxCopyObjectBytes = 5 << 30
+
+// JobTypeFolderCopy is the job type of copies of everything under a prefix
+const JobTypeFolderCopy = "folder-copy"
+
+var (
+	// ErrCopyOntoItself is returned when an object copy targets its own key
+	ErrCopyOntoItself = errors.New("an object cannot be copied onto itself")
+	// ErrObjectTooLargeToCopy is returned for objects CopyObject cannot copy in one
+	// request
+	ErrObjectTooLargeToCopy = errors.New("object is too large to copy")
+	// ErrCopyFolderIntoItself is returned when a folder copy targets a prefix inside the
+	// folder, whose listing would pick up the copies
+	ErrCopyFolderIntoItself = errors.New("a folder cannot be copied into itself")
+	// ErrRenameFolder is returned when renaming a folder, which takes a copy of every
+	// object under it
+	ErrRenameFolder = errors.New("folders cannot be renamed")
+	// ErrRenameIncomplete is returned when a renamed object was copied but the original
+	// could not be deleted
+	ErrRenameIncomplete = errors.New("object was copied but the original was not deleted")
+)
+
+// CopyObject copies an object server-side to targetKey in targetBucket, which defaults
+// to the source bucket, and returns the copy's metadata. An existing target is
+// handled by the request's conflict strategy.
+func (s *S3Service) CopyObject(ctx context.Context, bucket string, req models.CopyObjectRequest) (*models.CopyObjectResponse, error) {
+	targetBucket := req.TargetBucket
+	if targetBucket == "" {
+		targetBucket = bucket
+	}
+	if targetBucket == bucket && req.TargetKey == req.Key {
+		return nil, ErrCopyOntoItself
+	}
+	if !s.core.sameEndpoint(bucket, targetBucket) {
+		return nil, ErrCrossEndpointCopy
+	}
+
+	kms, err := s.kmsFor(req.KMSKeyID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail early, and clearly, on a missing or oversized source
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(req.Key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToInt64(head.ContentLength) > maxCopyObjectBytes {
+		return nil, ErrObjectTooLargeToCopy
+	}
+	targetKey, err := s.resolveConflict(ctx, targetBucket, req.TargetKey, req.Conflict)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.copyObject(ctx, bucket, req.Key, targetBucket, targetKey, kms); err != nil {
+		return nil, err
+	}
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", req.Key).
+		Str("targetBucket", targetBucket).
+		Str("targetKey", targetKey).
+		Msg("Copied object")
+
+	metadata, err := s.GetObjectMetadata(ctx, targetBucket, targetKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CopyObjectResponse{Bucket: targetBucket, Object: *metadata}, nil
+}
+
+// RenameObject moves an object to another key by copying it server-side and deleting
+// the original. S3 has no rename, so for a moment both keys exist; should the delete
+// fail, the copy is kept and ErrRenameIncomplete returned.
+func (s *S3Service) RenameObject(ctx context.Context, bucket, key string, req models.RenameObjectRequest) (*models.CopyObjectResponse, error) {
+	if strings.HasSuffix(key, "/") {
+		return nil, ErrRenameFolder
+	}
+
+	copied, err := s.CopyObject(ctx, bucket, models.CopyObjectRequest{
+		Key:          key,
+		TargetBucket: req.TargetBucket,
+		TargetKey:    req.TargetKey,
+		KMSKeyID:     req.KMSKeyID,
+		Conflict:     req.Conflict,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DeleteObject(ctx, bucket, key); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRenameIncomplete, err)
+	}
+	return copied, nil
+}
+
+// CopyFolder starts a background job that copies every object under a prefix
+// server-side to targetPrefix, keeping their paths below the prefix. Objects are
+// copied jobs.copyConcurrency at a time as the prefix is listed. Each object whose
+// target key exists is handled by the request's conflict strategy; those refused are
+// recorded as failed.
+func (s *S3Service) CopyFolder(ctx context.Context, bucket string, req models.CopyFolderRequest) (models.Job, error) {
+	targetBucket := req.TargetBucket
+	if targetBucket == "" {
+		targetBucket = bucket
+	}
+	prefix := folderPrefix(req.Prefix)
+	targetPrefix := folderPrefix(req.TargetPrefix)
+	if targetBucket == bucket && strings.HasPrefix(targetPrefix, prefix) {
+		if targetPrefix == prefix {
+			return models.Job{}, ErrCopyOntoItself
+		}
+		return models.Job{}, ErrCopyFolderIntoItself
+	}
+	if !s.core.sameEndpoint(bucket, targetBucket) {
+		return models.Job{}, ErrCrossEndpointCopy
+	}
+
+	kms, err := s.kmsFor(req.KMSKeyID, nil)
+	if err != nil {
+		return models.Job{}, err
+	}
+	conflict, err := s.conflictStrategy(req.Conflict)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	// Fail early on a missing or inaccessible bucket
+	for _, name := range []string{bucket, targetBucket} {
+		if _, err := s.core.Client(name).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(name)}); err != nil {
+			return models.Job{}, err
+		}
+	}
+
+	params := map[string]string{
+		"bucket":       bucket,
+		"prefix":       prefix,
+		"targetBucket": targetBucket,
+		"targetPrefix": targetPrefix,
+	}
+	if req.KMSKeyID != "" {
+		params["kmsKeyId"] = req.KMSKeyID
+	}
+	if req.Conflict != "" {
+		params["conflict"] = conflict
+	}
+
+	return s.core.Jobs.Submit(JobTypeFolderCopy, params, func(ctx context.Context, job *Job) error {
+		type listed struct {
+			key  string
+			size int64
+		}
+		work := make(chan listed)
+		var wg sync.WaitGroup
+		for range max(s.core.Config.Jobs.CopyConcurrency, 1) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for obj := range work {
+					if obj.size > maxCopyObjectBytes {
+						job.ItemFailed(obj.key, ErrObjectTooLargeToCopy)
+						continue
+					}
+					targetKey, err := s.resolveConflict(ctx, targetBucket, targetPrefix+strings.TrimPrefix(obj.key, prefix), conflict)
+					if err != nil {
+						job.ItemFailed(obj.key, err)
+						continue
+					}
+					if err := s.copyObject(ctx, bucket, obj.key, targetBucket, targetKey, kms); err != nil {
+						job.ItemFailed(obj.key, err)
+						continue
+					}
+					job.ItemSucceeded(obj.key, obj.size)
+				}
+			}()
+		}
+
+		err := s.forEachListed(ctx, job, bucket, prefix, func(key string, size int64) {
+			work <- listed{key: key, size: size}
+		})
+		close(work)
+		wg.Wait()
+		return err
+	}), nil
+}
+
+// folderPrefix turns a folder path into the prefix of the objects in it, "" for the
+// bucket root
+func folderPrefix(folder string) string {
+	if folder = strings.TrimPrefix(folder, "/"); folder != "" && !strings.HasSuffix(folder, "/") {
+		folder += "/"
+	}
+	return folder
+}
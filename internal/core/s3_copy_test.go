@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyObject(t *testing.T) {
+	var copySource, sse string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			copySource = r.Header.Get("X-Amz-Copy-Source")
+			sse = r.Header.Get("X-Amz-Server-Side-Encryption")
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"new"</ETag></CopyObjectResult>`)
+		case r.URL.Path == "/docs/big.bin":
+			w.Header().Set("Content-Length", fmt.Sprint(int64(6<<30)))
+		case r.URL.Path == "/docs/report 1.pdf", r.URL.Path == "/archive/2024/report.pdf":
+			w.Header().Set("Content-Length", "42")
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("ETag", `"new"`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	copied, err := c.S3Service.CopyObject(ctx, "docs", models.CopyObjectRequest{
+		Key:          "report 1.pdf",
+		TargetBucket: "archive",
+		TargetKey:    "2024/report.pdf",
+		KMSKeyID:     "alias/archive",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "docs%2Freport%201.pdf", copySource)
+	assert.Equal(t, "aws:kms", sse)
+	assert.Equal(t, "archive", copied.Bucket)
+	assert.Equal(t, "2024/report.pdf", copied.Object.Key)
+	assert.Equal(t, `"new"`, copied.Object.ETag)
+	assert.Equal(t, "application/pdf", copied.Object.ContentType)
+
+	_, err = c.S3Service.CopyObject(ctx, "docs", models.CopyObjectRequest{Key: "a.txt", TargetKey: "a.txt"})
+	assert.ErrorIs(t, err, ErrCopyOntoItself)
+
+	_, err = c.S3Service.CopyObject(ctx, "docs", models.CopyObjectRequest{Key: "big.bin", TargetKey: "big-copy.bin"})
+	assert.ErrorIs(t, err, ErrObjectTooLargeToCopy)
+
+	_, err = c.S3Service.CopyObject(ctx, "docs", models.CopyObjectRequest{Key: "missing.txt", TargetKey: "copy.txt"})
+	assert.True(t, hasErrorCode(err, "NotFound"))
+
+	c.BucketClients = map[string]*s3.Client{"minio": c.S3Client}
+	_, err = c.S3Service.CopyObject(ctx, "docs", models.CopyObjectRequest{Key: "report 1.pdf", TargetBucket: "minio", TargetKey: "a.pdf"})
+	assert.ErrorIs(t, err, ErrCrossEndpointCopy)
+}
+
+func TestRenameObject(t *testing.T) {
+	var deleted []string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"abc"</ETag></CopyObjectResult>`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/docs/locked.txt":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code></Error>`)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Length", "3")
+			w.Header().Set("ETag", `"abc"`)
+		}
+	})
+	ctx := context.Background()
+
+	renamed, err := c.S3Service.RenameObject(ctx, "docs", "draft.txt", models.RenameObjectRequest{TargetKey: "final.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "docs", renamed.Bucket)
+	assert.Equal(t, "final.txt", renamed.Object.Key)
+	assert.Equal(t, []string{"/docs/draft.txt"}, deleted)
+
+	_, err = c.S3Service.RenameObject(ctx, "docs", "locked.txt", models.RenameObjectRequest{TargetKey: "moved.txt"})
+	assert.ErrorIs(t, err, ErrRenameIncomplete)
+	assert.True(t, hasErrorCode(err, "AccessDenied"))
+
+	_, err = c.S3Service.RenameObject(ctx, "docs", "photos/", models.RenameObjectRequest{TargetKey: "images/"})
+	assert.ErrorIs(t, err, ErrRenameFolder)
+}
+
+func TestCopyFolder(t *testing.T) {
+	var mu sync.Mutex
+	var copied []string
+	cfg := &config.Config{
+		Jobs: config.JobsConfig{MaxConcurrent: 1, Retention: 10, CopyConcurrency: 2},
+	}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+		case r.URL.Query().Has("list-type"):
+			fmt.Fprintf(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`+
+				`<Contents><Key>reports/a.csv</Key><Size>10</Size></Contents>`+
+				`<Contents><Key>reports/2024/b.csv</Key><Size>20</Size></Contents>`+
+				`<Contents><Key>reports/huge.bin</Key><Size>%d</Size></Contents></ListBucketResult>`, int64(6<<30))
+		case r.Method == http.MethodPut:
+			mu.Lock()
+			copied = append(copied, r.Header.Get("X-Amz-Copy-Source")+" -> "+r.URL.Path)
+			mu.Unlock()
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"abc"</ETag></CopyObjectResult>`)
+		}
+	})
+	c.Jobs = NewJobManager(c)
+	ctx := context.Background()
+
+	submitted, err := c.S3Service.CopyFolder(ctx, "docs", models.CopyFolderRequest{
+		Prefix:       "reports",
+		TargetBucket: "archive",
+		TargetPrefix: "2024",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, JobTypeFolderCopy, submitted.Type)
+	assert.Equal(t, "reports/", submitted.Params["prefix"])
+
+	job := waitForJob(t, c.Jobs, submitted.ID)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, int64(3), job.Progress.Total)
+	assert.Equal(t, int64(2), job.Progress.Completed)
+	assert.Equal(t, int64(30), job.Progress.Bytes)
+	require.Len(t, job.Results, 1)
+	assert.Equal(t, "reports/huge.bin", job.Results[0].Key)
+	assert.ElementsMatch(t, []string{
+		"docs%2Freports%2Fa.csv -> /archive/2024/a.csv",
+		"docs%2Freports%2F2024%2Fb.csv -> /archive/2024/2024/b.csv",
+	}, copied)
+
+	_, err = c.S3Service.CopyFolder(ctx, "docs", models.CopyFolderRequest{Prefix: "reports/", TargetPrefix: "reports/old/"})
+	assert.ErrorIs(t, err, ErrCopyFolderIntoItself)
+	_, err = c.S3Service.CopyFolder(ctx, "docs", models.CopyFolderRequest{Prefix: "reports/", TargetPrefix: "/reports"})
+	assert.ErrorIs(t, err, ErrCopyOntoItself)
+}
+
+func TestCopyFolder_Conflict(t *testing.T) {
+	var mu sync.Mutex
+	var copied []string
+	cfg := &config.Config{
+		Jobs: config.JobsConfig{MaxConcurrent: 1, Retention: 10, CopyConcurrency: 1},
+	}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && (r.URL.Path == "/docs" || r.URL.Path == "/archive" || r.URL.Path == "/archive/2024/a.csv"):
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Has("list-type"):
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`+
+				`<Contents><Key>reports/a.csv</Key><Size>10</Size></Contents>`+
+				`<Contents><Key>reports/b.csv</Key><Size>20</Size></Contents></ListBucketResult>`)
+		case r.Method == http.MethodPut:
+			mu.Lock()
+			copied = append(copied, r.URL.Path)
+			mu.Unlock()
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"abc"</ETag></CopyObjectResult>`)
+		}
+	})
+	c.Jobs = NewJobManager(c)
+	ctx := context.Background()
+	copyFolder := func(conflict string) models.Job {
+		copied = nil
+		submitted, err := c.S3Service.CopyFolder(ctx, "docs", models.CopyFolderRequest{
+			Prefix:       "reports/",
+			TargetBucket: "archive",
+			TargetPrefix: "2024/",
+			Conflict:     conflict,
+		})
+		require.NoError(t, err)
+		return waitForJob(t, c.Jobs, submitted.ID)
+	}
+
+	// archive/2024/a.csv exists, so only b.csv is copied
+	job := copyFolder(ConflictFail)
+	assert.Equal(t, int64(1), job.Progress.Completed)
+	require.Len(t, job.Results, 1)
+	assert.Equal(t, "reports/a.csv", job.Results[0].Key)
+	assert.Equal(t, []string{"/archive/2024/b.csv"}, copied)
+
+	job = copyFolder(ConflictRename)
+	assert.Equal(t, int64(2), job.Progress.Completed)
+	assert.ElementsMatch(t, []string{"/archive/2024/a-1.csv", "/archive/2024/b.csv"}, copied)
+
+	_, err := c.S3Service.CopyFolder(ctx, "docs", models.CopyFolderRequest{Prefix: "reports/", TargetPrefix: "old/", Conflict: "skip"})
+	assert.ErrorIs(t, err, ErrInvalidConflict)
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// bytesPerGB is the GB storage is billed by
+const bytesPerGB = 1 << 30
+
+// EstimateCost estimates the monthly storage cost of a bucket, or a prefix within it,
+// from the size of its objects in each storage class and the configured prices
+func (s *S3Service) EstimateCost(ctx context.Context, bucket, prefix string) (*models.CostEstimate, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Msg("Estimating storage cost")
+
+	classes := make(map[string]*models.StorageClassCost)
+	truncated, err := s.scanPrefix(ctx, bucket, prefix, func(obj s3Types.Object) {
+		class := storageClass(obj)
+		cost, ok := classes[class]
+		if !ok {
+			cost = &models.StorageClassCost{StorageClass: class}
+			classes[class] = cost
+		}
+		cost.Objects++
+		cost.Bytes += aws.ToInt64(obj.Size)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	currency, prices := s.core.Pricing.Prices()
+	estimate := &models.CostEstimate{
+		Bucket:         bucket,
+		Prefix:         prefix,
+		Currency:       currency,
+		StorageClasses: make([]models.StorageClassCost, 0, len(classes)),
+		Truncated:      truncated,
+		ScanLimit:      s.core.Config.Reports.MaxObjects,
+		EstimatedAt:    time.Now().UTC(),
+	}
+	for _, cost := range classes {
+		if price, ok := prices[cost.StorageClass]; ok {
+			cost.Priced = true
+			cost.PerGBMonth = price
+			cost.MonthlyCost = float64(cost.Bytes) / bytesPerGB * price
+		}
+		estimate.Objects += cost.Objects
+		estimate.Bytes += cost.Bytes
+		estimate.MonthlyCost += cost.MonthlyCost
+		estimate.StorageClasses = append(estimate.StorageClasses, *cost)
+	}
+	sort.Slice(estimate.StorageClasses, func(i, j int) bool {
+		a, b := estimate.StorageClasses[i], estimate.StorageClasses[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.StorageClass < b.StorageClass
+	})
+
+	if !truncated {
+		s.cacheSize(bucket, prefix, estimate.Objects, estimate.Bytes)
+	}
+	return estimate, nil
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateCost(t *testing.T) {
+	objects := []testObject{
+		{key: "logs/a.gz", size: 2 << 30, storageClass: "STANDARD"},
+		{key: "logs/b.gz", size: 1 << 30, storageClass: "STANDARD"},
+		{key: "logs/old.gz", size: 10 << 30, storageClass: "GLACIER"},
+		{key: "logs/odd.gz", size: 1 << 30, storageClass: "SNOW"},
+	}
+	cfg := &config.Config{
+		Reports: config.ReportsConfig{MaxObjects: 100},
+		Pricing: config.PricingConfig{
+			Currency: "USD",
+			StorageClasses: []config.StorageClassPrice{
+				{StorageClass: "STANDARD", PerGBMonth: 0.02},
+				{StorageClass: "glacier", PerGBMonth: 0.004},
+			},
+		},
+	}
+	c := newTestListingCore(t, cfg, objects)
+
+	estimate, err := c.S3Service.EstimateCost(context.Background(), "data", "logs/")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", estimate.Currency)
+	assert.Equal(t, int64(4), estimate.Objects)
+	assert.Equal(t, int64(14<<30), estimate.Bytes)
+	assert.False(t, estimate.Truncated)
+	// Storage classes without a price are listed but not counted
+	assert.InDelta(t, 3*0.02+10*0.004, estimate.MonthlyCost, 1e-9)
+
+	require.Len(t, estimate.StorageClasses, 3)
+	assert.Equal(t, "GLACIER", estimate.StorageClasses[0].StorageClass)
+	assert.InDelta(t, 0.04, estimate.StorageClasses[0].MonthlyCost, 1e-9)
+	assert.Equal(t, "STANDARD", estimate.StorageClasses[1].StorageClass)
+	assert.Equal(t, int64(2), estimate.StorageClasses[1].Objects)
+	assert.Equal(t, "SNOW", estimate.StorageClasses[2].StorageClass)
+	assert.False(t, estimate.StorageClasses[2].Priced)
+}
+
+func TestPricingService_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	cfg := &config.Config{Pricing: config.PricingConfig{
+		Currency:       "USD",
+		StorageClasses: []config.StorageClassPrice{{StorageClass: "STANDARD", PerGBMonth: 0.023}},
+		File:           path,
+	}}
+	c := newTestListingCore(t, cfg, nil)
+
+	// Without the file the configured prices are used
+	currency, prices := c.Pricing.Prices()
+	assert.Equal(t, "USD", currency)
+	assert.Equal(t, map[string]float64{"STANDARD": 0.023}, prices)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"currency":"EUR","storageClasses":[{"storageClass":"standard","perGbMonth":0.03}]}`), 0o600))
+	currency, prices = c.Pricing.Prices()
+	assert.Equal(t, "EUR", currency)
+	assert.Equal(t, map[string]float64{"STANDARD": 0.03}, prices)
+
+	// An invalid update keeps the last good prices
+	require.NoError(t, os.WriteFile(path, []byte(`{"storageClasses":[{"storageClass":"STANDARD","perGbMonth":-1}]}`), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	currency, prices = c.Pricing.Prices()
+	assert.Equal(t, "EUR", currency)
+	assert.Equal(t, map[string]float64{"STANDARD": 0.03}, prices)
+}
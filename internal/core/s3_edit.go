@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// maxEditableBytes is the largest object that can be loaded for in-place editing
+const maxEditableBytes = 1024 * 1024
+
+var (
+	// ErrEditNotSupported is returned for objects that are not UTF-8 text
+	ErrEditNotSupported = errors.New("object cannot be edited as text")
+	// ErrObjectTooLargeToEdit is returned for objects above maxEditableBytes
+	ErrObjectTooLargeToEdit = errors.New("object too large to edit")
+	// ErrETagMismatch is returned when an object changed since the client loaded it
+	ErrETagMismatch = errors.New("object has been modified")
+)
+
+// GetObjectContent loads a small text object in full for editing
+func (s *S3Service) GetObjectContent(ctx context.Context, bucket, key string) (*models.ObjectContent, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Getting object content for editing")
+
+	head, err := s.headEditable(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToInt64(head.ContentLength) > maxEditableBytes {
+		return nil, ErrObjectTooLargeToEdit
+	}
+
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		IfMatch: head.ETag,
+	})
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return nil, ErrETagMismatch
+		}
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object content")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(output.Body, maxEditableBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxEditableBytes {
+		return nil, ErrObjectTooLargeToEdit
+	}
+	if !utf8.Valid(data) {
+		return nil, ErrEditNotSupported
+	}
+
+	return &models.ObjectContent{
+		Key:         key,
+		ContentType: aws.ToString(head.ContentType),
+		ETag:        aws.ToString(output.ETag),
+		Content:     string(data),
+	}, nil
+}
+
+// SaveObjectContent overwrites a text object, provided it still has the ETag the client
+// loaded. The check is enforced by S3 as a conditional write where supported, and by a
+// preceding HEAD request otherwise. The new content keeps the object's content type,
+// cache control, content disposition and encoding, user metadata, tags, storage class
+// and server-side encryption; under uploads.requireKms, an object not encrypted with
+// KMS yet gets the required key. Its ACL is reset to the bucket default.
+func (s *S3Service) SaveObjectContent(ctx context.Context, bucket, key, content, ifMatch string) (*models.ObjectContent, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Saving object content")
+
+	if len(content) > maxEditableBytes {
+		return nil, ErrObjectTooLargeToEdit
+	}
+	if !utf8.ValidString(content) {
+		return nil, ErrEditNotSupported
+	}
+
+	head, err := s.headEditable(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if normalizeETag(aws.ToString(head.ETag)) != normalizeETag(ifMatch) {
+		return nil, ErrETagMismatch
+	}
+
+	// Tags are not part of HEAD. Stores without tagging have none to keep.
+	tagging := ""
+	tags, err := s.GetObjectTags(ctx, bucket, key, "")
+	switch {
+	case hasErrorCode(err, "NotImplemented"):
+	case err != nil:
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object tags for editing")
+		return nil, err
+	default:
+		values := url.Values{}
+		for name, value := range tags.Tags {
+			values.Set(name, value)
+		}
+		tagging = values.Encode()
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:             aws.String(bucket),
+		Key:                aws.String(key),
+		Body:               strings.NewReader(content),
+		ContentType:        head.ContentType,
+		CacheControl:       head.CacheControl,
+		ContentDisposition: head.ContentDisposition,
+		ContentEncoding:    head.ContentEncoding,
+		Metadata:           head.Metadata,
+		StorageClass:       head.StorageClass,
+		IfMatch:            head.ETag,
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId, input.BucketKeyEnabled = s.keptEncryption(head)
+	if tagging != "" {
+		input.Tagging = aws.String(tagging)
+	}
+	output, err := s.core.Client(bucket).PutObject(ctx, input)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return nil, ErrETagMismatch
+		}
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to save object content")
+		return nil, err
+	}
+
+	s.core.Events.Publish(EventObjectCreated, bucket, key)
+
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Object content saved")
+
+	return &models.ObjectContent{
+		Key:         key,
+		ContentType: aws.ToString(head.ContentType),
+		ETag:        aws.ToString(output.ETag),
+		Content:     content,
+	}, nil
+}
+
+// headEditable fetches an object's metadata and checks that it holds editable text
+func (s *S3Service) headEditable(ctx context.Context, bucket, key string) (*s3.HeadObjectOutput, error) {
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for editing")
+		return nil, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(aws.ToString(head.ContentType))
+	if err != nil || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		mediaType, params, _ = mime.ParseMediaType(detectContentType(key))
+	}
+	if !isPreviewable(mediaType) {
+		return nil, ErrEditNotSupported
+	}
+	if charset := strings.ToLower(params["charset"]); charset != "" && charset != "utf-8" && charset != "us-ascii" {
+		return nil, ErrEditNotSupported
+	}
+
+	return head, nil
+}
+
+// normalizeETag strips the weak prefix and quotes so ETags from headers and S3 compare equal
+func normalizeETag(etag string) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`)
+}
+
+// isPreconditionFailed reports whether S3 rejected a conditional request
+func isPreconditionFailed(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "PreconditionFailed"
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveObjectContent(t *testing.T) {
+	var put http.Header
+	var body string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Content-Disposition", "attachment")
+			w.Header().Set("Content-Encoding", "identity")
+			w.Header().Set("X-Amz-Meta-Owner", "alice")
+			w.Header().Set("X-Amz-Storage-Class", "STANDARD_IA")
+			w.Header().Set("X-Amz-Server-Side-Encryption", "aws:kms")
+			w.Header().Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:us-east-1:111122223333:key/abc")
+			w.Header().Set("X-Amz-Server-Side-Encryption-Bucket-Key-Enabled", "true")
+		case r.Method == http.MethodGet && r.URL.Query().Has("tagging"):
+			fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>team</Key><Value>data &amp; ops</Value></Tag></TagSet></Tagging>`)
+		case r.Method == http.MethodPut:
+			put = r.Header.Clone()
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.Header().Set("ETag", `"v2"`)
+		}
+	})
+
+	saved, err := c.S3Service.SaveObjectContent(context.Background(), "docs", "notes.txt", "hello", `"v1"`)
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, saved.ETag)
+	assert.Equal(t, "hello", body)
+
+	// The object's headers, metadata, tags, storage class and encryption carry over
+	require.NotNil(t, put)
+	assert.Equal(t, "text/plain; charset=utf-8", put.Get("Content-Type"))
+	assert.Equal(t, "max-age=60", put.Get("Cache-Control"))
+	assert.Equal(t, "attachment", put.Get("Content-Disposition"))
+	assert.Equal(t, "identity", put.Get("Content-Encoding"))
+	assert.Equal(t, "alice", put.Get("X-Amz-Meta-Owner"))
+	assert.Equal(t, "STANDARD_IA", put.Get("X-Amz-Storage-Class"))
+	assert.Equal(t, `"v1"`, put.Get("If-Match"))
+	assert.Equal(t, "aws:kms", put.Get("X-Amz-Server-Side-Encryption"))
+	assert.Equal(t, "arn:aws:kms:us-east-1:111122223333:key/abc", put.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+	assert.Equal(t, "true", put.Get("X-Amz-Server-Side-Encryption-Bucket-Key-Enabled"))
+	tags, err := url.ParseQuery(put.Get("X-Amz-Tagging"))
+	require.NoError(t, err)
+	assert.Equal(t, "data & ops", tags.Get("team"))
+
+	_, err = c.S3Service.SaveObjectContent(context.Background(), "docs", "notes.txt", "hello", `"v0"`)
+	assert.ErrorIs(t, err, ErrETagMismatch)
+}
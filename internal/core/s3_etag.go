@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxMultipartParts is the most parts S3 accepts in one multipart upload
+const maxMultipartParts = 10000
+
+var (
+	// ErrInvalidPartMD5 is returned for part digests that are not hex MD5s
+	ErrInvalidPartMD5 = errors.New("part MD5s must be 32 hex digits")
+	// ErrInvalidChunking is returned for chunking plans S3 would not accept
+	ErrInvalidChunking = errors.New("invalid chunking plan")
+)
+
+// MultipartETag computes the ETag S3 gives a multipart upload: the MD5 of the parts'
+// binary MD5s concatenated, followed by "-" and the number of parts
+func MultipartETag(partMD5s []string) (string, error) {
+	if len(partMD5s) == 0 || len(partMD5s) > maxMultipartParts {
+		return "", fmt.Errorf("%w: between 1 and %d parts are needed", ErrInvalidPartMD5, maxMultipartParts)
+	}
+
+	digests := make([]byte, 0, len(partMD5s)*md5.Size)
+	for _, partMD5 := range partMD5s {
+		digest, err := hex.DecodeString(strings.Trim(partMD5, `"`))
+		if err != nil || len(digest) != md5.Size {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPartMD5, partMD5)
+		}
+		digests = append(digests, digest...)
+	}
+
+	sum := md5.Sum(digests)
+	return fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(partMD5s)), nil
+}
+
+// ChunkingPlan splits a file of size bytes into parts of partSize bytes, the last part
+// holding the remainder
+func ChunkingPlan(size, partSize int64) ([]models.PartRange, error) {
+	if size <= 0 || partSize <= 0 {
+		return nil, fmt.Errorf("%w: size and part size must be positive", ErrInvalidChunking)
+	}
+	count := (size + partSize - 1) / partSize
+	if count > maxMultipartParts {
+		return nil, fmt.Errorf("%w: %d parts exceed the limit of %d", ErrInvalidChunking, count, maxMultipartParts)
+	}
+
+	parts := make([]models.PartRange, 0, count)
+	for offset := int64(0); offset < size; offset += partSize {
+		parts = append(parts, models.PartRange{
+			Number: len(parts) + 1,
+			Offset: offset,
+			Size:   min(partSize, size-offset),
+		})
+	}
+	return parts, nil
+}
+
+// GetObjectParts reports how an object was uploaded. The part size is that of the
+// first part; the plan assumes every part but the last has that size, as upload tools
+// generally use a fixed part size.
+func (s *S3Service) GetObjectParts(ctx context.Context, bucket, key string) (*models.ObjectParts, error) {
+	output, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		PartNumber: aws.Int32(1),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object part metadata")
+		return nil, err
+	}
+
+	parts := &models.ObjectParts{
+		ETag:      aws.ToString(output.ETag),
+		Size:      aws.ToInt64(output.ContentLength),
+		PartCount: int(aws.ToInt32(output.PartsCount)),
+	}
+	if parts.PartCount == 0 {
+		return parts, nil
+	}
+
+	// For a part, the content length is the part's and the total follows the slash
+	// in the content range
+	parts.Multipart = true
+	parts.PartSize = aws.ToInt64(output.ContentLength)
+	if _, total, ok := strings.Cut(aws.ToString(output.ContentRange), "/"); ok {
+		if size, err := strconv.ParseInt(total, 10, 64); err == nil {
+			parts.Size = size
+		}
+	}
+	if plan, err := ChunkingPlan(parts.Size, parts.PartSize); err == nil && len(plan) == parts.PartCount {
+		parts.Parts = plan
+	}
+	return parts, nil
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipartETag(t *testing.T) {
+	// Parts "hello " and "world"
+	etag, err := MultipartETag([]string{"f814893777bcc2295fff05f00e508da6", `"7d793037a0760186574b0282f2f435e7"`})
+	require.NoError(t, err)
+	assert.Equal(t, `"e09e4fd6265b36115fe3db32df945d84-2"`, etag)
+
+	_, err = MultipartETag(nil)
+	assert.ErrorIs(t, err, ErrInvalidPartMD5)
+	_, err = MultipartETag([]string{"f814"})
+	assert.ErrorIs(t, err, ErrInvalidPartMD5)
+}
+
+func TestChunkingPlan(t *testing.T) {
+	parts, err := ChunkingPlan(20, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []models.PartRange{
+		{Number: 1, Offset: 0, Size: 8},
+		{Number: 2, Offset: 8, Size: 8},
+		{Number: 3, Offset: 16, Size: 4},
+	}, parts)
+
+	_, err = ChunkingPlan(10, 0)
+	assert.ErrorIs(t, err, ErrInvalidChunking)
+	_, err = ChunkingPlan(10001, 1)
+	assert.ErrorIs(t, err, ErrInvalidChunking)
+}
+
+func TestGetObjectParts(t *testing.T) {
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("partNumber"))
+		w.Header().Set("ETag", `"e09e4fd6265b36115fe3db32df945d84-3"`)
+		w.Header().Set("Content-Length", "8")
+		w.Header().Set("Content-Range", "bytes 0-7/20")
+		w.Header().Set("x-amz-mp-parts-count", "3")
+		w.WriteHeader(http.StatusPartialContent)
+	})
+
+	parts, err := c.S3Service.GetObjectParts(context.Background(), "data", "big.bin")
+	require.NoError(t, err)
+	assert.True(t, parts.Multipart)
+	assert.Equal(t, int64(20), parts.Size)
+	assert.Equal(t, int64(8), parts.PartSize)
+	assert.Equal(t, 3, parts.PartCount)
+	assert.Len(t, parts.Parts, 3)
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ObjectExists reports whether an object, or one version of it, exists. S3 answers a
+// HEAD request for a missing key with a bare 404, so a missing bucket reads as a
+// missing object, and without s3:ListBucket permission S3 answers 403 instead.
+func (s *S3Service) ObjectExists(ctx context.Context, bucket, key, versionID string) (*models.ObjectExistence, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Checking object exists")
+
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	existence := &models.ObjectExistence{Bucket: bucket, Key: key, VersionID: versionID}
+	output, err := s.core.Client(bucket).HeadObject(ctx, input)
+	switch {
+	case hasErrorCode(err, "NotFound"), hasErrorCode(err, "NoSuchKey"), hasErrorCode(err, "NoSuchVersion"):
+		return existence, nil
+	case err != nil:
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to check object exists")
+		return nil, err
+	}
+
+	existence.Exists = true
+	existence.Size = aws.ToInt64(output.ContentLength)
+	existence.ETag = aws.ToString(output.ETag)
+	existence.LastModified = output.LastModified
+	return existence, nil
+}
+
+// GetPresignedHeadURL generates a presigned URL for a HEAD request on an object, so
+// other systems can check that it exists without list or read permissions of their own
+func (s *S3Service) GetPresignedHeadURL(ctx context.Context, bucket, key, versionID string, expiresIn int64) (*models.PresignedHeadURL, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Int64("expiresIn", expiresIn).
+		Msg("Generating presigned HEAD URL")
+
+	if expiresIn <= 0 {
+		expiresIn = 15 * 60 // Default to 15 minutes
+	}
+	expires := time.Duration(expiresIn) * time.Second
+
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	resp, err := s.core.Presigner(bucket).PresignHeadObject(ctx, input, func(opts *s3.PresignOptions) {
+		opts.Expires = expires
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to generate presigned HEAD URL")
+		return nil, err
+	}
+
+	return &models.PresignedHeadURL{
+		URL:       resp.URL,
+		Method:    http.MethodHead,
+		ExpiresAt: time.Now().UTC().Add(expires),
+	}, nil
+}
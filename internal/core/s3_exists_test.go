@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectExists(t *testing.T) {
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/present.txt"):
+			w.Header().Set("Content-Length", "42")
+			w.Header().Set("ETag", `"abc"`)
+			w.Header().Set("Last-Modified", "Mon, 04 Mar 2024 10:00:00 GMT")
+		case strings.HasSuffix(r.URL.Path, "/secret.txt"):
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	existence, err := c.S3Service.ObjectExists(ctx, "docs", "present.txt", "")
+	require.NoError(t, err)
+	assert.True(t, existence.Exists)
+	assert.Equal(t, int64(42), existence.Size)
+	assert.Equal(t, `"abc"`, existence.ETag)
+	require.NotNil(t, existence.LastModified)
+
+	existence, err = c.S3Service.ObjectExists(ctx, "docs", "missing.txt", "")
+	require.NoError(t, err)
+	assert.False(t, existence.Exists)
+	assert.Nil(t, existence.LastModified)
+
+	_, err = c.S3Service.ObjectExists(ctx, "docs", "secret.txt", "")
+	assert.Error(t, err)
+
+	headURL, err := c.S3Service.GetPresignedHeadURL(ctx, "docs", "present.txt", "v1", 60)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodHead, headURL.Method)
+	assert.Contains(t, headURL.URL, "/docs/present.txt?")
+	assert.Contains(t, headURL.URL, "versionId=v1")
+	assert.Contains(t, headURL.URL, "X-Amz-Expires=60")
+}
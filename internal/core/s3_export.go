@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Listing export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// ErrExportFormat is returned for export formats other than csv and ndjson
+var ErrExportFormat = errors.New("unsupported export format")
+
+// listingExportColumns are the CSV columns of a listing export, in the order of
+// models.ListingRecord's fields
+var listingExportColumns = []string{"key", "size", "lastModified", "storageClass", "etag"}
+
+// ListingExport is a recursive listing of a prefix being exported. Its first page is
+// fetched up front so a missing bucket or denied access is reported before any part
+// of the response is written.
+type ListingExport struct {
+	Bucket    string
+	Prefix    string
+	Format    string
+	paginator *s3.ListObjectsV2Paginator
+	first     *s3.ListObjectsV2Output
+}
+
+// PrepareListingExport starts exporting every object under a prefix in the given format
+func (s *S3Service) PrepareListingExport(ctx context.Context, bucket, prefix, format string) (*ListingExport, error) {
+	if format != ExportFormatCSV && format != ExportFormatNDJSON {
+		return nil, ErrExportFormat
+	}
+
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Str("format", format).
+		Msg("Exporting listing")
+
+	paginator := s3.NewListObjectsV2Paginator(s.core.Client(bucket), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	first, err := paginator.NextPage(ctx)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("prefix", prefix).
+			Msg("Failed to list objects for export")
+		return nil, err
+	}
+
+	return &ListingExport{
+		Bucket:    bucket,
+		Prefix:    prefix,
+		Format:    format,
+		paginator: paginator,
+		first:     first,
+	}, nil
+}
+
+// WriteListingExport streams an export to w, one record per object. Like WriteZip,
+// errors once writing has started can only be logged, leaving the export incomplete.
+func (s *S3Service) WriteListingExport(ctx context.Context, export *ListingExport, w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	records, err := newListingRecordWriter(export.Format, buffered)
+	if err != nil {
+		return err
+	}
+
+	page := export.first
+	for {
+		for _, obj := range page.Contents {
+			err := records.Write(models.ListingRecord{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+				StorageClass: storageClass(obj),
+				ETag:         aws.ToString(obj.ETag),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Hand each page to the client before waiting for the next one
+		if err := records.Flush(); err != nil {
+			return err
+		}
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+		if !export.paginator.HasMorePages() {
+			return nil
+		}
+
+		page, err = export.paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", export.Bucket).
+				Str("prefix", export.Prefix).
+				Msg("Failed to list objects for export")
+			return err
+		}
+	}
+}
+
+// listingRecordWriter writes the records of a listing export in one format
+type listingRecordWriter interface {
+	Write(record models.ListingRecord) error
+	Flush() error
+}
+
+// newListingRecordWriter returns the record writer for a format, having written the
+// format's header if it has one
+func newListingRecordWriter(format string, w io.Writer) (listingRecordWriter, error) {
+	if format == ExportFormatNDJSON {
+		return ndjsonRecordWriter{json.NewEncoder(w)}, nil
+	}
+
+	writer := csvRecordWriter{csv.NewWriter(w)}
+	if err := writer.csv.Write(listingExportColumns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// csvRecordWriter writes records as CSV rows with RFC 3339 timestamps
+type csvRecordWriter struct {
+	csv *csv.Writer
+}
+
+func (w csvRecordWriter) Write(record models.ListingRecord) error {
+	return w.csv.Write([]string{
+		record.Key,
+		strconv.FormatInt(record.Size, 10),
+		record.LastModified.UTC().Format(time.RFC3339),
+		record.StorageClass,
+		record.ETag,
+	})
+}
+
+func (w csvRecordWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// ndjsonRecordWriter writes records as one JSON object per line
+type ndjsonRecordWriter struct {
+	encoder *json.Encoder
+}
+
+func (w ndjsonRecordWriter) Write(record models.ListingRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w ndjsonRecordWriter) Flush() error {
+	return nil
+}
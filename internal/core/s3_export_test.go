@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListingExport(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	objects := []testObject{
+		{key: "logs/a,b.gz", size: 10, storageClass: "STANDARD", modified: modified},
+		{key: "logs/old.gz", size: 20, storageClass: "GLACIER", modified: modified},
+	}
+	c := newTestListingCore(t, &config.Config{}, objects)
+	ctx := context.Background()
+
+	_, err := c.S3Service.PrepareListingExport(ctx, "data", "logs/", "xml")
+	assert.ErrorIs(t, err, ErrExportFormat)
+
+	export, err := c.S3Service.PrepareListingExport(ctx, "data", "logs/", ExportFormatCSV)
+	require.NoError(t, err)
+	var out strings.Builder
+	require.NoError(t, c.S3Service.WriteListingExport(ctx, export, &out))
+	assert.Equal(t, "key,size,lastModified,storageClass,etag\n"+
+		"\"logs/a,b.gz\",10,2024-05-01T12:00:00Z,STANDARD,\n"+
+		"logs/old.gz,20,2024-05-01T12:00:00Z,GLACIER,\n", out.String())
+
+	export, err = c.S3Service.PrepareListingExport(ctx, "data", "logs/", ExportFormatNDJSON)
+	require.NoError(t, err)
+	out.Reset()
+	require.NoError(t, c.S3Service.WriteListingExport(ctx, export, &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"key":"logs/old.gz","size":20,"lastModified":"2024-05-01T12:00:00Z","storageClass":"GLACIER","etag":""}`, lines[1])
+}
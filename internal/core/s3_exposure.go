@@ -0,0 +1,253 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// exposureConcurrency caps the requests an exposure report makes at once
+const exposureConcurrency = 8
+
+// GetExposureReport reports what makes a bucket effectively public: its Block Public
+// Access settings, bucket policy and bucket ACL. With objects set, the ACLs of objects
+// under prefix are read too, up to reports.maxAclChecks, unless the bucket's settings
+// keep ACLs from making anything public.
+func (s *S3Service) GetExposureReport(ctx context.Context, bucket, prefix string, objects bool) (*models.ExposureReport, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Bool("objects", objects).
+		Msg("Generating exposure report")
+
+	// Fail early on a missing or inaccessible bucket; the checks below only note errors
+	if _, err := s.core.Client(bucket).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return nil, err
+	}
+
+	report := s.bucketExposure(ctx, bucket)
+	report.Prefix = prefix
+	if objects && report.ACLsEnabled && !report.PublicAccessBlock.IgnorePublicAcls {
+		if err := s.objectExposure(ctx, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// GetExposureSummary reports the bucket-level exposure of every bucket
+func (s *S3Service) GetExposureSummary(ctx context.Context) (*models.ExposureSummary, error) {
+	buckets, err := s.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ExposureSummary{
+		Buckets:     make([]models.ExposureReport, len(buckets)),
+		GeneratedAt: time.Now().UTC(),
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, exposureConcurrency)
+	for i, bucket := range buckets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer func() { <-sem; wg.Done() }()
+			summary.Buckets[i] = *s.bucketExposure(ctx, name)
+		}(i, bucket.Name)
+	}
+	wg.Wait()
+	return summary, nil
+}
+
+// bucketExposure checks a bucket's own settings. Settings that cannot be read are
+// reported as unknown rather than failing the report.
+func (s *S3Service) bucketExposure(ctx context.Context, bucket string) *models.ExposureReport {
+	client := s.core.Client(bucket)
+	report := &models.ExposureReport{
+		Bucket:      bucket,
+		ACLsEnabled: s.aclsEnabled(ctx, bucket),
+		Findings:    []models.ExposureFinding{},
+		GeneratedAt: time.Now().UTC(),
+	}
+	addFinding := func(level, source, message string) {
+		report.Findings = append(report.Findings, models.ExposureFinding{Level: level, Source: source, Message: message})
+		if level == models.ExposurePublic {
+			report.Public = true
+		}
+	}
+	pab := &report.PublicAccessBlock
+
+	blockOutput, err := client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)})
+	switch {
+	case hasErrorCode(err, "NoSuchPublicAccessBlockConfiguration"):
+		addFinding(models.ExposureWarning, "publicAccessBlock", "Block Public Access is not configured for this bucket")
+	case err != nil:
+		addFinding(models.ExposureUnknown, "publicAccessBlock", unreadable("Block Public Access settings", err))
+	default:
+		if config := blockOutput.PublicAccessBlockConfiguration; config != nil {
+			pab.Configured = true
+			pab.BlockPublicAcls = aws.ToBool(config.BlockPublicAcls)
+			pab.IgnorePublicAcls = aws.ToBool(config.IgnorePublicAcls)
+			pab.BlockPublicPolicy = aws.ToBool(config.BlockPublicPolicy)
+			pab.RestrictPublicBuckets = aws.ToBool(config.RestrictPublicBuckets)
+		}
+		if off := disabledBlocks(*pab); len(off) > 0 {
+			addFinding(models.ExposureWarning, "publicAccessBlock", "Block Public Access settings are off: "+strings.Join(off, ", "))
+		}
+	}
+
+	statusOutput, err := client.GetBucketPolicyStatus(ctx, &s3.GetBucketPolicyStatusInput{Bucket: aws.String(bucket)})
+	switch {
+	case hasErrorCode(err, "NoSuchBucketPolicy"):
+	case err != nil:
+		addFinding(models.ExposureUnknown, "bucketPolicy", unreadable("bucket policy status", err))
+	case statusOutput.PolicyStatus != nil && aws.ToBool(statusOutput.PolicyStatus.IsPublic):
+		if pab.RestrictPublicBuckets {
+			addFinding(models.ExposureWarning, "bucketPolicy", "The bucket policy grants public access, but RestrictPublicBuckets limits it to AWS services and the bucket owner's account")
+		} else {
+			addFinding(models.ExposurePublic, "bucketPolicy", "The bucket policy grants public access")
+		}
+	}
+
+	aclOutput, err := client.GetBucketAcl(ctx, &s3.GetBucketAclInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		addFinding(models.ExposureUnknown, "bucketAcl", unreadable("bucket ACL", err))
+		return report
+	}
+	for _, grant := range aclOutput.Grants {
+		if grant.Grantee == nil || !isPublicGroup(aws.ToString(grant.Grantee.URI)) {
+			continue
+		}
+		message := fmt.Sprintf("The bucket ACL grants %s to %s", grant.Permission, groupName(aws.ToString(grant.Grantee.URI)))
+		if !report.ACLsEnabled || pab.IgnorePublicAcls {
+			addFinding(models.ExposureWarning, "bucketAcl", message+", but public ACLs are ignored")
+		} else {
+			addFinding(models.ExposurePublic, "bucketAcl", message)
+		}
+	}
+	return report
+}
+
+// objectExposure reads the ACLs of objects under the report's prefix and records those
+// that grant public access
+func (s *S3Service) objectExposure(ctx context.Context, report *models.ExposureReport) error {
+	limit := s.core.Config.Reports.MaxACLChecks
+	var keys []string
+	truncated, err := s.scanPrefixLimit(ctx, report.Bucket, report.Prefix, limit, func(obj s3Types.Object) {
+		keys = append(keys, aws.ToString(obj.Key))
+	})
+	if err != nil {
+		return err
+	}
+	report.Truncated = truncated
+	report.ScanLimit = limit
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures int
+	)
+	sem := make(chan struct{}, exposureConcurrency)
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer func() { <-sem; wg.Done() }()
+			output, err := s.core.Client(report.Bucket).GetObjectAcl(ctx, &s3.GetObjectAclInput{
+				Bucket: aws.String(report.Bucket),
+				Key:    aws.String(key),
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures++
+				return
+			}
+			report.ObjectsChecked++
+			for _, grant := range output.Grants {
+				if grant.Grantee == nil || !isPublicGroup(aws.ToString(grant.Grantee.URI)) {
+					continue
+				}
+				report.Findings = append(report.Findings, models.ExposureFinding{
+					Level:   models.ExposurePublic,
+					Source:  "objectAcl",
+					Key:     key,
+					Message: fmt.Sprintf("The object ACL grants %s to %s", grant.Permission, groupName(aws.ToString(grant.Grantee.URI))),
+				})
+				report.Public = true
+			}
+		}(key)
+	}
+	wg.Wait()
+	// Checks finish in any order
+	slices.SortStableFunc(report.Findings, func(a, b models.ExposureFinding) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	if failures > 0 {
+		report.Findings = append(report.Findings, models.ExposureFinding{
+			Level:   models.ExposureUnknown,
+			Source:  "objectAcl",
+			Message: fmt.Sprintf("%d object ACLs could not be read", failures),
+		})
+	}
+	return nil
+}
+
+// disabledBlocks names the Block Public Access settings that are off
+func disabledBlocks(pab models.PublicAccessBlock) []string {
+	var off []string
+	for name, on := range map[string]bool{
+		"BlockPublicAcls":       pab.BlockPublicAcls,
+		"IgnorePublicAcls":      pab.IgnorePublicAcls,
+		"BlockPublicPolicy":     pab.BlockPublicPolicy,
+		"RestrictPublicBuckets": pab.RestrictPublicBuckets,
+	} {
+		if !on {
+			off = append(off, name)
+		}
+	}
+	slices.Sort(off)
+	return off
+}
+
+// isPublicGroup reports whether a grantee URI is a group that makes data public
+func isPublicGroup(uri string) bool {
+	return uri == allUsersGroup || uri == authenticatedUsersGroup
+}
+
+// groupName describes a public group
+func groupName(uri string) string {
+	if uri == allUsersGroup {
+		return "everyone on the internet"
+	}
+	return "every AWS account"
+}
+
+// unreadable describes a setting that could not be read
+func unreadable(setting string, err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("The %s could not be read: %s", setting, apiErr.ErrorCode())
+	}
+	return fmt.Sprintf("The %s could not be read", setting)
+}
+
+// hasErrorCode reports whether err is an S3 error with the given code
+func hasErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
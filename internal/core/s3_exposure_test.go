@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const publicReadGrant = `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group">` +
+	`<URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ</Permission></Grant>`
+
+// newTestExposureCore fakes a bucket "site" without Block Public Access, with a public
+// bucket policy, and with objects a.html (public) and b.html (private)
+func newTestExposureCore(t *testing.T, publicAccessBlock string) *Core {
+	cfg := &config.Config{Reports: config.ReportsConfig{MaxObjects: 100, MaxACLChecks: 100}}
+	return newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		query := r.URL.Query()
+		switch {
+		case query.Has("publicAccessBlock") && publicAccessBlock == "":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchPublicAccessBlockConfiguration</Code></Error>`)
+		case query.Has("publicAccessBlock"):
+			fmt.Fprintf(w, `<PublicAccessBlockConfiguration>%s</PublicAccessBlockConfiguration>`, publicAccessBlock)
+		case query.Has("policyStatus"):
+			fmt.Fprint(w, `<PolicyStatus><IsPublic>true</IsPublic></PolicyStatus>`)
+		case query.Has("ownershipControls"):
+			fmt.Fprint(w, `<OwnershipControls><Rule><ObjectOwnership>ObjectWriter</ObjectOwnership></Rule></OwnershipControls>`)
+		case query.Has("acl"):
+			grants := ""
+			if strings.HasSuffix(r.URL.Path, "/a.html") {
+				grants = publicReadGrant
+			}
+			fmt.Fprintf(w, `<AccessControlPolicy><Owner><ID>o</ID></Owner><AccessControlList>%s</AccessControlList></AccessControlPolicy>`, grants)
+		case query.Get("list-type") == "2":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated><KeyCount>2</KeyCount>`+
+				`<Contents><Key>b.html</Key></Contents><Contents><Key>a.html</Key></Contents></ListBucketResult>`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+func TestGetExposureReport(t *testing.T) {
+	c := newTestExposureCore(t, "")
+
+	report, err := c.S3Service.GetExposureReport(context.Background(), "site", "", true)
+	require.NoError(t, err)
+	assert.True(t, report.Public)
+	assert.False(t, report.PublicAccessBlock.Configured)
+	assert.Equal(t, 2, report.ObjectsChecked)
+	assert.Equal(t, []models.ExposureFinding{
+		{Level: models.ExposureWarning, Source: "publicAccessBlock", Message: "Block Public Access is not configured for this bucket"},
+		{Level: models.ExposurePublic, Source: "bucketPolicy", Message: "The bucket policy grants public access"},
+		{Level: models.ExposurePublic, Source: "objectAcl", Key: "a.html", Message: "The object ACL grants READ to everyone on the internet"},
+	}, report.Findings)
+}
+
+func TestGetExposureReport_Blocked(t *testing.T) {
+	c := newTestExposureCore(t, "<BlockPublicAcls>true</BlockPublicAcls><IgnorePublicAcls>true</IgnorePublicAcls>"+
+		"<BlockPublicPolicy>true</BlockPublicPolicy><RestrictPublicBuckets>true</RestrictPublicBuckets>")
+
+	report, err := c.S3Service.GetExposureReport(context.Background(), "site", "", true)
+	require.NoError(t, err)
+	assert.False(t, report.Public)
+	// Ignored ACLs cannot make objects public, so they are not read
+	assert.Zero(t, report.ObjectsChecked)
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, models.ExposureWarning, report.Findings[0].Level)
+	assert.Equal(t, "bucketPolicy", report.Findings[0].Source)
+}
+
+func TestDisabledBlocks(t *testing.T) {
+	assert.Equal(t, []string{"BlockPublicPolicy", "RestrictPublicBuckets"},
+		disabledBlocks(models.PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true}))
+	assert.Empty(t, disabledBlocks(models.PublicAccessBlock{
+		BlockPublicAcls: true, IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true,
+	}))
+}
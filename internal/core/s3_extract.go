@@ -0,0 +1,265 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// JobTypeExtractArchive identifies archive extraction jobs
+const JobTypeExtractArchive = "extract-archive"
+
+var (
+	// ErrUnsafeArchiveEntry is returned for entries whose names would escape the target prefix
+	ErrUnsafeArchiveEntry = errors.New("archive entry path escapes target prefix")
+	// ErrArchiveEntryTooLarge is returned for entries above the configured size limit
+	ErrArchiveEntryTooLarge = errors.New("archive entry exceeds size limit")
+	// ErrArchiveLimitExceeded is returned when an archive has too many entries or too much data
+	ErrArchiveLimitExceeded = errors.New("archive exceeds extraction limits")
+)
+
+// ExtractArchive starts a background job that unpacks a zip or tar archive object into
+// targetPrefix of targetBucket. The source is checked before the job is queued so a
+// missing object or unsupported format is reported to the caller directly. Extracted
+// objects are encrypted with kmsKeyID when set.
+func (s *S3Service) ExtractArchive(ctx context.Context, bucket, key, targetBucket, targetPrefix, kmsKeyID string) (models.Job, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("targetBucket", targetBucket).
+		Str("targetPrefix", targetPrefix).
+		Msg("Starting archive extraction")
+
+	format := archiveFormat(key)
+	if format == "" {
+		return models.Job{}, ErrArchiveNotSupported
+	}
+
+	kms, err := s.kmsFor(kmsKeyID, nil)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get archive metadata")
+		return models.Job{}, err
+	}
+	size := aws.ToInt64(head.ContentLength)
+
+	if targetPrefix != "" && !strings.HasSuffix(targetPrefix, "/") {
+		targetPrefix += "/"
+	}
+
+	params := map[string]string{
+		"bucket":       bucket,
+		"key":          key,
+		"targetBucket": targetBucket,
+		"targetPrefix": targetPrefix,
+	}
+	if kmsKeyID != "" {
+		params["kmsKeyId"] = kmsKeyID
+	}
+
+	extractor := &archiveExtractor{
+		service:      s,
+		bucket:       bucket,
+		key:          key,
+		targetBucket: targetBucket,
+		targetPrefix: targetPrefix,
+		kms:          kms,
+	}
+
+	return s.core.Jobs.Submit(JobTypeExtractArchive, params, func(ctx context.Context, job *Job) error {
+		extractor.job = job
+		if format == "zip" {
+			return extractor.extractZip(ctx, size)
+		}
+		return extractor.extractTar(ctx, format == "tar.gz")
+	}), nil
+}
+
+// archiveExtractor holds the state of a single extraction job
+type archiveExtractor struct {
+	service      *S3Service
+	job          *Job
+	bucket       string
+	key          string
+	targetBucket string
+	targetPrefix string
+	kms          sseKMS
+
+	entries    int
+	totalBytes int64
+}
+
+// extractZip unpacks a zip archive, reading it through ranged requests
+func (e *archiveExtractor) extractZip(ctx context.Context, size int64) error {
+	readerAt := newObjectReaderAt(ctx, e.service.core.Client(e.bucket), e.bucket, e.key, size)
+	zipReader, err := zip.NewReader(readerAt, readerAt.Size())
+	if err != nil {
+		if errors.Is(err, zip.ErrFormat) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrInvalidArchive
+		}
+		return err
+	}
+
+	e.job.AddTotal(int64(len(zipReader.File)))
+	for _, file := range zipReader.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if file.FileInfo().IsDir() {
+			e.job.ItemSucceeded(file.Name, 0)
+			continue
+		}
+
+		err := e.extractEntry(ctx, file.Name, int64(file.UncompressedSize64), func() (io.ReadCloser, error) {
+			return file.Open()
+		})
+		if errors.Is(err, ErrArchiveLimitExceeded) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractTar unpacks a tarball, streaming it from start to end
+func (e *archiveExtractor) extractTar(ctx context.Context, gzipped bool) error {
+	output, err := e.service.core.Client(e.bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(e.bucket),
+		Key:    aws.String(e.key),
+	})
+	if err != nil {
+		return err
+	}
+	defer output.Body.Close()
+
+	var reader io.Reader = output.Body
+	if gzipped {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return ErrInvalidArchive
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return ErrInvalidArchive
+		}
+
+		// Only regular files carry data; links and devices are never materialised
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		e.job.AddTotal(1)
+		err = e.extractEntry(ctx, header.Name, header.Size, func() (io.ReadCloser, error) {
+			return io.NopCloser(tarReader), nil
+		})
+		if errors.Is(err, ErrArchiveLimitExceeded) {
+			return err
+		}
+	}
+}
+
+// extractEntry validates a single archive entry and uploads it. Per-entry failures are
+// recorded on the job; only ErrArchiveLimitExceeded aborts the whole extraction.
+func (e *archiveExtractor) extractEntry(ctx context.Context, name string, size int64, open func() (io.ReadCloser, error)) error {
+	limits := e.service.core.Config.Extract
+
+	e.entries++
+	if e.entries > limits.MaxEntries {
+		return ErrArchiveLimitExceeded
+	}
+
+	relative, ok := safeArchivePath(name)
+	if !ok {
+		e.job.ItemFailed(name, ErrUnsafeArchiveEntry)
+		return ErrUnsafeArchiveEntry
+	}
+	if size > limits.MaxEntryBytes {
+		e.job.ItemFailed(name, ErrArchiveEntryTooLarge)
+		return ErrArchiveEntryTooLarge
+	}
+	if e.totalBytes+size > limits.MaxTotalBytes {
+		return ErrArchiveLimitExceeded
+	}
+	e.totalBytes += size
+
+	body, err := open()
+	if err != nil {
+		e.job.ItemFailed(name, err)
+		return err
+	}
+	defer body.Close()
+
+	targetKey := e.targetPrefix + relative
+	sse, kmsKeyID := e.kms.params()
+	_, err = e.service.core.Client(e.targetBucket).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(e.targetBucket),
+		Key:                  aws.String(targetKey),
+		Body:                 io.LimitReader(body, size),
+		ContentLength:        aws.Int64(size),
+		ContentType:          aws.String(detectContentType(targetKey)),
+		ServerSideEncryption: sse,
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	if err != nil {
+		e.service.core.Logger.Error().
+			Err(err).
+			Str("bucket", e.targetBucket).
+			Str("key", targetKey).
+			Msg("Failed to upload extracted entry")
+		e.job.ItemFailed(name, fmt.Errorf("upload failed: %w", err))
+		return err
+	}
+
+	e.service.core.Events.Publish(EventObjectCreated, e.targetBucket, targetKey)
+	e.job.ItemSucceeded(name, size)
+	return nil
+}
+
+// safeArchivePath normalises an archive entry name into a relative key suffix. Names
+// that are absolute or climb out of the extraction root ("zip slip") are rejected.
+func safeArchivePath(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") || strings.ContainsRune(name, 0) {
+		return "", false
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	return cleaned, true
+}
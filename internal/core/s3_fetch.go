@@ -0,0 +1,374 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// JobTypeFetchObject is the job type of imports from remote URLs
+const JobTypeFetchObject = "fetch-object"
+
+const (
+	// fetchPartSize is the smallest part imports are uploaded in. One part is held in
+	// memory at a time; objects that fit in one are uploaded with a single PutObject.
+	fetchPartSize = 16 * 1024 * 1024
+	// maxFetchRedirects caps the redirects followed when fetching a URL
+	maxFetchRedirects = 5
+)
+
+var (
+	// ErrInvalidFetchURL is returned for fetch URLs that are not absolute HTTPS URLs
+	ErrInvalidFetchURL = errors.New("fetch URL must be an absolute https URL")
+	// ErrFetchHostNotAllowed is returned for fetch URLs whose host is not in fetch.allowedHosts
+	ErrFetchHostNotAllowed = errors.New("host is not allowed for fetching")
+	// ErrFetchTooLarge is returned when a remote object is larger than fetch.maxBytes
+	ErrFetchTooLarge = errors.New("remote object exceeds the import size limit")
+
+	// errFetchAddressBlocked is returned when a fetch would connect to a private address
+	errFetchAddressBlocked = errors.New("address is on a private network")
+)
+
+// newFetchClient returns the HTTP client imports are downloaded with. It connects
+// only to public addresses unless fetch.allowPrivateNetworks is set, checked on every
+// connection so DNS answers and redirects cannot lead it to internal services, and
+// ignores proxy settings for the same reason.
+func newFetchClient(cfg *config.Config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			if cfg.Fetch.AllowPrivateNetworks {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicAddress(ip) {
+				return fmt.Errorf("%w: %s", errFetchAddressBlocked, host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: time.Minute,
+			ForceAttemptHTTP2:     true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			return checkFetchURL(cfg.Fetch, req.URL)
+		},
+	}
+}
+
+// nonPublicNetworks are special-purpose ranges the stdlib predicates do not cover but
+// that still route to internal hosts: "this network", carrier-grade NAT, benchmarking
+// and NAT64, which embeds IPv4 addresses, private ones included
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("198.18.0.0/15"),
+	mustParseCIDR("64:ff9b::/96"),
+	mustParseCIDR("64:ff9b:1::/48"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isPublicAddress reports whether an IP address is reachable on the internet, as
+// opposed to loopback, private, link-local (such as cloud metadata services) and other
+// special addresses
+func isPublicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFetchURL checks that a URL is an HTTPS URL on an allowed host
+func checkFetchURL(cfg config.FetchConfig, u *url.URL) error {
+	if u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidFetchURL
+	}
+	if len(cfg.AllowedHosts) == 0 {
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range cfg.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed {
+			return nil
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrFetchHostNotAllowed, host)
+}
+
+// FetchObject starts a background job that downloads an HTTPS URL and uploads it to
+// key, streaming it through the server, so large files do not have to pass through a
+// client. The content type is the requested one, else the one the remote server
+// reports, else the one the key or URL path implies, else the one the first bytes
+// suggest. Imports are refused when uploads.requirePolicy is set, as the limits of a
+// policy are meant for presigned uploads. An existing key is handled by the conflict
+// strategy when the job is started; the job's key parameter is the key written.
+func (s *S3Service) FetchObject(ctx context.Context, bucket string, req models.FetchObjectRequest) (models.Job, error) {
+	if s.UploadPolicyRequired() {
+		return models.Job{}, ErrUploadPolicyRequired
+	}
+	source, err := url.Parse(req.URL)
+	if err != nil {
+		return models.Job{}, ErrInvalidFetchURL
+	}
+	if err := checkFetchURL(s.core.Config.Fetch, source); err != nil {
+		return models.Job{}, err
+	}
+	kms, err := s.kmsFor(req.KMSKeyID, nil)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	// Fail early on a missing or inaccessible bucket
+	if _, err := s.core.Client(bucket).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return models.Job{}, err
+	}
+	key, err := s.resolveConflict(ctx, bucket, req.Key, req.Conflict)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	params := map[string]string{
+		"bucket": bucket,
+		"key":    key,
+		"url":    source.Redacted(),
+	}
+	return s.core.Jobs.Submit(JobTypeFetchObject, params, func(ctx context.Context, job *Job) error {
+		job.AddTotal(1)
+		size, err := s.fetchObject(ctx, bucket, key, source, req.ContentType, kms)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", key).
+				Str("url", source.Redacted()).
+				Msg("Failed to import object from URL")
+			job.ItemFailed(key, err)
+			return err
+		}
+		job.ItemSucceeded(key, size)
+		return nil
+	}), nil
+}
+
+// fetchObject downloads source into key and returns the size imported
+func (s *S3Service) fetchObject(ctx context.Context, bucket, key string, source *url.URL, contentType string, kms sseKMS) (int64, error) {
+	cfg := s.core.Config.Fetch
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	response, err := s.fetchClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("remote server answered %s", response.Status)
+	}
+	if response.ContentLength > cfg.MaxBytes {
+		return 0, fmt.Errorf("%w: %d bytes", ErrFetchTooLarge, response.ContentLength)
+	}
+
+	body := bufio.NewReaderSize(&maxBytesReader{r: response.Body, remaining: cfg.MaxBytes}, sniffLength)
+	if contentType == "" {
+		contentType = fetchedContentType(key, response, body)
+	}
+
+	partSize := max(fetchPartSize, cfg.MaxBytes/maxUploadParts+1)
+	return s.uploadStream(ctx, bucket, key, body, partSize, contentType, kms)
+}
+
+// fetchedContentType tells the content type of a fetched object from the response,
+// the key, the URL path or the first bytes of the body, in that order
+func fetchedContentType(key string, response *http.Response, body *bufio.Reader) string {
+	if reported := response.Header.Get("Content-Type"); !isGenericContentType(reported) {
+		if mediaType, _, err := mime.ParseMediaType(reported); err == nil {
+			return mediaType
+		}
+	}
+	if contentType, ok := extensionContentType(key); ok {
+		return contentType
+	}
+	if contentType, ok := extensionContentType(path.Base(response.Request.URL.Path)); ok {
+		return contentType
+	}
+	// A short body is read in full, which Peek reports as an error
+	data, _ := body.Peek(sniffLength)
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+// maxUploadParts is the most parts S3 accepts in a multipart upload
+const maxUploadParts = 10000
+
+// uploadStream uploads a stream of unknown length to key, in parts of partSize when it
+// does not fit in one, and returns its size. Failed multipart uploads are aborted.
+func (s *S3Service) uploadStream(ctx context.Context, bucket, key string, body io.Reader, partSize int64, contentType string, kms sseKMS) (int64, error) {
+	client := s.core.Client(bucket)
+	sse, kmsKeyID := kms.params()
+	buf := make([]byte, partSize)
+
+	n, err := io.ReadFull(body, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+	if int64(n) < partSize {
+		_, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               aws.String(bucket),
+			Key:                  aws.String(key),
+			Body:                 bytes.NewReader(buf[:n]),
+			ContentLength:        aws.Int64(int64(n)),
+			ContentType:          aws.String(contentType),
+			ServerSideEncryption: sse,
+			SSEKMSKeyId:          kmsKeyID,
+		})
+		if err != nil {
+			return 0, err
+		}
+		s.core.Events.Publish(EventObjectCreated, bucket, key)
+		return int64(n), nil
+	}
+
+	upload, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: sse,
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	abort := func(err error) (int64, error) {
+		// The upload's parts would otherwise be kept, and billed, until a lifecycle rule
+		// removes them
+		_, abortErr := client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		})
+		if abortErr != nil {
+			s.core.Logger.Warn().
+				Err(abortErr).
+				Str("bucket", bucket).
+				Str("key", key).
+				Msg("Failed to abort multipart upload")
+		}
+		return 0, err
+	}
+
+	var (
+		parts []s3Types.CompletedPart
+		size  int64
+	)
+	for n > 0 {
+		partNumber := int32(len(parts) + 1)
+		if partNumber > maxUploadParts {
+			return abort(fmt.Errorf("%w: more than %d parts", ErrFetchTooLarge, maxUploadParts))
+		}
+		output, err := client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(bucket),
+			Key:           aws.String(key),
+			UploadId:      upload.UploadId,
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return abort(err)
+		}
+		parts = append(parts, s3Types.CompletedPart{ETag: output.ETag, PartNumber: aws.Int32(partNumber)})
+		size += int64(n)
+
+		n, err = io.ReadFull(body, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return abort(err)
+		}
+	}
+
+	_, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3Types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(err)
+	}
+	s.core.Events.Publish(EventObjectCreated, bucket, key)
+	return size, nil
+}
+
+// maxBytesReader fails with ErrFetchTooLarge once more than remaining bytes are read,
+// for bodies whose length was not announced
+type maxBytesReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining < 0 {
+		return 0, ErrFetchTooLarge
+	}
+	// Read one byte past the limit to tell a body of exactly the limit from a larger one
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return n, ErrFetchTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPreviewTooLarge is returned when an object exceeds the size limit for formatting
+var ErrPreviewTooLarge = errors.New("object too large to format")
+
+// yamlErrorLine extracts the line number from yaml.v3 error messages such as
+// "yaml: line 3: mapping values are not allowed in this context"
+var yamlErrorLine = regexp.MustCompile(`line (\d+): (.*)`)
+
+// structuredFormat returns "json" or "yaml" for objects that can be pretty-printed
+func structuredFormat(mediaType, key string) string {
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "yaml"):
+		return "yaml"
+	}
+
+	lower := strings.ToLower(key)
+	switch {
+	case strings.HasSuffix(lower, ".json"):
+		return "json"
+	case strings.HasSuffix(lower, ".yaml"), strings.HasSuffix(lower, ".yml"):
+		return "yaml"
+	default:
+		return ""
+	}
+}
+
+// GetFormattedPreview validates a JSON or YAML object and returns a pretty-printed copy.
+// Syntax errors are reported with their line numbers rather than as a failure.
+func (s *S3Service) GetFormattedPreview(ctx context.Context, bucket, key string, decompress bool) (*models.FormattedPreview, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Getting formatted preview")
+
+	maxBytes := s.core.Config.Preview.MaxFormatBytes
+
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object for formatted preview")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	gzipped := decompress && isGzipObject(aws.ToString(output.ContentEncoding), aws.ToString(output.ContentType), key)
+	formatKey := key
+	if gzipped {
+		formatKey = trimGzipExtension(key)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(aws.ToString(output.ContentType))
+	format := structuredFormat(mediaType, formatKey)
+	if format == "" {
+		return nil, ErrPreviewNotSupported
+	}
+
+	var data []byte
+	if gzipped {
+		var truncated bool
+		data, truncated, err = readGzipPrefix(output.Body, maxBytes)
+		if err != nil {
+			return nil, ErrPreviewNotSupported
+		}
+		if truncated {
+			return nil, ErrPreviewTooLarge
+		}
+	} else {
+		if aws.ToInt64(output.ContentLength) > maxBytes {
+			return nil, ErrPreviewTooLarge
+		}
+		data, err = io.ReadAll(io.LimitReader(output.Body, maxBytes))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	preview := &models.FormattedPreview{
+		Key:    key,
+		Format: format,
+		Size:   int64(len(data)),
+		Errors: make([]models.SyntaxError, 0),
+	}
+
+	var syntaxErr *models.SyntaxError
+	if format == "json" {
+		preview.Content, syntaxErr = formatJSON(data)
+	} else {
+		preview.Content, syntaxErr = formatYAML(data)
+	}
+	if syntaxErr != nil {
+		preview.Errors = append(preview.Errors, *syntaxErr)
+		// Show the original text so the error can be located in it
+		preview.Content = strings.ToValidUTF8(string(data), "�")
+	}
+	preview.Valid = syntaxErr == nil
+
+	return preview, nil
+}
+
+// formatJSON indents a JSON document, or reports where it is malformed
+func formatJSON(data []byte) (string, *models.SyntaxError) {
+	var buf bytes.Buffer
+	err := json.Indent(&buf, data, "", "  ")
+	if err == nil {
+		return buf.String() + "\n", nil
+	}
+
+	result := &models.SyntaxError{Message: err.Error()}
+	var jsonErr *json.SyntaxError
+	if errors.As(err, &jsonErr) {
+		// Offset counts the bytes read, including the offending one
+		result.Line, result.Column = lineAndColumn(data, jsonErr.Offset-1)
+	}
+	return "", result
+}
+
+// formatYAML re-encodes every document in a YAML stream with consistent indentation,
+// keeping comments, or reports where it is malformed
+func formatYAML(data []byte) (string, *models.SyntaxError) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			result := &models.SyntaxError{Message: err.Error()}
+			if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+				result.Line, _ = strconv.Atoi(match[1])
+				result.Message = match[2]
+			}
+			return "", result
+		}
+
+		if err := encoder.Encode(&node); err != nil {
+			return "", &models.SyntaxError{Message: err.Error()}
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", &models.SyntaxError{Message: err.Error()}
+	}
+	return buf.String(), nil
+}
+
+// lineAndColumn converts a byte offset into a 1-based line and column
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(data)))
+	before := data[:offset]
+
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
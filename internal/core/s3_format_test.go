@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredFormat(t *testing.T) {
+	assert.Equal(t, "json", structuredFormat("application/json", "x"))
+	assert.Equal(t, "json", structuredFormat("application/geo+json", "x"))
+	assert.Equal(t, "yaml", structuredFormat("application/x-yaml", "x"))
+	assert.Equal(t, "yaml", structuredFormat("application/octet-stream", "deploy.YML"))
+	assert.Equal(t, "json", structuredFormat("", "data.json"))
+	assert.Equal(t, "", structuredFormat("text/plain", "notes.txt"))
+}
+
+func TestFormatJSON(t *testing.T) {
+	content, syntaxErr := formatJSON([]byte(`{"a":[1,2],"b":{"c":true}}`))
+	require.Nil(t, syntaxErr)
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {\n    \"c\": true\n  }\n}\n", content)
+
+	_, syntaxErr = formatJSON([]byte("{\n  \"a\": 1,\n  \"b\" 2\n}"))
+	require.NotNil(t, syntaxErr)
+	assert.Equal(t, 3, syntaxErr.Line)
+	assert.Equal(t, 7, syntaxErr.Column)
+}
+
+func TestFormatYAML(t *testing.T) {
+	content, syntaxErr := formatYAML([]byte("a:\n    - 1\n    - 2 # two\n---\nb: c\n"))
+	require.Nil(t, syntaxErr)
+	assert.Equal(t, "a:\n  - 1\n  - 2 # two\n---\nb: c\n", content)
+
+	_, syntaxErr = formatYAML([]byte("a: 1\nb: [1, 2\nc: 3\n"))
+	require.NotNil(t, syntaxErr)
+	assert.NotZero(t, syntaxErr.Line)
+	assert.NotEmpty(t, syntaxErr.Message)
+}
+
+func TestLineAndColumn(t *testing.T) {
+	data := []byte("ab\ncd\n")
+	line, column := lineAndColumn(data, 0)
+	assert.Equal(t, []int{1, 1}, []int{line, column})
+	line, column = lineAndColumn(data, 4)
+	assert.Equal(t, []int{2, 2}, []int{line, column})
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// JobTypeGarbageCleanup is the job type of garbage cleanups
+const JobTypeGarbageCleanup = "garbage-cleanup"
+
+// GetGarbageReport lists the incomplete multipart uploads and empty folder markers of a
+// bucket, or a prefix within it, older than reports.garbageAge, and the share links to
+// its objects that expired or were revoked
+func (s *S3Service) GetGarbageReport(ctx context.Context, bucket, prefix string) (*models.GarbageReport, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Msg("Generating garbage report")
+
+	now := time.Now().UTC()
+	minAge := s.core.Config.Reports.GarbageAge
+	report := &models.GarbageReport{
+		Bucket:            bucket,
+		Prefix:            prefix,
+		MinAge:            minAge.String(),
+		IncompleteUploads: []models.IncompleteUpload{},
+		FolderMarkers:     []models.FolderMarker{},
+		EndedShares:       []models.Share{},
+		ScanLimit:         s.core.Config.Reports.MaxObjects,
+		GeneratedAt:       now,
+	}
+
+	paginator := s3.NewListMultipartUploadsPaginator(s.core.Client(bucket), &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("prefix", prefix).
+				Msg("Failed to list multipart uploads for report")
+			return nil, err
+		}
+		for _, upload := range page.Uploads {
+			initiated := aws.ToTime(upload.Initiated)
+			if now.Sub(initiated) < minAge {
+				continue
+			}
+			report.IncompleteUploads = append(report.IncompleteUploads, models.IncompleteUpload{
+				Key:       aws.ToString(upload.Key),
+				UploadID:  aws.ToString(upload.UploadId),
+				Initiated: initiated,
+			})
+		}
+	}
+
+	markers, truncated, err := s.emptyFolderMarkers(ctx, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, marker := range markers {
+		if now.Sub(marker.LastModified) >= minAge {
+			report.FolderMarkers = append(report.FolderMarkers, marker)
+		}
+	}
+	report.Truncated = truncated
+
+	report.EndedShares = append(report.EndedShares, s.core.Shares.Ended(bucket, prefix)...)
+	return report, nil
+}
+
+// emptyFolderMarkers lists the folder markers under a prefix with no object under them
+// other than further folder markers. When the listing is truncated, markers whose
+// folder may continue past the last key listed are left out.
+func (s *S3Service) emptyFolderMarkers(ctx context.Context, bucket, prefix string) ([]models.FolderMarker, bool, error) {
+	var markers []models.FolderMarker
+	used := make(map[string]bool)
+	last := ""
+	truncated, err := s.scanPrefix(ctx, bucket, prefix, func(obj s3Types.Object) {
+		key := aws.ToString(obj.Key)
+		last = key
+		if strings.HasSuffix(key, "/") && aws.ToInt64(obj.Size) == 0 {
+			markers = append(markers, models.FolderMarker{Key: key, LastModified: aws.ToTime(obj.LastModified)})
+			return
+		}
+		for folder := parentFolder(key); folder != "" && !used[folder]; folder = parentFolder(strings.TrimSuffix(folder, "/")) {
+			used[folder] = true
+		}
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	markers = slices.DeleteFunc(markers, func(marker models.FolderMarker) bool {
+		return used[marker.Key] || (truncated && strings.HasPrefix(last, marker.Key))
+	})
+	return markers, truncated, nil
+}
+
+// StartGarbageCleanup starts a background job that aborts the incomplete multipart
+// uploads, deletes the empty folder markers and forgets the ended share links a fresh
+// garbage report lists. Each removal is logged with the user who started the cleanup
+// and listed in the job's results.
+func (s *S3Service) StartGarbageCleanup(ctx context.Context, bucket, prefix, user string) (models.Job, error) {
+	// Fail early on a missing or inaccessible bucket
+	if _, err := s.core.Client(bucket).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return models.Job{}, err
+	}
+
+	params := map[string]string{"bucket": bucket, "prefix": prefix, "user": user}
+	return s.core.Jobs.Submit(JobTypeGarbageCleanup, params, func(ctx context.Context, job *Job) error {
+		report, err := s.GetGarbageReport(ctx, bucket, prefix)
+		if err != nil {
+			return err
+		}
+		job.AddTotal(int64(len(report.IncompleteUploads) + len(report.FolderMarkers) + len(report.EndedShares)))
+
+		audit := func(kind, item string) {
+			s.core.Logger.Info().
+				Str("bucket", bucket).
+				Str("kind", kind).
+				Str("item", item).
+				Str("user", user).
+				Msg("Garbage removed")
+		}
+
+		for _, upload := range report.IncompleteUploads {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			item := bucket + "/" + upload.Key + "?uploadId=" + upload.UploadID
+			if err := s.AbortMultipartUpload(ctx, bucket, upload.Key, upload.UploadID); err != nil && !hasErrorCode(err, "NoSuchUpload") {
+				job.ItemFailed(item, err)
+				continue
+			}
+			audit("incomplete-upload", item)
+			job.ItemReported(item, 0)
+		}
+
+		for _, marker := range report.FolderMarkers {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			item := bucket + "/" + marker.Key
+			if err := s.DeleteObject(ctx, bucket, marker.Key); err != nil {
+				job.ItemFailed(item, err)
+				continue
+			}
+			audit("folder-marker", item)
+			job.ItemReported(item, 0)
+		}
+
+		tokens := make([]string, 0, len(report.EndedShares))
+		for _, share := range report.EndedShares {
+			tokens = append(tokens, share.Token)
+		}
+		removed, err := s.core.Shares.Purge(tokens)
+		if err != nil {
+			for _, token := range tokens {
+				job.ItemFailed("share/"+token, err)
+			}
+			return nil
+		}
+		for _, token := range removed {
+			audit("ended-share", "share/"+token)
+			job.ItemReported("share/"+token, 0)
+		}
+		return nil
+	}), nil
+}
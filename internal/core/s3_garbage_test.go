@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGarbageReportAndCleanup(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	fresh := time.Now().UTC().Format(time.RFC3339)
+
+	var mu sync.Mutex
+	var removed []string
+	cfg := &config.Config{
+		Reports: config.ReportsConfig{MaxObjects: 1000, GarbageAge: 24 * time.Hour},
+		Jobs:    config.JobsConfig{MaxConcurrent: 1, Retention: 10},
+		Shares:  config.SharesConfig{MaxExpiry: 24 * time.Hour},
+	}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodHead:
+		case r.Method == http.MethodGet && query.Has("uploads"):
+			fmt.Fprintf(w, `<ListMultipartUploadsResult><IsTruncated>false</IsTruncated>`+
+				`<Upload><Key>big.iso</Key><UploadId>up-old</UploadId><Initiated>%s</Initiated></Upload>`+
+				`<Upload><Key>new.iso</Key><UploadId>up-new</UploadId><Initiated>%s</Initiated></Upload>`+
+				`</ListMultipartUploadsResult>`, old, fresh)
+		case r.Method == http.MethodGet:
+			fmt.Fprintf(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`+
+				`<Contents><Key>empty/</Key><Size>0</Size><LastModified>%[1]s</LastModified></Contents>`+
+				`<Contents><Key>empty/sub/</Key><Size>0</Size><LastModified>%[1]s</LastModified></Contents>`+
+				`<Contents><Key>just-created/</Key><Size>0</Size><LastModified>%[2]s</LastModified></Contents>`+
+				`<Contents><Key>reports/</Key><Size>0</Size><LastModified>%[1]s</LastModified></Contents>`+
+				`<Contents><Key>reports/2024/q1.csv</Key><Size>10</Size><LastModified>%[1]s</LastModified></Contents>`+
+				`</ListBucketResult>`, old, fresh)
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			removed = append(removed, r.URL.Path+"?"+query.Get("uploadId"))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	c.Jobs = NewJobManager(c)
+	c.Shares = NewShareService(c)
+	now := time.Now().UTC()
+	_, err := c.Shares.Import([]models.Share{
+		{Token: "expired", Bucket: "docs", Key: "reports/2024/q1.csv", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+		{Token: "active", Bucket: "docs", Key: "reports/2024/q1.csv", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{Token: "elsewhere", Bucket: "photos", Key: "cat.jpg", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	report, err := c.S3Service.GetGarbageReport(ctx, "docs", "")
+	require.NoError(t, err)
+	assert.Equal(t, "24h0m0s", report.MinAge)
+	require.Len(t, report.IncompleteUploads, 1)
+	assert.Equal(t, "up-old", report.IncompleteUploads[0].UploadID)
+	// Folders holding objects and folders just created are not garbage
+	require.Len(t, report.FolderMarkers, 2)
+	assert.Equal(t, "empty/", report.FolderMarkers[0].Key)
+	assert.Equal(t, "empty/sub/", report.FolderMarkers[1].Key)
+	require.Len(t, report.EndedShares, 1)
+	assert.Equal(t, "expired", report.EndedShares[0].Token)
+	assert.False(t, report.Truncated)
+
+	submitted, err := c.S3Service.StartGarbageCleanup(ctx, "docs", "", "ann")
+	require.NoError(t, err)
+	assert.Equal(t, "ann", submitted.Params["user"])
+
+	job := waitForJob(t, c.Jobs, submitted.ID)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, int64(4), job.Progress.Completed)
+	assert.Equal(t, []string{"docs/big.iso?uploadId=up-old", "docs/empty/", "docs/empty/sub/", "share/expired"},
+		[]string{job.Results[0].Key, job.Results[1].Key, job.Results[2].Key, job.Results[3].Key})
+	assert.ElementsMatch(t, []string{"/docs/big.iso?up-old", "/docs/empty/?", "/docs/empty/sub/?"}, removed)
+
+	_, err = c.Shares.Get("expired")
+	assert.ErrorIs(t, err, ErrShareNotFound)
+	_, err = c.Shares.Get("active")
+	assert.NoError(t, err)
+}
+
+func TestEmptyFolderMarkersTruncated(t *testing.T) {
+	c := newTestListingCore(t, &config.Config{Reports: config.ReportsConfig{MaxObjects: 3}}, []testObject{
+		{key: "a/", modified: time.Now()},
+		{key: "b/", modified: time.Now()},
+		{key: "b/c/", modified: time.Now()},
+		{key: "b/c/d.txt", size: 1, modified: time.Now()},
+	})
+
+	// The listing stops at b/c/, so b/ and b/c/ may have more under them
+	markers, truncated, err := c.S3Service.emptyFolderMarkers(context.Background(), "docs", "")
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	require.Len(t, markers, 1)
+	assert.Equal(t, "a/", markers[0].Key)
+}
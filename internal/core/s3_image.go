@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// imageMetadataBytes is how much of an image is read to find its dimensions and EXIF data.
+// JPEG EXIF segments are limited to 64KB and image headers precede the pixel data.
+const imageMetadataBytes = 256 * 1024
+
+// ErrNotAnImage is returned when image metadata is requested for an object that is not an image
+var ErrNotAnImage = errors.New("object is not a supported image")
+
+// GetImageMetadata reads the dimensions and EXIF details of an image object from its
+// leading bytes. GPS coordinates are omitted when includeGPS is false.
+func (s *S3Service) GetImageMetadata(ctx context.Context, bucket, key string, includeGPS bool) (*models.ImageMetadata, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Getting image metadata")
+
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for image")
+		return nil, err
+	}
+
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(aws.ToString(head.ContentType), ";")[0]))
+	if !thumbnailSourceTypes[contentType] {
+		contentType = detectContentType(key)
+	}
+	if !thumbnailSourceTypes[contentType] {
+		return nil, ErrNotAnImage
+	}
+
+	size := aws.ToInt64(head.ContentLength)
+	metadata := &models.ImageMetadata{
+		Key:         key,
+		ContentType: contentType,
+		Size:        size,
+	}
+	if size == 0 {
+		return nil, ErrNotAnImage
+	}
+
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", min(size, imageMetadataBytes)-1)),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to read image header")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(output.Body, imageMetadataBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if config, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		metadata.Format = format
+		metadata.Width = config.Width
+		metadata.Height = config.Height
+	}
+
+	if tiff, err := findJPEGExif(data); err == nil {
+		if exif, err := parseExif(tiff); err == nil {
+			metadata.CameraMake = exif.make
+			metadata.CameraModel = exif.model
+			metadata.TakenAt = exif.takenAt
+			metadata.Orientation = exif.orientation
+			if exif.gps != nil {
+				if includeGPS {
+					metadata.GPS = exif.gps
+				} else {
+					metadata.GPSHidden = true
+				}
+			}
+		}
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+
+	"explorer451/internal/models"
+)
+
+// ListAllObjects lists a prefix from cursor page by page, handing each page to fn,
+// until the listing ends or listing.maxAllItems entries were listed. It returns the
+// cursor the listing resumes from, or "" when it ended.
+func (s *S3Service) ListAllObjects(ctx context.Context, bucket, prefix, cursor, delimiter string, fn func(*models.ListObjectsResponse) error) (string, error) {
+	return s.walkListing(cursor, func(cursor string, pageSize int32) (int, models.Pagination, error) {
+		page, err := s.ListObjects(ctx, bucket, prefix, cursor, delimiter, pageSize)
+		if err != nil {
+			return 0, models.Pagination{}, err
+		}
+		return len(page.Objects), page.Pagination, fn(page)
+	})
+}
+
+// ListAllObjectVersions lists the versions under a prefix from cursor page by page,
+// handing each page to fn, like ListAllObjects
+func (s *S3Service) ListAllObjectVersions(ctx context.Context, bucket, prefix, cursor string, fn func(*models.ListVersionsResponse) error) (string, error) {
+	return s.walkListing(cursor, func(cursor string, pageSize int32) (int, models.Pagination, error) {
+		page, err := s.ListObjectVersions(ctx, bucket, prefix, "", cursor, pageSize)
+		if err != nil {
+			return 0, models.Pagination{}, err
+		}
+		return len(page.Versions), page.Pagination, fn(page)
+	})
+}
+
+// walkListing calls next for each page of a listing, asking for no more entries than
+// are left of listing.maxAllItems so the cap falls on a page boundary, and returns the
+// cursor of the first page not listed
+func (s *S3Service) walkListing(cursor string, next func(cursor string, pageSize int32) (int, models.Pagination, error)) (string, error) {
+	remaining := s.core.Config.Listing.MaxAllItems
+	for {
+		listed, pagination, err := next(cursor, int32(min(remaining, s.core.Config.Listing.MaxPageSize)))
+		if err != nil {
+			return "", err
+		}
+		if !pagination.HasMore {
+			return "", nil
+		}
+		cursor = pagination.Cursor
+		if remaining -= listed; remaining <= 0 {
+			return cursor, nil
+		}
+	}
+}
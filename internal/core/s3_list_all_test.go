@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAllObjects(t *testing.T) {
+	keys := []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}
+	var pageSizes []string
+	c := newTestS3Core(t, &config.Config{Listing: config.ListingConfig{MaxPageSize: 2, DefaultPageSize: 2, MaxAllItems: 3}}, func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("max-keys"))
+		pageSizes = append(pageSizes, r.URL.Query().Get("max-keys"))
+		end := min(start+size, len(keys))
+
+		var contents strings.Builder
+		for _, key := range keys[start:end] {
+			fmt.Fprintf(&contents, "<Contents><Key>%s</Key><Size>1</Size></Contents>", key)
+		}
+		truncated := end < len(keys)
+		next := ""
+		if truncated {
+			next = fmt.Sprintf("<NextContinuationToken>%d</NextContinuationToken>", end)
+		}
+		fmt.Fprintf(w, "<ListBucketResult><IsTruncated>%t</IsTruncated>%s%s</ListBucketResult>", truncated, next, contents.String())
+	})
+	ctx := context.Background()
+
+	var listed []string
+	collect := func(page *models.ListObjectsResponse) error {
+		for _, obj := range page.Objects {
+			listed = append(listed, obj.Key)
+		}
+		return nil
+	}
+
+	// The cap falls on a page boundary, and the cursor resumes after it
+	cursor, err := c.S3Service.ListAllObjects(ctx, "docs", "", "", "", collect)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "b.txt", "c.txt"}, listed)
+	assert.Equal(t, []string{"2", "1"}, pageSizes)
+	require.NotEmpty(t, cursor)
+
+	cursor, err = c.S3Service.ListAllObjects(ctx, "docs", "", cursor, "", collect)
+	require.NoError(t, err)
+	assert.Equal(t, keys, listed)
+	assert.Empty(t, cursor)
+}
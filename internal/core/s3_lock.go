@@ -0,0 +1,194 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// lockCheckConcurrency caps the requests a delete preflight makes at once
+const lockCheckConcurrency = 8
+
+// lockedObject is an object whose Object Lock settings a preflight reads
+type lockedObject struct {
+	bucket string
+	key    string
+}
+
+// DeletePreflight reports which of the given objects, and of the objects under the
+// given prefixes, Object Lock retention or a legal hold protects from deletion, so a
+// delete can be narrowed down before it runs into them. Only buckets with Object Lock
+// enabled are checked, up to reports.maxLockChecks objects in all.
+func (s *S3Service) DeletePreflight(ctx context.Context, items []models.SelectionItem) (*models.DeletePreflight, error) {
+	s.core.Logger.Debug().
+		Int("items", len(items)).
+		Msg("Running delete preflight")
+
+	limit := s.core.Config.Reports.MaxLockChecks
+	report := &models.DeletePreflight{
+		LockedBuckets: []string{},
+		Protected:     []models.ProtectedObject{},
+		ScanLimit:     limit,
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	locked := make(map[string]bool)
+	for _, item := range items {
+		if _, ok := locked[item.Bucket]; ok {
+			continue
+		}
+		enabled, err := s.objectLockEnabled(ctx, item.Bucket)
+		if err != nil {
+			return nil, err
+		}
+		locked[item.Bucket] = enabled
+		if enabled {
+			report.LockedBuckets = append(report.LockedBuckets, item.Bucket)
+		}
+	}
+
+	var objects []lockedObject
+	for _, item := range items {
+		if !locked[item.Bucket] {
+			continue
+		}
+		if item.Key != "" && !strings.HasSuffix(item.Key, "/") {
+			if len(objects) >= limit {
+				report.Truncated = true
+				break
+			}
+			objects = append(objects, lockedObject{bucket: item.Bucket, key: item.Key})
+			continue
+		}
+		truncated, err := s.scanPrefixLimit(ctx, item.Bucket, item.Key, limit-len(objects), func(obj s3Types.Object) {
+			objects = append(objects, lockedObject{bucket: item.Bucket, key: aws.ToString(obj.Key)})
+		})
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			report.Truncated = true
+			break
+		}
+	}
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		now = time.Now()
+	)
+	sem := make(chan struct{}, lockCheckConcurrency)
+	for _, object := range objects {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			protected, found, err := s.objectProtection(ctx, object, now)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				report.Unreadable++
+			case found:
+				report.ObjectsChecked++
+				if protected != nil {
+					report.Protected = append(report.Protected, *protected)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(report.Protected, func(a, b models.ProtectedObject) int {
+		return cmp.Or(cmp.Compare(a.Bucket, b.Bucket), cmp.Compare(a.Key, b.Key))
+	})
+	return report, nil
+}
+
+// objectLockEnabled reports whether a bucket has Object Lock enabled. When its
+// configuration cannot be read, the bucket is assumed to have it.
+func (s *S3Service) objectLockEnabled(ctx context.Context, bucket string) (bool, error) {
+	output, err := s.core.Client(bucket).GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	switch {
+	case hasErrorCode(err, "ObjectLockConfigurationNotFoundError"):
+		return false, nil
+	case hasErrorCode(err, "NoSuchBucket"):
+		return false, err
+	case err != nil:
+		s.core.Logger.Debug().
+			Err(err).
+			Str("bucket", bucket).
+			Msg("Failed to read Object Lock configuration")
+		return true, nil
+	}
+	return output.ObjectLockConfiguration != nil &&
+		output.ObjectLockConfiguration.ObjectLockEnabled == s3Types.ObjectLockEnabledEnabled, nil
+}
+
+// objectProtection reads the Object Lock settings of an object's current version,
+// returning nil when nothing protects it. found is false for objects that no longer
+// exist.
+func (s *S3Service) objectProtection(ctx context.Context, object lockedObject, now time.Time) (protected *models.ProtectedObject, found bool, err error) {
+	retention, found, err := s.objectRetention(ctx, object, now)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	if !retention.Retained && !retention.LegalHold {
+		return nil, true, nil
+	}
+
+	result := models.ProtectedObject{
+		Bucket:    object.bucket,
+		Key:       object.key,
+		VersionID: retention.VersionID,
+		LegalHold: retention.LegalHold,
+	}
+	if retention.Retained {
+		result.Mode = retention.Mode
+		result.RetainUntil = retention.RetainUntil
+	}
+	return &result, true, nil
+}
+
+// objectRetention reads the retention and legal hold of an object's current version,
+// whether or not they still protect it. found is false for objects that no longer
+// exist.
+func (s *S3Service) objectRetention(ctx context.Context, object lockedObject, now time.Time) (retention *models.ObjectRetention, found bool, err error) {
+	head, err := s.core.Client(object.bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(object.bucket),
+		Key:    aws.String(object.key),
+	})
+	if hasErrorCode(err, "NotFound") || hasErrorCode(err, "NoSuchKey") {
+		return nil, false, nil
+	}
+	if err != nil {
+		s.core.Logger.Debug().
+			Err(err).
+			Str("bucket", object.bucket).
+			Str("key", object.key).
+			Msg("Failed to read object lock settings")
+		return nil, false, err
+	}
+
+	retention = &models.ObjectRetention{
+		Key:         object.key,
+		VersionID:   aws.ToString(head.VersionId),
+		Mode:        string(head.ObjectLockMode),
+		RetainUntil: head.ObjectLockRetainUntilDate,
+		LegalHold:   head.ObjectLockLegalHoldStatus == s3Types.ObjectLockLegalHoldStatusOn,
+	}
+	retention.Retained = retention.Mode != "" && retention.RetainUntil != nil && retention.RetainUntil.After(now)
+	return retention, true, nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLockCore fakes a bucket "vault" with Object Lock, whose objects a.txt is on
+// legal hold, b.txt is retained until 2999 and c.txt was retained until 2000, and a
+// bucket "plain" without it. New objects in "vault" are retained for 30 days.
+func newTestLockCore(t *testing.T) *Core {
+	cfg := &config.Config{Reports: config.ReportsConfig{MaxLockChecks: 100}}
+	return newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		query := r.URL.Query()
+		switch {
+		case query.Has("object-lock") && bucket == "vault":
+			fmt.Fprint(w, `<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled>`+
+				`<Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>30</Days></DefaultRetention></Rule></ObjectLockConfiguration>`)
+		case query.Has("object-lock"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>ObjectLockConfigurationNotFoundError</Code></Error>`)
+		case query.Get("list-type") == "2":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`+
+				`<Contents><Key>docs/a.txt</Key></Contents><Contents><Key>docs/b.txt</Key></Contents>`+
+				`<Contents><Key>docs/c.txt</Key></Contents><Contents><Key>docs/d.txt</Key></Contents></ListBucketResult>`)
+		case r.Method == http.MethodHead:
+			w.Header().Set("X-Amz-Version-Id", "v1")
+			switch key {
+			case "docs/a.txt":
+				w.Header().Set("X-Amz-Object-Lock-Legal-Hold", "ON")
+			case "docs/b.txt":
+				w.Header().Set("X-Amz-Object-Lock-Mode", "COMPLIANCE")
+				w.Header().Set("X-Amz-Object-Lock-Retain-Until-Date", "2999-01-01T00:00:00Z")
+			case "docs/c.txt":
+				w.Header().Set("X-Amz-Object-Lock-Mode", "GOVERNANCE")
+				w.Header().Set("X-Amz-Object-Lock-Retain-Until-Date", "2000-01-01T00:00:00Z")
+			case "missing.txt":
+				w.WriteHeader(http.StatusNotFound)
+			}
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+func TestDeletePreflight(t *testing.T) {
+	c := newTestLockCore(t)
+
+	report, err := c.S3Service.DeletePreflight(context.Background(), []models.SelectionItem{
+		{Bucket: "vault", Key: "docs/"},
+		{Bucket: "vault", Key: "missing.txt"},
+		{Bucket: "plain", Key: "docs/"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vault"}, report.LockedBuckets)
+	assert.Equal(t, 4, report.ObjectsChecked, "missing objects and unlocked buckets are not counted")
+	assert.False(t, report.Truncated)
+
+	until := time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Len(t, report.Protected, 2)
+	assert.Equal(t, models.ProtectedObject{Bucket: "vault", Key: "docs/a.txt", VersionID: "v1", LegalHold: true}, report.Protected[0])
+	assert.Equal(t, "COMPLIANCE", report.Protected[1].Mode)
+	assert.True(t, until.Equal(*report.Protected[1].RetainUntil))
+
+	c.Config.Reports.MaxLockChecks = 2
+	report, err = c.S3Service.DeletePreflight(context.Background(), []models.SelectionItem{{Bucket: "vault", Key: "docs/"}})
+	require.NoError(t, err)
+	assert.True(t, report.Truncated)
+	assert.Equal(t, 2, report.ObjectsChecked)
+}
+
+func TestRetentionReport(t *testing.T) {
+	c := newTestLockCore(t)
+
+	report, err := c.S3Service.GetRetentionReport(context.Background(), "vault", "docs/")
+	require.NoError(t, err)
+	assert.True(t, report.ObjectLockEnabled)
+	assert.Equal(t, &models.DefaultRetention{Mode: "GOVERNANCE", Days: 30}, report.DefaultRetention)
+	require.Len(t, report.Objects, 4)
+	assert.True(t, report.Objects[0].LegalHold)
+	assert.True(t, report.Objects[1].Retained)
+	// A lapsed retention is listed, but no longer protects the object
+	assert.Equal(t, "GOVERNANCE", report.Objects[2].Mode)
+	assert.False(t, report.Objects[2].Retained)
+	assert.Equal(t, models.RetentionSummary{Objects: 4, Compliance: 1, LegalHolds: 1, Unprotected: 2}, report.Summary)
+
+	var out strings.Builder
+	require.NoError(t, WriteRetentionReportCSV(report, &out))
+	assert.Equal(t, "key,versionId,mode,retainUntil,retained,legalHold,error\n"+
+		"docs/a.txt,v1,,,false,true,\n"+
+		"docs/b.txt,v1,COMPLIANCE,2999-01-01T00:00:00Z,true,false,\n"+
+		"docs/c.txt,v1,GOVERNANCE,2000-01-01T00:00:00Z,false,false,\n"+
+		"docs/d.txt,v1,,,false,false,\n", out.String())
+
+	report, err = c.S3Service.GetRetentionReport(context.Background(), "plain", "docs/")
+	require.NoError(t, err)
+	assert.False(t, report.ObjectLockEnabled)
+	assert.Empty(t, report.Objects)
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+const (
+	// maxManifestFiles caps the files of one upload manifest
+	maxManifestFiles = 1000
+	// manifestPartSize is the smallest part size of manifest files uploaded in parts;
+	// smaller files are posted in one request
+	manifestPartSize = 64 << 20
+	// maxObjectSize is the largest object S3 stores
+	maxObjectSize = 5 << 40
+)
+
+// ErrInvalidManifest is returned for upload manifests with invalid or duplicate paths,
+// or files that cannot be uploaded
+var ErrInvalidManifest = errors.New("invalid upload manifest")
+
+// PresignUploadManifest returns an upload target for every file of a local folder: a
+// presigned POST limited to the file's size, or for files over 64 MiB a multipart
+// upload with a presigned URL for each part. Under an upload policy every file is
+// posted in one request, within the policy's limits. Should a target fail, the
+// multipart uploads already started are aborted.
+func (s *S3Service) PresignUploadManifest(ctx context.Context, bucket, user string, req models.UploadManifestRequest, customerKey *CustomerKey) (*models.UploadManifestResponse, error) {
+	keys, err := manifestKeys(req)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn := time.Duration(req.ExpiresInSeconds) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	expiresIn = min(expiresIn, maxPartURLExpiry)
+	if req.Policy != "" {
+		policy, ok := s.uploadPolicy(req.Policy)
+		if !ok {
+			return nil, ErrUploadPolicyNotFound
+		}
+		for _, file := range req.Files {
+			if policy.MaxSizeBytes > 0 && file.Size > policy.MaxSizeBytes {
+				return nil, fmt.Errorf("%w: %s is larger than the upload policy allows", ErrInvalidManifest, file.Path)
+			}
+		}
+		expiresIn = policy.Expiry
+	} else if s.UploadPolicyRequired() {
+		return nil, ErrUploadPolicyRequired
+	}
+
+	response := &models.UploadManifestResponse{
+		Targets:   make([]models.UploadTarget, 0, len(req.Files)),
+		ExpiresAt: time.Now().Add(expiresIn).UTC(),
+	}
+	for i, file := range req.Files {
+		target, err := s.uploadTarget(ctx, bucket, user, keys[i], file, req, expiresIn, customerKey)
+		if err != nil {
+			s.abortTargets(ctx, bucket, response.Targets)
+			return nil, err
+		}
+		response.Targets = append(response.Targets, *target)
+	}
+
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("prefix", req.Prefix).
+		Str("user", user).
+		Int("files", len(response.Targets)).
+		Msg("Upload manifest presigned")
+
+	return response, nil
+}
+
+// uploadTarget presigns the upload of one manifest file to key
+func (s *S3Service) uploadTarget(ctx context.Context, bucket, user, key string, file models.ManifestFile, req models.UploadManifestRequest, expiresIn time.Duration, customerKey *CustomerKey) (*models.UploadTarget, error) {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	target := &models.UploadTarget{Path: file.Path}
+
+	var post *models.PresignedPostURLResponse
+	var err error
+	switch {
+	case req.Policy != "":
+		post, err = s.GeneratePolicyPostURL(ctx, bucket, user, models.PresignedPostURLRequest{
+			Key:         key,
+			ContentType: contentType,
+			Policy:      req.Policy,
+			Tags:        req.Tags,
+			KMSKeyID:    req.KMSKeyID,
+			Conflict:    req.Conflict,
+		}, customerKey)
+	case file.Size <= manifestPartSize:
+		// A limit of zero falls back to the 10 MB default, so empty files get one byte
+		post, err = s.GeneratePresignedPostURL(ctx, bucket, key, contentType, expiresIn, max(file.Size, 1), req.KMSKeyID, customerKey, req.Conflict)
+	case customerKey != nil:
+		return nil, fmt.Errorf("%w: %s is uploaded in parts, which cannot use a customer key", ErrInvalidManifest, file.Path)
+	default:
+		return s.multipartTarget(ctx, bucket, key, contentType, file, req, expiresIn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The content type may have been chosen here, so it is posted with the fields
+	post.Fields["Content-Type"] = contentType
+	target.Key = post.Fields["key"]
+	target.Post = post
+	return target, nil
+}
+
+// multipartTarget starts a multipart upload for a large manifest file and presigns
+// every part. Parts are at least 64 MiB, and larger when the file would otherwise need
+// more parts than S3 allows.
+func (s *S3Service) multipartTarget(ctx context.Context, bucket, key, contentType string, file models.ManifestFile, req models.UploadManifestRequest, expiresIn time.Duration) (*models.UploadTarget, error) {
+	partSize := max(int64(manifestPartSize), (file.Size+maxMultipartParts-1)/maxMultipartParts)
+	plan, err := ChunkingPlan(file.Size, partSize)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := s.CreateMultipartUpload(ctx, bucket, models.CreateMultipartUploadRequest{
+		Key:         key,
+		ContentType: contentType,
+		KMSKeyID:    req.KMSKeyID,
+		Conflict:    req.Conflict,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make([]int32, 0, len(plan))
+	for _, part := range plan {
+		numbers = append(numbers, int32(part.Number))
+	}
+	parts, err := s.PresignUploadParts(ctx, bucket, upload.UploadID, models.PartURLsRequest{
+		Key:              upload.Key,
+		PartNumbers:      numbers,
+		ExpiresInSeconds: int64(expiresIn / time.Second),
+	})
+	if err != nil {
+		_ = s.AbortMultipartUpload(ctx, bucket, upload.Key, upload.UploadID)
+		return nil, err
+	}
+
+	return &models.UploadTarget{
+		Path:     file.Path,
+		Key:      upload.Key,
+		UploadID: upload.UploadID,
+		PartSize: partSize,
+		Parts:    parts.Parts,
+	}, nil
+}
+
+// abortTargets aborts the multipart uploads of targets already presigned
+func (s *S3Service) abortTargets(ctx context.Context, bucket string, targets []models.UploadTarget) {
+	for _, target := range targets {
+		if target.UploadID != "" {
+			_ = s.AbortMultipartUpload(ctx, bucket, target.Key, target.UploadID)
+		}
+	}
+}
+
+// manifestKeys checks a manifest and returns the key of each file: the prefix followed
+// by the file's relative path
+func manifestKeys(req models.UploadManifestRequest) ([]string, error) {
+	if len(req.Files) == 0 || len(req.Files) > maxManifestFiles {
+		return nil, fmt.Errorf("%w: between 1 and %d files are needed", ErrInvalidManifest, maxManifestFiles)
+	}
+
+	prefix := strings.TrimPrefix(req.Prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	keys := make([]string, 0, len(req.Files))
+	seen := make(map[string]bool, len(req.Files))
+	for _, file := range req.Files {
+		relative := strings.ReplaceAll(file.Path, `\`, "/")
+		segments := strings.Split(relative, "/")
+		if relative == "" || strings.HasPrefix(relative, "/") || slices.Contains(segments, "..") || slices.Contains(segments, "") {
+			return nil, fmt.Errorf("%w: %q is not a relative file path", ErrInvalidManifest, file.Path)
+		}
+		if file.Size < 0 || file.Size > maxObjectSize {
+			return nil, fmt.Errorf("%w: size of %s must be between 0 and 5 TiB", ErrInvalidManifest, file.Path)
+		}
+
+		key := prefix + relative
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s is listed twice", ErrInvalidManifest, file.Path)
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresignUploadManifest(t *testing.T) {
+	var aborted []string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/docs/fail.raw":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code></Error>`)
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>up-1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodDelete:
+			aborted = append(aborted, r.URL.Path+"?"+query.Get("uploadId"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	manifest, err := c.S3Service.PresignUploadManifest(ctx, "docs", "ann", models.UploadManifestRequest{
+		Prefix: "photos",
+		Files: []models.ManifestFile{
+			{Path: "trip/a.jpg", Size: 1000},
+			{Path: `trip\b.raw`, Size: 200 << 20, ContentType: "image/x-raw"},
+			{Path: "trip/empty", Size: 0},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, manifest.Targets, 3)
+
+	small := manifest.Targets[0]
+	assert.Equal(t, "photos/trip/a.jpg", small.Key)
+	require.NotNil(t, small.Post)
+	assert.Equal(t, "image/jpeg", small.Post.Fields["Content-Type"])
+	assert.Empty(t, small.UploadID)
+
+	large := manifest.Targets[1]
+	assert.Equal(t, `trip\b.raw`, large.Path)
+	assert.Equal(t, "photos/trip/b.raw", large.Key)
+	assert.Nil(t, large.Post)
+	assert.Equal(t, "up-1", large.UploadID)
+	assert.Equal(t, int64(64<<20), large.PartSize)
+	assert.Len(t, large.Parts, 4)
+
+	assert.Equal(t, "application/octet-stream", manifest.Targets[2].Post.Fields["Content-Type"])
+
+	// Uploads already started are aborted when a later target fails
+	_, err = c.S3Service.PresignUploadManifest(ctx, "docs", "ann", models.UploadManifestRequest{
+		Files: []models.ManifestFile{{Path: "ok.raw", Size: 100 << 20}, {Path: "fail.raw", Size: 100 << 20}},
+	}, nil)
+	assert.True(t, hasErrorCode(err, "AccessDenied"))
+	assert.Equal(t, []string{"/docs/ok.raw?up-1"}, aborted)
+}
+
+func TestManifestKeys(t *testing.T) {
+	keys, err := manifestKeys(models.UploadManifestRequest{
+		Prefix: "/incoming/",
+		Files:  []models.ManifestFile{{Path: "a.txt"}, {Path: `docs\b.txt`}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"incoming/a.txt", "incoming/docs/b.txt"}, keys)
+
+	for _, files := range [][]models.ManifestFile{
+		nil,
+		{{Path: "../a.txt"}},
+		{{Path: "/etc/passwd"}},
+		{{Path: "docs//a.txt"}},
+		{{Path: "docs/"}},
+		{{Path: "a.txt"}, {Path: "a.txt"}},
+		{{Path: "a.txt", Size: -1}},
+	} {
+		_, err := manifestKeys(models.UploadManifestRequest{Files: files})
+		assert.ErrorIs(t, err, ErrInvalidManifest, "%v", files)
+	}
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// mediaProbeURLExpiry is how long the presigned URL handed to ffprobe stays valid
+const mediaProbeURLExpiry = 5 * time.Minute
+
+var (
+	// ErrMediaProbeDisabled is returned when ffprobe integration is not enabled
+	ErrMediaProbeDisabled = errors.New("media probing is disabled")
+	// ErrNotMedia is returned when media metadata is requested for a non audio/video object
+	ErrNotMedia = errors.New("object is not audio or video")
+)
+
+// ffprobeOutput mirrors the parts of `ffprobe -print_format json` output that are used
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		Index      int    `json:"index"`
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		Width      int    `json:"width"`
+		Height     int    `json:"height"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+		Duration   string `json:"duration"`
+		FrameRate  string `json:"avg_frame_rate"`
+	} `json:"streams"`
+}
+
+// GetMediaMetadata runs ffprobe against an audio or video object to report its
+// duration, resolution and codecs. ffprobe reads the object through a short-lived
+// presigned URL, so only the byte ranges it needs are downloaded.
+func (s *S3Service) GetMediaMetadata(ctx context.Context, bucket, key string) (*models.MediaMetadata, error) {
+	cfg := s.core.Config.Media.FFprobe
+	if !cfg.Enabled {
+		return nil, ErrMediaProbeDisabled
+	}
+
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Probing media object")
+
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for media probe")
+		return nil, err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(aws.ToString(head.ContentType))
+	if err != nil || !isMediaType(mediaType) {
+		mediaType = detectContentType(key)
+	}
+	if !isMediaType(mediaType) {
+		return nil, ErrNotMedia
+	}
+
+	presigned, err := s.core.Presigner(bucket).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = mediaProbeURLExpiry
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(probeCtx, cfg.Path,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		presigned.URL,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		// Never log the command line: it contains a presigned URL
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("ffprobe failed")
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+
+	metadata, err := parseFFprobeOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Key = key
+	metadata.ContentType = mediaType
+	metadata.Size = aws.ToInt64(head.ContentLength)
+	return metadata, nil
+}
+
+// isMediaType reports whether a media type is audio or video
+func isMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "video/") || strings.HasPrefix(mediaType, "audio/")
+}
+
+// parseFFprobeOutput converts ffprobe's JSON output into media metadata
+func parseFFprobeOutput(data []byte) (*models.MediaMetadata, error) {
+	var probe ffprobeOutput
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("parsing ffprobe output: %w", err)
+	}
+
+	metadata := &models.MediaMetadata{
+		Format:   probe.Format.FormatName,
+		Duration: parseFloat(probe.Format.Duration),
+		BitRate:  int64(parseFloat(probe.Format.BitRate)),
+		Streams:  make([]models.MediaStream, 0, len(probe.Streams)),
+	}
+
+	for _, stream := range probe.Streams {
+		entry := models.MediaStream{
+			Index:      stream.Index,
+			Type:       stream.CodecType,
+			Codec:      stream.CodecName,
+			Width:      stream.Width,
+			Height:     stream.Height,
+			SampleRate: int(parseFloat(stream.SampleRate)),
+			Channels:   stream.Channels,
+			Duration:   parseFloat(stream.Duration),
+			FrameRate:  parseFrameRate(stream.FrameRate),
+		}
+		metadata.Streams = append(metadata.Streams, entry)
+
+		// Report the first video stream's resolution at the top level
+		if stream.CodecType == "video" && metadata.Width == 0 {
+			metadata.Width = stream.Width
+			metadata.Height = stream.Height
+		}
+	}
+
+	return metadata, nil
+}
+
+// parseFloat parses a numeric ffprobe field, treating missing or "N/A" values as zero
+func parseFloat(value string) float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// parseFrameRate converts an ffprobe rational such as "30000/1001" into frames per second
+func parseFrameRate(value string) float64 {
+	numerator, denominator, ok := strings.Cut(value, "/")
+	if !ok {
+		return parseFloat(value)
+	}
+
+	d := parseFloat(denominator)
+	if d == 0 {
+		return 0
+	}
+	return parseFloat(numerator) / d
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFFprobeOutput(t *testing.T) {
+	output := []byte(`{
+		"streams": [
+			{"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080,
+			 "avg_frame_rate": "30000/1001", "duration": "12.345"},
+			{"index": 1, "codec_type": "audio", "codec_name": "aac", "sample_rate": "48000", "channels": 2,
+			 "avg_frame_rate": "0/0"}
+		],
+		"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "12.400000", "bit_rate": "5000000"}
+	}`)
+
+	metadata, err := parseFFprobeOutput(output)
+	require.NoError(t, err)
+	assert.Equal(t, "mov,mp4,m4a,3gp,3g2,mj2", metadata.Format)
+	assert.InDelta(t, 12.4, metadata.Duration, 1e-9)
+	assert.Equal(t, int64(5000000), metadata.BitRate)
+	assert.Equal(t, 1920, metadata.Width)
+	assert.Equal(t, 1080, metadata.Height)
+	require.Len(t, metadata.Streams, 2)
+	assert.InDelta(t, 29.97, metadata.Streams[0].FrameRate, 0.01)
+	assert.Equal(t, 48000, metadata.Streams[1].SampleRate)
+	assert.Zero(t, metadata.Streams[1].FrameRate)
+
+	_, err = parseFFprobeOutput([]byte("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxUserMetadataBytes is S3's limit on the user metadata of an object, counting the
+// bytes of every key and value
+const maxUserMetadataBytes = 2048
+
+// ErrInvalidMetadata is returned for metadata changes S3 would reject or mangle
+var ErrInvalidMetadata = errors.New("invalid object metadata")
+
+// UpdateObjectMetadata changes an object's Content-Type, Cache-Control and user
+// metadata. S3 cannot change metadata in place, so the object is copied onto itself
+// with MetadataDirective=REPLACE: the copy gets a new ETag and last-modified time, and
+// a new version in versioned buckets. Content-Disposition, Content-Encoding, storage
+// class, tags and server-side encryption are carried over, as for SaveObjectContent;
+// the ACL is reset to the bucket's default, as with every copy. When ifMatch is set,
+// the object must still have that ETag.
+func (s *S3Service) UpdateObjectMetadata(ctx context.Context, bucket, key string, req models.UpdateObjectMetadataRequest, ifMatch string) (*models.ObjectMetadata, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Updating object metadata")
+
+	if err := validateObjectMetadata(req); err != nil {
+		return nil, err
+	}
+
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for update")
+		return nil, err
+	}
+	if ifMatch != "" && normalizeETag(aws.ToString(head.ETag)) != normalizeETag(ifMatch) {
+		return nil, ErrETagMismatch
+	}
+	if aws.ToInt64(head.ContentLength) > maxCopyObjectBytes {
+		return nil, ErrObjectTooLargeToCopy
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		CopySource: aws.String(url.PathEscape(bucket + "/" + key)),
+		// Fails should the object be overwritten between the HEAD and the copy
+		CopySourceIfMatch:  head.ETag,
+		MetadataDirective:  s3Types.MetadataDirectiveReplace,
+		ContentType:        head.ContentType,
+		CacheControl:       head.CacheControl,
+		ContentDisposition: head.ContentDisposition,
+		ContentEncoding:    head.ContentEncoding,
+		ContentLanguage:    head.ContentLanguage,
+		Metadata:           head.Metadata,
+	}
+	if req.ContentType != nil {
+		input.ContentType = req.ContentType
+	}
+	if req.CacheControl != nil {
+		input.CacheControl = nil
+		if *req.CacheControl != "" {
+			input.CacheControl = req.CacheControl
+		}
+	}
+	if req.Metadata != nil {
+		input.Metadata = req.Metadata
+	}
+	// A copy defaults to STANDARD, which would move objects out of cheaper classes
+	if head.StorageClass != "" && head.StorageClass != s3Types.StorageClassStandard {
+		input.StorageClass = head.StorageClass
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId, input.BucketKeyEnabled = s.keptEncryption(head)
+
+	if _, err := s.core.Client(bucket).CopyObject(ctx, input); err != nil {
+		if isPreconditionFailed(err) {
+			return nil, ErrETagMismatch
+		}
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to update object metadata")
+		return nil, err
+	}
+
+	s.core.Events.Publish(EventObjectCreated, bucket, key)
+
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Object metadata updated")
+
+	return s.GetObjectMetadata(ctx, bucket, key, nil)
+}
+
+// validateObjectMetadata checks a metadata change against S3's rules. Header values
+// must be printable ASCII, since S3 does not decode other characters in headers.
+func validateObjectMetadata(req models.UpdateObjectMetadataRequest) error {
+	if req.ContentType != nil {
+		if _, _, err := mime.ParseMediaType(*req.ContentType); err != nil {
+			return fmt.Errorf("%w: content type %q is not a media type", ErrInvalidMetadata, *req.ContentType)
+		}
+		if !isHeaderValue(*req.ContentType) {
+			return fmt.Errorf("%w: content type must be printable ASCII", ErrInvalidMetadata)
+		}
+	}
+	if req.CacheControl != nil && !isHeaderValue(*req.CacheControl) {
+		return fmt.Errorf("%w: cache control must be printable ASCII", ErrInvalidMetadata)
+	}
+
+	size := 0
+	for name, value := range req.Metadata {
+		if name == "" || !isHeaderToken(name) {
+			return fmt.Errorf("%w: metadata key %q is not a valid header name", ErrInvalidMetadata, name)
+		}
+		if name != strings.ToLower(name) {
+			// S3 lowercases keys, so mixed case would not read back as written
+			return fmt.Errorf("%w: metadata key %q must be lowercase", ErrInvalidMetadata, name)
+		}
+		if !isHeaderValue(value) {
+			return fmt.Errorf("%w: value of metadata key %q must be printable ASCII", ErrInvalidMetadata, name)
+		}
+		size += len(name) + len(value)
+	}
+	if size > maxUserMetadataBytes {
+		return fmt.Errorf("%w: user metadata is limited to %d bytes", ErrInvalidMetadata, maxUserMetadataBytes)
+	}
+	return nil
+}
+
+// isHeaderToken reports whether s only holds characters allowed in an HTTP header name
+func isHeaderToken(s string) bool {
+	for _, r := range s {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHeaderValue reports whether s only holds printable ASCII
+func isHeaderValue(s string) bool {
+	for _, r := range s {
+		if r < ' ' || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
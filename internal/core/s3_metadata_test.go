@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateObjectMetadata(t *testing.T) {
+	var copied http.Header
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("tagging"):
+			fmt.Fprint(w, `<Tagging><TagSet></TagSet></Tagging>`)
+		case r.Method == http.MethodPut:
+			copied = r.Header.Clone()
+			if r.Header.Get("X-Amz-Copy-Source-If-Match") != `"old"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				fmt.Fprint(w, `<Error><Code>PreconditionFailed</Code></Error>`)
+				return
+			}
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"new"</ETag></CopyObjectResult>`)
+		case r.URL.Path == "/docs/plain.txt":
+			w.Header().Set("ETag", `"old"`)
+			w.Header().Set("X-Amz-Server-Side-Encryption", "AES256")
+		case r.URL.Path == "/docs/big.bin":
+			w.Header().Set("Content-Length", fmt.Sprint(int64(6<<30)))
+		case r.URL.Path == "/docs/exports/q1.csv":
+			w.Header().Set("Content-Length", "42")
+			w.Header().Set("ETag", `"old"`)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Content-Disposition", "attachment")
+			w.Header().Set("X-Amz-Storage-Class", "STANDARD_IA")
+			w.Header().Set("X-Amz-Server-Side-Encryption", "aws:kms")
+			w.Header().Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:eu-central-1:1:key/k")
+			w.Header().Set("X-Amz-Meta-Owner", "sales")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	metadata, err := c.S3Service.UpdateObjectMetadata(ctx, "docs", "exports/q1.csv", models.UpdateObjectMetadataRequest{
+		ContentType: aws.String("text/csv"),
+		Metadata:    map[string]string{"owner": "finance"},
+	}, `"old"`)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "docs%2Fexports%2Fq1.csv", copied.Get("X-Amz-Copy-Source"))
+	assert.Equal(t, "REPLACE", copied.Get("X-Amz-Metadata-Directive"))
+	assert.Equal(t, "text/csv", copied.Get("Content-Type"))
+	assert.Equal(t, "finance", copied.Get("X-Amz-Meta-Owner"))
+	// Headers that were not changed are carried over
+	assert.Equal(t, "no-cache", copied.Get("Cache-Control"))
+	assert.Equal(t, "attachment", copied.Get("Content-Disposition"))
+	assert.Equal(t, "STANDARD_IA", copied.Get("X-Amz-Storage-Class"))
+	assert.Equal(t, "arn:aws:kms:eu-central-1:1:key/k", copied.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+
+	// An empty cache control removes the header, and user metadata is kept unless set
+	_, err = c.S3Service.UpdateObjectMetadata(ctx, "docs", "exports/q1.csv", models.UpdateObjectMetadataRequest{
+		CacheControl: aws.String(""),
+	}, "")
+	require.NoError(t, err)
+	assert.Empty(t, copied.Get("Cache-Control"))
+	assert.Equal(t, "application/octet-stream", copied.Get("Content-Type"))
+	assert.Equal(t, "sales", copied.Get("X-Amz-Meta-Owner"))
+
+	// SSE-S3 is kept too, unless uploads.requireKms asks for KMS
+	_, err = c.S3Service.UpdateObjectMetadata(ctx, "docs", "plain.txt", models.UpdateObjectMetadataRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "AES256", copied.Get("X-Amz-Server-Side-Encryption"))
+	c.Config.Uploads.RequireKMS = true
+	_, err = c.S3Service.UpdateObjectMetadata(ctx, "docs", "plain.txt", models.UpdateObjectMetadataRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "aws:kms", copied.Get("X-Amz-Server-Side-Encryption"))
+	_, err = c.S3Service.UpdateObjectMetadata(ctx, "docs", "exports/q1.csv", models.UpdateObjectMetadataRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:kms:eu-central-1:1:key/k", copied.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+	c.Config.Uploads.RequireKMS = false
+
+	_, err = c.S3Service.UpdateObjectMetadata(ctx, "docs", "exports/q1.csv", models.UpdateObjectMetadataRequest{}, `"stale"`)
+	assert.ErrorIs(t, err, ErrETagMismatch)
+
+	_, err = c.S3Service.UpdateObjectMetadata(ctx, "docs", "big.bin", models.UpdateObjectMetadataRequest{}, "")
+	assert.ErrorIs(t, err, ErrObjectTooLargeToCopy)
+}
+
+func TestValidateObjectMetadata(t *testing.T) {
+	assert.NoError(t, validateObjectMetadata(models.UpdateObjectMetadataRequest{
+		ContentType:  aws.String("text/csv; charset=utf-8"),
+		CacheControl: aws.String("public, max-age=3600"),
+		Metadata:     map[string]string{"owner": "finance", "x-source": "import"},
+	}))
+	assert.ErrorIs(t, validateObjectMetadata(models.UpdateObjectMetadataRequest{ContentType: aws.String("not a type")}), ErrInvalidMetadata)
+	assert.ErrorIs(t, validateObjectMetadata(models.UpdateObjectMetadataRequest{CacheControl: aws.String("max-age=1\r\nX: y")}), ErrInvalidMetadata)
+	assert.ErrorIs(t, validateObjectMetadata(models.UpdateObjectMetadataRequest{Metadata: map[string]string{"Owner": "a"}}), ErrInvalidMetadata)
+	assert.ErrorIs(t, validateObjectMetadata(models.UpdateObjectMetadataRequest{Metadata: map[string]string{"team name": "a"}}), ErrInvalidMetadata)
+	assert.ErrorIs(t, validateObjectMetadata(models.UpdateObjectMetadataRequest{Metadata: map[string]string{"owner": "Jürgen"}}), ErrInvalidMetadata)
+	assert.ErrorIs(t, validateObjectMetadata(models.UpdateObjectMetadataRequest{Metadata: map[string]string{"notes": strings.Repeat("x", 2048)}}), ErrInvalidMetadata)
+}
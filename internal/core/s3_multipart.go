@@ -0,0 +1,218 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxPartURLExpiry is the longest a presigned part URL may live, the limit of SigV4
+const maxPartURLExpiry = 7 * 24 * time.Hour
+
+// ErrInvalidParts is returned for part numbers outside 1-10000, duplicated, or missing
+var ErrInvalidParts = errors.New("invalid multipart upload parts")
+
+// CreateMultipartUpload starts a multipart upload, encrypted with SSE-KMS when kmsKeyID
+// is set. Uploads are refused when uploads.requirePolicy is set, as the limits of a
+// policy cannot be pinned on presigned parts. An existing key is handled by the
+// conflict strategy when the upload starts; the parts go to the returned key.
+func (s *S3Service) CreateMultipartUpload(ctx context.Context, bucket string, req models.CreateMultipartUploadRequest) (*models.MultipartUpload, error) {
+	if s.UploadPolicyRequired() {
+		return nil, ErrUploadPolicyRequired
+	}
+	kms, err := s.kmsFor(req.KMSKeyID, nil)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.resolveConflict(ctx, bucket, req.Key, req.Conflict)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if req.ContentType != "" {
+		input.ContentType = aws.String(req.ContentType)
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = kms.params()
+
+	output, err := s.core.Client(bucket).CreateMultipartUpload(ctx, input)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to create multipart upload")
+		return nil, err
+	}
+
+	return &models.MultipartUpload{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: aws.ToString(output.UploadId),
+	}, nil
+}
+
+// PresignUploadParts returns presigned URLs to PUT the given parts of a multipart
+// upload to. The ETag header of each response is needed to complete the upload.
+func (s *S3Service) PresignUploadParts(ctx context.Context, bucket, uploadID string, req models.PartURLsRequest) (*models.PartURLsResponse, error) {
+	if err := validPartNumbers(req.PartNumbers); err != nil {
+		return nil, err
+	}
+
+	expiresIn := time.Duration(req.ExpiresInSeconds) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	expiresIn = min(expiresIn, maxPartURLExpiry)
+
+	response := &models.PartURLsResponse{
+		UploadID:  uploadID,
+		Parts:     make([]models.PartURL, 0, len(req.PartNumbers)),
+		ExpiresAt: time.Now().Add(expiresIn).UTC(),
+	}
+	presigner := s.core.Presigner(bucket)
+	for _, number := range req.PartNumbers {
+		presigned, err := presigner.PresignUploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(req.Key),
+			UploadId:   aws.String(uploadID),
+			PartNumber: aws.Int32(number),
+		}, s3.WithPresignExpires(expiresIn))
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", req.Key).
+				Int32("partNumber", number).
+				Msg("Failed to presign upload part")
+			return nil, err
+		}
+		response.Parts = append(response.Parts, models.PartURL{PartNumber: number, URL: presigned.URL})
+	}
+	return response, nil
+}
+
+// ListUploadedParts lists the parts of a multipart upload S3 has received
+func (s *S3Service) ListUploadedParts(ctx context.Context, bucket, key, uploadID string) (*models.ListPartsResponse, error) {
+	response := &models.ListPartsResponse{UploadID: uploadID, Key: key, Parts: []models.UploadedPart{}}
+	paginator := s3.NewListPartsPaginator(s.core.Client(bucket), &s3.ListPartsInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", key).
+				Msg("Failed to list uploaded parts")
+			return nil, err
+		}
+		for _, part := range page.Parts {
+			response.Parts = append(response.Parts, models.UploadedPart{
+				PartNumber:   aws.ToInt32(part.PartNumber),
+				ETag:         aws.ToString(part.ETag),
+				Size:         aws.ToInt64(part.Size),
+				LastModified: aws.ToTime(part.LastModified),
+			})
+		}
+	}
+	return response, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts, in part number order, into the
+// object
+func (s *S3Service) CompleteMultipartUpload(ctx context.Context, bucket, uploadID string, req models.CompleteMultipartUploadRequest) (*models.CompleteMultipartUploadResponse, error) {
+	numbers := make([]int32, 0, len(req.Parts))
+	for _, part := range req.Parts {
+		if part.ETag == "" {
+			return nil, fmt.Errorf("%w: part %d has no ETag", ErrInvalidParts, part.PartNumber)
+		}
+		numbers = append(numbers, part.PartNumber)
+	}
+	if err := validPartNumbers(numbers); err != nil {
+		return nil, err
+	}
+
+	parts := make([]s3Types.CompletedPart, 0, len(req.Parts))
+	for _, part := range slices.SortedFunc(slices.Values(req.Parts), func(a, b models.CompletedPart) int {
+		return cmp.Compare(a.PartNumber, b.PartNumber)
+	}) {
+		parts = append(parts, s3Types.CompletedPart{
+			PartNumber: aws.Int32(part.PartNumber),
+			ETag:       aws.String(part.ETag),
+		})
+	}
+
+	output, err := s.core.Client(bucket).CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(req.Key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3Types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", req.Key).
+			Msg("Failed to complete multipart upload")
+		return nil, err
+	}
+
+	s.core.Events.Publish(EventObjectCreated, bucket, req.Key)
+	return &models.CompleteMultipartUploadResponse{
+		Bucket:    bucket,
+		Key:       req.Key,
+		ETag:      aws.ToString(output.ETag),
+		VersionID: aws.ToString(output.VersionId),
+	}, nil
+}
+
+// AbortMultipartUpload discards a multipart upload and the parts uploaded for it
+func (s *S3Service) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	_, err := s.core.Client(bucket).AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to abort multipart upload")
+	}
+	return err
+}
+
+// validPartNumbers checks that part numbers are given, unique and between 1 and 10000
+func validPartNumbers(numbers []int32) error {
+	if len(numbers) == 0 {
+		return fmt.Errorf("%w: no parts given", ErrInvalidParts)
+	}
+	seen := make(map[int32]bool, len(numbers))
+	for _, number := range numbers {
+		if number < 1 || number > maxMultipartParts {
+			return fmt.Errorf("%w: part numbers run from 1 to %d", ErrInvalidParts, maxMultipartParts)
+		}
+		if seen[number] {
+			return fmt.Errorf("%w: part %d is given twice", ErrInvalidParts, number)
+		}
+		seen[number] = true
+	}
+	return nil
+}
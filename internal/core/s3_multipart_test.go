@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipartUpload(t *testing.T) {
+	var completeBody, sse string
+	aborted := false
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			sse = r.Header.Get("X-Amz-Server-Side-Encryption")
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>up-1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodGet && query.Get("uploadId") == "gone":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchUpload</Code></Error>`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `<ListPartsResult><IsTruncated>false</IsTruncated>`+
+				`<Part><PartNumber>1</PartNumber><ETag>"p1"</ETag><Size>5242880</Size></Part>`+
+				`<Part><PartNumber>2</PartNumber><ETag>"p2"</ETag><Size>100</Size></Part></ListPartsResult>`)
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			completeBody = string(body)
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"whole-2"</ETag></CompleteMultipartUploadResult>`)
+		case r.Method == http.MethodDelete:
+			aborted = query.Get("uploadId") == "up-1"
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	ctx := context.Background()
+
+	upload, err := c.S3Service.CreateMultipartUpload(ctx, "docs", models.CreateMultipartUploadRequest{Key: "big.iso", KMSKeyID: "alias/uploads"})
+	require.NoError(t, err)
+	assert.Equal(t, "up-1", upload.UploadID)
+	assert.Equal(t, "aws:kms", sse)
+
+	urls, err := c.S3Service.PresignUploadParts(ctx, "docs", "up-1", models.PartURLsRequest{Key: "big.iso", PartNumbers: []int32{1, 2}})
+	require.NoError(t, err)
+	require.Len(t, urls.Parts, 2)
+	presigned, err := url.Parse(urls.Parts[1].URL)
+	require.NoError(t, err)
+	assert.Equal(t, "/docs/big.iso", presigned.Path)
+	assert.Equal(t, "2", presigned.Query().Get("partNumber"))
+	assert.Equal(t, "up-1", presigned.Query().Get("uploadId"))
+
+	_, err = c.S3Service.PresignUploadParts(ctx, "docs", "up-1", models.PartURLsRequest{Key: "big.iso", PartNumbers: []int32{0}})
+	assert.ErrorIs(t, err, ErrInvalidParts)
+
+	parts, err := c.S3Service.ListUploadedParts(ctx, "docs", "big.iso", "up-1")
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 2}, []int32{parts.Parts[0].PartNumber, parts.Parts[1].PartNumber})
+	assert.Equal(t, int64(5242880), parts.Parts[0].Size)
+
+	_, err = c.S3Service.ListUploadedParts(ctx, "docs", "big.iso", "gone")
+	assert.True(t, hasErrorCode(err, "NoSuchUpload"))
+
+	completed, err := c.S3Service.CompleteMultipartUpload(ctx, "docs", "up-1", models.CompleteMultipartUploadRequest{
+		Key:   "big.iso",
+		Parts: []models.CompletedPart{{PartNumber: 2, ETag: `"p2"`}, {PartNumber: 1, ETag: `"p1"`}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `"whole-2"`, completed.ETag)
+	assert.Regexp(t, `<PartNumber>1</PartNumber>.*<PartNumber>2</PartNumber>`, completeBody, "parts are sent in order")
+
+	_, err = c.S3Service.CompleteMultipartUpload(ctx, "docs", "up-1", models.CompleteMultipartUploadRequest{
+		Key:   "big.iso",
+		Parts: []models.CompletedPart{{PartNumber: 1, ETag: `"p1"`}, {PartNumber: 1, ETag: `"p1"`}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidParts)
+
+	require.NoError(t, c.S3Service.AbortMultipartUpload(ctx, "docs", "big.iso", "up-1"))
+	assert.True(t, aborted)
+
+	c.Config.Uploads = config.UploadsConfig{RequirePolicy: true, Policies: []config.UploadPolicy{{Name: "invoices"}}}
+	_, err = c.S3Service.CreateMultipartUpload(ctx, "docs", models.CreateMultipartUploadRequest{Key: "big.iso"})
+	assert.ErrorIs(t, err, ErrUploadPolicyRequired)
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// canaryName starts the name of canary objects written to test notifications
+	canaryName = "explorer451-canary-"
+
+	// Events a canary object triggers: a PUT, then a delete of that version
+	canaryCreatedEvent = "s3:ObjectCreated:Put"
+	canaryRemovedEvent = "s3:ObjectRemoved:Delete"
+)
+
+// TestNotifications writes a small canary object under prefix, with suffix ending its
+// name, deletes it again, and reports which of the bucket's notification destinations
+// are configured to receive the two events. Delivery itself is not confirmed: the
+// explorer does not consume the destinations, whose messages belong to other systems.
+// The canary's version is deleted permanently, leaving nothing behind in versioned
+// buckets.
+func (s *S3Service) TestNotifications(ctx context.Context, bucket, prefix, suffix string) (*models.NotificationTest, error) {
+	client := s.core.Client(bucket)
+	test := &models.NotificationTest{
+		Bucket:       bucket,
+		Key:          fmt.Sprintf("%s%s%d%s", prefix, canaryName, time.Now().UnixNano(), suffix),
+		Destinations: []models.NotificationDestination{},
+	}
+
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", test.Key).
+		Msg("Testing bucket notifications")
+
+	config, err := client.GetBucketNotificationConfiguration(ctx, &s3.GetBucketNotificationConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Msg("Failed to get bucket notification configuration")
+		return nil, err
+	}
+	for _, queue := range config.QueueConfigurations {
+		test.Destinations = append(test.Destinations, notificationDestination("queue", queue.Id, queue.QueueArn, queue.Events, queue.Filter, test.Key))
+	}
+	for _, topic := range config.TopicConfigurations {
+		test.Destinations = append(test.Destinations, notificationDestination("topic", topic.Id, topic.TopicArn, topic.Events, topic.Filter, test.Key))
+	}
+	for _, function := range config.LambdaFunctionConfigurations {
+		test.Destinations = append(test.Destinations, notificationDestination("lambda", function.Id, function.LambdaFunctionArn, function.Events, function.Filter, test.Key))
+	}
+	test.EventBridge = config.EventBridgeConfiguration != nil
+
+	kms, err := s.kmsFor("", nil)
+	if err != nil {
+		return nil, err
+	}
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(test.Key),
+		Body:        strings.NewReader("Event notification test written by explorer451\n"),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = kms.params()
+	output, err := client.PutObject(ctx, input)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", test.Key).
+			Msg("Failed to write notification canary")
+		return nil, err
+	}
+	test.VersionID = aws.ToString(output.VersionId)
+
+	deleteInput := &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(test.Key),
+	}
+	if test.VersionID != "" {
+		deleteInput.VersionId = aws.String(test.VersionID)
+	}
+	if _, err := client.DeleteObject(ctx, deleteInput); err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", test.Key).
+			Msg("Failed to delete notification canary")
+		return nil, err
+	}
+
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", test.Key).
+		Int("destinations", len(test.Destinations)).
+		Msg("Tested bucket notifications")
+	return test, nil
+}
+
+// notificationDestination describes a destination and whether it receives the
+// canary's events
+func notificationDestination(kind string, id, arn *string, events []s3Types.Event, filter *s3Types.NotificationConfigurationFilter, key string) models.NotificationDestination {
+	matches := matchesKeyFilter(filter, key)
+	return models.NotificationDestination{
+		Type:    kind,
+		ID:      aws.ToString(id),
+		ARN:     aws.ToString(arn),
+		Created: matches && matchesEvent(events, canaryCreatedEvent),
+		Removed: matches && matchesEvent(events, canaryRemovedEvent),
+	}
+}
+
+// matchesEvent reports whether a destination's event types, exact or ending in a
+// wildcard, include event
+func matchesEvent(events []s3Types.Event, event string) bool {
+	for _, candidate := range events {
+		name := string(candidate)
+		if name == event {
+			return true
+		}
+		if wildcard, ok := strings.CutSuffix(name, "*"); ok && strings.HasPrefix(event, wildcard) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesKeyFilter reports whether a key passes a destination's prefix and suffix
+// rules
+func matchesKeyFilter(filter *s3Types.NotificationConfigurationFilter, key string) bool {
+	if filter == nil || filter.Key == nil {
+		return true
+	}
+	for _, rule := range filter.Key.FilterRules {
+		value := aws.ToString(rule.Value)
+		switch strings.ToLower(string(rule.Name)) {
+		case "prefix":
+			if !strings.HasPrefix(key, value) {
+				return false
+			}
+		case "suffix":
+			if !strings.HasSuffix(key, value) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestNotifications(t *testing.T) {
+	var written, deleted, deletedVersion string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("notification"):
+			fmt.Fprint(w, `<NotificationConfiguration>
+				<QueueConfiguration><Id>ingest</Id><Queue>arn:aws:sqs:eu-central-1:1:ingest</Queue>
+					<Event>s3:ObjectCreated:*</Event>
+					<Filter><S3Key><FilterRule><Name>prefix</Name><Value>incoming/</Value></FilterRule></S3Key></Filter>
+				</QueueConfiguration>
+				<QueueConfiguration><Id>csv</Id><Queue>arn:aws:sqs:eu-central-1:1:csv</Queue>
+					<Event>s3:ObjectCreated:*</Event>
+					<Filter><S3Key><FilterRule><Name>suffix</Name><Value>.csv</Value></FilterRule></S3Key></Filter>
+				</QueueConfiguration>
+				<TopicConfiguration><Topic>arn:aws:sns:eu-central-1:1:deletes</Topic><Event>s3:ObjectRemoved:Delete</Event></TopicConfiguration>
+			</NotificationConfiguration>`)
+		case r.Method == http.MethodPut:
+			written = r.URL.Path
+			w.Header().Set("X-Amz-Version-Id", "v1")
+		case r.Method == http.MethodDelete:
+			deleted = r.URL.Path
+			deletedVersion = r.URL.Query().Get("versionId")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	test, err := c.S3Service.TestNotifications(context.Background(), "docs", "incoming/", "")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(test.Key, "incoming/"+canaryName))
+	assert.Equal(t, "/docs/"+test.Key, written)
+	assert.Equal(t, written, deleted)
+	assert.Equal(t, "v1", deletedVersion)
+	require.Len(t, test.Destinations, 3)
+
+	assert.Equal(t, "queue", test.Destinations[0].Type)
+	assert.Equal(t, "arn:aws:sqs:eu-central-1:1:ingest", test.Destinations[0].ARN)
+	assert.True(t, test.Destinations[0].Created)
+	assert.False(t, test.Destinations[0].Removed)
+	// The canary does not end in .csv
+	assert.False(t, test.Destinations[1].Created)
+	assert.Equal(t, "topic", test.Destinations[2].Type)
+	assert.False(t, test.Destinations[2].Created)
+	assert.True(t, test.Destinations[2].Removed)
+	assert.False(t, test.EventBridge)
+}
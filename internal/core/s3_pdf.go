@@ -0,0 +1,208 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// pdfRenderSize is the bounding box the first page is rendered into before thumbnailing
+const pdfRenderSize = 1024
+
+var (
+	// ErrPDFDisabled is returned when PDF inspection is not enabled
+	ErrPDFDisabled = errors.New("pdf support is disabled")
+	// ErrNotPDF is returned when PDF metadata is requested for another kind of object
+	ErrNotPDF = errors.New("object is not a pdf")
+	// ErrPDFTooLarge is returned for PDFs above the configured size limit
+	ErrPDFTooLarge = errors.New("pdf too large to inspect")
+)
+
+// isPDFObject reports whether an object with the given stored content type is a PDF
+func isPDFObject(contentType, key string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/pdf" || detectContentType(key) == "application/pdf"
+}
+
+// GetPDFMetadata reports the page count and document information of a PDF object.
+// The PDF is downloaded to a temporary file and inspected with pdfinfo.
+func (s *S3Service) GetPDFMetadata(ctx context.Context, bucket, key string) (*models.PDFMetadata, error) {
+	cfg := s.core.Config.PDF
+	if !cfg.Enabled {
+		return nil, ErrPDFDisabled
+	}
+
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Msg("Getting pdf metadata")
+
+	path, size, cleanup, err := s.downloadPDF(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	probeCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(probeCtx, cfg.PdfinfoPath, "-enc", "UTF-8", path).Output()
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("pdfinfo failed")
+		return nil, fmt.Errorf("pdfinfo: %w", err)
+	}
+
+	metadata := parsePDFInfo(output)
+	metadata.Key = key
+	metadata.Size = size
+	return metadata, nil
+}
+
+// renderPDFFirstPage renders the first page of a PDF object into an image
+func (s *S3Service) renderPDFFirstPage(ctx context.Context, bucket, key string) (image.Image, error) {
+	cfg := s.core.Config.PDF
+	if !cfg.Enabled {
+		return nil, ErrPDFDisabled
+	}
+
+	path, _, cleanup, err := s.downloadPDF(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	renderCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	prefix := filepath.Join(filepath.Dir(path), "page")
+	cmd := exec.CommandContext(renderCtx, cfg.PdftoppmPath,
+		"-png",
+		"-f", "1", "-l", "1",
+		"-singlefile",
+		"-scale-to", strconv.Itoa(pdfRenderSize),
+		path, prefix,
+	)
+	if err := cmd.Run(); err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("pdftoppm failed")
+		return nil, fmt.Errorf("pdftoppm: %w", err)
+	}
+
+	data, err := os.ReadFile(prefix + ".png")
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(data))
+}
+
+// downloadPDF copies a PDF object into a private temporary directory, returning the file
+// path, its size and a function that removes it
+func (s *S3Service) downloadPDF(ctx context.Context, bucket, key string) (string, int64, func(), error) {
+	maxBytes := s.core.Config.PDF.MaxSourceBytes
+
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get pdf object")
+		return "", 0, nil, err
+	}
+	defer output.Body.Close()
+
+	if !isPDFObject(aws.ToString(output.ContentType), key) {
+		return "", 0, nil, ErrNotPDF
+	}
+	if aws.ToInt64(output.ContentLength) > maxBytes {
+		return "", 0, nil, ErrPDFTooLarge
+	}
+
+	dir, err := os.MkdirTemp("", "explorer451-pdf-")
+	if err != nil {
+		return "", 0, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	path := filepath.Join(dir, "document.pdf")
+	file, err := os.Create(path)
+	if err != nil {
+		cleanup()
+		return "", 0, nil, err
+	}
+	size, err := io.Copy(file, io.LimitReader(output.Body, maxBytes))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", 0, nil, err
+	}
+
+	return path, size, cleanup, nil
+}
+
+// parsePDFInfo reads the "Field: value" lines printed by pdfinfo
+func parsePDFInfo(output []byte) *models.PDFMetadata {
+	metadata := &models.PDFMetadata{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		field, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "Title":
+			metadata.Title = value
+		case "Author":
+			metadata.Author = value
+		case "Subject":
+			metadata.Subject = value
+		case "Creator":
+			metadata.Creator = value
+		case "Producer":
+			metadata.Producer = value
+		case "CreationDate":
+			metadata.Created = value
+		case "ModDate":
+			metadata.Modified = value
+		case "Pages":
+			metadata.Pages, _ = strconv.Atoi(value)
+		case "Encrypted":
+			metadata.Encrypted = strings.HasPrefix(value, "yes")
+		case "PDF version":
+			metadata.Version = value
+		}
+	}
+
+	return metadata
+}
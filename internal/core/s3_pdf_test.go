@@ -0,0 +1,46 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePDFInfo(t *testing.T) {
+	output := []byte(`Title:           Quarterly Report: Q3
+Author:          Jane Doe
+Creator:         LaTeX with hyperref
+Producer:        pdfTeX-1.40.25
+CreationDate:    Tue Oct  1 10:00:00 2024 UTC
+Tagged:          no
+Pages:           12
+Encrypted:       no
+Page size:       595.276 x 841.89 pts (A4)
+PDF version:     1.5
+`)
+
+	metadata := parsePDFInfo(output)
+
+	assert.Equal(t, "Quarterly Report: Q3", metadata.Title)
+	assert.Equal(t, "Jane Doe", metadata.Author)
+	assert.Equal(t, "LaTeX with hyperref", metadata.Creator)
+	assert.Equal(t, "pdfTeX-1.40.25", metadata.Producer)
+	assert.Equal(t, "Tue Oct  1 10:00:00 2024 UTC", metadata.Created)
+	assert.Equal(t, 12, metadata.Pages)
+	assert.False(t, metadata.Encrypted)
+	assert.Equal(t, "1.5", metadata.Version)
+}
+
+func TestParsePDFInfoEncrypted(t *testing.T) {
+	metadata := parsePDFInfo([]byte("Pages: 3\nEncrypted: yes (print:yes copy:no change:no addNotes:no)\n"))
+
+	assert.Equal(t, 3, metadata.Pages)
+	assert.True(t, metadata.Encrypted)
+	assert.Empty(t, metadata.Title)
+}
+
+func TestIsPDFObject(t *testing.T) {
+	assert.True(t, isPDFObject("application/pdf", "doc"))
+	assert.True(t, isPDFObject("application/octet-stream", "docs/report.PDF"))
+	assert.False(t, isPDFObject("text/plain", "notes.txt"))
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// ErrPreviewNotSupported is returned when an object's content type cannot be previewed as text
+var ErrPreviewNotSupported = errors.New("content type not supported for preview")
+
+// previewableTypes lists the non text/* media types that are safe to preview as text
+var previewableTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/x-yaml":     true,
+	"application/yaml":       true,
+	"application/x-sh":       true,
+	"application/csv":        true,
+	"application/sql":        true,
+	"application/toml":       true,
+	"application/x-ndjson":   true,
+	"image/svg+xml":          true,
+}
+
+// isPreviewable reports whether a media type holds text that can be shown inline
+func isPreviewable(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		previewableTypes[mediaType]
+}
+
+// GetObjectPreview returns up to maxBytes from the start of a text-like object, decoded to UTF-8.
+// With decompress set, gzip-compressed objects are previewed as their decompressed content.
+func (s *S3Service) GetObjectPreview(ctx context.Context, bucket, key string, maxBytes int64, decompress bool) (*models.ObjectPreview, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Int64("maxBytes", maxBytes).
+		Msg("Getting object preview")
+
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for preview")
+		return nil, err
+	}
+
+	gzipped := decompress && isGzipObject(aws.ToString(head.ContentEncoding), aws.ToString(head.ContentType), key)
+
+	// Fall back to the key's extension when the stored content type is missing or generic
+	contentType := aws.ToString(head.ContentType)
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" ||
+		(gzipped && (mediaType == "application/gzip" || mediaType == "application/x-gzip")) {
+		contentType = detectContentType(trimGzipExtension(key))
+		mediaType, params, _ = mime.ParseMediaType(contentType)
+	}
+	if !isPreviewable(mediaType) {
+		return nil, ErrPreviewNotSupported
+	}
+
+	preview := &models.ObjectPreview{
+		Key:         key,
+		ContentType: mediaType,
+		Charset:     strings.ToLower(params["charset"]),
+		Size:        aws.ToInt64(head.ContentLength),
+	}
+	if preview.Charset == "" {
+		preview.Charset = "utf-8"
+	}
+	if preview.Size == 0 || maxBytes <= 0 {
+		preview.Truncated = preview.Size > 0
+		return preview, nil
+	}
+
+	// Compressed objects are read from the start and decompressed until maxBytes are produced
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if !gzipped {
+		input.Range = aws.String(fmt.Sprintf("bytes=0-%d", maxBytes-1))
+	}
+
+	output, err := s.core.Client(bucket).GetObject(ctx, input)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object for preview")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	if gzipped {
+		data, truncated, err := readGzipPrefix(output.Body, maxBytes)
+		if err != nil {
+			s.core.Logger.Warn().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", key).
+				Msg("Failed to decompress object for preview")
+			return nil, ErrPreviewNotSupported
+		}
+
+		preview.Decompressed = true
+		preview.BytesRead = int64(len(data))
+		preview.Truncated = truncated
+		preview.Content = decodeText(data, preview.Charset, preview.Truncated)
+		return preview, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(output.Body, maxBytes))
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to read object for preview")
+		return nil, err
+	}
+
+	preview.BytesRead = int64(len(data))
+	preview.Truncated = preview.BytesRead < preview.Size
+	preview.Content = decodeText(data, preview.Charset, preview.Truncated)
+
+	return preview, nil
+}
+
+// decodeText converts text in the given charset to valid UTF-8. When the data was
+// cut off, a trailing partial UTF-8 sequence is dropped rather than replaced.
+func decodeText(data []byte, charset string, truncated bool) string {
+	if charset != "utf-8" && charset != "us-ascii" {
+		if enc, err := htmlindex.Get(charset); err == nil {
+			if decoded, err := enc.NewDecoder().Bytes(data); err == nil {
+				return strings.ToValidUTF8(string(decoded), "�")
+			}
+		}
+	}
+
+	if truncated {
+		// Drop a multi-byte sequence that was cut off at the end of the buffer
+		for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(data[i]) {
+				if !utf8.FullRune(data[i:]) {
+					data = data[:i]
+				}
+				break
+			}
+		}
+	}
+
+	return strings.ToValidUTF8(string(data), "�")
+}
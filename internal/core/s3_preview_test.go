@@ -0,0 +1,131 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPreviewable(t *testing.T) {
+	assert.True(t, isPreviewable("text/plain"))
+	assert.True(t, isPreviewable("text/csv"))
+	assert.True(t, isPreviewable("application/json"))
+	assert.True(t, isPreviewable("application/vnd.api+json"))
+	assert.False(t, isPreviewable("image/png"))
+	assert.False(t, isPreviewable("application/octet-stream"))
+	assert.False(t, isPreviewable("application/pdf"))
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		charset   string
+		truncated bool
+		expected  string
+	}{
+		{name: "utf-8", data: []byte("héllo"), charset: "utf-8", expected: "héllo"},
+		{name: "cut multi-byte rune", data: []byte("hé")[:2], charset: "utf-8", truncated: true, expected: "h"},
+		{name: "cut four-byte rune", data: []byte("a🚀")[:3], charset: "utf-8", truncated: true, expected: "a"},
+		{name: "invalid utf-8 replaced", data: []byte{'a', 0xff, 'b'}, charset: "utf-8", expected: "a�b"},
+		{name: "latin1", data: []byte{'c', 'a', 'f', 0xe9}, charset: "iso-8859-1", expected: "café"},
+		{name: "windows-1252", data: []byte{0x80, '5'}, charset: "windows-1252", expected: "€5"},
+		{name: "unknown charset treated as utf-8", data: []byte("plain"), charset: "x-unknown", expected: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, decodeText(tt.data, tt.charset, tt.truncated))
+		})
+	}
+}
+
+func TestSniffDelimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		sample   string
+		key      string
+		expected rune
+	}{
+		{name: "comma", sample: "a,b,c\n1,2,3\n4,5,6\n", key: "data.csv", expected: ','},
+		{name: "tab", sample: "a\tb\n1\t2\n", key: "data.txt", expected: '\t'},
+		{name: "semicolon with commas in values", sample: "name;price\n\"a, b\";1,50\n\"c\";2,75\n", key: "prices.csv", expected: ';'},
+		{name: "pipe", sample: "a|b|c\n1|2|3\n", key: "data", expected: '|'},
+		{name: "single column falls back to extension", sample: "value\n1\n2\n", key: "data.tsv", expected: '\t'},
+		{name: "empty sample", sample: "", key: "data.csv", expected: ','},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, string(tt.expected), string(sniffDelimiter(tt.sample, tt.key)))
+		})
+	}
+}
+
+func TestNormalizeETag(t *testing.T) {
+	assert.Equal(t, "abc", normalizeETag(`"abc"`))
+	assert.Equal(t, "abc", normalizeETag(`W/"abc"`))
+	assert.Equal(t, "abc", normalizeETag(`abc`))
+	assert.Equal(t, normalizeETag(`"abc-2"`), normalizeETag(` "abc-2" `))
+}
+
+func TestIsGzipObject(t *testing.T) {
+	assert.True(t, isGzipObject("gzip", "text/plain", "app.log"))
+	assert.True(t, isGzipObject("", "application/gzip", "app.log"))
+	assert.True(t, isGzipObject("", "", "logs/app.log.GZ"))
+	assert.False(t, isGzipObject("", "", "backup.tar.gz"), "tarballs are archives, not compressed text")
+	assert.False(t, isGzipObject("", "text/plain", "app.log"))
+
+	assert.Equal(t, "logs/app.log", trimGzipExtension("logs/app.log.gz"))
+	assert.Equal(t, "app.log", trimGzipExtension("app.log"))
+}
+
+func TestReadGzipPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte(strings.Repeat("line\n", 100)))
+	_ = writer.Close()
+	compressed := buf.Bytes()
+
+	data, truncated, err := readGzipPrefix(bytes.NewReader(compressed), 10)
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, "line\nline\n", string(data))
+
+	data, truncated, err = readGzipPrefix(bytes.NewReader(compressed), 1000)
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, data, 500)
+
+	// A stream cut off part-way is returned as a truncated preview
+	_, truncated, err = readGzipPrefix(bytes.NewReader(compressed[:len(compressed)-8]), 1000)
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+
+	_, _, err = readGzipPrefix(strings.NewReader("not gzip"), 10)
+	assert.Error(t, err)
+}
+
+func TestTrimPartialLine(t *testing.T) {
+	data, offset := trimPartialLine([]byte("ial line\nfull line\nlast\n"), 100)
+	assert.Equal(t, "full line\nlast\n", string(data))
+	assert.Equal(t, int64(109), offset)
+
+	data, offset = trimPartialLine([]byte("one very long line"), 100)
+	assert.Equal(t, "one very long line", string(data))
+	assert.Equal(t, int64(100), offset)
+}
+
+func TestMatchesContentType(t *testing.T) {
+	patterns := []string{"text/html", "image/*"}
+	assert.True(t, matchesContentType(patterns, "text/html"))
+	assert.True(t, matchesContentType(patterns, "TEXT/HTML"))
+	assert.True(t, matchesContentType(patterns, "image/svg+xml"))
+	assert.False(t, matchesContentType(patterns, "text/plain"))
+	assert.False(t, matchesContentType(patterns, "imagex/png"))
+	assert.False(t, matchesContentType(patterns, ""))
+	assert.False(t, matchesContentType(nil, "text/html"))
+}
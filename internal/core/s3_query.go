@@ -0,0 +1,202 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxQueryBytes caps the result records returned for one query; queries returning
+// more are cut short and reported as truncated
+const maxQueryBytes = 10 << 20
+
+// ErrInvalidQuery is returned for queries without an expression or with options S3
+// Select does not support
+var ErrInvalidQuery = errors.New("invalid query")
+
+// QueryObject runs an S3 Select SQL expression over a CSV, JSON or Parquet object so
+// large files can be inspected without downloading them. S3 Select only reads the
+// latest version of an object. The result records are handed to emit, one JSON document
+// each, as S3 streams them. It returns the bytes S3 read and returned, and whether the
+// results were cut short at 10 MiB.
+func (s *S3Service) QueryObject(ctx context.Context, bucket, key string, req models.ObjectQueryRequest, customerKey *CustomerKey, emit func([]json.RawMessage) error) (models.ObjectQueryStats, bool, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("expression", req.Expression).
+		Msg("Querying object")
+
+	var stats models.ObjectQueryStats
+	if strings.TrimSpace(req.Expression) == "" {
+		return stats, false, fmt.Errorf("%w: an expression is required", ErrInvalidQuery)
+	}
+	serialization, err := queryInput(key, req)
+	if err != nil {
+		return stats, false, err
+	}
+
+	input := &s3.SelectObjectContentInput{
+		Bucket:             aws.String(bucket),
+		Key:                aws.String(key),
+		Expression:         aws.String(req.Expression),
+		ExpressionType:     s3Types.ExpressionTypeSql,
+		InputSerialization: serialization,
+		OutputSerialization: &s3Types.OutputSerialization{
+			JSON: &s3Types.JSONOutput{RecordDelimiter: aws.String("\n")},
+		},
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = customerKey.params()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	output, err := s.core.Client(bucket).SelectObjectContent(ctx, input)
+	if err != nil {
+		return stats, false, err
+	}
+	stream := output.GetStream()
+	defer stream.Close()
+
+	// Records arrive in chunks that may end in the middle of a record
+	var pending []byte
+	returned := 0
+	for event := range stream.Events() {
+		switch event := event.(type) {
+		case *s3Types.SelectObjectContentEventStreamMemberRecords:
+			pending = append(pending, event.Value.Payload...)
+			end := bytes.LastIndexByte(pending, '\n')
+			if end < 0 {
+				continue
+			}
+			var records []json.RawMessage
+			for _, line := range bytes.Split(pending[:end], []byte("\n")) {
+				if len(bytes.TrimSpace(line)) == 0 {
+					continue
+				}
+				if returned+len(line) > maxQueryBytes {
+					if len(records) > 0 {
+						err = emit(records)
+					}
+					return stats, true, err
+				}
+				returned += len(line)
+				records = append(records, json.RawMessage(bytes.Clone(line)))
+			}
+			pending = bytes.Clone(pending[end+1:])
+			if len(records) > 0 {
+				if err := emit(records); err != nil {
+					return stats, false, err
+				}
+			}
+		case *s3Types.SelectObjectContentEventStreamMemberStats:
+			if details := event.Value.Details; details != nil {
+				stats = models.ObjectQueryStats{
+					BytesScanned:   aws.ToInt64(details.BytesScanned),
+					BytesProcessed: aws.ToInt64(details.BytesProcessed),
+					BytesReturned:  aws.ToInt64(details.BytesReturned),
+				}
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return stats, false, err
+	}
+
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", key).
+		Int64("bytesScanned", stats.BytesScanned).
+		Int64("bytesReturned", stats.BytesReturned).
+		Msg("Object queried")
+
+	return stats, false, nil
+}
+
+// queryInput describes an object to S3 Select, taking what the request leaves out from
+// the key's extensions, e.g. orders.csv.gz
+func queryInput(key string, req models.ObjectQueryRequest) (*s3Types.InputSerialization, error) {
+	name := strings.ToLower(path.Base(key))
+	compression := strings.ToLower(req.Compression)
+	switch ext := path.Ext(name); ext {
+	case ".gz", ".bz2":
+		name = strings.TrimSuffix(name, ext)
+		if compression == "" {
+			compression = map[string]string{".gz": "gzip", ".bz2": "bzip2"}[ext]
+		}
+	}
+	ext := path.Ext(name)
+
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		switch ext {
+		case ".csv", ".tsv":
+			format = "csv"
+		case ".json", ".jsonl", ".ndjson":
+			format = "json"
+		case ".parquet":
+			format = "parquet"
+		default:
+			return nil, fmt.Errorf("%w: the format of %s is unknown; give csv, json or parquet", ErrInvalidQuery, key)
+		}
+	}
+
+	serialization := &s3Types.InputSerialization{}
+	switch compression {
+	case "", "none":
+		serialization.CompressionType = s3Types.CompressionTypeNone
+	case "gzip":
+		serialization.CompressionType = s3Types.CompressionTypeGzip
+	case "bzip2":
+		serialization.CompressionType = s3Types.CompressionTypeBzip2
+	default:
+		return nil, fmt.Errorf("%w: compression must be none, gzip or bzip2", ErrInvalidQuery)
+	}
+
+	switch format {
+	case "csv":
+		header := s3Types.FileHeaderInfo(strings.ToUpper(req.CSVHeader))
+		if header == "" {
+			header = s3Types.FileHeaderInfoUse
+		}
+		if header != s3Types.FileHeaderInfoUse && header != s3Types.FileHeaderInfoIgnore && header != s3Types.FileHeaderInfoNone {
+			return nil, fmt.Errorf("%w: csvHeader must be use, ignore or none", ErrInvalidQuery)
+		}
+		delimiter := req.Delimiter
+		if delimiter == "" {
+			delimiter = ","
+			if ext == ".tsv" {
+				delimiter = "\t"
+			}
+		}
+		serialization.CSV = &s3Types.CSVInput{FileHeaderInfo: header, FieldDelimiter: aws.String(delimiter)}
+	case "json":
+		jsonType := s3Types.JSONType(strings.ToUpper(req.JSONType))
+		if jsonType == "" {
+			jsonType = s3Types.JSONTypeDocument
+			if ext == ".jsonl" || ext == ".ndjson" {
+				jsonType = s3Types.JSONTypeLines
+			}
+		}
+		if jsonType != s3Types.JSONTypeDocument && jsonType != s3Types.JSONTypeLines {
+			return nil, fmt.Errorf("%w: jsonType must be lines or document", ErrInvalidQuery)
+		}
+		serialization.JSON = &s3Types.JSONInput{Type: jsonType}
+	case "parquet":
+		if serialization.CompressionType != s3Types.CompressionTypeNone {
+			return nil, fmt.Errorf("%w: Parquet objects are compressed internally", ErrInvalidQuery)
+		}
+		serialization.Parquet = &s3Types.ParquetInput{}
+	default:
+		return nil, fmt.Errorf("%w: format must be csv, json or parquet", ErrInvalidQuery)
+	}
+	return serialization, nil
+}
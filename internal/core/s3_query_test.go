@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// selectEvent encodes one message of an S3 Select event stream
+func selectEvent(eventType, payload string) []byte {
+	var headers []byte
+	for _, header := range [][2]string{{":message-type", "event"}, {":event-type", eventType}} {
+		headers = append(headers, byte(len(header[0])))
+		headers = append(headers, header[0]...)
+		headers = append(headers, 7) // string
+		headers = binary.BigEndian.AppendUint16(headers, uint16(len(header[1])))
+		headers = append(headers, header[1]...)
+	}
+
+	message := binary.BigEndian.AppendUint32(nil, uint32(16+len(headers)+len(payload)))
+	message = binary.BigEndian.AppendUint32(message, uint32(len(headers)))
+	message = binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+	message = append(message, headers...)
+	message = append(message, payload...)
+	return binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+}
+
+func TestQueryObject(t *testing.T) {
+	var request struct {
+		Expression string `xml:"Expression"`
+		Input      struct {
+			CompressionType string `xml:"CompressionType"`
+			CSV             *struct {
+				FileHeaderInfo string `xml:"FileHeaderInfo"`
+				FieldDelimiter string `xml:"FieldDelimiter"`
+			} `xml:"CSV"`
+		} `xml:"InputSerialization"`
+	}
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, xml.Unmarshal(body, &request))
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		// The second record is split across two events
+		w.Write(selectEvent("Records", `{"id":"1"}`+"\n"+`{"id"`))
+		w.Write(selectEvent("Records", `:"2"}`+"\n"))
+		w.Write(selectEvent("Stats", `<Stats><BytesScanned>300</BytesScanned><BytesProcessed>300</BytesProcessed>`+
+			`<BytesReturned>22</BytesReturned></Stats>`))
+		w.Write(selectEvent("End", ""))
+	})
+	ctx := context.Background()
+
+	var records []string
+	emit := func(batch []json.RawMessage) error {
+		for _, record := range batch {
+			records = append(records, string(record))
+		}
+		return nil
+	}
+	req := models.ObjectQueryRequest{Expression: "SELECT s.id FROM S3Object s"}
+	stats, truncated, err := c.S3Service.QueryObject(ctx, "data", "exports/orders.tsv.gz", req, nil, emit)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"id":"1"}`, `{"id":"2"}`}, records)
+	assert.Equal(t, models.ObjectQueryStats{BytesScanned: 300, BytesProcessed: 300, BytesReturned: 22}, stats)
+	assert.False(t, truncated)
+
+	// The format and compression come from the key's extensions
+	assert.Equal(t, "SELECT s.id FROM S3Object s", request.Expression)
+	assert.Equal(t, "GZIP", request.Input.CompressionType)
+	require.NotNil(t, request.Input.CSV)
+	assert.Equal(t, "USE", request.Input.CSV.FileHeaderInfo)
+	assert.Equal(t, "\t", request.Input.CSV.FieldDelimiter)
+
+	_, _, err = c.S3Service.QueryObject(ctx, "data", "orders.csv", models.ObjectQueryRequest{}, nil, emit)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
+func TestQueryInput(t *testing.T) {
+	input, err := queryInput("logs/app.ndjson", models.ObjectQueryRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "LINES", string(input.JSON.Type))
+
+	input, err = queryInput("data.bin", models.ObjectQueryRequest{Format: "parquet"})
+	require.NoError(t, err)
+	assert.NotNil(t, input.Parquet)
+
+	_, err = queryInput("data.bin", models.ObjectQueryRequest{})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, err = queryInput("data.parquet.gz", models.ObjectQueryRequest{})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, err = queryInput("data.csv", models.ObjectQueryRequest{CSVHeader: "first"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// defaultReadBlockSize is the size of the ranged GETs issued by objectReaderAt
+	defaultReadBlockSize = 1024 * 1024
+	// defaultReadCacheBlocks is the number of blocks objectReaderAt keeps in memory
+	defaultReadCacheBlocks = 16
+)
+
+// objectGetter is the subset of the S3 client needed for ranged reads
+type objectGetter interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// objectReaderAt exposes an S3 object as an io.ReaderAt backed by ranged GET requests.
+// Reads are served from fixed-size blocks that are fetched on demand and cached, so
+// parsers issuing many small reads (such as archive/zip) only cause a few requests.
+type objectReaderAt struct {
+	ctx       context.Context
+	client    objectGetter
+	bucket    string
+	key       string
+	size      int64
+	blockSize int64
+	maxBlocks int
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	order  []int64
+}
+
+// newObjectReaderAt creates a reader for an object whose total size is already known
+func newObjectReaderAt(ctx context.Context, client objectGetter, bucket, key string, size int64) *objectReaderAt {
+	return &objectReaderAt{
+		ctx:       ctx,
+		client:    client,
+		bucket:    bucket,
+		key:       key,
+		size:      size,
+		blockSize: defaultReadBlockSize,
+		maxBlocks: defaultReadCacheBlocks,
+		blocks:    make(map[int64][]byte),
+	}
+}
+
+// Size returns the total size of the object
+func (r *objectReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt
+func (r *objectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+
+	n := 0
+	for n < len(p) && off < r.size {
+		index := off / r.blockSize
+		block, err := r.block(index)
+		if err != nil {
+			return n, err
+		}
+
+		copied := copy(p[n:], block[off-index*r.blockSize:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// block returns the cached block with the given index, fetching it if necessary
+func (r *objectReaderAt) block(index int64) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if block, ok := r.blocks[index]; ok {
+		return block, nil
+	}
+
+	start := index * r.blockSize
+	end := min(start+r.blockSize, r.size) - 1
+
+	output, err := r.client.GetObject(r.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	block, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(block)) != end-start+1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	// Evict the oldest block once the cache is full
+	if len(r.order) == r.maxBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[index] = block
+	r.order = append(r.order, index)
+
+	return block, nil
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeObjectGetter serves ranged GETs from an in-memory object and counts requests
+type fakeObjectGetter struct {
+	data     []byte
+	requests int
+}
+
+func (f *fakeObjectGetter) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.requests++
+
+	var start, end int64
+	if _, err := fmt.Sscanf(aws.ToString(params.Range), "bytes=%d-%d", &start, &end); err != nil {
+		return nil, err
+	}
+	end = min(end, int64(len(f.data))-1)
+
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(bytes.NewReader(f.data[start : end+1])),
+	}, nil
+}
+
+func TestObjectReaderAt_ReadAt(t *testing.T) {
+	data := make([]byte, 2500)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	getter := &fakeObjectGetter{data: data}
+
+	reader := newObjectReaderAt(context.Background(), getter, "bucket", "key", int64(len(data)))
+	reader.blockSize = 1000
+
+	buf := make([]byte, 300)
+	n, err := reader.ReadAt(buf, 900) // spans the first two blocks
+	require.NoError(t, err)
+	assert.Equal(t, 300, n)
+	assert.Equal(t, data[900:1200], buf)
+	assert.Equal(t, 2, getter.requests)
+
+	n, err = reader.ReadAt(buf, 950) // served from cache
+	require.NoError(t, err)
+	assert.Equal(t, data[950:1250], buf[:n])
+	assert.Equal(t, 2, getter.requests)
+
+	n, err = reader.ReadAt(buf, 2400) // short read at the end
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 100, n)
+	assert.Equal(t, data[2400:], buf[:n])
+}
+
+func TestObjectReaderAt_Zip(t *testing.T) {
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	for i := 0; i < 50; i++ {
+		w, err := zipWriter.Create(fmt.Sprintf("dir/file-%02d.txt", i))
+		require.NoError(t, err)
+		_, err = w.Write(bytes.Repeat([]byte("x"), 10000))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+
+	getter := &fakeObjectGetter{data: archive.Bytes()}
+	reader := newObjectReaderAt(context.Background(), getter, "bucket", "a.zip", int64(archive.Len()))
+	reader.blockSize = 4096
+
+	zipReader, err := zip.NewReader(reader, reader.Size())
+	require.NoError(t, err)
+	assert.Len(t, zipReader.File, 50)
+	assert.Equal(t, "dir/file-49.txt", zipReader.File[49].Name)
+
+	// Only the central directory at the end of the archive should have been fetched
+	totalBlocks := (archive.Len() + 4095) / 4096
+	assert.Less(t, getter.requests, totalBlocks)
+}
+
+func TestArchiveFormat(t *testing.T) {
+	assert.Equal(t, "zip", archiveFormat("data/Backup.ZIP"))
+	assert.Equal(t, "tar.gz", archiveFormat("logs.tar.gz"))
+	assert.Equal(t, "tar.gz", archiveFormat("logs.tgz"))
+	assert.Equal(t, "tar", archiveFormat("logs.tar"))
+	assert.Equal(t, "", archiveFormat("notes.txt"))
+}
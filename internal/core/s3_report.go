@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// GetStorageReport counts the objects and bytes of a bucket, or a prefix within it, by
+// storage class and by age, to show which data lifecycle rules would move or expire
+func (s *S3Service) GetStorageReport(ctx context.Context, bucket, prefix string) (*models.StorageReport, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Msg("Generating storage report")
+
+	now := time.Now().UTC()
+	ageDays := s.core.Config.Reports.AgeDays
+	report := &models.StorageReport{
+		Bucket:      bucket,
+		Prefix:      prefix,
+		Ages:        newAgeGroups(ageDays),
+		GeneratedAt: now,
+	}
+
+	classes := make(map[string]*models.StorageClassUsage)
+	truncated, err := s.scanPrefix(ctx, bucket, prefix, func(obj s3Types.Object) {
+		class := storageClass(obj)
+		usage, ok := classes[class]
+		if !ok {
+			usage = &models.StorageClassUsage{StorageClass: class, Ages: newAgeGroups(ageDays)}
+			classes[class] = usage
+		}
+
+		size := aws.ToInt64(obj.Size)
+		group := ageGroup(ageDays, now.Sub(aws.ToTime(obj.LastModified)))
+		usage.Objects++
+		usage.Bytes += size
+		usage.Ages[group].Objects++
+		usage.Ages[group].Bytes += size
+		report.Objects++
+		report.Bytes += size
+		report.Ages[group].Objects++
+		report.Ages[group].Bytes += size
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Truncated = truncated
+	report.ScanLimit = s.core.Config.Reports.MaxObjects
+
+	report.StorageClasses = make([]models.StorageClassUsage, 0, len(classes))
+	for _, usage := range classes {
+		report.StorageClasses = append(report.StorageClasses, *usage)
+	}
+	sort.Slice(report.StorageClasses, func(i, j int) bool {
+		a, b := report.StorageClasses[i], report.StorageClasses[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.StorageClass < b.StorageClass
+	})
+
+	if !truncated {
+		s.cacheSize(bucket, prefix, report.Objects, report.Bytes)
+	}
+	return report, nil
+}
+
+// newAgeGroups returns empty age groups bounded by the given ascending day counts
+func newAgeGroups(ageDays []int) []models.AgeGroupUsage {
+	groups := make([]models.AgeGroupUsage, 0, len(ageDays)+1)
+	lower := 0
+	for _, days := range ageDays {
+		groups = append(groups, models.AgeGroupUsage{
+			Label:   fmt.Sprintf("%d-%dd", lower, days),
+			MinDays: lower,
+			MaxDays: days,
+		})
+		lower = days
+	}
+	return append(groups, models.AgeGroupUsage{Label: fmt.Sprintf("%dd+", lower), MinDays: lower})
+}
+
+// ageGroup returns the index of the age group an object of the given age falls into
+func ageGroup(ageDays []int, age time.Duration) int {
+	days := int(age / (24 * time.Hour))
+	return sort.Search(len(ageDays), func(i int) bool { return days < ageDays[i] })
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStorageReport(t *testing.T) {
+	now := time.Now()
+	days := func(n int) time.Time { return now.Add(-time.Duration(n)*24*time.Hour - time.Hour) }
+	objects := []testObject{
+		{key: "logs/today.gz", size: 10, storageClass: "STANDARD", modified: days(0)},
+		{key: "logs/june.gz", size: 20, storageClass: "STANDARD", modified: days(45)},
+		{key: "logs/2023.gz", size: 30, storageClass: "GLACIER", modified: days(400)},
+	}
+	cfg := &config.Config{Reports: config.ReportsConfig{MaxObjects: 100, AgeDays: []int{30, 365}}}
+	c := newTestListingCore(t, cfg, objects)
+
+	report, err := c.S3Service.GetStorageReport(context.Background(), "data", "logs/")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), report.Objects)
+	assert.Equal(t, int64(60), report.Bytes)
+	assert.False(t, report.Truncated)
+
+	require.Len(t, report.Ages, 3)
+	assert.Equal(t, []string{"0-30d", "30-365d", "365d+"}, []string{report.Ages[0].Label, report.Ages[1].Label, report.Ages[2].Label})
+	assert.Equal(t, []int64{10, 20, 30}, []int64{report.Ages[0].Bytes, report.Ages[1].Bytes, report.Ages[2].Bytes})
+	assert.Zero(t, report.Ages[2].MaxDays)
+
+	require.Len(t, report.StorageClasses, 2)
+	standard := report.StorageClasses[1]
+	assert.Equal(t, "STANDARD", standard.StorageClass)
+	assert.Equal(t, int64(2), standard.Objects)
+	assert.Equal(t, int64(1), standard.Ages[0].Objects)
+	assert.Equal(t, int64(1), standard.Ages[1].Objects)
+	assert.Equal(t, int64(0), standard.Ages[2].Objects)
+}
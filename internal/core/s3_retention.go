@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// retentionReportColumns is the header row of a retention report exported as CSV
+var retentionReportColumns = []string{"key", "versionId", "mode", "retainUntil", "retained", "legalHold", "error"}
+
+// GetRetentionReport reads the Object Lock retention and legal hold of every object
+// under a prefix, up to reports.maxLockChecks objects, as evidence that they are kept
+// write-once. Lapsed retentions are listed too. Buckets without Object Lock get a
+// report with no objects.
+func (s *S3Service) GetRetentionReport(ctx context.Context, bucket, prefix string) (*models.RetentionReport, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Msg("Generating retention report")
+
+	limit := s.core.Config.Reports.MaxLockChecks
+	report := &models.RetentionReport{
+		Bucket:      bucket,
+		Prefix:      prefix,
+		Objects:     []models.ObjectRetention{},
+		ScanLimit:   limit,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	output, err := s.core.Client(bucket).GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	switch {
+	case hasErrorCode(err, "ObjectLockConfigurationNotFoundError"):
+		return report, nil
+	case hasErrorCode(err, "NoSuchBucket"), hasErrorCode(err, "AccessDenied"):
+		return nil, err
+	case err != nil:
+		// Some S3-compatible services cannot report the configuration; the objects
+		// still tell
+		s.core.Logger.Debug().
+			Err(err).
+			Str("bucket", bucket).
+			Msg("Failed to read Object Lock configuration")
+		report.ObjectLockEnabled = true
+	case output.ObjectLockConfiguration == nil || output.ObjectLockConfiguration.ObjectLockEnabled != s3Types.ObjectLockEnabledEnabled:
+		return report, nil
+	default:
+		report.ObjectLockEnabled = true
+		if rule := output.ObjectLockConfiguration.Rule; rule != nil && rule.DefaultRetention != nil {
+			report.DefaultRetention = &models.DefaultRetention{
+				Mode:  string(rule.DefaultRetention.Mode),
+				Days:  aws.ToInt32(rule.DefaultRetention.Days),
+				Years: aws.ToInt32(rule.DefaultRetention.Years),
+			}
+		}
+	}
+
+	var objects []lockedObject
+	truncated, err := s.scanPrefixLimit(ctx, bucket, prefix, limit, func(obj s3Types.Object) {
+		objects = append(objects, lockedObject{bucket: bucket, key: aws.ToString(obj.Key)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Truncated = truncated
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		now = report.GeneratedAt
+	)
+	sem := make(chan struct{}, lockCheckConcurrency)
+	for _, object := range objects {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			retention, found, err := s.objectRetention(ctx, object, now)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				report.Objects = append(report.Objects, models.ObjectRetention{Key: object.key, Error: err.Error()})
+			case found:
+				report.Objects = append(report.Objects, *retention)
+			}
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(report.Objects, func(a, b models.ObjectRetention) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	for _, object := range report.Objects {
+		summary := &report.Summary
+		summary.Objects++
+		switch {
+		case object.Error != "":
+			summary.Unreadable++
+			continue
+		case object.Retained && object.Mode == string(s3Types.ObjectLockModeCompliance):
+			summary.Compliance++
+		case object.Retained:
+			summary.Governance++
+		}
+		if object.LegalHold {
+			summary.LegalHolds++
+		}
+		if !object.Retained && !object.LegalHold {
+			summary.Unprotected++
+		}
+	}
+	return report, nil
+}
+
+// WriteRetentionReportCSV writes the objects of a retention report as CSV rows, with
+// RFC 3339 retain-until dates
+func WriteRetentionReportCSV(report *models.RetentionReport, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(retentionReportColumns); err != nil {
+		return err
+	}
+	for _, object := range report.Objects {
+		retainUntil := ""
+		if object.RetainUntil != nil {
+			retainUntil = object.RetainUntil.UTC().Format(time.RFC3339)
+		}
+		err := writer.Write([]string{
+			object.Key,
+			object.VersionID,
+			object.Mode,
+			retainUntil,
+			strconv.FormatBool(object.Retained),
+			strconv.FormatBool(object.LegalHold),
+			object.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// scanPrefix lists every object under a prefix and calls fn for each, stopping after
+// reports.maxObjects objects. It reports whether the listing was cut short.
+func (s *S3Service) scanPrefix(ctx context.Context, bucket, prefix string, fn func(s3Types.Object)) (bool, error) {
+	return s.scanPrefixLimit(ctx, bucket, prefix, s.core.Config.Reports.MaxObjects, fn)
+}
+
+// scanPrefixLimit is scanPrefix with its own limit on the objects listed
+func (s *S3Service) scanPrefixLimit(ctx context.Context, bucket, prefix string, maxObjects int, fn func(s3Types.Object)) (bool, error) {
+	paginator := s3.NewListObjectsV2Paginator(s.core.Client(bucket), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	scanned := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("prefix", prefix).
+				Msg("Failed to list objects for report")
+			return false, err
+		}
+
+		for _, obj := range page.Contents {
+			if scanned >= maxObjects {
+				return true, nil
+			}
+			fn(obj)
+			scanned++
+		}
+	}
+	return false, nil
+}
+
+// storageClass returns an object's storage class, which listings leave empty for
+// STANDARD on some S3-compatible services
+func storageClass(obj s3Types.Object) string {
+	if obj.StorageClass == "" {
+		return string(s3Types.ObjectStorageClassStandard)
+	}
+	return string(obj.StorageClass)
+}
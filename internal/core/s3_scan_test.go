@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testObject is an object served by newTestListingCore
+type testObject struct {
+	key          string
+	size         int64
+	storageClass string
+	modified     time.Time
+}
+
+// newTestS3Core returns a Core with cfg whose S3 client talks to a fake S3 server that
+// answers every request with handler
+func newTestS3Core(t *testing.T, cfg *config.Config, handler http.HandlerFunc) *Core {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	c := &Core{
+		Config:      cfg,
+		Logger:      logger.New("error", "json"),
+		Events:      NewEventFeed(defaultEventFeedCapacity),
+		S3Client:    client,
+		S3Presigner: s3.NewPresignClient(client),
+	}
+	c.S3Service = NewS3Service(c)
+	return c
+}
+
+// newTestListingCore returns a Core whose S3 client talks to a server that lists the
+// given objects, in a single page, for any bucket and prefix
+func newTestListingCore(t *testing.T, cfg *config.Config, objects []testObject) *Core {
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		var contents strings.Builder
+		for _, obj := range objects {
+			fmt.Fprintf(&contents, "<Contents><Key>%s</Key><Size>%d</Size><StorageClass>%s</StorageClass><LastModified>%s</LastModified></Contents>",
+				obj.key, obj.size, obj.storageClass, obj.modified.UTC().Format(time.RFC3339))
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<ListBucketResult><IsTruncated>false</IsTruncated><KeyCount>%d</KeyCount>%s</ListBucketResult>`,
+			len(objects), contents.String())
+	})
+	c.Pricing = NewPricingService(c)
+	return c
+}
+
+func TestScanPrefix(t *testing.T) {
+	objects := []testObject{
+		{key: "a", size: 1, storageClass: "STANDARD"},
+		{key: "b", size: 2, storageClass: ""},
+		{key: "c", size: 3, storageClass: "GLACIER"},
+	}
+	c := newTestListingCore(t, &config.Config{Reports: config.ReportsConfig{MaxObjects: 10}}, objects)
+
+	var classes []string
+	truncated, err := c.S3Service.scanPrefix(context.Background(), "data", "", func(obj s3Types.Object) {
+		classes = append(classes, storageClass(obj))
+	})
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	// An empty storage class is STANDARD
+	assert.Equal(t, []string{"STANDARD", "STANDARD", "GLACIER"}, classes)
+
+	c.Config.Reports.MaxObjects = 2
+	scanned := 0
+	truncated, err = c.S3Service.scanPrefix(context.Background(), "data", "", func(s3Types.Object) { scanned++ })
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, 2, scanned)
+}
@@ -0,0 +1,305 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Job types of operations applied to a selection
+const (
+	JobTypeSelectionCopy   = "selection-copy"
+	JobTypeSelectionMove   = "selection-move"
+	JobTypeSelectionDelete = "selection-delete"
+)
+
+var (
+	// ErrSelectionEmpty is returned when applying an operation to an empty selection
+	ErrSelectionEmpty = errors.New("selection is empty")
+	// ErrCrossEndpointCopy is returned when copying between buckets reached through
+	// different endpoints or credentials, which S3 cannot copy server-side
+	ErrCrossEndpointCopy = errors.New("buckets are on different endpoints")
+	// ErrTargetInsideSelection is returned when copying a prefix into itself
+	ErrTargetInsideSelection = errors.New("target is inside a selected prefix")
+	// ErrSelectionNameClash is returned when copying selected items of the same name,
+	// whose copies would overwrite each other
+	ErrSelectionNameClash = errors.New("selected items have the same name")
+	// errSameObject is recorded for objects that would be copied onto themselves
+	errSameObject = errors.New("source and target are the same object")
+)
+
+// CopySelection starts a background job that copies every selected object into
+// targetPrefix of targetBucket, or moves them when move is set. Selected objects are
+// placed directly in the target prefix and selected prefixes become folders in it, as
+// when pasting files in a file manager, so items of the same name from different
+// folders or buckets are refused before anything is copied. Copies are encrypted with
+// kmsKeyID when set. Each object whose target key exists is handled by the conflict
+// strategy; those refused are recorded as failed and, when moving, left in place.
+func (s *S3Service) CopySelection(ctx context.Context, sel models.Selection, targetBucket, targetPrefix string, move bool, kmsKeyID, conflict string) (models.Job, error) {
+	if len(sel.Items) == 0 {
+		return models.Job{}, ErrSelectionEmpty
+	}
+	targetPrefix = folderPrefix(targetPrefix)
+
+	kms, err := s.kmsFor(kmsKeyID, nil)
+	if err != nil {
+		return models.Job{}, err
+	}
+	strategy, err := s.conflictStrategy(conflict)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	// Items only share target keys when they share a name: the keys under a selected
+	// prefix all start with the prefix's name
+	names := make(map[string]bool, len(sel.Items))
+	for _, item := range sel.Items {
+		name := zipEntryName(item.Key, parentFolder(item.Key))
+		if names[name] {
+			return models.Job{}, fmt.Errorf("%w: %s", ErrSelectionNameClash, name)
+		}
+		names[name] = true
+
+		if !s.core.sameEndpoint(item.Bucket, targetBucket) {
+			return models.Job{}, ErrCrossEndpointCopy
+		}
+		// The listing would pick up the copies and never end
+		if item.Bucket == targetBucket && strings.HasSuffix(item.Key, "/") && strings.HasPrefix(targetPrefix, item.Key) {
+			return models.Job{}, ErrTargetInsideSelection
+		}
+	}
+
+	// Fail early on a missing or inaccessible target bucket
+	if _, err := s.core.Client(targetBucket).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(targetBucket)}); err != nil {
+		return models.Job{}, err
+	}
+
+	jobType := JobTypeSelectionCopy
+	if move {
+		jobType = JobTypeSelectionMove
+	}
+	params := map[string]string{
+		"selection":    sel.ID,
+		"buckets":      selectionBuckets(sel),
+		"targetBucket": targetBucket,
+		"targetPrefix": targetPrefix,
+	}
+	if kmsKeyID != "" {
+		params["kmsKeyId"] = kmsKeyID
+	}
+	if conflict != "" {
+		params["conflict"] = strategy
+	}
+
+	return s.core.Jobs.Submit(jobType, params, func(ctx context.Context, job *Job) error {
+		for _, item := range sel.Items {
+			err := s.forEachSelected(ctx, job, item, func(key, name string, size int64) error {
+				targetKey := targetPrefix + name
+				if item.Bucket == targetBucket && key == targetKey {
+					return errSameObject
+				}
+				targetKey, err := s.resolveConflict(ctx, targetBucket, targetKey, strategy)
+				if err != nil {
+					return err
+				}
+				if err := s.copyObject(ctx, item.Bucket, key, targetBucket, targetKey, kms); err != nil {
+					return err
+				}
+				if move {
+					return s.DeleteObject(ctx, item.Bucket, key)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+// DeleteSelection starts a background job that deletes every selected object and
+// everything under the selected prefixes
+func (s *S3Service) DeleteSelection(ctx context.Context, sel models.Selection) (models.Job, error) {
+	if len(sel.Items) == 0 {
+		return models.Job{}, ErrSelectionEmpty
+	}
+
+	params := map[string]string{"selection": sel.ID, "buckets": selectionBuckets(sel)}
+	return s.core.Jobs.Submit(JobTypeSelectionDelete, params, func(ctx context.Context, job *Job) error {
+		for _, item := range sel.Items {
+			err := s.forEachSelected(ctx, job, item, func(key, name string, size int64) error {
+				return s.DeleteObject(ctx, item.Bucket, key)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+// PrepareZipFromSelection builds a manifest of every selected object. Entries are named
+// like the copies CopySelection makes, under a folder per bucket when the selection
+// spans several buckets.
+func (s *S3Service) PrepareZipFromSelection(ctx context.Context, sel models.Selection) (*ZipManifest, error) {
+	if len(sel.Items) == 0 {
+		return nil, ErrSelectionEmpty
+	}
+
+	limits := s.zipLimits()
+	manifest := &ZipManifest{}
+	buckets := make(map[string]bool)
+	for _, item := range sel.Items {
+		buckets[item.Bucket] = true
+	}
+	if len(buckets) == 1 {
+		manifest.Bucket = sel.Items[0].Bucket
+	}
+
+	for _, item := range sel.Items {
+		root := ""
+		if manifest.Bucket == "" {
+			root = item.Bucket + "/"
+		}
+		base := parentFolder(item.Key)
+
+		if strings.HasSuffix(item.Key, "/") {
+			if err := s.addPrefixToManifest(ctx, manifest, item.Bucket, item.Key, base, root); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		head, err := s.core.Client(item.Bucket).HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(item.Bucket),
+			Key:    aws.String(item.Key),
+		})
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", item.Bucket).
+				Str("key", item.Key).
+				Msg("Failed to get object metadata for zip download")
+			return nil, err
+		}
+
+		name := root + zipEntryName(item.Key, base)
+		if err := manifest.add(limits, item.Bucket, item.Key, name, aws.ToInt64(head.ContentLength), aws.ToTime(head.LastModified)); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest.Len() == 0 {
+		return nil, ErrZipEmpty
+	}
+	return manifest, nil
+}
+
+// forEachSelected calls fn for a selected object, or for every object under a selected
+// prefix, with its key and its name relative to the item's parent folder. Failures are
+// recorded on the job; only listing errors and cancellation stop it.
+func (s *S3Service) forEachSelected(ctx context.Context, job *Job, item models.SelectionItem, fn func(key, name string, size int64) error) error {
+	base := parentFolder(item.Key)
+	apply := func(key string, size int64) {
+		if err := fn(key, zipEntryName(key, base), size); err != nil {
+			job.ItemFailed(item.Bucket+"/"+key, err)
+			return
+		}
+		job.ItemSucceeded(item.Bucket+"/"+key, size)
+	}
+
+	if !strings.HasSuffix(item.Key, "/") {
+		job.AddTotal(1)
+		apply(item.Key, 0)
+		return nil
+	}
+
+	return s.forEachListed(ctx, job, item.Bucket, item.Key, apply)
+}
+
+// forEachListed calls apply for every object under prefix, adding each page of objects
+// to the job's total as it is listed
+func (s *S3Service) forEachListed(ctx context.Context, job *Job, bucket, prefix string, apply func(key string, size int64)) error {
+	paginator := s3.NewListObjectsV2Paginator(s.core.Client(bucket), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("prefix", prefix).
+				Msg("Failed to list prefix")
+			return err
+		}
+
+		job.AddTotal(int64(len(page.Contents)))
+		for _, obj := range page.Contents {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			apply(aws.ToString(obj.Key), aws.ToInt64(obj.Size))
+		}
+	}
+	return nil
+}
+
+// copyObject copies an object server-side, encrypting the copy as kms says. Both buckets
+// must be reachable with the same client.
+func (s *S3Service) copyObject(ctx context.Context, bucket, key, targetBucket, targetKey string, kms sseKMS) error {
+	sse, kmsKey := kms.params()
+	_, err := s.core.Client(targetBucket).CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:               aws.String(targetBucket),
+		Key:                  aws.String(targetKey),
+		CopySource:           aws.String(url.PathEscape(bucket + "/" + key)),
+		ServerSideEncryption: sse,
+		SSEKMSKeyId:          kmsKey,
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Str("targetBucket", targetBucket).
+			Str("targetKey", targetKey).
+			Msg("Failed to copy object")
+		return err
+	}
+
+	s.core.Events.Publish(EventObjectCreated, targetBucket, targetKey)
+	return nil
+}
+
+// selectionBuckets lists the buckets of a selection's items, comma separated, for the
+// params of its jobs
+func selectionBuckets(sel models.Selection) string {
+	var buckets []string
+	for _, item := range sel.Items {
+		if !slices.Contains(buckets, item.Bucket) {
+			buckets = append(buckets, item.Bucket)
+		}
+	}
+	return strings.Join(buckets, ",")
+}
+
+// parentFolder returns the folder containing a key, or the parent of a folder key,
+// ending in "/", or "" at the bucket root
+func parentFolder(key string) string {
+	parent := path.Dir(strings.TrimSuffix(key, "/"))
+	if parent == "." || parent == "/" {
+		return ""
+	}
+	return parent + "/"
+}
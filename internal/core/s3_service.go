@@ -2,8 +2,12 @@ package core
 
 import (
 	"context"
-	"path/filepath"
+	"errors"
+	"net/http"
+	"path"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"explorer451/internal/models"
@@ -16,12 +20,21 @@ import (
 // S3Service handles S3 operations
 type S3Service struct {
 	core *Core
+
+	mu sync.Mutex
+	// sizes holds prefix sizes counted by reports, see cacheSize
+	sizes map[string]prefixSize
+
+	// fetchClient downloads objects imported from remote URLs
+	fetchClient *http.Client
 }
 
 // NewS3Service creates a new S3Service
 func NewS3Service(core *Core) *S3Service {
 	return &S3Service{
-		core: core,
+		core:        core,
+		sizes:       make(map[string]prefixSize),
+		fetchClient: newFetchClient(core.Config),
 	}
 }
 
@@ -43,15 +56,53 @@ func (s *S3Service) ListBuckets(ctx context.Context) ([]models.Bucket, error) {
 		}
 	}
 
+	// Buckets on other endpoints are not in the default endpoint's listing
+	for _, endpoint := range s.core.Config.AWS.Buckets {
+		if !slices.ContainsFunc(buckets, func(b models.Bucket) bool { return b.Name == endpoint.Name }) {
+			buckets = append(buckets, models.Bucket{Name: endpoint.Name})
+		}
+	}
+	slices.SortFunc(buckets, func(a, b models.Bucket) int { return strings.Compare(a.Name, b.Name) })
+
+	for i := range buckets {
+		s.describeBucket(&buckets[i])
+		_, buckets[i].ObjectLambda = s.core.objectLambdaRegion(buckets[i].Name)
+		buckets[i].Anonymous = s.core.anonymousBucket(buckets[i].Name)
+	}
 	return buckets, nil
 }
 
+// describeBucket fills in a bucket's display name, description, group and tags from
+// the first entry of the buckets setting that matches its name
+func (s *S3Service) describeBucket(bucket *models.Bucket) {
+	for _, info := range s.core.Config.Buckets {
+		if ok, _ := path.Match(info.Name, bucket.Name); !ok {
+			continue
+		}
+		bucket.DisplayName = info.DisplayName
+		bucket.Description = info.Description
+		bucket.Group = info.Group
+		bucket.Tags = info.Tags
+		return
+	}
+}
+
 // GetBucketDetails retrieves detailed information about a bucket including its region
 func (s *S3Service) GetBucketDetails(ctx context.Context, bucketName string) (*models.BucketDetail, error) {
 	s.core.Logger.Debug().Str("bucket", bucketName).Msg("Getting bucket details")
 
+	// Access points have neither a location nor a creation date to look up
+	if region, ok := s.core.objectLambdaRegion(bucketName); ok {
+		return &models.BucketDetail{Name: bucketName, Region: region, ObjectLambda: true}, nil
+	}
+	// Anonymous requests may not look up a bucket's location or list buckets
+	if s.core.anonymousBucket(bucketName) {
+		region := s.core.Client(bucketName).Options().Region
+		return &models.BucketDetail{Name: bucketName, Region: region, Anonymous: true}, nil
+	}
+
 	// Get bucket location/region
-	locationResp, err := s.core.S3Client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+	locationResp, err := s.core.Client(bucketName).GetBucketLocation(ctx, &s3.GetBucketLocationInput{
 		Bucket: aws.String(bucketName),
 	})
 	if err != nil {
@@ -66,7 +117,7 @@ func (s *S3Service) GetBucketDetails(ctx context.Context, bucketName string) (*m
 	}
 
 	// Get bucket creation date from ListBuckets
-	bucketsResp, err := s.core.S3Client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	bucketsResp, err := s.core.Client(bucketName).ListBuckets(ctx, &s3.ListBucketsInput{})
 	if err != nil {
 		s.core.Logger.Error().Err(err).Msg("Failed to list buckets")
 		return nil, err
@@ -87,21 +138,31 @@ func (s *S3Service) GetBucketDetails(ctx context.Context, bucketName string) (*m
 	}, nil
 }
 
-// ListObjects lists objects in a bucket with optional prefix for folder navigation
-func (s *S3Service) ListObjects(ctx context.Context, bucket, prefix, nextToken string, delimiter string, maxKeys int32) (*models.ListObjectsResponse, error) {
+// ListObjects lists objects in a bucket with optional prefix for folder navigation.
+// The cursor is an opaque value returned in the pagination envelope of a previous page.
+// A maxKeys of 0 uses listing.defaultPageSize; larger ones are capped at
+// listing.maxPageSize.
+func (s *S3Service) ListObjects(ctx context.Context, bucket, prefix, cursor string, delimiter string, maxKeys int32) (*models.ListObjectsResponse, error) {
 	s.core.Logger.Debug().
 		Str("bucket", bucket).
 		Str("prefix", prefix).
-		Str("nextToken", nextToken).
+		Str("cursor", cursor).
 		Msg("Listing objects")
 
+	nextToken, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
 	if delimiter == "" {
 		delimiter = "/" // Default delimiter for folder-like navigation
 	}
 
-	if maxKeys <= 0 || maxKeys > 1000 {
-		maxKeys = 1000 // Use AWS default/max
+	maxPageSize := int32(s.core.Config.Listing.MaxPageSize)
+	if maxKeys <= 0 {
+		maxKeys = int32(s.core.Config.Listing.DefaultPageSize)
 	}
+	maxKeys = min(maxKeys, maxPageSize)
 
 	input := &s3.ListObjectsV2Input{
 		Bucket:    aws.String(bucket),
@@ -115,7 +176,7 @@ func (s *S3Service) ListObjects(ctx context.Context, bucket, prefix, nextToken s
 		input.ContinuationToken = aws.String(nextToken)
 	}
 
-	output, err := s.core.S3Client.ListObjectsV2(ctx, input)
+	output, err := s.core.Client(bucket).ListObjectsV2(ctx, input)
 	if err != nil {
 		s.core.Logger.Error().
 			Err(err).
@@ -126,9 +187,13 @@ func (s *S3Service) ListObjects(ctx context.Context, bucket, prefix, nextToken s
 	}
 
 	response := &models.ListObjectsResponse{
-		Objects:     make([]models.ObjectInfo, 0, len(output.Contents)+len(output.CommonPrefixes)),
-		IsTruncated: *output.IsTruncated,
-		PageSize:    int(maxKeys),
+		Objects: make([]models.ObjectInfo, 0, len(output.Contents)+len(output.CommonPrefixes)),
+		Pagination: models.Pagination{
+			Cursor:      EncodeCursor(aws.ToString(output.NextContinuationToken)),
+			HasMore:     aws.ToBool(output.IsTruncated),
+			PageSize:    int(maxKeys),
+			MaxPageSize: int(maxPageSize),
+		},
 	}
 
 	// Process CommonPrefixes (folders)
@@ -150,7 +215,7 @@ func (s *S3Service) ListObjects(ctx context.Context, bucket, prefix, nextToken s
 			continue
 		}
 
-		// Basic content type detection based on extension
+		// Listings carry no content types, so they are told from the key
 		contentType := ""
 		if !strings.HasSuffix(key, "/") {
 			contentType = detectContentType(key)
@@ -168,12 +233,21 @@ func (s *S3Service) ListObjects(ctx context.Context, bucket, prefix, nextToken s
 		})
 	}
 
+	s.sniffListedContentTypes(ctx, bucket, response.Objects)
 	response.ItemsInPage = len(response.Objects)
+
+	// The total is only known for certain when the whole listing fits in the first page
+	if nextToken == "" && !response.Pagination.HasMore {
+		total := int64(response.ItemsInPage)
+		response.Pagination.TotalEstimate = &total
+	}
+
 	return response, nil
 }
 
-// GetPresignedURL generates a presigned URL for downloading an object
-func (s *S3Service) GetPresignedURL(ctx context.Context, bucket, key string, expiresIn int64) (string, error) {
+// GetPresignedURL generates a presigned URL for downloading an object, or a specific
+// version of it when versionID is set
+func (s *S3Service) GetPresignedURL(ctx context.Context, bucket, key, versionID string, expiresIn int64) (string, error) {
 	s.core.Logger.Debug().
 		Str("bucket", bucket).
 		Str("key", key).
@@ -184,12 +258,25 @@ func (s *S3Service) GetPresignedURL(ctx context.Context, bucket, key string, exp
 		expiresIn = 15 * 60 // Default to 15 minutes
 	}
 
+	if err := s.core.Antivirus.CheckDownload(ctx, bucket, key); err != nil {
+		if errors.Is(err, ErrObjectInfected) {
+			s.core.Logger.Warn().
+				Str("bucket", bucket).
+				Str("key", key).
+				Msg("Refusing presigned URL for infected object")
+		}
+		return "", err
+	}
+
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
 
-	presignClient := s.core.S3Presigner
+	presignClient := s.core.Presigner(bucket)
 	resp, err := presignClient.PresignGetObject(ctx, input,
 		func(opts *s3.PresignOptions) {
 			opts.Expires = time.Duration(expiresIn) * time.Second
@@ -206,17 +293,21 @@ func (s *S3Service) GetPresignedURL(ctx context.Context, bucket, key string, exp
 	return resp.URL, nil
 }
 
-// GetObjectMetadata retrieves detailed metadata for an S3 object
-func (s *S3Service) GetObjectMetadata(ctx context.Context, bucket, key string) (*models.ObjectMetadata, error) {
+// GetObjectMetadata retrieves detailed metadata for an S3 object. Objects encrypted with
+// SSE-C need their customerKey.
+func (s *S3Service) GetObjectMetadata(ctx context.Context, bucket, key string, customerKey *CustomerKey) (*models.ObjectMetadata, error) {
 	s.core.Logger.Debug().
 		Str("bucket", bucket).
 		Str("key", key).
 		Msg("Getting object metadata")
 
-	output, err := s.core.S3Client.HeadObject(ctx, &s3.HeadObjectInput{
+	input := &s3.HeadObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-	})
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = customerKey.params()
+
+	output, err := s.core.Client(bucket).HeadObject(ctx, input)
 	if err != nil {
 		s.core.Logger.Error().
 			Err(err).
@@ -226,9 +317,15 @@ func (s *S3Service) GetObjectMetadata(ctx context.Context, bucket, key string) (
 		return nil, err
 	}
 
+	contentType := aws.ToString(output.ContentType)
+	// Objects encrypted with a customer key cannot be read to sniff them
+	if customerKey == nil {
+		contentType = s.objectContentType(ctx, bucket, key, contentType, aws.ToInt64(output.ContentLength))
+	}
+
 	metadata := &models.ObjectMetadata{
 		Key:           key,
-		ContentType:   aws.ToString(output.ContentType),
+		ContentType:   contentType,
 		ContentLength: aws.ToInt64(output.ContentLength),
 		ETag:          aws.ToString(output.ETag),
 		LastModified:  aws.ToTime(output.LastModified),
@@ -241,12 +338,44 @@ func (s *S3Service) GetObjectMetadata(ctx context.Context, bucket, key string) (
 	if output.ServerSideEncryption != "" {
 		metadata.ServerSideEncryption = string(output.ServerSideEncryption)
 	}
+	metadata.SSECustomerAlgorithm = aws.ToString(output.SSECustomerAlgorithm)
+
+	// HEAD does not return tags. Callers without s3:GetObjectTagging, and stores that do
+	// not support tagging, still get the rest of the metadata.
+	tags, err := s.GetObjectTags(ctx, bucket, key, "")
+	if err != nil {
+		s.core.Logger.Debug().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object tags")
+	} else {
+		metadata.Tags = tags.Tags
+	}
+
+	// The same goes for the ACL, which buckets that disable ACLs still answer with the
+	// owner's full control
+	acl, err := s.objectACL(ctx, bucket, key)
+	if err != nil {
+		s.core.Logger.Debug().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object ACL")
+	} else {
+		metadata.Grants = acl.Grants
+		metadata.Public = len(acl.Warnings) > 0
+	}
 
 	return metadata, nil
 }
 
-// GeneratePresignedPostURL generates a presigned POST URL for uploading objects
-func (s *S3Service) GeneratePresignedPostURL(ctx context.Context, bucket, key, contentType string, expiresIn time.Duration, maxSize int64) (*models.PresignedPostURLResponse, error) {
+// GeneratePresignedPostURL generates a presigned POST URL for uploading objects, encrypted
+// with SSE-KMS when kmsKeyID is set or with SSE-C when customerKey is set. The customer
+// key is returned in the form fields, as the client has to post it with the file. An
+// existing key is handled by the conflict strategy when the URL is generated; the
+// final key is returned in the "key" field.
+func (s *S3Service) GeneratePresignedPostURL(ctx context.Context, bucket, key, contentType string, expiresIn time.Duration, maxSize int64, kmsKeyID string, customerKey *CustomerKey, conflict string) (*models.PresignedPostURLResponse, error) {
 	s.core.Logger.Debug().
 		Str("bucket", bucket).
 		Str("key", key).
@@ -264,8 +393,28 @@ func (s *S3Service) GeneratePresignedPostURL(ctx context.Context, bucket, key, c
 		maxSize = 10 * 1024 * 1024 // 10MB
 	}
 
-	// Create presigned POST policy
-	resp, err := s.core.S3Presigner.PresignPostObject(ctx, &s3.PutObjectInput{
+	kms, err := s.kmsFor(kmsKeyID, customerKey)
+	if err != nil {
+		return nil, err
+	}
+	extra := kms.fields()
+	if customerKey != nil {
+		extra = customerKey.fields()
+	}
+
+	key, err = s.resolveConflict(ctx, bucket, key, conflict)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.presignPost(ctx, bucket, key, contentType, expiresIn, maxSize, extra)
+}
+
+// presignPost presigns a POST upload restricted to contentType and maxSize bytes.
+// Extra form fields, such as tagging or encryption settings, are returned with the
+// signed fields and pinned by the policy so the client cannot change them.
+func (s *S3Service) presignPost(ctx context.Context, bucket, key, contentType string, expiresIn time.Duration, maxSize int64, extra map[string]string) (*models.PresignedPostURLResponse, error) {
+	resp, err := s.core.Presigner(bucket).PresignPostObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(bucket),
 		Key:         aws.String(key),
 		ContentType: aws.String(contentType),
@@ -277,6 +426,9 @@ func (s *S3Service) GeneratePresignedPostURL(ctx context.Context, bucket, key, c
 			// Restrict content length
 			[]interface{}{"content-length-range", 0, maxSize},
 		)
+		for field, value := range extra {
+			opts.Conditions = append(opts.Conditions, []interface{}{"eq", "$" + field, value})
+		}
 	})
 	if err != nil {
 		s.core.Logger.Error().
@@ -287,12 +439,95 @@ func (s *S3Service) GeneratePresignedPostURL(ctx context.Context, bucket, key, c
 		return nil, err
 	}
 
+	for field, value := range extra {
+		resp.Values[field] = value
+	}
 	return &models.PresignedPostURLResponse{
 		URL:    resp.URL,
 		Fields: resp.Values,
 	}, nil
 }
 
+// GetObjectStream opens an object, or a specific version of it when versionID is set,
+// for streaming to a client. The optional rangeHeader is passed through to S3 so
+// clients can seek within media files. Objects encrypted with SSE-C need their
+// customerKey.
+//
+// With decompress set and no range requested, gzip-compressed objects are decompressed
+// on the fly; their length is then unknown and ranges are not offered.
+func (s *S3Service) GetObjectStream(ctx context.Context, bucket, key, versionID, rangeHeader string, decompress bool, customerKey *CustomerKey) (*models.ObjectStream, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("range", rangeHeader).
+		Msg("Streaming object")
+
+	if err := s.core.Antivirus.CheckDownload(ctx, bucket, key); err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	if rangeHeader != "" {
+		input.Range = aws.String(rangeHeader)
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = customerKey.params()
+
+	output, err := s.core.Client(bucket).GetObject(ctx, input)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object for streaming")
+		return nil, err
+	}
+
+	if decompress && rangeHeader == "" && isGzipObject(aws.ToString(output.ContentEncoding), aws.ToString(output.ContentType), key) {
+		body, err := newGzipReadCloser(output.Body)
+		if err == nil {
+			return &models.ObjectStream{
+				Body:          body,
+				ContentType:   detectContentType(trimGzipExtension(key)),
+				ContentLength: -1,
+				LastModified:  aws.ToTime(output.LastModified),
+				Decompressed:  true,
+			}, nil
+		}
+
+		// Not actually gzip data: fall back to the stored bytes from a fresh request
+		output.Body.Close()
+		s.core.Logger.Warn().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Object is not valid gzip, streaming as stored")
+		if output, err = s.core.Client(bucket).GetObject(ctx, input); err != nil {
+			return nil, err
+		}
+	}
+
+	// Browsers only play media inline when the content type is right, so replace generic types
+	contentType := aws.ToString(output.ContentType)
+	if contentType == "" || contentType == "application/octet-stream" || contentType == "binary/octet-stream" {
+		contentType = detectContentType(key)
+	}
+
+	return &models.ObjectStream{
+		Body:          output.Body,
+		ContentType:   contentType,
+		ContentLength: aws.ToInt64(output.ContentLength),
+		ContentRange:  aws.ToString(output.ContentRange),
+		ETag:          aws.ToString(output.ETag),
+		LastModified:  aws.ToTime(output.LastModified),
+	}, nil
+}
+
 // DeleteObject deletes a single object from S3
 func (s *S3Service) DeleteObject(ctx context.Context, bucket, key string) error {
 	s.core.Logger.Debug().
@@ -300,7 +535,7 @@ func (s *S3Service) DeleteObject(ctx context.Context, bucket, key string) error
 		Str("key", key).
 		Msg("Deleting object")
 
-	_, err := s.core.S3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+	_, err := s.core.Client(bucket).DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
@@ -317,6 +552,7 @@ func (s *S3Service) DeleteObject(ctx context.Context, bucket, key string) error
 		Str("bucket", bucket).
 		Str("key", key).
 		Msg("Successfully deleted object")
+	s.core.Events.Publish(EventObjectRemoved, bucket, key)
 
 	return nil
 }
@@ -335,7 +571,7 @@ func (s *S3Service) DeleteObjectsByPrefix(ctx context.Context, bucket, prefix st
 	}
 
 	var objectsToDelete []s3Types.ObjectIdentifier
-	paginator := s3.NewListObjectsV2Paginator(s.core.S3Client, input)
+	paginator := s3.NewListObjectsV2Paginator(s.core.Client(bucket), input)
 
 	for paginator.HasMorePages() {
 		page, err := paginator.NextPage(ctx)
@@ -372,7 +608,7 @@ func (s *S3Service) DeleteObjectsByPrefix(ctx context.Context, bucket, prefix st
 		}
 
 		batch := objectsToDelete[i:end]
-		_, err := s.core.S3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		output, err := s.core.Client(bucket).DeleteObjects(ctx, &s3.DeleteObjectsInput{
 			Bucket: aws.String(bucket),
 			Delete: &s3Types.Delete{
 				Objects: batch,
@@ -395,6 +631,10 @@ func (s *S3Service) DeleteObjectsByPrefix(ctx context.Context, bucket, prefix st
 			Str("prefix", prefix).
 			Int("count", len(batch)).
 			Msg("Successfully deleted batch of objects")
+
+		for _, deleted := range output.Deleted {
+			s.core.Events.Publish(EventObjectRemoved, bucket, aws.ToString(deleted.Key))
+		}
 	}
 
 	s.core.Logger.Info().
@@ -418,10 +658,15 @@ func (s *S3Service) CreateFolder(ctx context.Context, bucket, key string) error
 		key = key + "/"
 	}
 
-	_, err := s.core.S3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-		Body:   strings.NewReader(""), // Empty body for folder marker
+	// Without a key of its own the write cannot break uploads.requireKms
+	kms, _ := s.kmsFor("", nil)
+	sse, kmsKeyID := kms.params()
+	_, err := s.core.Client(bucket).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		Body:                 strings.NewReader(""), // Empty body for folder marker
+		ServerSideEncryption: sse,
+		SSEKMSKeyId:          kmsKeyID,
 	})
 	if err != nil {
 		s.core.Logger.Error().
@@ -436,33 +681,7 @@ func (s *S3Service) CreateFolder(ctx context.Context, bucket, key string) error
 		Str("bucket", bucket).
 		Str("key", key).
 		Msg("Successfully created folder")
+	s.core.Events.Publish(EventObjectCreated, bucket, key)
 
 	return nil
 }
-
-// detectContentType detects the content type of a file based on its extension
-func detectContentType(filename string) string {
-	ext := strings.ToLower(filepath.Ext(filename))
-	switch ext {
-	case ".txt":
-		return "text/plain"
-	case ".html", ".htm":
-		return "text/html"
-	case ".css":
-		return "text/css"
-	case ".js":
-		return "application/javascript"
-	case ".json":
-		return "application/json"
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
-	case ".png":
-		return "image/png"
-	case ".gif":
-		return "image/gif"
-	case ".pdf":
-		return "application/pdf"
-	default:
-		return "application/octet-stream"
-	}
-}
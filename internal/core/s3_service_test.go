@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListObjectsPageSize(t *testing.T) {
+	var maxKeys string
+	cfg := &config.Config{Listing: config.ListingConfig{DefaultPageSize: 100, MaxPageSize: 500}}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		maxKeys = r.URL.Query().Get("max-keys")
+		fmt.Fprint(w, `<ListBucketResult><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken></ListBucketResult>`)
+	})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		requested int32
+		applied   int
+	}{
+		{requested: 0, applied: 100},
+		{requested: 20, applied: 20},
+		{requested: 5000, applied: 500},
+	} {
+		resp, err := c.S3Service.ListObjects(ctx, "docs", "", "", "", tc.requested)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprint(tc.applied), maxKeys)
+		assert.Equal(t, tc.applied, resp.Pagination.PageSize)
+		assert.Equal(t, 500, resp.Pagination.MaxPageSize)
+	}
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// maxTablePreviewBytes bounds how much of an object is read for a table preview
+	maxTablePreviewBytes = 4 * 1024 * 1024
+	// delimiterSniffBytes is the amount of leading data inspected to guess the delimiter
+	delimiterSniffBytes = 64 * 1024
+)
+
+// delimiterCandidates are the separators considered when sniffing a delimited file
+var delimiterCandidates = []rune{',', '\t', ';', '|'}
+
+// GetTablePreview parses the first rows of a CSV/TSV object. A zero delimiter is
+// detected from the data; with header set the first row provides the column names.
+// With decompress set, gzip-compressed files are decompressed on the fly.
+func (s *S3Service) GetTablePreview(ctx context.Context, bucket, key string, maxRows int, delimiter rune, header, decompress bool) (*models.TablePreview, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Int("maxRows", maxRows).
+		Msg("Getting table preview")
+
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object for table preview")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	body := output.Body
+	sniffKey := key
+	if decompress && isGzipObject(aws.ToString(output.ContentEncoding), aws.ToString(output.ContentType), key) {
+		body, err = newGzipReadCloser(output.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPreviewNotSupported, err)
+		}
+		defer body.Close()
+		sniffKey = trimGzipExtension(key)
+	}
+
+	// Closing the body early stops the download once enough rows have been read
+	reader := bufio.NewReaderSize(io.LimitReader(body, maxTablePreviewBytes), delimiterSniffBytes)
+	if delimiter == 0 {
+		sample, _ := reader.Peek(delimiterSniffBytes)
+		delimiter = sniffDelimiter(string(sample), sniffKey)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	preview := &models.TablePreview{
+		Key:       key,
+		Delimiter: string(delimiter),
+		Rows:      make([][]string, 0),
+	}
+
+	width := 0
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// A parse error past the first rows usually means the byte limit cut a record
+			if len(preview.Rows) > 0 || preview.Columns != nil {
+				preview.Truncated = true
+				break
+			}
+			return nil, fmt.Errorf("%w: %v", ErrPreviewNotSupported, err)
+		}
+
+		if header && preview.Columns == nil {
+			preview.Columns = record
+			width = len(record)
+			continue
+		}
+		if len(preview.Rows) == maxRows {
+			preview.Truncated = true
+			break
+		}
+
+		preview.Rows = append(preview.Rows, record)
+		width = max(width, len(record))
+	}
+
+	// Generate column names when there is no header row, and pad a short header
+	for i := len(preview.Columns); i < width; i++ {
+		preview.Columns = append(preview.Columns, fmt.Sprintf("column%d", i+1))
+	}
+	if preview.Columns == nil {
+		preview.Columns = []string{}
+	}
+
+	preview.RowCount = len(preview.Rows)
+	return preview, nil
+}
+
+// sniffDelimiter guesses the field separator of delimited text. It picks the candidate
+// that appears the same non-zero number of times on the most leading lines, ignoring
+// separators inside quoted fields, and falls back to the file extension.
+func sniffDelimiter(sample, key string) rune {
+	fallback := ','
+	if ext := strings.ToLower(filepath.Ext(key)); ext == ".tsv" || ext == ".tab" {
+		fallback = '\t'
+	}
+
+	lines := strings.Split(sample, "\n")
+	if len(lines) > 1 {
+		// The last line is likely cut off by the sample size
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+
+	best, bestScore := fallback, 0
+	for _, candidate := range delimiterCandidates {
+		first, consistent := -1, 0
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			count := countUnquoted(line, candidate)
+			if first == -1 {
+				first = count
+			}
+			if count == 0 || count != first {
+				break
+			}
+			consistent++
+		}
+
+		// Prefer consistency across lines, then the number of columns it produces
+		score := consistent*1000 + max(first, 0)
+		if consistent > 0 && score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+
+	return best
+}
+
+// countUnquoted counts occurrences of r in line outside double-quoted sections
+func countUnquoted(line string, r rune) int {
+	count, quoted := 0, false
+	for _, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == r && !quoted:
+			count++
+		}
+	}
+	return count
+}
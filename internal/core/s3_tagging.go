@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// JobTypeBulkTagging is the job type of tag changes across many objects
+const JobTypeBulkTagging = "bulk-tagging"
+
+const (
+	// maxObjectTags is the most tags S3 allows on one object
+	maxObjectTags = 10
+	// maxTagKeyLength and maxTagValueLength are S3's limits on tags, in characters
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+var (
+	// ErrInvalidTagging is returned for tag changes that are empty or contradict
+	// themselves
+	ErrInvalidTagging = errors.New("invalid tagging request")
+
+	// errTooManyTags is recorded for objects that would end up with more tags than S3
+	// allows
+	errTooManyTags = fmt.Errorf("objects can have at most %d tags", maxObjectTags)
+)
+
+// BulkTag starts a background job that applies a tag change to every object under a
+// prefix, or to a list of keys, with a result per object in the job's results
+func (s *S3Service) BulkTag(ctx context.Context, bucket string, req models.BulkTaggingRequest) (models.Job, error) {
+	if len(req.Set) == 0 && len(req.Remove) == 0 {
+		return models.Job{}, fmt.Errorf("%w: no tags to set or remove", ErrInvalidTagging)
+	}
+	if err := validateTags(req.Set); err != nil {
+		return models.Job{}, err
+	}
+	for _, name := range req.Remove {
+		if _, ok := req.Set[name]; ok {
+			return models.Job{}, fmt.Errorf("%w: tag %q is both set and removed", ErrInvalidTagging, name)
+		}
+	}
+
+	// Fail early on a missing or inaccessible bucket
+	if _, err := s.core.Client(bucket).HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return models.Job{}, err
+	}
+
+	params := map[string]string{"bucket": bucket}
+	if len(req.Keys) > 0 {
+		params["keys"] = fmt.Sprint(len(req.Keys))
+	} else {
+		params["prefix"] = req.Prefix
+	}
+	if len(req.Set) > 0 {
+		pairs := make([]string, 0, len(req.Set))
+		for _, name := range slices.Sorted(maps.Keys(req.Set)) {
+			pairs = append(pairs, name+"="+req.Set[name])
+		}
+		params["set"] = strings.Join(pairs, ",")
+	}
+	if len(req.Remove) > 0 {
+		params["remove"] = strings.Join(req.Remove, ",")
+	}
+
+	return s.core.Jobs.Submit(JobTypeBulkTagging, params, func(ctx context.Context, job *Job) error {
+		apply := func(key string, size int64) {
+			if err := s.retag(ctx, bucket, key, req.Set, req.Remove); err != nil {
+				job.ItemFailed(key, err)
+				return
+			}
+			job.ItemReported(key, 0)
+		}
+
+		if len(req.Keys) == 0 {
+			return s.forEachListed(ctx, job, bucket, req.Prefix, apply)
+		}
+		job.AddTotal(int64(len(req.Keys)))
+		for _, key := range req.Keys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			apply(key, 0)
+		}
+		return nil
+	}), nil
+}
+
+// retag sets and removes tags on an object, keeping its other tags. Objects whose tags
+// would not change are left alone.
+func (s *S3Service) retag(ctx context.Context, bucket, key string, set map[string]string, remove []string) error {
+	existing, err := s.core.Client(bucket).GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+
+	tags := tagMap(existing.TagSet)
+	changed := maps.Clone(tags)
+	maps.Copy(changed, set)
+	for _, name := range remove {
+		delete(changed, name)
+	}
+	if maps.Equal(tags, changed) {
+		return nil
+	}
+	if len(changed) > maxObjectTags {
+		return errTooManyTags
+	}
+
+	tagSet := make([]s3Types.Tag, 0, len(changed))
+	for _, name := range slices.Sorted(maps.Keys(changed)) {
+		tagSet = append(tagSet, s3Types.Tag{Key: aws.String(name), Value: aws.String(changed[name])})
+	}
+	if len(tagSet) == 0 {
+		_, err = s.core.Client(bucket).DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		return err
+	}
+	_, err = s.core.Client(bucket).PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &s3Types.Tagging{TagSet: tagSet},
+	})
+	return err
+}
+
+// validateTags checks tags against S3's limits: at most 10 tags, keys of 1 to 128
+// characters that do not start with the reserved "aws:" prefix, values of up to 256
+// characters, and only letters, digits, spaces and + - = . _ : / @
+func validateTags(tags map[string]string) error {
+	if len(tags) > maxObjectTags {
+		return fmt.Errorf("%w: %s", ErrInvalidTagging, errTooManyTags)
+	}
+	for name, value := range tags {
+		switch {
+		case name == "" || utf8.RuneCountInString(name) > maxTagKeyLength:
+			return fmt.Errorf("%w: tag keys must be 1 to %d characters", ErrInvalidTagging, maxTagKeyLength)
+		case utf8.RuneCountInString(value) > maxTagValueLength:
+			return fmt.Errorf("%w: the value of tag %q is longer than %d characters", ErrInvalidTagging, name, maxTagValueLength)
+		case strings.HasPrefix(strings.ToLower(name), "aws:"):
+			return fmt.Errorf("%w: tag %q uses the reserved aws: prefix", ErrInvalidTagging, name)
+		case !validTagText(name) || !validTagText(value):
+			return fmt.Errorf("%w: tag %q has characters S3 does not allow", ErrInvalidTagging, name)
+		}
+	}
+	return nil
+}
+
+// validTagText reports whether a tag key or value uses only the characters S3 allows
+func validTagText(text string) bool {
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) && !strings.ContainsRune("+-=._:/@", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetObjectTags returns the tags of an object, or of one version of it
+func (s *S3Service) GetObjectTags(ctx context.Context, bucket, key, versionID string) (*models.ObjectTags, error) {
+	input := &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	output, err := s.core.Client(bucket).GetObjectTagging(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ObjectTags{
+		Key:       key,
+		VersionID: aws.ToString(output.VersionId),
+		Tags:      tagMap(output.TagSet),
+	}, nil
+}
+
+// PutObjectTags replaces all tags of an object, or of one version of it. An empty set
+// removes the tags.
+func (s *S3Service) PutObjectTags(ctx context.Context, bucket, key, versionID string, tags map[string]string) (*models.ObjectTags, error) {
+	if err := validateTags(tags); err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return s.DeleteObjectTags(ctx, bucket, key, versionID)
+	}
+
+	tagSet := make([]s3Types.Tag, 0, len(tags))
+	for _, name := range slices.Sorted(maps.Keys(tags)) {
+		tagSet = append(tagSet, s3Types.Tag{Key: aws.String(name), Value: aws.String(tags[name])})
+	}
+	input := &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &s3Types.Tagging{TagSet: tagSet},
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	output, err := s.core.Client(bucket).PutObjectTagging(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ObjectTags{Key: key, VersionID: aws.ToString(output.VersionId), Tags: tags}, nil
+}
+
+// DeleteObjectTags removes all tags of an object, or of one version of it
+func (s *S3Service) DeleteObjectTags(ctx context.Context, bucket, key, versionID string) (*models.ObjectTags, error) {
+	input := &s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	output, err := s.core.Client(bucket).DeleteObjectTagging(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ObjectTags{Key: key, VersionID: aws.ToString(output.VersionId), Tags: map[string]string{}}, nil
+}
+
+// tagMap converts an S3 tag set to a map of tag names to values
+func tagMap(tagSet []s3Types.Tag) map[string]string {
+	tags := make(map[string]string, len(tagSet))
+	for _, tag := range tagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags
+}
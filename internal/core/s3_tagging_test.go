@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkTag(t *testing.T) {
+	var mu sync.Mutex
+	put := make(map[string]string)
+	deleted := make(map[string]bool)
+	cfg := &config.Config{
+		Jobs: config.JobsConfig{MaxConcurrent: 1, Retention: 10},
+	}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := strings.TrimPrefix(r.URL.Path, "/docs/")
+		switch {
+		case r.Method == http.MethodHead:
+		case r.URL.Query().Has("list-type"):
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`+
+				`<Contents><Key>reports/a.csv</Key><Size>1</Size></Contents>`+
+				`<Contents><Key>reports/b.csv</Key><Size>1</Size></Contents>`+
+				`<Contents><Key>reports/c.csv</Key><Size>1</Size></Contents></ListBucketResult>`)
+		case key == "reports/locked.csv":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code></Error>`)
+		case r.Method == http.MethodGet && key == "reports/b.csv":
+			fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>team</Key><Value>data</Value></Tag>`+
+				`<Tag><Key>draft</Key><Value>yes</Value></Tag></TagSet></Tagging>`)
+		case r.Method == http.MethodGet && key == "reports/c.csv":
+			fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>draft</Key><Value>yes</Value></Tag></TagSet></Tagging>`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `<Tagging><TagSet></TagSet></Tagging>`)
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			put[key] = string(body)
+		case r.Method == http.MethodDelete:
+			deleted[key] = true
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	c.Jobs = NewJobManager(c)
+	ctx := context.Background()
+
+	submitted, err := c.S3Service.BulkTag(ctx, "docs", models.BulkTaggingRequest{
+		Prefix: "reports/",
+		Set:    map[string]string{"reviewed": "2024"},
+		Remove: []string{"draft"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, JobTypeBulkTagging, submitted.Type)
+	assert.Equal(t, "reviewed=2024", submitted.Params["set"])
+
+	job := waitForJob(t, c.Jobs, submitted.ID)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, int64(3), job.Progress.Completed)
+	assert.Len(t, job.Results, 3)
+	mu.Lock()
+	assert.Contains(t, put["reports/a.csv"], "<Key>reviewed</Key><Value>2024</Value>")
+	assert.Contains(t, put["reports/b.csv"], "<Key>team</Key><Value>data</Value>")
+	assert.NotContains(t, put["reports/b.csv"], "draft")
+	mu.Unlock()
+
+	submitted, err = c.S3Service.BulkTag(ctx, "docs", models.BulkTaggingRequest{
+		Keys:   []string{"reports/c.csv", "reports/locked.csv"},
+		Remove: []string{"draft"},
+	})
+	require.NoError(t, err)
+	job = waitForJob(t, c.Jobs, submitted.ID)
+	assert.Equal(t, int64(1), job.Progress.Completed)
+	assert.Equal(t, int64(1), job.Progress.Failed)
+	mu.Lock()
+	assert.True(t, deleted["reports/c.csv"], "removing the last tag deletes the tag set")
+	mu.Unlock()
+
+	_, err = c.S3Service.BulkTag(ctx, "docs", models.BulkTaggingRequest{Prefix: "reports/"})
+	assert.ErrorIs(t, err, ErrInvalidTagging)
+	_, err = c.S3Service.BulkTag(ctx, "docs", models.BulkTaggingRequest{
+		Set:    map[string]string{"draft": "no"},
+		Remove: []string{"draft"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidTagging)
+}
+
+func TestValidateTags(t *testing.T) {
+	assert.NoError(t, validateTags(nil))
+	assert.NoError(t, validateTags(map[string]string{"team": "data", "path": "s3://a/b c", "owner": "ana@example.com", "stage": ""}))
+	assert.NoError(t, validateTags(map[string]string{strings.Repeat("k", 128): strings.Repeat("v", 256)}))
+	// Limits count characters, not bytes
+	assert.NoError(t, validateTags(map[string]string{"名前": strings.Repeat("é", 256)}))
+
+	tooMany := make(map[string]string)
+	for i := range 11 {
+		tooMany[fmt.Sprint("tag", i)] = "x"
+	}
+	for _, tags := range []map[string]string{
+		tooMany,
+		{"": "x"},
+		{strings.Repeat("k", 129): "x"},
+		{"team": strings.Repeat("v", 257)},
+		{"aws:createdBy": "x"},
+		{"team": "data;drop"},
+		{"team#": "data"},
+	} {
+		assert.ErrorIs(t, validateTags(tags), ErrInvalidTagging, tags)
+	}
+}
+
+func TestObjectTags(t *testing.T) {
+	var put string
+	deleted := false
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/missing.csv"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+		case r.Method == http.MethodGet:
+			assert.Equal(t, "v1", r.URL.Query().Get("versionId"))
+			w.Header().Set("x-amz-version-id", "v1")
+			fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>team</Key><Value>data</Value></Tag></TagSet></Tagging>`)
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			put = string(body)
+		case r.Method == http.MethodDelete:
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	ctx := context.Background()
+
+	tags, err := c.S3Service.GetObjectTags(ctx, "docs", "a.csv", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, &models.ObjectTags{Key: "a.csv", VersionID: "v1", Tags: map[string]string{"team": "data"}}, tags)
+
+	_, err = c.S3Service.GetObjectTags(ctx, "docs", "missing.csv", "")
+	assert.Error(t, err)
+
+	tags, err = c.S3Service.PutObjectTags(ctx, "docs", "a.csv", "", map[string]string{"team": "ops", "env": "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "ops", "env": "prod"}, tags.Tags)
+	assert.Contains(t, put, "<Tag><Key>env</Key><Value>prod</Value></Tag><Tag><Key>team</Key><Value>ops</Value></Tag>")
+
+	_, err = c.S3Service.PutObjectTags(ctx, "docs", "a.csv", "", map[string]string{"aws:team": "ops"})
+	assert.ErrorIs(t, err, ErrInvalidTagging)
+
+	// Putting an empty set removes the tags
+	tags, err = c.S3Service.PutObjectTags(ctx, "docs", "a.csv", "", nil)
+	require.NoError(t, err)
+	assert.Empty(t, tags.Tags)
+	assert.True(t, deleted)
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// GetObjectTail returns up to maxBytes from the end of a text object using a single
+// ranged request. When the range starts mid-file, the partial first line is dropped
+// so the content always begins at a line boundary.
+func (s *S3Service) GetObjectTail(ctx context.Context, bucket, key string, maxBytes int64) (*models.ObjectTail, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Int64("maxBytes", maxBytes).
+		Msg("Getting object tail")
+
+	head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for tail")
+		return nil, err
+	}
+
+	// The end of a compressed stream cannot be decoded without reading all of it
+	if isGzipObject(aws.ToString(head.ContentEncoding), aws.ToString(head.ContentType), key) {
+		return nil, ErrPreviewNotSupported
+	}
+
+	mediaType, params, err := mime.ParseMediaType(aws.ToString(head.ContentType))
+	if err != nil || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		mediaType, params, _ = mime.ParseMediaType(detectContentType(key))
+	}
+	if !isPreviewable(mediaType) {
+		return nil, ErrPreviewNotSupported
+	}
+
+	tail := &models.ObjectTail{
+		Key:         key,
+		ContentType: mediaType,
+		Charset:     strings.ToLower(params["charset"]),
+		Size:        aws.ToInt64(head.ContentLength),
+	}
+	if tail.Charset == "" {
+		tail.Charset = "utf-8"
+	}
+	if tail.Size == 0 || maxBytes <= 0 {
+		tail.Truncated = tail.Size > 0
+		tail.Offset = tail.Size
+		return tail, nil
+	}
+
+	// Read one byte before the window so a window starting exactly on a line keeps it
+	start := max(0, tail.Size-maxBytes-1)
+	output, err := s.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, tail.Size-1)),
+		IfMatch: head.ETag,
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object for tail")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(output.Body, maxBytes+1))
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to read object for tail")
+		return nil, err
+	}
+
+	if start > 0 {
+		data, start = trimPartialLine(data, start)
+	}
+	if int64(len(data)) > maxBytes {
+		data = data[len(data)-int(maxBytes):]
+		start = tail.Size - maxBytes
+	}
+
+	tail.Offset = start
+	tail.BytesRead = int64(len(data))
+	tail.Truncated = start > 0
+	tail.Content = decodeText(data, tail.Charset, false)
+	return tail, nil
+}
+
+// trimPartialLine drops everything up to and including the first newline of a chunk
+// read from offset, returning the remaining data and its new offset. A chunk without
+// any newline is a single long line and is kept whole.
+func trimPartialLine(data []byte, offset int64) ([]byte, int64) {
+	index := bytes.IndexByte(data, '\n')
+	if index < 0 {
+		return data, offset
+	}
+	return data[index+1:], offset + int64(index+1)
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// MaxTreeDepth is the deepest tree returned by one request
+const MaxTreeDepth = 5
+
+// ErrInvalidTreeDepth is returned for a depth outside 0 to MaxTreeDepth
+var ErrInvalidTreeDepth = errors.New("invalid tree depth")
+
+// GetTree returns the folders under prefix down to depth levels, each with the number
+// of folders and objects directly inside it, so a tree can be drawn without a listing
+// per folder. Folders are listed a level at a time; the tree is cut short after
+// listing.treeMaxFolders folders.
+func (s *S3Service) GetTree(ctx context.Context, bucket, prefix string, depth int) (*models.TreeResponse, error) {
+	if depth < 0 || depth > MaxTreeDepth {
+		return nil, fmt.Errorf("%w: must be between 0 and %d", ErrInvalidTreeDepth, MaxTreeDepth)
+	}
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Int("depth", depth).
+		Msg("Building folder tree")
+
+	if prefix = strings.TrimPrefix(prefix, "/"); prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	response := &models.TreeResponse{
+		Bucket:     bucket,
+		Depth:      depth,
+		MaxFolders: s.core.Config.Listing.TreeMaxFolders,
+		Root:       &models.TreeNode{Prefix: prefix, Name: path.Base(strings.TrimSuffix(prefix, "/"))},
+	}
+	if prefix == "" {
+		response.Root.Name = ""
+	}
+
+	// Each level is listed to count its entries; children are attached above depth
+	level := []*models.TreeNode{response.Root}
+	listed := 0
+	for d := 0; len(level) > 0; d++ {
+		if maxFolders := s.core.Config.Listing.TreeMaxFolders; listed+len(level) > maxFolders {
+			level = level[:maxFolders-listed]
+			response.Truncated = true
+		}
+		listed += len(level)
+
+		// Folders past the cap would be left without counts, so a cut-short level is not
+		// expanded
+		children, err := s.listTreeLevel(ctx, bucket, level, d < depth && !response.Truncated)
+		if err != nil {
+			return nil, err
+		}
+		level = children
+	}
+	return response, nil
+}
+
+// listTreeLevel lists the folders of one tree level, filling in their counts and, with
+// expand set, their children. It returns the children of the whole level.
+func (s *S3Service) listTreeLevel(ctx context.Context, bucket string, level []*models.TreeNode, expand bool) ([]*models.TreeNode, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, s.core.Config.Listing.Concurrency)
+	for _, node := range level {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(node *models.TreeNode) {
+			defer func() { <-sem; wg.Done() }()
+			folders, err := s.countFolder(ctx, bucket, node)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if expand {
+				node.Children = make([]*models.TreeNode, 0, len(folders))
+				for _, folder := range folders {
+					node.Children = append(node.Children, &models.TreeNode{
+						Prefix: folder,
+						Name:   path.Base(strings.TrimSuffix(folder, "/")),
+					})
+				}
+			}
+		}(node)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Keep the listing order, which goroutines do not
+	var children []*models.TreeNode
+	for _, node := range level {
+		children = append(children, node.Children...)
+	}
+	return children, nil
+}
+
+// countFolder counts the folders and objects directly in a tree node, up to
+// listing.treeMaxFolderEntries, and returns the folders' prefixes
+func (s *S3Service) countFolder(ctx context.Context, bucket string, node *models.TreeNode) ([]string, error) {
+	paginator := s3.NewListObjectsV2Paginator(s.core.Client(bucket), &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucket),
+		Prefix:    aws.String(node.Prefix),
+		Delimiter: aws.String("/"),
+	})
+
+	var folders []string
+	for paginator.HasMorePages() {
+		if node.Folders+node.Objects >= s.core.Config.Listing.TreeMaxFolderEntries {
+			node.Truncated = true
+			break
+		}
+
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("prefix", node.Prefix).
+				Msg("Failed to list folder for tree")
+			return nil, err
+		}
+
+		for _, prefix := range page.CommonPrefixes {
+			folders = append(folders, aws.ToString(prefix.Prefix))
+		}
+		node.Folders += len(page.CommonPrefixes)
+		for _, obj := range page.Contents {
+			// The folder's own marker object is not one of its entries
+			if aws.ToString(obj.Key) != node.Prefix {
+				node.Objects++
+			}
+		}
+	}
+	return folders, nil
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTreeCore fakes a bucket with folders a/ (holding a/x/ and two objects) and b/
+// (empty apart from its marker), plus one object at the top
+func newTestTreeCore(t *testing.T) *Core {
+	listings := map[string]string{
+		"":     `<CommonPrefixes><Prefix>a/</Prefix></CommonPrefixes><CommonPrefixes><Prefix>b/</Prefix></CommonPrefixes><Contents><Key>top.txt</Key></Contents>`,
+		"a/":   `<CommonPrefixes><Prefix>a/x/</Prefix></CommonPrefixes><Contents><Key>a/1</Key></Contents><Contents><Key>a/2</Key></Contents>`,
+		"a/x/": `<Contents><Key>a/x/deep</Key></Contents>`,
+		"b/":   `<Contents><Key>b/</Key></Contents>`,
+	}
+	cfg := &config.Config{Listing: config.ListingConfig{
+		Concurrency:          8,
+		TreeMaxFolders:       1000,
+		TreeMaxFolderEntries: 10000,
+	}}
+	return newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		prefix := r.URL.Query().Get("prefix")
+		if strings.HasPrefix(prefix, "missing") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchBucket</Code></Error>`)
+			return
+		}
+		fmt.Fprintf(w, `<ListBucketResult><IsTruncated>false</IsTruncated>%s</ListBucketResult>`, listings[prefix])
+	})
+}
+
+func TestGetTree(t *testing.T) {
+	c := newTestTreeCore(t)
+	ctx := context.Background()
+
+	tree, err := c.S3Service.GetTree(ctx, "files", "", 1)
+	require.NoError(t, err)
+	assert.False(t, tree.Truncated)
+	assert.Equal(t, 2, tree.Root.Folders)
+	assert.Equal(t, 1, tree.Root.Objects)
+	require.Len(t, tree.Root.Children, 2)
+
+	a, b := tree.Root.Children[0], tree.Root.Children[1]
+	assert.Equal(t, "a", a.Name)
+	assert.Equal(t, 1, a.Folders)
+	assert.Equal(t, 2, a.Objects)
+	assert.Nil(t, a.Children, "the last level is counted but not expanded")
+	assert.Equal(t, "b/", b.Prefix)
+	assert.Zero(t, b.Objects, "the folder marker is not counted")
+
+	tree, err = c.S3Service.GetTree(ctx, "files", "a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "a/", tree.Root.Prefix)
+	require.Len(t, tree.Root.Children, 1)
+	x := tree.Root.Children[0]
+	assert.Equal(t, "x", x.Name)
+	assert.Equal(t, 1, x.Objects)
+	assert.NotNil(t, x.Children)
+	assert.Empty(t, x.Children)
+
+	tree, err = c.S3Service.GetTree(ctx, "files", "", 0)
+	require.NoError(t, err)
+	assert.Nil(t, tree.Root.Children)
+	assert.Equal(t, 2, tree.Root.Folders)
+
+	_, err = c.S3Service.GetTree(ctx, "files", "", MaxTreeDepth+1)
+	assert.ErrorIs(t, err, ErrInvalidTreeDepth)
+
+	_, err = c.S3Service.GetTree(ctx, "files", "missing/", 1)
+	assert.Error(t, err)
+}
+
+func TestGetTreeLimits(t *testing.T) {
+	c := newTestTreeCore(t)
+	c.Config.Listing.TreeMaxFolders = 2
+	ctx := context.Background()
+
+	tree, err := c.S3Service.GetTree(ctx, "files", "", 2)
+	require.NoError(t, err)
+	assert.True(t, tree.Truncated)
+	assert.Equal(t, 2, tree.MaxFolders)
+	require.Len(t, tree.Root.Children, 2)
+	a, b := tree.Root.Children[0], tree.Root.Children[1]
+	assert.Equal(t, 2, a.Objects)
+	assert.Nil(t, a.Children, "a cut-short level is not expanded")
+	assert.Zero(t, b.Folders+b.Objects, "only one folder fits after the root")
+}
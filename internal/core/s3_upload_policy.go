@@ -0,0 +1,164 @@
+package core
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"slices"
+	"strings"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+)
+
+var (
+	// ErrUploadPolicyNotFound is returned for upload policies that are not configured
+	ErrUploadPolicyNotFound = errors.New("upload policy not found")
+	// ErrUploadPolicyRequired is returned for uploads without a policy when
+	// uploads.requirePolicy is set
+	ErrUploadPolicyRequired = errors.New("an upload policy is required")
+	// ErrUploadContentType is returned when a policy does not allow the content type
+	ErrUploadContentType = errors.New("content type is not allowed by the upload policy")
+	// ErrUploadTagMissing is returned when a tag the policy requires has no value
+	ErrUploadTagMissing = errors.New("a required tag is missing")
+	// ErrUploadKey is returned for keys that would escape the policy's key prefix
+	ErrUploadKey = errors.New("invalid upload key")
+)
+
+// GeneratePolicyPostURL generates a presigned POST URL under a named upload policy.
+// The policy, not the client, decides the size limit, lifetime, key prefix, tags and
+// encryption of the upload; the client only picks the rest of the key, the content
+// type among those allowed, values for the required tags, and a KMS or customer key
+// when the policy sets no encryption. The final key is returned in the "key" field.
+func (s *S3Service) GeneratePolicyPostURL(ctx context.Context, bucket, user string, req models.PresignedPostURLRequest, customerKey *CustomerKey) (*models.PresignedPostURLResponse, error) {
+	policy, ok := s.uploadPolicy(req.Policy)
+	if !ok {
+		return nil, ErrUploadPolicyNotFound
+	}
+
+	if len(policy.ContentTypes) > 0 {
+		mediaType, _, err := mime.ParseMediaType(req.ContentType)
+		if err != nil || !matchesContentType(policy.ContentTypes, mediaType) {
+			return nil, ErrUploadContentType
+		}
+	}
+
+	key, err := policyKey(policy, user, req.Key, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	key, err = s.resolveConflict(ctx, bucket, key, req.Conflict)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := policyTags(policy, req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := make(map[string]string)
+	if policy.SSE == "" {
+		// The client may still choose a key when the policy leaves encryption open
+		kms, err := s.kmsFor(req.KMSKeyID, customerKey)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(extra, kms.fields())
+		maps.Copy(extra, customerKey.fields())
+	} else {
+		extra["x-amz-server-side-encryption"] = policy.SSE
+		kmsKeyID := policy.KMSKeyID
+		if kmsKeyID == "" && s.core.Config.Uploads.RequireKMS {
+			kmsKeyID = s.core.Config.Uploads.KMSKeyID
+		}
+		if kmsKeyID != "" {
+			extra["x-amz-server-side-encryption-aws-kms-key-id"] = kmsKeyID
+		}
+	}
+	if len(tags) > 0 {
+		extra["tagging"] = taggingXML(tags)
+	}
+
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("policy", policy.Name).
+		Str("user", user).
+		Msg("Generating presigned POST URL under upload policy")
+
+	return s.presignPost(ctx, bucket, key, req.ContentType, policy.Expiry, policy.MaxSizeBytes, extra)
+}
+
+// UploadPolicyRequired reports whether uploads must name an upload policy
+func (s *S3Service) UploadPolicyRequired() bool {
+	return s.core.Config.Uploads.RequirePolicy
+}
+
+// uploadPolicy looks up a configured upload policy by name
+func (s *S3Service) uploadPolicy(name string) (config.UploadPolicy, bool) {
+	for _, policy := range s.core.Config.Uploads.Policies {
+		if policy.Name == name {
+			return policy, true
+		}
+	}
+	return config.UploadPolicy{}, false
+}
+
+// policyKey puts the policy's expanded key prefix in front of the key the client asked
+// for. Keys that climb out of the prefix are refused.
+func policyKey(policy config.UploadPolicy, user, key string, now time.Time) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || slices.Contains(strings.Split(key, "/"), "..") {
+		return "", ErrUploadKey
+	}
+
+	prefix := strings.NewReplacer(
+		// A user name must not add folders of its own
+		"{user}", strings.ReplaceAll(user, "/", "_"),
+		"{date}", now.Format(time.DateOnly),
+	).Replace(policy.KeyPrefix)
+	return prefix + key, nil
+}
+
+// policyTags merges the policy's fixed tags with the client's values for the required
+// tags. Other tags from the client are ignored.
+func policyTags(policy config.UploadPolicy, given map[string]string) (map[string]string, error) {
+	tags := make(map[string]string, len(policy.Tags)+len(policy.RequiredTags))
+	for _, name := range policy.RequiredTags {
+		value := strings.TrimSpace(given[name])
+		if value == "" {
+			return nil, fmt.Errorf("%w: %s", ErrUploadTagMissing, name)
+		}
+		tags[name] = value
+	}
+	for name, value := range policy.Tags {
+		tags[name] = value
+	}
+	return tags, nil
+}
+
+// taggingXML encodes tags as the Tagging document S3 expects in the tagging form field
+func taggingXML(tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("<Tagging><TagSet>")
+	for _, name := range names {
+		b.WriteString("<Tag><Key>")
+		_ = xml.EscapeText(&b, []byte(name))
+		b.WriteString("</Key><Value>")
+		_ = xml.EscapeText(&b, []byte(tags[name]))
+		b.WriteString("</Value></Tag>")
+	}
+	b.WriteString("</TagSet></Tagging>")
+	return b.String()
+}
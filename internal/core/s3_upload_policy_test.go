@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUploadCore(policies ...config.UploadPolicy) *Core {
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("http://s3.test"),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	c := &Core{
+		Config:      &config.Config{Uploads: config.UploadsConfig{Policies: policies}},
+		Logger:      logger.New("error", "json"),
+		S3Client:    client,
+		S3Presigner: s3.NewPresignClient(client),
+	}
+	c.S3Service = NewS3Service(c)
+	return c
+}
+
+func TestGeneratePolicyPostURL(t *testing.T) {
+	c := newTestUploadCore(config.UploadPolicy{
+		Name:         "invoices",
+		ContentTypes: []string{"application/pdf", "image/*"},
+		MaxSizeBytes: 5 << 20,
+		KeyPrefix:    "incoming/{user}/",
+		Expiry:       5 * time.Minute,
+		Tags:         map[string]string{"source": "upload"},
+		RequiredTags: []string{"project"},
+		SSE:          "aws:kms",
+		KMSKeyID:     "alias/uploads",
+	})
+	ctx := context.Background()
+
+	resp, err := c.S3Service.GeneratePolicyPostURL(ctx, "docs", "ann", models.PresignedPostURLRequest{
+		Key:          "2024/invoice.pdf",
+		ContentType:  "application/pdf",
+		MaxSizeBytes: 1 << 40, // ignored in favour of the policy
+		Policy:       "invoices",
+		Tags:         map[string]string{"project": "a&b", "ignored": "x"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "incoming/ann/2024/invoice.pdf", resp.Fields["key"])
+	assert.Equal(t, "<Tagging><TagSet><Tag><Key>project</Key><Value>a&amp;b</Value></Tag><Tag><Key>source</Key><Value>upload</Value></Tag></TagSet></Tagging>", resp.Fields["tagging"])
+	assert.Equal(t, "aws:kms", resp.Fields["x-amz-server-side-encryption"])
+	assert.Equal(t, "alias/uploads", resp.Fields["x-amz-server-side-encryption-aws-kms-key-id"])
+
+	policy, err := base64.StdEncoding.DecodeString(resp.Fields["policy"])
+	require.NoError(t, err)
+	assert.Contains(t, string(policy), `["content-length-range",0,5242880]`)
+	assert.Contains(t, string(policy), `["eq","$x-amz-server-side-encryption","aws:kms"]`)
+	assert.Contains(t, string(policy), `"$tagging"`)
+
+	_, err = c.S3Service.GeneratePolicyPostURL(ctx, "docs", "ann", models.PresignedPostURLRequest{
+		Key: "a.exe", ContentType: "application/octet-stream", Policy: "invoices",
+		Tags: map[string]string{"project": "a"},
+	}, nil)
+	assert.ErrorIs(t, err, ErrUploadContentType)
+
+	_, err = c.S3Service.GeneratePolicyPostURL(ctx, "docs", "ann", models.PresignedPostURLRequest{
+		Key: "a.png", ContentType: "image/png", Policy: "invoices",
+	}, nil)
+	assert.ErrorIs(t, err, ErrUploadTagMissing)
+
+	_, err = c.S3Service.GeneratePolicyPostURL(ctx, "docs", "ann", models.PresignedPostURLRequest{
+		Key: "../bob/a.png", ContentType: "image/png", Policy: "invoices",
+		Tags: map[string]string{"project": "a"},
+	}, nil)
+	assert.ErrorIs(t, err, ErrUploadKey)
+
+	_, err = c.S3Service.GeneratePolicyPostURL(ctx, "docs", "ann", models.PresignedPostURLRequest{
+		Key: "a.pdf", ContentType: "application/pdf", Policy: "missing",
+	}, nil)
+	assert.ErrorIs(t, err, ErrUploadPolicyNotFound)
+}
+
+func TestPolicyKey(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	policy := config.UploadPolicy{KeyPrefix: "drop/{date}/{user}/"}
+
+	key, err := policyKey(policy, "ann/admin", "/report.csv", now)
+	require.NoError(t, err)
+	assert.Equal(t, "drop/2024-03-09/ann_admin/report.csv", key)
+
+	_, err = policyKey(policy, "ann", "", now)
+	assert.ErrorIs(t, err, ErrUploadKey)
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// versionsCursor is the position a version listing continues from: S3 needs both a
+// key and a version ID marker
+type versionsCursor struct {
+	KeyMarker       string `json:"k"`
+	VersionIDMarker string `json:"v,omitempty"`
+}
+
+// ListObjectVersions lists the versions and delete markers of the objects under a
+// prefix, a page at a time. Versions of a key are returned together, newest first.
+// With a delimiter, prefixes below it are returned as folders instead.
+func (s *S3Service) ListObjectVersions(ctx context.Context, bucket, prefix, delimiter, cursor string, pageSize int32) (*models.ListVersionsResponse, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Str("cursor", cursor).
+		Msg("Listing object versions")
+
+	token, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPageSize := int32(s.core.Config.Listing.MaxPageSize)
+	if pageSize <= 0 {
+		pageSize = int32(s.core.Config.Listing.DefaultPageSize)
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	input := &s3.ListObjectVersionsInput{
+		Bucket:  aws.String(bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(pageSize),
+	}
+	if delimiter != "" {
+		input.Delimiter = aws.String(delimiter)
+	}
+	if token != "" {
+		var position versionsCursor
+		if err := json.Unmarshal([]byte(token), &position); err != nil || position.KeyMarker == "" {
+			return nil, ErrInvalidCursor
+		}
+		input.KeyMarker = aws.String(position.KeyMarker)
+		if position.VersionIDMarker != "" {
+			input.VersionIdMarker = aws.String(position.VersionIDMarker)
+		}
+	}
+
+	output, err := s.core.Client(bucket).ListObjectVersions(ctx, input)
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("prefix", prefix).
+			Msg("Failed to list object versions")
+		return nil, err
+	}
+
+	response := &models.ListVersionsResponse{
+		Versions: make([]models.ObjectVersion, 0, len(output.Versions)+len(output.DeleteMarkers)),
+		Pagination: models.Pagination{
+			HasMore:     aws.ToBool(output.IsTruncated),
+			PageSize:    int(pageSize),
+			MaxPageSize: int(maxPageSize),
+		},
+	}
+	for _, version := range output.Versions {
+		response.Versions = append(response.Versions, models.ObjectVersion{
+			Key:          aws.ToString(version.Key),
+			VersionID:    aws.ToString(version.VersionId),
+			IsLatest:     aws.ToBool(version.IsLatest),
+			Size:         aws.ToInt64(version.Size),
+			ETag:         aws.ToString(version.ETag),
+			StorageClass: string(version.StorageClass),
+			LastModified: aws.ToTime(version.LastModified),
+		})
+	}
+	for _, marker := range output.DeleteMarkers {
+		response.Versions = append(response.Versions, models.ObjectVersion{
+			Key:          aws.ToString(marker.Key),
+			VersionID:    aws.ToString(marker.VersionId),
+			IsLatest:     aws.ToBool(marker.IsLatest),
+			DeleteMarker: true,
+			LastModified: aws.ToTime(marker.LastModified),
+		})
+	}
+	// S3 returns versions and delete markers in separate lists; interleave them again
+	slices.SortStableFunc(response.Versions, func(a, b models.ObjectVersion) int {
+		return cmp.Or(cmp.Compare(a.Key, b.Key), b.LastModified.Compare(a.LastModified), latestFirst(a, b))
+	})
+	for _, folder := range output.CommonPrefixes {
+		response.Folders = append(response.Folders, aws.ToString(folder.Prefix))
+	}
+
+	if response.Pagination.HasMore && aws.ToString(output.NextKeyMarker) != "" {
+		next, _ := json.Marshal(versionsCursor{
+			KeyMarker:       aws.ToString(output.NextKeyMarker),
+			VersionIDMarker: aws.ToString(output.NextVersionIdMarker),
+		})
+		response.Pagination.Cursor = EncodeCursor(string(next))
+	}
+	return response, nil
+}
+
+// DeleteObjectVersion permanently deletes one version of an object, or one delete
+// marker. Unlike a plain delete in a versioned bucket, no delete marker is left and
+// the version cannot be restored; deleting the latest version makes the one before it
+// current. It reports whether the deleted version was a delete marker.
+func (s *S3Service) DeleteObjectVersion(ctx context.Context, bucket, key, versionID string) (bool, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("versionId", versionID).
+		Msg("Deleting object version")
+
+	output, err := s.core.Client(bucket).DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		s.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Str("versionId", versionID).
+			Msg("Failed to delete object version")
+		return false, err
+	}
+
+	deleteMarker := aws.ToBool(output.DeleteMarker)
+	s.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("versionId", versionID).
+		Bool("deleteMarker", deleteMarker).
+		Msg("Permanently deleted object version")
+	s.core.Events.Publish(EventObjectRemoved, bucket, key)
+
+	return deleteMarker, nil
+}
+
+// latestFirst orders the latest version of a key before others modified at the same
+// second
+func latestFirst(a, b models.ObjectVersion) int {
+	switch {
+	case a.IsLatest == b.IsLatest:
+		return 0
+	case a.IsLatest:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListObjectVersions(t *testing.T) {
+	var query map[string]string
+	c := newTestS3Core(t, &config.Config{Listing: config.ListingConfig{DefaultPageSize: 2, MaxPageSize: 10}}, func(w http.ResponseWriter, r *http.Request) {
+		query = map[string]string{
+			"prefix":            r.URL.Query().Get("prefix"),
+			"key-marker":        r.URL.Query().Get("key-marker"),
+			"version-id-marker": r.URL.Query().Get("version-id-marker"),
+			"max-keys":          r.URL.Query().Get("max-keys"),
+		}
+		fmt.Fprint(w, `<ListVersionsResult>
+			<IsTruncated>true</IsTruncated>
+			<NextKeyMarker>docs/b.txt</NextKeyMarker>
+			<NextVersionIdMarker>v4</NextVersionIdMarker>
+			<Version><Key>docs/a.txt</Key><VersionId>v1</VersionId><IsLatest>false</IsLatest><LastModified>2024-01-01T00:00:00Z</LastModified><Size>3</Size><ETag>"one"</ETag><StorageClass>STANDARD</StorageClass></Version>
+			<Version><Key>docs/b.txt</Key><VersionId>v4</VersionId><IsLatest>true</IsLatest><LastModified>2024-01-04T00:00:00Z</LastModified><Size>5</Size><ETag>"four"</ETag><StorageClass>STANDARD</StorageClass></Version>
+			<DeleteMarker><Key>docs/a.txt</Key><VersionId>v2</VersionId><IsLatest>true</IsLatest><LastModified>2024-01-02T00:00:00Z</LastModified></DeleteMarker>
+		</ListVersionsResult>`)
+	})
+	ctx := context.Background()
+
+	versions, err := c.S3Service.ListObjectVersions(ctx, "docs", "docs/", "", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "docs/", query["prefix"])
+	assert.Equal(t, "2", query["max-keys"])
+	require.Len(t, versions.Versions, 3)
+
+	// Delete markers are interleaved with the versions of their key, newest first
+	assert.Equal(t, "v2", versions.Versions[0].VersionID)
+	assert.True(t, versions.Versions[0].DeleteMarker)
+	assert.True(t, versions.Versions[0].IsLatest)
+	assert.Equal(t, "v1", versions.Versions[1].VersionID)
+	assert.False(t, versions.Versions[1].IsLatest)
+	assert.Equal(t, int64(3), versions.Versions[1].Size)
+	assert.Equal(t, "docs/b.txt", versions.Versions[2].Key)
+	assert.True(t, versions.Pagination.HasMore)
+	require.NotEmpty(t, versions.Pagination.Cursor)
+
+	// The cursor continues from both markers
+	_, err = c.S3Service.ListObjectVersions(ctx, "docs", "docs/", "", versions.Pagination.Cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "docs/b.txt", query["key-marker"])
+	assert.Equal(t, "v4", query["version-id-marker"])
+
+	_, err = c.S3Service.ListObjectVersions(ctx, "docs", "docs/", "", EncodeCursor("docs/b.txt"), 0)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestDeleteObjectVersion(t *testing.T) {
+	var deletedVersion string
+	c := newTestS3Core(t, &config.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		deletedVersion = r.URL.Query().Get("versionId")
+		if deletedVersion == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchVersion</Code></Error>`)
+			return
+		}
+		if deletedVersion == "v2" {
+			w.Header().Set("X-Amz-Delete-Marker", "true")
+		}
+		w.Header().Set("X-Amz-Version-Id", deletedVersion)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	deleteMarker, err := c.S3Service.DeleteObjectVersion(ctx, "docs", "docs/a.txt", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", deletedVersion)
+	assert.False(t, deleteMarker)
+
+	deleteMarker, err = c.S3Service.DeleteObjectVersion(ctx, "docs", "docs/a.txt", "v2")
+	require.NoError(t, err)
+	assert.True(t, deleteMarker)
+
+	_, err = c.S3Service.DeleteObjectVersion(ctx, "docs", "docs/a.txt", "missing")
+	assert.True(t, hasErrorCode(err, "NoSuchVersion"))
+}
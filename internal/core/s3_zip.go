@@ -0,0 +1,325 @@
+package core
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	// ErrZipTooLarge is returned when the objects selected for a ZIP download exceed the configured limits
+	ErrZipTooLarge = errors.New("selection exceeds zip download limits")
+	// ErrZipEmpty is returned when a ZIP download would not contain any objects
+	ErrZipEmpty = errors.New("no objects to download")
+)
+
+// ZipManifest lists the objects that make up a streamed ZIP download. It is built
+// up front so limits can be enforced before any part of the response is written.
+type ZipManifest struct {
+	// Bucket is the bucket downloaded from, empty for selections spanning buckets
+	Bucket     string
+	TotalBytes int64
+	entries    []zipManifestEntry
+	seen       map[string]bool
+}
+
+// zipManifestEntry is a single object in a ZipManifest
+type zipManifestEntry struct {
+	bucket   string
+	key      string
+	name     string
+	size     int64
+	modified time.Time
+}
+
+// Len returns the number of objects in the manifest
+func (m *ZipManifest) Len() int {
+	return len(m.entries)
+}
+
+// add appends an object to the manifest, enforcing the download limits. Objects that
+// are already part of the manifest are ignored.
+func (m *ZipManifest) add(limits zipLimits, bucket, key, name string, size int64, modified time.Time) error {
+	id := bucket + "/" + key
+	if m.seen[id] {
+		return nil
+	}
+	if len(m.entries) >= limits.maxObjects || m.TotalBytes+size > limits.maxBytes {
+		return ErrZipTooLarge
+	}
+
+	m.entries = append(m.entries, zipManifestEntry{
+		bucket:   bucket,
+		key:      key,
+		name:     name,
+		size:     size,
+		modified: modified,
+	})
+	m.TotalBytes += size
+	if m.seen == nil {
+		m.seen = make(map[string]bool)
+	}
+	m.seen[id] = true
+	return nil
+}
+
+// zipLimits holds the configured ZIP download limits
+type zipLimits struct {
+	maxBytes   int64
+	maxObjects int
+}
+
+func (s *S3Service) zipLimits() zipLimits {
+	return zipLimits{
+		maxBytes:   s.core.Config.Download.MaxZipBytes,
+		maxObjects: s.core.Config.Download.MaxZipObjects,
+	}
+}
+
+// PrepareZipFromPrefix builds a manifest of all objects under a prefix. Entry names are
+// relative to the prefix, so downloading "photos/2024/" yields a ZIP rooted at 2024's contents.
+func (s *S3Service) PrepareZipFromPrefix(ctx context.Context, bucket, prefix string) (*ZipManifest, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Msg("Preparing zip download")
+
+	manifest := &ZipManifest{Bucket: bucket}
+	if err := s.addPrefixToManifest(ctx, manifest, bucket, prefix, prefix, ""); err != nil {
+		return nil, err
+	}
+	if manifest.Len() == 0 {
+		return nil, ErrZipEmpty
+	}
+	return manifest, nil
+}
+
+// PrepareZipFromKeys builds a manifest from an explicit selection of objects, which may
+// come from different folders. Keys ending in "/" select everything under that folder.
+// Entry names are relative to the deepest folder containing the whole selection.
+func (s *S3Service) PrepareZipFromKeys(ctx context.Context, bucket string, keys []string) (*ZipManifest, error) {
+	s.core.Logger.Debug().
+		Str("bucket", bucket).
+		Int("keys", len(keys)).
+		Msg("Preparing zip download for selection")
+
+	limits := s.zipLimits()
+	if len(keys) > limits.maxObjects {
+		return nil, ErrZipTooLarge
+	}
+
+	base := commonFolder(keys)
+	manifest := &ZipManifest{Bucket: bucket}
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/") {
+			if err := s.addPrefixToManifest(ctx, manifest, bucket, key, base, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		head, err := s.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("key", key).
+				Msg("Failed to get object metadata for zip download")
+			return nil, err
+		}
+
+		name := zipEntryName(key, base)
+		if err := manifest.add(limits, bucket, key, name, aws.ToInt64(head.ContentLength), aws.ToTime(head.LastModified)); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest.Len() == 0 {
+		return nil, ErrZipEmpty
+	}
+	return manifest, nil
+}
+
+// addPrefixToManifest lists a prefix of bucket and adds every object under it, naming
+// entries relative to base within the folder root of the archive
+func (s *S3Service) addPrefixToManifest(ctx context.Context, manifest *ZipManifest, bucket, prefix, base, root string) error {
+	limits := s.zipLimits()
+	paginator := s3.NewListObjectsV2Paginator(s.core.Client(bucket), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", bucket).
+				Str("prefix", prefix).
+				Msg("Failed to list objects for zip download")
+			return err
+		}
+
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			// Folder markers carry no data and are implied by the entry paths
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+
+			name := root + zipEntryName(key, base)
+			if err := manifest.add(limits, bucket, key, name, aws.ToInt64(obj.Size), aws.ToTime(obj.LastModified)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// zipEntryObject is an object opened for a ZIP entry
+type zipEntryObject struct {
+	output *s3.GetObjectOutput
+	err    error
+}
+
+// WriteZip streams the objects in a manifest to w as a ZIP archive. Entries are stored
+// without compression, so the archive is produced with constant memory and no temp
+// files. Up to download.zipConcurrency objects are requested ahead of the entry being
+// written; their bodies are read in order, as the archive is written. Once writing has
+// started errors can no longer be reported to the client, so the archive is simply
+// left incomplete.
+func (s *S3Service) WriteZip(ctx context.Context, manifest *ZipManifest, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	objects := make([]chan zipEntryObject, len(manifest.entries))
+	for i := range objects {
+		objects[i] = make(chan zipEntryObject, 1)
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.core.Config.Download.ZipConcurrency)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, entry := range manifest.entries {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				output, err := s.core.Client(entry.bucket).GetObject(ctx, &s3.GetObjectInput{
+					Bucket: aws.String(entry.bucket),
+					Key:    aws.String(entry.key),
+				})
+				objects[i] <- zipEntryObject{output: output, err: err}
+			}()
+		}
+	}()
+
+	written := 0
+	// Close the objects opened ahead of an entry that failed
+	defer func() {
+		cancel()
+		wg.Wait()
+		for _, object := range objects[written:] {
+			select {
+			case opened := <-object:
+				if opened.output != nil {
+					opened.output.Body.Close()
+				}
+			default:
+			}
+		}
+	}()
+
+	zipWriter := zip.NewWriter(w)
+	for i, entry := range manifest.entries {
+		var object zipEntryObject
+		select {
+		case object = <-objects[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		written++
+
+		err := object.err
+		if err == nil {
+			err = writeZipEntry(zipWriter, entry, object.output)
+		}
+		<-sem
+		if err != nil {
+			s.core.Logger.Error().
+				Err(err).
+				Str("bucket", entry.bucket).
+				Str("key", entry.key).
+				Msg("Failed to write zip entry")
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// writeZipEntry copies a single object into the archive
+func writeZipEntry(zipWriter *zip.Writer, entry zipManifestEntry, output *s3.GetObjectOutput) error {
+	defer output.Body.Close()
+
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     entry.name,
+		Method:   zip.Store,
+		Modified: entry.modified,
+	})
+	if err != nil {
+		return err
+	}
+
+	written, err := io.Copy(writer, output.Body)
+	if err != nil {
+		return err
+	}
+	if written != aws.ToInt64(output.ContentLength) {
+		return fmt.Errorf("short read for %s: %w", entry.key, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// zipEntryName returns the archive path for a key relative to base. A key equal to
+// base (an object downloaded on its own) keeps just its file name.
+func zipEntryName(key, base string) string {
+	name := strings.TrimPrefix(key, base)
+	if name == "" {
+		name = path.Base(key)
+	}
+	return strings.TrimPrefix(name, "/")
+}
+
+// commonFolder returns the deepest folder (ending in "/") that contains every key, or
+// "" when the keys only share the bucket root
+func commonFolder(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+
+	common := keys[0][:strings.LastIndex(keys[0], "/")+1]
+	for _, key := range keys[1:] {
+		for !strings.HasPrefix(key, common) {
+			common = common[:strings.LastIndex(strings.TrimSuffix(common, "/"), "/")+1]
+		}
+	}
+	return common
+}
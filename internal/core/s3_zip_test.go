@@ -0,0 +1,115 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZipEntryName(t *testing.T) {
+	assert.Equal(t, "2024/a.jpg", zipEntryName("photos/2024/a.jpg", "photos/"))
+	assert.Equal(t, "a.jpg", zipEntryName("photos/a.jpg", "photos"))
+	assert.Equal(t, "a.jpg", zipEntryName("photos/a.jpg", "photos/a.jpg"))
+	assert.Equal(t, "photos/a.jpg", zipEntryName("photos/a.jpg", ""))
+}
+
+func TestZipManifest_Limits(t *testing.T) {
+	limits := zipLimits{maxBytes: 100, maxObjects: 2}
+	manifest := &ZipManifest{}
+
+	assert.NoError(t, manifest.add(limits, "data", "a", "a", 60, time.Time{}))
+	assert.ErrorIs(t, manifest.add(limits, "data", "b", "b", 50, time.Time{}), ErrZipTooLarge)
+	assert.NoError(t, manifest.add(limits, "data", "c", "c", 40, time.Time{}))
+	assert.NoError(t, manifest.add(limits, "data", "c", "c", 40, time.Time{}), "duplicates are ignored")
+	assert.ErrorIs(t, manifest.add(limits, "data", "d", "d", 0, time.Time{}), ErrZipTooLarge)
+	assert.Equal(t, 2, manifest.Len())
+	assert.Equal(t, int64(100), manifest.TotalBytes)
+}
+
+func TestCommonFolder(t *testing.T) {
+	assert.Equal(t, "", commonFolder(nil))
+	assert.Equal(t, "a/b/", commonFolder([]string{"a/b/c.txt"}))
+	assert.Equal(t, "a/b/", commonFolder([]string{"a/b/"}))
+	assert.Equal(t, "a/", commonFolder([]string{"a/b/c.txt", "a/d/e.txt", "a/f.txt"}))
+	assert.Equal(t, "a/", commonFolder([]string{"a/bc/x.txt", "a/bd/y.txt"}))
+	assert.Equal(t, "", commonFolder([]string{"a/x.txt", "b/y.txt"}))
+	assert.Equal(t, "", commonFolder([]string{"root.txt", "a/b.txt"}))
+}
+
+// newTestZipCore fakes a bucket "data" with objects under docs/ whose content is their
+// key, answering more slowly for earlier keys; missing.txt does not exist
+func newTestZipCore(t *testing.T) *Core {
+	cfg := &config.Config{Download: config.DownloadConfig{MaxZipBytes: 1000, MaxZipObjects: 10, ZipConcurrency: 2}}
+	return newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/data/")
+		if r.URL.Query().Get("list-type") == "2" {
+			w.Header().Set("Content-Type", "application/xml")
+			var contents strings.Builder
+			for _, name := range []string{"docs/", "docs/a.txt", "docs/b.txt", "docs/sub/c.txt"} {
+				fmt.Fprintf(&contents, `<Contents><Key>%s</Key><Size>%d</Size></Contents>`, name, len(name))
+			}
+			fmt.Fprintf(w, `<ListBucketResult><IsTruncated>false</IsTruncated>%s</ListBucketResult>`, contents.String())
+			return
+		}
+		if key == "missing.txt" {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		if key == "docs/a.txt" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(key)))
+		_, _ = io.WriteString(w, key)
+	})
+}
+
+func TestWriteZip(t *testing.T) {
+	c := newTestZipCore(t)
+
+	manifest, err := c.S3Service.PrepareZipFromPrefix(context.Background(), "data", "docs/")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, c.S3Service.WriteZip(context.Background(), manifest, &buf))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		reader, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "docs/"+file.Name, string(content))
+	}
+	assert.Equal(t, []string{"a.txt", "b.txt", "sub/c.txt"}, names, "entries keep the listing order")
+
+	c.Config.Download.MaxZipObjects = 2
+	_, err = c.S3Service.PrepareZipFromPrefix(context.Background(), "data", "docs/")
+	assert.ErrorIs(t, err, ErrZipTooLarge)
+}
+
+func TestWriteZipMissingObject(t *testing.T) {
+	c := newTestZipCore(t)
+	manifest := &ZipManifest{Bucket: "data"}
+	limits := c.S3Service.zipLimits()
+	for _, key := range []string{"docs/a.txt", "missing.txt", "docs/b.txt", "docs/sub/c.txt"} {
+		require.NoError(t, manifest.add(limits, "data", key, key, int64(len(key)), time.Time{}))
+	}
+
+	err := c.S3Service.WriteZip(context.Background(), manifest, io.Discard)
+	assert.True(t, hasErrorCode(err, "NoSuchKey"))
+}
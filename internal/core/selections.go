@@ -0,0 +1,170 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+)
+
+var (
+	// ErrSelectionNotFound is returned for unknown or expired selection IDs, and for
+	// selections that belong to another user
+	ErrSelectionNotFound = errors.New("selection not found")
+	// ErrSelectionTooLarge is returned when a selection would exceed maxSelectionItems
+	ErrSelectionTooLarge = errors.New("selection has too many items")
+)
+
+const (
+	// maxSelectionItems caps the items in one selection; prefixes count as one item
+	maxSelectionItems = 10000
+	// selectionRetention is how long a selection is kept after it was last changed
+	selectionRetention = 24 * time.Hour
+)
+
+// SelectionService keeps each user's selections: sets of objects and prefixes,
+// possibly from several buckets, collected while browsing and then copied, moved,
+// deleted or downloaded together. Selections are short-lived and held in memory only.
+type SelectionService struct {
+	core *Core
+
+	mu         sync.Mutex
+	selections map[string]*selection
+}
+
+// selection is a selection along with the user it belongs to
+type selection struct {
+	models.Selection
+	owner string
+}
+
+// NewSelectionService creates a new SelectionService
+func NewSelectionService(core *Core) *SelectionService {
+	return &SelectionService{
+		core:       core,
+		selections: make(map[string]*selection),
+	}
+}
+
+// Create starts a new selection for a user, optionally with initial items
+func (s *SelectionService) Create(user string, items []models.SelectionItem) (models.Selection, error) {
+	now := time.Now().UTC()
+	sel := &selection{
+		Selection: models.Selection{
+			ID:        newSelectionID(),
+			Items:     []models.SelectionItem{},
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		owner: user,
+	}
+	if err := sel.add(items); err != nil {
+		return models.Selection{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+	s.selections[sel.ID] = sel
+	return sel.snapshot(), nil
+}
+
+// Get returns one of a user's selections
+func (s *SelectionService) Get(user, id string) (models.Selection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sel, err := s.getLocked(user, id)
+	if err != nil {
+		return models.Selection{}, err
+	}
+	return sel.snapshot(), nil
+}
+
+// Update adds items to and removes items from one of a user's selections. Items that
+// are already selected are not added twice.
+func (s *SelectionService) Update(user, id string, add, remove []models.SelectionItem) (models.Selection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sel, err := s.getLocked(user, id)
+	if err != nil {
+		return models.Selection{}, err
+	}
+
+	previous := sel.Items
+	sel.Items = slices.DeleteFunc(slices.Clone(sel.Items), func(item models.SelectionItem) bool {
+		return slices.Contains(remove, item)
+	})
+	if err := sel.add(add); err != nil {
+		sel.Items = previous
+		return models.Selection{}, err
+	}
+	sel.UpdatedAt = time.Now().UTC()
+	return sel.snapshot(), nil
+}
+
+// Delete discards one of a user's selections
+func (s *SelectionService) Delete(user, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.getLocked(user, id); err != nil {
+		return err
+	}
+	delete(s.selections, id)
+	return nil
+}
+
+// getLocked looks up a selection, hiding other users' selections
+func (s *SelectionService) getLocked(user, id string) (*selection, error) {
+	sel, ok := s.selections[id]
+	if !ok || sel.owner != user || time.Since(sel.UpdatedAt) > selectionRetention {
+		return nil, ErrSelectionNotFound
+	}
+	return sel, nil
+}
+
+// pruneLocked drops selections that have not been changed for selectionRetention
+func (s *SelectionService) pruneLocked() {
+	for id, sel := range s.selections {
+		if time.Since(sel.UpdatedAt) > selectionRetention {
+			delete(s.selections, id)
+		}
+	}
+}
+
+// add appends items that are not selected yet
+func (sel *selection) add(items []models.SelectionItem) error {
+	for _, item := range items {
+		item.Key = strings.TrimPrefix(item.Key, "/")
+		if slices.Contains(sel.Items, item) {
+			continue
+		}
+		if len(sel.Items) >= maxSelectionItems {
+			return ErrSelectionTooLarge
+		}
+		sel.Items = append(sel.Items, item)
+	}
+	return nil
+}
+
+// snapshot returns a copy of the selection that is safe to use without the lock
+func (sel *selection) snapshot() models.Selection {
+	snapshot := sel.Selection
+	snapshot.Items = slices.Clone(sel.Items)
+	return snapshot
+}
+
+// newSelectionID generates a random selection identifier
+func newSelectionID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectionService(t *testing.T) {
+	selections := NewSelectionService(&Core{})
+	report := models.SelectionItem{Bucket: "data", Key: "reports/q1.csv"}
+	exports := models.SelectionItem{Bucket: "archive", Key: "exports/"}
+
+	sel, err := selections.Create("alice", []models.SelectionItem{report})
+	require.NoError(t, err)
+
+	// Items already selected are not added twice
+	sel, err = selections.Update("alice", sel.ID, []models.SelectionItem{exports, {Bucket: "data", Key: "/reports/q1.csv"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []models.SelectionItem{report, exports}, sel.Items)
+
+	sel, err = selections.Update("alice", sel.ID, nil, []models.SelectionItem{report})
+	require.NoError(t, err)
+	assert.Equal(t, []models.SelectionItem{exports}, sel.Items)
+
+	// Other users cannot see the selection
+	_, err = selections.Get("bob", sel.ID)
+	assert.ErrorIs(t, err, ErrSelectionNotFound)
+	assert.ErrorIs(t, selections.Delete("bob", sel.ID), ErrSelectionNotFound)
+
+	// Idle selections expire
+	selections.selections[sel.ID].UpdatedAt = time.Now().Add(-selectionRetention - time.Minute)
+	_, err = selections.Get("alice", sel.ID)
+	assert.ErrorIs(t, err, ErrSelectionNotFound)
+
+	sel, err = selections.Create("alice", nil)
+	require.NoError(t, err)
+	require.NoError(t, selections.Delete("alice", sel.ID))
+	_, err = selections.Get("alice", sel.ID)
+	assert.ErrorIs(t, err, ErrSelectionNotFound)
+}
+
+func TestCopySelection_Rejected(t *testing.T) {
+	c := newTestListingCore(t, &config.Config{}, nil)
+	ctx := context.Background()
+
+	_, err := c.S3Service.CopySelection(ctx, models.Selection{}, "data", "", false, "", "")
+	assert.ErrorIs(t, err, ErrSelectionEmpty)
+
+	sel := models.Selection{Items: []models.SelectionItem{{Bucket: "data", Key: "photos/"}}}
+	_, err = c.S3Service.CopySelection(ctx, sel, "data", "photos/2024", true, "", "")
+	assert.ErrorIs(t, err, ErrTargetInsideSelection)
+
+	// Both would be moved to shared/report.pdf, and one lost
+	sel = models.Selection{Items: []models.SelectionItem{
+		{Bucket: "data", Key: "a/report.pdf"},
+		{Bucket: "data", Key: "b/report.pdf"},
+	}}
+	_, err = c.S3Service.CopySelection(ctx, sel, "data", "shared/", true, "", "")
+	assert.ErrorIs(t, err, ErrSelectionNameClash)
+	sel = models.Selection{Items: []models.SelectionItem{
+		{Bucket: "data", Key: "2023/photos/"},
+		{Bucket: "backup", Key: "2024/photos/"},
+	}}
+	_, err = c.S3Service.CopySelection(ctx, sel, "data", "shared/", false, "", "")
+	assert.ErrorIs(t, err, ErrSelectionNameClash)
+}
+
+func TestPrepareZipFromSelection(t *testing.T) {
+	objects := []testObject{
+		{key: "photos/2024/cat.jpg", size: 10},
+		{key: "photos/2024/dog.jpg", size: 20},
+	}
+	cfg := &config.Config{Download: config.DownloadConfig{MaxZipBytes: 1000, MaxZipObjects: 10}}
+	c := newTestListingCore(t, cfg, objects)
+
+	// The fake lists the same objects for every prefix, in every bucket
+	sel := models.Selection{Items: []models.SelectionItem{
+		{Bucket: "data", Key: "photos/2024/"},
+		{Bucket: "backup", Key: "photos/2024/"},
+	}}
+	manifest, err := c.S3Service.PrepareZipFromSelection(context.Background(), sel)
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Bucket)
+	assert.Equal(t, int64(60), manifest.TotalBytes)
+
+	names := make([]string, 0, manifest.Len())
+	for _, entry := range manifest.entries {
+		names = append(names, entry.name)
+	}
+	assert.Equal(t, []string{"data/2024/cat.jpg", "data/2024/dog.jpg", "backup/2024/cat.jpg", "backup/2024/dog.jpg"}, names)
+}
+
+func TestParentFolder(t *testing.T) {
+	assert.Equal(t, "", parentFolder("cat.jpg"))
+	assert.Equal(t, "", parentFolder("photos/"))
+	assert.Equal(t, "photos/", parentFolder("photos/cat.jpg"))
+	assert.Equal(t, "photos/", parentFolder("photos/2024/"))
+}
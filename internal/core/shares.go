@@ -0,0 +1,463 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	// ErrShareNotFound is returned for unknown share tokens
+	ErrShareNotFound = errors.New("share not found")
+	// ErrShareExpired is returned when a share link's lifetime has passed
+	ErrShareExpired = errors.New("share has expired")
+	// ErrShareRevoked is returned when a share link has been revoked
+	ErrShareRevoked = errors.New("share has been revoked")
+	// ErrShareExpiryTooLong is returned when a share link would outlive shares.maxExpiry
+	ErrShareExpiryTooLong = errors.New("share expiry exceeds the maximum")
+)
+
+const (
+	// shareRetention is how long expired and revoked shares are kept, so their links
+	// answer "expired" rather than "not found" for a while
+	shareRetention = 7 * 24 * time.Hour
+	// maxShareAccesses is the number of individual accesses kept per share; older ones
+	// only count towards the totals
+	maxShareAccesses = 100
+	// shareAccessSaveInterval is how long accesses to shares may wait to be saved, unless
+	// another change to the shares saves them first
+	shareAccessSaveInterval = 30 * time.Second
+)
+
+// ShareService manages share links: short tokens that stand for an object and are
+// exchanged for a freshly presigned URL on each use
+type ShareService struct {
+	core *Core
+
+	mu     sync.Mutex
+	shares map[string]*shareRecord
+	// pending holds the accesses not saved yet by token, so that opening a link does
+	// not rewrite every share
+	pending map[string]*shareAccesses
+	savedAt time.Time
+}
+
+// shareRecord is a share together with its access statistics, as kept in the state file
+type shareRecord struct {
+	models.Share
+	Accesses     int64                `json:"accesses"`
+	Bytes        int64                `json:"bytes"`
+	LastAccessAt *time.Time           `json:"lastAccessAt,omitempty"`
+	Recent       []models.ShareAccess `json:"recent,omitempty"`
+}
+
+// shareAccesses are accesses to a share not saved yet
+type shareAccesses struct {
+	count  int64
+	bytes  int64
+	recent []models.ShareAccess
+}
+
+// NewShareService creates a new ShareService, restoring the shares saved by the
+// previous run
+func NewShareService(core *Core) *ShareService {
+	s := &ShareService{
+		core:    core,
+		pending: make(map[string]*shareAccesses),
+		savedAt: time.Now(),
+	}
+
+	var shares []*shareRecord
+	if err := core.restoreState("shares", core.Config.Shares.StateFile, &shares); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore share links")
+	}
+	s.setLocked(shares)
+	return s
+}
+
+// Create creates a share link for an object, or a specific version of it, on behalf of
+// user, that works for expiresIn. A zero expiresIn uses the configured default.
+func (s *ShareService) Create(ctx context.Context, user, bucket, key, versionID string, expiresIn time.Duration) (models.Share, error) {
+	cfg := s.core.Config.Shares
+	if expiresIn <= 0 {
+		expiresIn = cfg.DefaultExpiry
+	}
+	if expiresIn > cfg.MaxExpiry {
+		return models.Share{}, ErrShareExpiryTooLong
+	}
+
+	// Refuse links to objects that do not exist rather than handing out dead links
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	head, err := s.core.Client(bucket).HeadObject(ctx, input)
+	if err != nil {
+		return models.Share{}, err
+	}
+
+	now := time.Now().UTC()
+	share := models.Share{
+		Token:     newShareToken(),
+		Bucket:    bucket,
+		Key:       key,
+		VersionID: versionID,
+		Size:      aws.ToInt64(head.ContentLength),
+		CreatedBy: user,
+		CreatedAt: now,
+		ExpiresAt: now.Add(expiresIn),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+	s.pruneLocked(now)
+	s.shares[share.Token] = &shareRecord{Share: share}
+	if err := s.saveLocked(); err != nil {
+		delete(s.shares, share.Token)
+		return models.Share{}, err
+	}
+
+	s.core.Logger.Info().
+		Str("token", share.Token).
+		Str("bucket", bucket).
+		Str("key", key).
+		Str("user", user).
+		Time("expiresAt", share.ExpiresAt).
+		Msg("Created share link")
+	return share, nil
+}
+
+// Get returns a share link, whether or not it can still be used
+func (s *ShareService) Get(token string) (models.Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	record, ok := s.shares[token]
+	if !ok {
+		return models.Share{}, ErrShareNotFound
+	}
+	return record.Share, nil
+}
+
+// Resolve returns a share link that can be used now
+func (s *ShareService) Resolve(token string) (models.Share, error) {
+	share, err := s.Get(token)
+	if err != nil {
+		return models.Share{}, err
+	}
+	if share.RevokedAt != nil {
+		return models.Share{}, ErrShareRevoked
+	}
+	if time.Now().After(share.ExpiresAt) {
+		return models.Share{}, ErrShareExpired
+	}
+	return share, nil
+}
+
+// List returns all share links, newest first
+func (s *ShareService) List() []models.Share {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	shares := make([]models.Share, 0, len(s.shares))
+	for _, record := range s.shares {
+		shares = append(shares, record.Share)
+	}
+	slices.SortFunc(shares, func(a, b models.Share) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return shares
+}
+
+// Revoke stops a share link from working
+func (s *ShareService) Revoke(token string) (models.Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	record, ok := s.shares[token]
+	if !ok {
+		return models.Share{}, ErrShareNotFound
+	}
+	if record.RevokedAt == nil {
+		now := time.Now().UTC()
+		record.RevokedAt = &now
+		if err := s.saveLocked(); err != nil {
+			return models.Share{}, err
+		}
+	}
+	return record.Share, nil
+}
+
+// RecordAccess records that a share link was opened from ip and redirected to its
+// object, counting the object's size when the link was created. Accesses are saved
+// with the next change to the shares, at the latest after shareAccessSaveInterval.
+func (s *ShareService) RecordAccess(token, ip, userAgent string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	record, ok := s.shares[token]
+	if !ok {
+		return
+	}
+
+	access := models.ShareAccess{
+		Time:      time.Now().UTC(),
+		IP:        ip,
+		UserAgent: userAgent,
+		Bytes:     record.Size,
+	}
+	accesses := s.pending[token]
+	if accesses == nil {
+		accesses = &shareAccesses{}
+		s.pending[token] = accesses
+	}
+	accesses.count++
+	accesses.bytes += access.Bytes
+	accesses.recent = append(accesses.recent, access)
+	if len(accesses.recent) > maxShareAccesses {
+		accesses.recent = accesses.recent[len(accesses.recent)-maxShareAccesses:]
+	}
+	if time.Since(s.savedAt) < shareAccessSaveInterval {
+		return
+	}
+
+	// The access has been served either way; losing it from the state file is not fatal
+	if err := s.saveLocked(); err != nil {
+		s.core.Logger.Warn().Err(err).Str("token", token).Msg("Failed to save share accesses")
+	}
+}
+
+// Save persists the shares together with the accesses not saved yet
+func (s *ShareService) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// Stats returns the access statistics of a share link
+func (s *ShareService) Stats(token string) (models.ShareStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	record, ok := s.shares[token]
+	if !ok {
+		return models.ShareStats{}, ErrShareNotFound
+	}
+	record = record.withAccesses(s.pending[token])
+
+	recent := make([]models.ShareAccess, len(record.Recent))
+	for i, access := range record.Recent {
+		recent[len(recent)-1-i] = access
+	}
+	return models.ShareStats{
+		Token:        token,
+		Accesses:     record.Accesses,
+		Bytes:        record.Bytes,
+		LastAccessAt: record.LastAccessAt,
+		Recent:       recent,
+	}, nil
+}
+
+// forObject returns copies of the shares of an object, including expired and revoked
+// ones still kept
+func (s *ShareService) forObject(bucket, key string) []shareRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	var records []shareRecord
+	for token, record := range s.shares {
+		if record.Bucket == bucket && record.Key == key {
+			records = append(records, *record.withAccesses(s.pending[token]))
+		}
+	}
+	return records
+}
+
+// Ended returns the shares of objects in a bucket under prefix that expired or were
+// revoked, and are only kept so their links answer "expired", newest first
+func (s *ShareService) Ended(bucket, prefix string) []models.Share {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	now := time.Now()
+	var shares []models.Share
+	for _, record := range s.shares {
+		if record.Bucket == bucket && strings.HasPrefix(record.Key, prefix) && record.ended(now) {
+			shares = append(shares, record.Share)
+		}
+	}
+	slices.SortFunc(shares, func(a, b models.Share) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return shares
+}
+
+// Purge forgets ended shares ahead of their retention, after which their links answer
+// "not found". Shares that can still be used are kept; the tokens removed are returned.
+func (s *ShareService) Purge(tokens []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	now := time.Now()
+	removed := make(map[string]*shareRecord)
+	for _, token := range tokens {
+		if record, ok := s.shares[token]; ok && record.ended(now) {
+			removed[token] = record
+			delete(s.shares, token)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	if err := s.saveLocked(); err != nil {
+		maps.Copy(s.shares, removed)
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(removed)), nil
+}
+
+// ended reports whether a share expired or was revoked
+func (r *shareRecord) ended(now time.Time) bool {
+	return r.RevokedAt != nil || now.After(r.ExpiresAt)
+}
+
+// Import adds shares exported from another deployment, skipping tokens that already
+// exist and shares naming no bucket or key, and returns the number added. No imported
+// share outlives one created now with shares.maxExpiry. Access statistics start from
+// zero.
+func (s *ShareService) Import(shares []models.Share) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+
+	latest := time.Now().UTC().Add(s.core.Config.Shares.MaxExpiry)
+	var added []string
+	for _, share := range shares {
+		if _, ok := s.shares[share.Token]; ok || share.Token == "" || share.Bucket == "" || share.Key == "" {
+			continue
+		}
+		if share.ExpiresAt.After(latest) {
+			share.ExpiresAt = latest
+		}
+		s.shares[share.Token] = &shareRecord{Share: share}
+		added = append(added, share.Token)
+	}
+	if err := s.saveLocked(); err != nil {
+		for _, token := range added {
+			delete(s.shares, token)
+		}
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// withAccesses returns a copy of the record with accesses not saved yet added, if any
+func (r *shareRecord) withAccesses(accesses *shareAccesses) *shareRecord {
+	copied := *r
+	copied.Recent = slices.Clone(r.Recent)
+	if accesses == nil {
+		return &copied
+	}
+
+	last := accesses.recent[len(accesses.recent)-1].Time
+	copied.Accesses += accesses.count
+	copied.Bytes += accesses.bytes
+	copied.LastAccessAt = &last
+	copied.Recent = append(copied.Recent, accesses.recent...)
+	if len(copied.Recent) > maxShareAccesses {
+		copied.Recent = copied.Recent[len(copied.Recent)-maxShareAccesses:]
+	}
+	return &copied
+}
+
+// refreshLocked reloads the shares when another instance changed them, so links created
+// or revoked there resolve the same here
+func (s *ShareService) refreshLocked() {
+	var shares []*shareRecord
+	reloaded, err := s.core.refreshState("shares", s.core.Config.Shares.StateFile, &shares)
+	if err != nil {
+		s.core.Logger.Warn().
+			Err(err).
+			Msg("Failed to refresh share links")
+		return
+	}
+	if reloaded {
+		s.setLocked(shares)
+	}
+}
+
+// setLocked replaces the shares with saved ones. Accesses not saved yet are kept and
+// added to the saved counts when they are saved.
+func (s *ShareService) setLocked(shares []*shareRecord) {
+	s.shares = make(map[string]*shareRecord, len(shares))
+	for _, record := range shares {
+		s.shares[record.Token] = record
+	}
+}
+
+// saveLocked persists the shares, adding the accesses not saved yet
+func (s *ShareService) saveLocked() error {
+	shares := make([]*shareRecord, 0, len(s.shares))
+	for token, record := range s.shares {
+		if accesses, ok := s.pending[token]; ok {
+			record = record.withAccesses(accesses)
+		}
+		shares = append(shares, record)
+	}
+	if err := s.core.persistState("shares", s.core.Config.Shares.StateFile, shares); err != nil {
+		return err
+	}
+
+	for _, record := range shares {
+		s.shares[record.Token] = record
+	}
+	clear(s.pending)
+	s.savedAt = time.Now()
+	return nil
+}
+
+// pruneLocked forgets shares that expired or were revoked more than shareRetention ago
+func (s *ShareService) pruneLocked(now time.Time) {
+	cutoff := now.Add(-shareRetention)
+	for token, record := range s.shares {
+		ended := record.ExpiresAt
+		if record.RevokedAt != nil && record.RevokedAt.Before(ended) {
+			ended = *record.RevokedAt
+		}
+		if ended.Before(cutoff) {
+			delete(s.shares, token)
+		}
+	}
+}
+
+// newShareToken generates a short random token that is safe to use in URLs
+func newShareToken() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
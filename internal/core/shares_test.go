@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestShareService returns a ShareService whose S3 client talks to a server that
+// knows only photos/cat.jpg
+func newTestShareService(t *testing.T, stateFile string) *ShareService {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/photos/cat.jpg" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", "2048")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	c := &Core{
+		Config: &config.Config{Shares: config.SharesConfig{
+			DefaultExpiry: time.Hour,
+			MaxExpiry:     24 * time.Hour,
+			StateFile:     stateFile,
+		}},
+		Logger: logger.New("error", "json"),
+		S3Client: s3.New(s3.Options{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(server.URL),
+			UsePathStyle: true,
+			Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		}),
+	}
+	return NewShareService(c)
+}
+
+func TestShareService_Lifecycle(t *testing.T) {
+	shares := newTestShareService(t, "")
+	ctx := context.Background()
+
+	share, err := shares.Create(ctx, "alice", "photos", "cat.jpg", "", 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, share.Token)
+	assert.Equal(t, "alice", share.CreatedBy)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), share.ExpiresAt, time.Minute)
+
+	resolved, err := shares.Resolve(share.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "cat.jpg", resolved.Key)
+
+	_, err = shares.Revoke(share.Token)
+	require.NoError(t, err)
+	_, err = shares.Resolve(share.Token)
+	assert.ErrorIs(t, err, ErrShareRevoked)
+
+	_, err = shares.Resolve("unknown")
+	assert.ErrorIs(t, err, ErrShareNotFound)
+}
+
+func TestShareService_Create(t *testing.T) {
+	shares := newTestShareService(t, "")
+	ctx := context.Background()
+
+	_, err := shares.Create(ctx, "alice", "photos", "cat.jpg", "", 48*time.Hour)
+	assert.ErrorIs(t, err, ErrShareExpiryTooLong)
+
+	// Links to missing objects are refused
+	_, err = shares.Create(ctx, "alice", "photos", "dog.jpg", "", 0)
+	assert.Error(t, err)
+	assert.Empty(t, shares.List())
+}
+
+func TestShareService_Expired(t *testing.T) {
+	shares := newTestShareService(t, "")
+	share, err := shares.Create(context.Background(), "alice", "photos", "cat.jpg", "", time.Minute)
+	require.NoError(t, err)
+
+	shares.shares[share.Token].ExpiresAt = time.Now().Add(-time.Second)
+
+	_, err = shares.Resolve(share.Token)
+	assert.ErrorIs(t, err, ErrShareExpired)
+}
+
+func TestShareService_Import(t *testing.T) {
+	shares := newTestShareService(t, "")
+	now := time.Now().UTC()
+
+	added, err := shares.Import([]models.Share{
+		{Token: "forever", Bucket: "photos", Key: "cat.jpg", CreatedAt: now, ExpiresAt: now.Add(365 * 24 * time.Hour)},
+		{Token: "nokey", Bucket: "photos", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{Token: "nobucket", Key: "cat.jpg", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	// Imported links expire no later than shares.maxExpiry from now
+	share, err := shares.Get("forever")
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(24*time.Hour), share.ExpiresAt, time.Minute)
+}
+
+func TestShareService_Persistence(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "shares.json")
+	share, err := newTestShareService(t, stateFile).Create(context.Background(), "alice", "photos", "cat.jpg", "v2", 0)
+	require.NoError(t, err)
+
+	restored, err := newTestShareService(t, stateFile).Resolve(share.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", restored.VersionID)
+	assert.True(t, share.ExpiresAt.Equal(restored.ExpiresAt))
+}
+
+func TestShareService_Stats(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "shares.json")
+	shares := newTestShareService(t, stateFile)
+	share, err := shares.Create(context.Background(), "alice", "photos", "cat.jpg", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2048), share.Size)
+
+	shares.RecordAccess(share.Token, "203.0.113.7", "curl/8.5.0")
+	shares.RecordAccess(share.Token, "198.51.100.2", "Mozilla/5.0")
+	shares.RecordAccess("unknown", "198.51.100.2", "Mozilla/5.0")
+
+	// Statistics are saved on shutdown and survive a restart
+	require.NoError(t, shares.Save())
+	stats, err := newTestShareService(t, stateFile).Stats(share.Token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Accesses)
+	assert.Equal(t, int64(4096), stats.Bytes)
+	require.Len(t, stats.Recent, 2)
+	assert.Equal(t, "198.51.100.2", stats.Recent[0].IP)
+	assert.Equal(t, "curl/8.5.0", stats.Recent[1].UserAgent)
+	assert.Equal(t, stats.Recent[0].Time, *stats.LastAccessAt)
+
+	_, err = shares.Stats("unknown")
+	assert.ErrorIs(t, err, ErrShareNotFound)
+}
@@ -0,0 +1,368 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"explorer451/internal/store"
+)
+
+// maxStateConflicts bounds the attempts at saving a document other instances keep
+// changing first
+const maxStateConflicts = 5
+
+// recordKeys are the fields identifying the records of a list, tried in order: a list
+// whose records all have a distinct one is merged record by record
+var recordKeys = []string{"id", "token", "name"}
+
+// storedState is what an instance knows of a document in the store, decoded as
+// generic JSON
+type storedState struct {
+	// version and saved are the document as last read from or written to the store
+	version int64
+	saved   any
+	// base is this instance's own data when it was last restored or saved; what the
+	// data being saved changed from it is what this instance changed
+	base any
+	// checked is when the version in the store was last compared with version
+	checked time.Time
+	// merged is set when the last save carried this instance's changes onto another
+	// instance's, so saved holds data this instance does not have yet
+	merged bool
+}
+
+// absent stands for a key or record missing from one side of a merge
+type absent struct{}
+
+// restoreState reads the data saved under name into v: from path when the feature has
+// a state file of its own, otherwise from the store. Having nothing saved yet is not
+// an error and leaves v unchanged.
+func (c *Core) restoreState(name, path string, v any) error {
+	var err error
+	switch {
+	case path != "":
+		err = loadState(path, v)
+	case c.Store != nil:
+		err = c.loadStored(name, v)
+	}
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// persistState saves v under name, following the same rules as restoreState. Without a
+// state file or store it does nothing.
+func (c *Core) persistState(name, path string, v any) error {
+	switch {
+	case path != "":
+		return saveState(path, v)
+	case c.Store != nil:
+		return c.saveStored(name, v)
+	}
+	return nil
+}
+
+// refreshState reads the data saved under name into v again when another instance
+// saved it since this instance last read or wrote it, and reports whether it did. Only
+// data in the store is refreshed, at most every store.refreshInterval; a state file
+// belongs to one instance.
+func (c *Core) refreshState(name, path string, v any) (bool, error) {
+	if path != "" || c.Store == nil {
+		return false, nil
+	}
+
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	if c.states == nil {
+		c.states = make(map[string]*storedState)
+	}
+	state := c.states[name]
+	if state == nil {
+		state = &storedState{}
+		c.states[name] = state
+	}
+	now := time.Now()
+	if !state.merged && now.Sub(state.checked) < c.Config.Store.RefreshInterval {
+		return false, nil
+	}
+	state.checked = now
+
+	ctx := context.Background()
+	if !state.merged {
+		version, err := c.Store.Version(ctx, name)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && version == state.version) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var data json.RawMessage
+	version, err := c.Store.Load(ctx, name, &data)
+	if err != nil {
+		return false, err
+	}
+	saved, err := decodeState(data)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	state.version, state.saved, state.base, state.merged = version, saved, saved, false
+	return true, nil
+}
+
+// loadStored reads a document from the store into v, remembering its version and
+// content for the next save
+func (c *Core) loadStored(name string, v any) error {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	var data json.RawMessage
+	version, err := c.Store.Load(context.Background(), name, &data)
+	if err != nil {
+		return err
+	}
+	saved, err := decodeState(data)
+	if err != nil {
+		return err
+	}
+	if c.states == nil {
+		c.states = make(map[string]*storedState)
+	}
+	c.states[name] = &storedState{version: version, saved: saved, base: saved, checked: time.Now()}
+	return json.Unmarshal(data, v)
+}
+
+// saveStored writes v to the store as the changes this instance made since it last
+// restored or saved the document, carried onto the version in the store. When another
+// instance saved the document in between, its latest version is read and the changes
+// are carried onto that instead, so records only the other instance changed survive;
+// where both changed the same field, this save wins.
+func (c *Core) saveStored(name string, v any) error {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ours, err := decodeState(data)
+	if err != nil {
+		return err
+	}
+	if c.states == nil {
+		c.states = make(map[string]*storedState)
+	}
+	state := c.states[name]
+	if state == nil {
+		state = &storedState{}
+		c.states[name] = state
+	}
+
+	ctx := context.Background()
+	for range maxStateConflicts {
+		merged := mergeState(state.base, ours, state.saved)
+		version, err := c.Store.Save(ctx, name, merged, state.version)
+		if err == nil {
+			state.version, state.saved, state.base = version, merged, ours
+			state.merged = !reflect.DeepEqual(merged, ours)
+			return nil
+		}
+		if !errors.Is(err, store.ErrConflict) {
+			return err
+		}
+
+		c.Logger.Debug().
+			Str("name", name).
+			Int64("version", state.version).
+			Msg("Stored state changed by another instance, merging")
+		var latest json.RawMessage
+		version, err = c.Store.Load(ctx, name, &latest)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			state.version, state.saved = 0, nil
+		case err != nil:
+			return err
+		default:
+			saved, err := decodeState(latest)
+			if err != nil {
+				return err
+			}
+			state.version, state.saved = version, saved
+		}
+	}
+	return store.ErrConflict
+}
+
+// decodeState decodes JSON as generic maps, lists and values, keeping numbers as they
+// were written
+func decodeState(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// mergeState carries the changes from base to ours onto theirs. Objects are merged key
+// by key and lists of records by their recordKeys, recursively; anything else changed
+// on both sides is taken from ours.
+func mergeState(base, ours, theirs any) any {
+	if reflect.DeepEqual(ours, base) {
+		return theirs
+	}
+	if reflect.DeepEqual(theirs, base) || reflect.DeepEqual(theirs, ours) {
+		return ours
+	}
+
+	// Go saves empty maps and lists as null, so null merges as either
+	ourObject, oursIsObject := ours.(map[string]any)
+	theirObject, theirsIsObject := theirs.(map[string]any)
+	if (oursIsObject || ours == nil) && (theirsIsObject || theirs == nil) && (oursIsObject || theirsIsObject) {
+		// A base of another shape, or none, holds none of the keys
+		base, _ := base.(map[string]any)
+		merged := make(map[string]any, len(theirObject))
+		for key, value := range theirObject {
+			if value = mergeState(field(base, key), field(ourObject, key), value); value != (absent{}) {
+				merged[key] = value
+			}
+		}
+		for key, value := range ourObject {
+			if _, ok := theirObject[key]; ok {
+				continue
+			}
+			if value = mergeState(field(base, key), value, absent{}); value != (absent{}) {
+				merged[key] = value
+			}
+		}
+		return merged
+	}
+
+	ourList, oursIsList := ours.([]any)
+	theirList, theirsIsList := theirs.([]any)
+	if (oursIsList || ours == nil) && (theirsIsList || theirs == nil) && (oursIsList || theirsIsList) {
+		base, _ := base.([]any)
+		if key := recordKey(base, ourList, theirList); key != "" {
+			return mergeRecords(key, base, ourList, theirList)
+		}
+	}
+	return ours
+}
+
+// mergeRecords merges lists of records identified by key, keeping theirs' order and
+// appending the records only ours has
+func mergeRecords(key string, base, ours, theirs []any) []any {
+	baseRecords, ourRecords := recordsByKey(key, base), recordsByKey(key, ours)
+	merged := make([]any, 0, len(theirs))
+	seen := make(map[string]bool, len(theirs))
+	for _, record := range theirs {
+		id := record.(map[string]any)[key].(string)
+		seen[id] = true
+		if value := mergeState(recordOf(baseRecords, id), recordOf(ourRecords, id), record); value != (absent{}) {
+			merged = append(merged, value)
+		}
+	}
+	for _, record := range ours {
+		id := record.(map[string]any)[key].(string)
+		if seen[id] {
+			continue
+		}
+		if value := mergeState(recordOf(baseRecords, id), record, absent{}); value != (absent{}) {
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+// recordKey returns the first of recordKeys that identifies every record of the lists
+// within each list, or "" when the lists do not hold records
+func recordKey(lists ...[]any) string {
+next:
+	for _, key := range recordKeys {
+		found := false
+		for _, list := range lists {
+			ids := make(map[string]bool, len(list))
+			for _, item := range list {
+				record, ok := item.(map[string]any)
+				if !ok {
+					return ""
+				}
+				id, ok := record[key].(string)
+				if !ok || ids[id] {
+					continue next
+				}
+				ids[id] = true
+				found = true
+			}
+		}
+		if found {
+			return key
+		}
+	}
+	return ""
+}
+
+// recordsByKey indexes a list of records by key
+func recordsByKey(key string, list []any) map[string]any {
+	records := make(map[string]any, len(list))
+	for _, record := range list {
+		records[record.(map[string]any)[key].(string)] = record
+	}
+	return records
+}
+
+// field returns the value of a key of an object, or absent
+func field(object map[string]any, key string) any {
+	if value, ok := object[key]; ok {
+		return value
+	}
+	return absent{}
+}
+
+// recordOf returns the record with an id, or absent
+func recordOf(records map[string]any, id string) any {
+	if record, ok := records[id]; ok {
+		return record
+	}
+	return absent{}
+}
+
+// saveState writes v as JSON to path. It writes to a temporary file first so a crash
+// never leaves a truncated state file.
+func saveState(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadState reads JSON written by saveState into v
+func loadState(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
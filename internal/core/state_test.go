@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+	"explorer451/internal/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreState(t *testing.T) {
+	st, err := store.Open(context.Background(), config.StoreConfig{
+		Driver: store.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "explorer451.db"),
+	})
+	require.NoError(t, err)
+	defer st.Close()
+
+	newCore := func() *Core {
+		return &Core{Config: &config.Config{}, Logger: logger.New("error", "json"), Store: st}
+	}
+
+	bookmarks := NewBookmarkService(newCore())
+	added, err := bookmarks.Add("alice", "data", "reports/", "")
+	require.NoError(t, err)
+
+	// A new instance restores what the first one saved
+	restored := NewBookmarkService(newCore()).List("alice")
+	require.Len(t, restored, 1)
+	assert.Equal(t, added.ID, restored[0].ID)
+
+	// A state file of the feature's own takes precedence over the store
+	c := newCore()
+	c.Config.Bookmarks.StateFile = filepath.Join(t.TempDir(), "bookmarks.json")
+	assert.Empty(t, NewBookmarkService(c).List("alice"))
+}
+
+func TestState_WithoutStore(t *testing.T) {
+	c := &Core{Config: &config.Config{}}
+
+	var v []string
+	assert.NoError(t, c.persistState("things", "", []string{"a"}))
+	assert.NoError(t, c.restoreState("things", "", &v))
+	assert.Nil(t, v)
+}
+
+// memoryStore is a versioned store kept in memory
+type memoryStore struct {
+	mu        sync.Mutex
+	documents map[string][]byte
+	versions  map[string]int64
+}
+
+func (m *memoryStore) Load(_ context.Context, name string, v any) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.documents[name]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	return m.versions[name], json.Unmarshal(data, v)
+}
+
+func (m *memoryStore) Version(_ context.Context, name string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.documents[name]; !ok {
+		return 0, store.ErrNotFound
+	}
+	return m.versions[name], nil
+}
+
+func (m *memoryStore) Save(_ context.Context, name string, v any, version int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.versions[name] != version {
+		return 0, store.ErrConflict
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	m.documents[name] = data
+	m.versions[name] = version + 1
+	return version + 1, nil
+}
+
+func (m *memoryStore) Close() error { return nil }
+
+func TestStoreState_Instances(t *testing.T) {
+	st := &memoryStore{documents: map[string][]byte{}, versions: map[string]int64{}}
+	newCore := func() *Core {
+		return &Core{Config: &config.Config{}, Logger: logger.New("error", "json"), Store: st}
+	}
+
+	// Two instances start from the same users, then change different ones
+	first := NewAccessService(newCore())
+	_, err := first.CreateUser(models.CreateUserRequest{Name: "alice", Roles: []string{"viewer"}})
+	require.NoError(t, err)
+	_, err = first.CreateUser(models.CreateUserRequest{Name: "bob", Roles: []string{"viewer"}})
+	require.NoError(t, err)
+	second := NewAccessService(newCore())
+
+	editor := []string{"editor"}
+	_, err = first.UpdateUser("alice", models.UpdateUserRequest{Roles: &editor})
+	require.NoError(t, err)
+	disabled := true
+	_, err = second.UpdateUser("bob", models.UpdateUserRequest{Disabled: &disabled})
+	require.NoError(t, err)
+	_, err = second.CreateUser(models.CreateUserRequest{Name: "carol"})
+	require.NoError(t, err)
+
+	// The second save is merged onto the first instead of overwriting it
+	restored := NewAccessService(newCore())
+	alice, err := restored.User("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, alice.Roles)
+	assert.True(t, restored.Disabled("bob"))
+	assert.Len(t, restored.Users(), 3)
+
+	// A deletion carries over too
+	_, err = first.CreateTeam(models.CreateTeamRequest{Name: "finance"})
+	require.NoError(t, err)
+	require.NoError(t, NewAccessService(newCore()).DeleteTeam("finance"))
+	_, err = first.CreateTeam(models.CreateTeamRequest{Name: "ops"})
+	require.NoError(t, err)
+	teams := NewAccessService(newCore()).Teams()
+	require.Len(t, teams, 1)
+	assert.Equal(t, "ops", teams[0].Name)
+}
+
+func TestMergeState(t *testing.T) {
+	decode := func(s string) any {
+		v, err := decodeState([]byte(s))
+		require.NoError(t, err)
+		return v
+	}
+	merge := func(base, ours, theirs string) string {
+		data, err := json.Marshal(mergeState(decode(base), decode(ours), decode(theirs)))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// Objects are merged key by key, recursively
+	assert.Equal(t, `{"a":{"x":2,"y":3},"c":4}`, merge(`{"a":{"x":1,"y":1},"b":1}`, `{"a":{"x":2,"y":1},"b":1}`, `{"a":{"x":1,"y":3},"c":4}`))
+	// Both changed the same value: ours wins
+	assert.Equal(t, `{"a":2}`, merge(`{"a":1}`, `{"a":2}`, `{"a":3}`))
+	// Records are merged by id, keeping theirs' order
+	assert.Equal(t, `[{"id":"c","n":1},{"id":"a","n":2},{"id":"d","n":1}]`,
+		merge(`[{"id":"a","n":1},{"id":"b","n":1}]`, `[{"id":"a","n":2},{"id":"d","n":1}]`, `[{"id":"c","n":1},{"id":"a","n":1},{"id":"b","n":1}]`))
+	// Null is an empty list or object
+	assert.Equal(t, `[{"id":"b"}]`, merge(`[{"id":"a"}]`, `[{"id":"a"},{"id":"b"}]`, `null`))
+	assert.Equal(t, `{"b":1}`, merge(`{"a":1}`, `null`, `{"a":1,"b":1}`))
+	// Other lists are values
+	assert.Equal(t, `["x"]`, merge(`["a"]`, `["x"]`, `["a","b"]`))
+	// Without a base, nothing is taken as deleted
+	assert.Equal(t, `[{"token":"a"},{"token":"b"}]`, merge(`null`, `[{"token":"b"}]`, `[{"token":"a"}]`))
+}
+
+func TestStoreState_Refresh(t *testing.T) {
+	st := &memoryStore{documents: map[string][]byte{}, versions: map[string]int64{}}
+	newCore := func() *Core {
+		cfg := &config.Config{}
+		cfg.Shares.MaxExpiry = 24 * time.Hour
+		return &Core{Config: cfg, Logger: logger.New("error", "json"), Store: st}
+	}
+
+	// A user created and then disabled on one instance is seen so on another
+	first, second := NewAccessService(newCore()), NewAccessService(newCore())
+	_, err := first.CreateUser(models.CreateUserRequest{Name: "alice", Roles: []string{"viewer"}})
+	require.NoError(t, err)
+	_, err = second.User("alice")
+	require.NoError(t, err)
+	disabled := true
+	_, err = first.UpdateUser("alice", models.UpdateUserRequest{Disabled: &disabled})
+	require.NoError(t, err)
+	assert.True(t, second.Disabled("alice"))
+
+	// So is a share created and then revoked
+	sharesA, sharesB := NewShareService(newCore()), NewShareService(newCore())
+	_, err = sharesA.Import([]models.Share{{Token: "t1", Bucket: "docs", Key: "a.txt", ExpiresAt: time.Now().Add(time.Hour)}})
+	require.NoError(t, err)
+	_, err = sharesB.Resolve("t1")
+	require.NoError(t, err)
+	_, err = sharesA.Revoke("t1")
+	require.NoError(t, err)
+	_, err = sharesB.Resolve("t1")
+	assert.ErrorIs(t, err, ErrShareRevoked)
+
+	// Accesses are counted at once but saved later
+	version := st.versions["shares"]
+	sharesB.RecordAccess("t1", "192.0.2.1", "curl")
+	assert.Equal(t, version, st.versions["shares"])
+	stats, err := sharesB.Stats("t1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, stats.Accesses)
+	require.NoError(t, sharesB.Save())
+	stats, err = sharesA.Stats("t1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, stats.Accesses)
+	assert.NotNil(t, stats.LastAccessAt)
+}
@@ -0,0 +1,286 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register the WebP decoder
+)
+
+var (
+	// ErrThumbnailNotSupported is returned when an object is not an image that can be thumbnailed
+	ErrThumbnailNotSupported = errors.New("content type not supported for thumbnails")
+	// ErrThumbnailSourceTooLarge is returned when the source image exceeds the configured size limit
+	ErrThumbnailSourceTooLarge = errors.New("image too large for thumbnail generation")
+	// ErrInvalidThumbnailSize is returned when a size that is not configured is requested
+	ErrInvalidThumbnailSize = errors.New("thumbnail size not allowed")
+)
+
+// thumbnailSourceTypes lists the image content types thumbnails can be generated from
+var thumbnailSourceTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ThumbnailService generates and caches downscaled previews of image objects
+type ThumbnailService struct {
+	core *Core
+}
+
+// NewThumbnailService creates a new ThumbnailService
+func NewThumbnailService(core *Core) *ThumbnailService {
+	return &ThumbnailService{
+		core: core,
+	}
+}
+
+// GetThumbnail returns a thumbnail fitting in a size x size box for an image object,
+// generating and caching it on first use. A size of 0 selects the default size.
+func (t *ThumbnailService) GetThumbnail(ctx context.Context, bucket, key string, size int) (*models.Thumbnail, error) {
+	cfg := t.core.Config.Thumbnails
+	if size == 0 {
+		size = cfg.Sizes[0]
+	}
+	if !slices.Contains(cfg.Sizes, size) {
+		return nil, ErrInvalidThumbnailSize
+	}
+
+	t.core.Logger.Debug().
+		Str("bucket", bucket).
+		Str("key", key).
+		Int("size", size).
+		Msg("Getting thumbnail")
+
+	head, err := t.core.Client(bucket).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get object metadata for thumbnail")
+		return nil, err
+	}
+
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(aws.ToString(head.ContentType), ";")[0]))
+	if !thumbnailSourceTypes[contentType] {
+		contentType = detectContentType(key)
+	}
+
+	// PDFs are thumbnailed from their first page when poppler is available
+	pdf := t.core.Config.PDF.Enabled && isPDFObject(contentType, key)
+	maxSourceBytes := cfg.MaxSourceBytes
+	if pdf {
+		maxSourceBytes = t.core.Config.PDF.MaxSourceBytes
+	} else if !thumbnailSourceTypes[contentType] {
+		return nil, ErrThumbnailNotSupported
+	}
+	if aws.ToInt64(head.ContentLength) > maxSourceBytes {
+		return nil, ErrThumbnailSourceTooLarge
+	}
+
+	// The ETag is part of the cache key so a changed object never serves a stale thumbnail
+	etag := aws.ToString(head.ETag)
+	name := thumbnailCacheName(bucket, key, etag, size)
+
+	if thumb, ok := t.readCache(ctx, bucket, name); ok {
+		return thumb, nil
+	}
+
+	var thumb *models.Thumbnail
+	if pdf {
+		thumb, err = t.generatePDF(ctx, bucket, key, size)
+	} else {
+		thumb, err = t.generate(ctx, bucket, key, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.writeCache(ctx, bucket, name, thumb)
+	return thumb, nil
+}
+
+// generate downloads an image and encodes a downscaled copy of it
+func (t *ThumbnailService) generate(ctx context.Context, bucket, key string, size int) (*models.Thumbnail, error) {
+	output, err := t.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.core.Logger.Error().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to get image for thumbnail")
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	src, format, err := image.Decode(io.LimitReader(output.Body, t.core.Config.Thumbnails.MaxSourceBytes))
+	if err != nil {
+		t.core.Logger.Warn().
+			Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Msg("Failed to decode image for thumbnail")
+		return nil, ErrThumbnailNotSupported
+	}
+
+	dst := resizeToFit(src, size)
+
+	// Keep transparency for formats that can have it; everything else becomes JPEG
+	var buf bytes.Buffer
+	thumb := &models.Thumbnail{}
+	switch format {
+	case "png", "gif", "webp":
+		thumb.ContentType = "image/png"
+		err = png.Encode(&buf, dst)
+	default:
+		thumb.ContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding thumbnail: %w", err)
+	}
+
+	thumb.Data = buf.Bytes()
+	return thumb, nil
+}
+
+// generatePDF renders the first page of a PDF and encodes a downscaled copy of it
+func (t *ThumbnailService) generatePDF(ctx context.Context, bucket, key string, size int) (*models.Thumbnail, error) {
+	src, err := t.core.S3Service.renderPDFFirstPage(ctx, bucket, key)
+	if err != nil {
+		if errors.Is(err, ErrNotPDF) {
+			return nil, ErrThumbnailNotSupported
+		}
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resizeToFit(src, size), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("encoding thumbnail: %w", err)
+	}
+
+	return &models.Thumbnail{
+		ContentType: "image/jpeg",
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// readCache looks up a previously generated thumbnail
+func (t *ThumbnailService) readCache(ctx context.Context, bucket, name string) (*models.Thumbnail, bool) {
+	cfg := t.core.Config.Thumbnails
+
+	var (
+		data []byte
+		err  error
+	)
+	if cfg.CachePrefix != "" {
+		var output *s3.GetObjectOutput
+		output, err = t.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(cfg.CachePrefix + name),
+		})
+		if err != nil {
+			return nil, false
+		}
+		defer output.Body.Close()
+		data, err = io.ReadAll(output.Body)
+	} else {
+		data, err = os.ReadFile(filepath.Join(cfg.CacheDir, name))
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	return &models.Thumbnail{
+		ContentType: thumbnailContentType(data),
+		Data:        data,
+	}, true
+}
+
+// writeCache stores a generated thumbnail. Failures are logged but not returned,
+// since the thumbnail itself was generated successfully.
+func (t *ThumbnailService) writeCache(ctx context.Context, bucket, name string, thumb *models.Thumbnail) {
+	cfg := t.core.Config.Thumbnails
+
+	var err error
+	if cfg.CachePrefix != "" {
+		_, err = t.core.Client(bucket).PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(cfg.CachePrefix + name),
+			Body:        bytes.NewReader(thumb.Data),
+			ContentType: aws.String(thumb.ContentType),
+		})
+	} else if err = os.MkdirAll(cfg.CacheDir, 0o755); err == nil {
+		err = os.WriteFile(filepath.Join(cfg.CacheDir, name), thumb.Data, 0o644)
+	}
+
+	if err != nil {
+		t.core.Logger.Warn().
+			Err(err).
+			Str("bucket", bucket).
+			Str("name", name).
+			Msg("Failed to cache thumbnail")
+	}
+}
+
+// resizeToFit scales an image down to fit in a size x size box, preserving its
+// aspect ratio. Images that already fit are returned unchanged.
+func resizeToFit(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return src
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
+
+// thumbnailCacheName derives a stable cache file name for a thumbnail
+func thumbnailCacheName(bucket, key, etag string, size int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d", bucket, key, etag, size)))
+	return hex.EncodeToString(sum[:])
+}
+
+// thumbnailContentType identifies a cached thumbnail's format from its leading bytes
+func thumbnailContentType(data []byte) string {
+	if bytes.HasPrefix(data, []byte("\x89PNG")) {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizeToFit(t *testing.T) {
+	tests := []struct {
+		name           string
+		width, height  int
+		size           int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{name: "landscape", width: 1000, height: 500, size: 256, expectedWidth: 256, expectedHeight: 128},
+		{name: "portrait", width: 300, height: 1200, size: 128, expectedWidth: 32, expectedHeight: 128},
+		{name: "already fits", width: 100, height: 80, size: 128, expectedWidth: 100, expectedHeight: 80},
+		{name: "extreme ratio keeps one pixel", width: 5000, height: 2, size: 100, expectedWidth: 100, expectedHeight: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			dst := resizeToFit(src, tt.size)
+			assert.Equal(t, tt.expectedWidth, dst.Bounds().Dx())
+			assert.Equal(t, tt.expectedHeight, dst.Bounds().Dy())
+		})
+	}
+}
+
+func TestThumbnailCacheName(t *testing.T) {
+	name := thumbnailCacheName("bucket", "a.jpg", `"etag1"`, 128)
+	assert.Len(t, name, 64)
+	assert.Equal(t, name, thumbnailCacheName("bucket", "a.jpg", `"etag1"`, 128))
+	assert.NotEqual(t, name, thumbnailCacheName("bucket", "a.jpg", `"etag2"`, 128))
+	assert.NotEqual(t, name, thumbnailCacheName("bucket", "a.jpg", `"etag1"`, 256))
+}
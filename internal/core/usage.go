@@ -0,0 +1,387 @@
+package core
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"explorer451/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// JobTypeIngestStorageLens is the job type of Storage Lens export ingestion
+const JobTypeIngestStorageLens = "ingest-storage-lens"
+
+// usageDateLayout is the format of the days usage is recorded for
+const usageDateLayout = "2006-01-02"
+
+// storageLensSchema is the column order of Storage Lens CSV exports, which name their
+// columns in the export manifest rather than in a header row
+var storageLensSchema = []string{
+	"version_number", "configuration_id", "report_date", "aws_account_number", "aws_region",
+	"storage_class", "record_type", "record_value", "bucket_name", "metric_name", "metric_value",
+}
+
+var (
+	// ErrStorageLensNotConfigured is returned when ingesting Storage Lens exports
+	// without usage.storageLensBucket set
+	ErrStorageLensNotConfigured = errors.New("storage lens exports are not configured")
+	// ErrInvalidUsageRange is returned for from and to dates that are not YYYY-MM-DD
+	ErrInvalidUsageRange = errors.New("invalid usage date range")
+
+	// errInvalidStorageLensExport is recorded for export files that cannot be read
+	errInvalidStorageLensExport = errors.New("not a Storage Lens CSV export")
+)
+
+// UsageService keeps the size of each bucket over time, one point per day. Points come
+// from storage reports and cost estimates that count a whole bucket, and from S3
+// Storage Lens exports, whose figures take precedence for the days they cover.
+type UsageService struct {
+	core *Core
+
+	mu sync.Mutex
+	// history maps buckets to their points by date
+	history map[string]map[string]models.UsagePoint
+}
+
+// NewUsageService creates a new UsageService, restoring the history saved by the
+// previous run
+func NewUsageService(core *Core) *UsageService {
+	u := &UsageService{
+		core:    core,
+		history: make(map[string]map[string]models.UsagePoint),
+	}
+
+	if err := core.restoreState("usage", core.Config.Usage.StateFile, &u.history); err != nil {
+		core.Logger.Warn().
+			Err(err).
+			Msg("Failed to restore usage history")
+	}
+	return u
+}
+
+// Record notes the size of a bucket counted today. A Storage Lens figure for today,
+// should one have been ingested, is kept.
+func (u *UsageService) Record(bucket string, objects, bytes int64) {
+	date := time.Now().UTC().Format(usageDateLayout)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	days, ok := u.history[bucket]
+	if !ok {
+		days = make(map[string]models.UsagePoint)
+		u.history[bucket] = days
+	}
+	if existing, ok := days[date]; ok && existing.Source == models.UsageSourceStorageLens {
+		return
+	}
+	days[date] = models.UsagePoint{Date: date, Objects: objects, Bytes: bytes, Source: models.UsageSourceReport}
+
+	if err := u.saveLocked(); err != nil {
+		u.core.Logger.Warn().
+			Err(err).
+			Str("bucket", bucket).
+			Msg("Failed to save usage history")
+	}
+}
+
+// Series returns a bucket's history between from and to, inclusive; either may be
+// empty to leave that end open
+func (u *UsageService) Series(bucket, from, to string) (models.UsageSeries, error) {
+	if err := validUsageRange(from, to); err != nil {
+		return models.UsageSeries{}, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.seriesLocked(bucket, from, to), nil
+}
+
+// All returns the history of every bucket between from and to, see Series
+func (u *UsageService) All(from, to string) (models.UsageResponse, error) {
+	if err := validUsageRange(from, to); err != nil {
+		return models.UsageResponse{}, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	response := models.UsageResponse{From: from, To: to, Buckets: []models.UsageSeries{}}
+	for _, bucket := range slices.Sorted(maps.Keys(u.history)) {
+		if series := u.seriesLocked(bucket, from, to); len(series.Points) > 0 {
+			response.Buckets = append(response.Buckets, series)
+		}
+	}
+	return response, nil
+}
+
+// seriesLocked collects a bucket's points within a validated range, oldest first
+func (u *UsageService) seriesLocked(bucket, from, to string) models.UsageSeries {
+	series := models.UsageSeries{Bucket: bucket, Points: []models.UsagePoint{}}
+	// Dates in YYYY-MM-DD order as strings
+	for _, date := range slices.Sorted(maps.Keys(u.history[bucket])) {
+		if (from != "" && date < from) || (to != "" && date > to) {
+			continue
+		}
+		series.Points = append(series.Points, u.history[bucket][date])
+	}
+	return series
+}
+
+// IngestStorageLens starts a job reading the Storage Lens CSV exports under
+// usage.storageLensPrefix. Every run reads all exports, so files added to a day that
+// was already ingested are accounted for.
+func (u *UsageService) IngestStorageLens() (models.Job, error) {
+	bucket := u.core.Config.Usage.StorageLensBucket
+	prefix := u.core.Config.Usage.StorageLensPrefix
+	if bucket == "" {
+		return models.Job{}, ErrStorageLensNotConfigured
+	}
+
+	params := map[string]string{"bucket": bucket, "prefix": prefix}
+	return u.core.Jobs.Submit(JobTypeIngestStorageLens, params, func(ctx context.Context, job *Job) error {
+		return u.ingestStorageLens(ctx, job, bucket, prefix)
+	}), nil
+}
+
+// ingestStorageLens sums the bucket sizes in every export file and records them
+func (u *UsageService) ingestStorageLens(ctx context.Context, job *Job, bucket, prefix string) error {
+	var keys []string
+	_, err := u.core.S3Service.scanPrefix(ctx, bucket, prefix, func(obj s3Types.Object) {
+		key := strings.ToLower(aws.ToString(obj.Key))
+		// Parquet exports are not supported
+		if strings.HasSuffix(key, ".csv") || strings.HasSuffix(key, ".csv.gz") {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	job.AddTotal(int64(len(keys)))
+
+	totals := make(map[string]map[string]*models.UsagePoint)
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		size, err := u.readStorageLensExport(ctx, bucket, key, totals)
+		if err != nil {
+			job.ItemFailed(key, err)
+			continue
+		}
+		job.ItemSucceeded(key, size)
+	}
+
+	points := u.mergeStorageLens(totals)
+	u.core.Logger.Info().
+		Str("bucket", bucket).
+		Str("prefix", prefix).
+		Int("files", len(keys)).
+		Int("points", points).
+		Msg("Ingested Storage Lens exports")
+	return nil
+}
+
+// readStorageLensExport adds the bucket-level object counts and sizes in one export
+// file to totals, and returns the file's size
+func (u *UsageService) readStorageLensExport(ctx context.Context, bucket, key string, totals map[string]map[string]*models.UsagePoint) (int64, error) {
+	output, err := u.core.Client(bucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer output.Body.Close()
+
+	var body io.Reader = output.Body
+	if isGzipObject(aws.ToString(output.ContentEncoding), aws.ToString(output.ContentType), key) {
+		gzipReader, err := gzip.NewReader(output.Body)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", errInvalidStorageLensExport, err)
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	columns := newStorageLensColumns(storageLensSchema)
+	// Storage Lens never writes a header row, but exports copied elsewhere may have one
+	first := true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", errInvalidStorageLensExport, err)
+		}
+		if first {
+			first = false
+			if slices.Contains(record, "report_date") {
+				columns = newStorageLensColumns(record)
+				continue
+			}
+		}
+
+		row, ok := columns.bucketMetric(record)
+		if !ok {
+			continue
+		}
+		days, ok := totals[row.bucket]
+		if !ok {
+			days = make(map[string]*models.UsagePoint)
+			totals[row.bucket] = days
+		}
+		point, ok := days[row.date]
+		if !ok {
+			point = &models.UsagePoint{Date: row.date, Source: models.UsageSourceStorageLens}
+			days[row.date] = point
+		}
+		// Rows are broken down by storage class and region
+		if row.metric == "ObjectCount" {
+			point.Objects += row.value
+		} else {
+			point.Bytes += row.value
+		}
+	}
+	return aws.ToInt64(output.ContentLength), nil
+}
+
+// mergeStorageLens records ingested points, replacing any for the same days, and
+// returns how many were kept
+func (u *UsageService) mergeStorageLens(totals map[string]map[string]*models.UsagePoint) int {
+	oldest := u.oldestDate()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	kept := 0
+	for bucket, points := range totals {
+		days, ok := u.history[bucket]
+		if !ok {
+			days = make(map[string]models.UsagePoint)
+			u.history[bucket] = days
+		}
+		for date, point := range points {
+			if date < oldest {
+				continue
+			}
+			days[date] = *point
+			kept++
+		}
+	}
+
+	if err := u.saveLocked(); err != nil {
+		u.core.Logger.Warn().
+			Err(err).
+			Msg("Failed to save usage history")
+	}
+	return kept
+}
+
+// oldestDate is the first day within usage.retentionDays
+func (u *UsageService) oldestDate() string {
+	return time.Now().UTC().AddDate(0, 0, -u.core.Config.Usage.RetentionDays).Format(usageDateLayout)
+}
+
+// pruneLocked drops points from before oldest
+func (u *UsageService) pruneLocked(oldest string) {
+	for bucket, days := range u.history {
+		maps.DeleteFunc(days, func(date string, _ models.UsagePoint) bool {
+			return date < oldest
+		})
+		if len(days) == 0 {
+			delete(u.history, bucket)
+		}
+	}
+}
+
+// saveLocked persists the history
+func (u *UsageService) saveLocked() error {
+	u.pruneLocked(u.oldestDate())
+	return u.core.persistState("usage", u.core.Config.Usage.StateFile, u.history)
+}
+
+// storageLensRow is a bucket-level size metric read from an export
+type storageLensRow struct {
+	bucket string
+	date   string
+	metric string
+	value  int64
+}
+
+// storageLensColumns maps the columns of an export to their positions
+type storageLensColumns map[string]int
+
+// newStorageLensColumns maps column names, in order, to their positions
+func newStorageLensColumns(names []string) storageLensColumns {
+	columns := make(storageLensColumns, len(names))
+	for i, name := range names {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return columns
+}
+
+// bucketMetric reads a record's object count or size metric for a bucket, skipping
+// other metrics, account and prefix records, and records it cannot read
+func (c storageLensColumns) bucketMetric(record []string) (storageLensRow, bool) {
+	field := func(name string) string {
+		if i, ok := c[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := storageLensRow{bucket: field("bucket_name"), metric: field("metric_name")}
+	if field("record_type") != "BUCKET" || row.bucket == "" {
+		return row, false
+	}
+	if row.metric != "ObjectCount" && row.metric != "StorageBytes" {
+		return row, false
+	}
+	date, err := time.Parse(usageDateLayout, field("report_date"))
+	if err != nil {
+		return row, false
+	}
+	value, err := strconv.ParseFloat(field("metric_value"), 64)
+	if err != nil {
+		return row, false
+	}
+	row.date = date.Format(usageDateLayout)
+	row.value = int64(value)
+	return row, true
+}
+
+// validUsageRange checks that from and to, when set, are YYYY-MM-DD dates
+func validUsageRange(from, to string) error {
+	return validDateRange(ErrInvalidUsageRange, from, to)
+}
+
+// validDateRange checks that from and to, when set, are YYYY-MM-DD dates, wrapping
+// sentinel in the error for one that is not
+func validDateRange(sentinel error, from, to string) error {
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(usageDateLayout, date); err != nil {
+			return fmt.Errorf("%w: %q is not a YYYY-MM-DD date", sentinel, date)
+		}
+	}
+	return nil
+}
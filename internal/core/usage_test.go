@@ -0,0 +1,101 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUsageCore fakes a bucket of Storage Lens exports under lens/
+func newTestUsageCore(t *testing.T, files map[string][]byte) *Core {
+	cfg := &config.Config{
+		Jobs:    config.JobsConfig{MaxConcurrent: 1, Retention: 10},
+		Reports: config.ReportsConfig{MaxObjects: 100},
+		Usage:   config.UsageConfig{StorageLensBucket: "exports", StorageLensPrefix: "lens/", RetentionDays: 10000},
+	}
+	c := newTestS3Core(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/exports/")
+		if r.URL.Query().Has("list-type") {
+			w.Header().Set("Content-Type", "application/xml")
+			var contents strings.Builder
+			for name := range files {
+				fmt.Fprintf(&contents, `<Contents><Key>%s</Key></Contents>`, name)
+			}
+			fmt.Fprintf(w, `<ListBucketResult><IsTruncated>false</IsTruncated>%s</ListBucketResult>`, contents.String())
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(files[key])))
+		_, _ = w.Write(files[key])
+	})
+	c.Jobs = NewJobManager(c)
+	c.Usage = NewUsageService(c)
+	return c
+}
+
+func TestUsageRecord(t *testing.T) {
+	c := newTestUsageCore(t, nil)
+	today := time.Now().UTC().Format(usageDateLayout)
+
+	c.Usage.Record("docs", 3, 300)
+	c.Usage.Record("docs", 4, 400)
+	series, err := c.Usage.Series("docs", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []models.UsagePoint{{Date: today, Objects: 4, Bytes: 400, Source: models.UsageSourceReport}}, series.Points)
+
+	series, err = c.Usage.Series("docs", "", "2000-01-01")
+	require.NoError(t, err)
+	assert.Empty(t, series.Points)
+
+	_, err = c.Usage.All("yesterday", "")
+	assert.ErrorIs(t, err, ErrInvalidUsageRange)
+}
+
+func TestIngestStorageLens(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte("version_number,configuration_id,report_date,aws_account_number,aws_region,storage_class,record_type,record_value,bucket_name,metric_name,metric_value\n" +
+		"1.0,dash,2024-03-02,123,us-east-1,STANDARD,BUCKET,,docs,StorageBytes,5000\n" +
+		"1.0,dash,2024-03-02,123,us-east-1,STANDARD,BUCKET,,docs,ObjectCount,5\n"))
+	require.NoError(t, gz.Close())
+
+	c := newTestUsageCore(t, map[string][]byte{
+		"lens/dt=2024-03-01/a.csv": []byte(
+			"1.0,dash,2024-03-01,123,us-east-1,STANDARD,BUCKET,,docs,StorageBytes,1000\n" +
+				"1.0,dash,2024-03-01,123,us-east-1,GLACIER,BUCKET,,docs,StorageBytes,500.0\n" +
+				"1.0,dash,2024-03-01,123,us-east-1,STANDARD,BUCKET,,docs,ObjectCount,2\n" +
+				"1.0,dash,2024-03-01,123,us-east-1,STANDARD,BUCKET,,docs,DeleteMarkerCount,9\n" +
+				"1.0,dash,2024-03-01,123,us-east-1,STANDARD,ACCOUNT,,,StorageBytes,99999\n"),
+		"lens/dt=2024-03-02/b.csv.gz":  compressed.Bytes(),
+		"lens/dt=2024-03-02/c.parquet": []byte("PAR1"),
+		"lens/dt=2024-03-03/d.csv.gz":  []byte("not gzip"),
+	})
+
+	submitted, err := c.Usage.IngestStorageLens()
+	require.NoError(t, err)
+	job := waitForJob(t, c.Jobs, submitted.ID)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, int64(3), job.Progress.Total, "parquet files are skipped")
+	assert.Equal(t, int64(1), job.Progress.Failed)
+
+	usage, err := c.Usage.All("", "")
+	require.NoError(t, err)
+	require.Len(t, usage.Buckets, 1)
+	assert.Equal(t, []models.UsagePoint{
+		{Date: "2024-03-01", Objects: 2, Bytes: 1500, Source: models.UsageSourceStorageLens},
+		{Date: "2024-03-02", Objects: 5, Bytes: 5000, Source: models.UsageSourceStorageLens},
+	}, usage.Buckets[0].Points)
+
+	c.Config.Usage.StorageLensBucket = ""
+	_, err = c.Usage.IngestStorageLens()
+	assert.ErrorIs(t, err, ErrStorageLensNotConfigured)
+}
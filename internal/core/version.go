@@ -0,0 +1,58 @@
+package core
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"explorer451/internal/models"
+)
+
+// BuildInfo identifies the build, as injected at link time
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// VersionInfo reports the build along with the enabled features and configured backends
+func (c *Core) VersionInfo() models.VersionInfo {
+	cfg := c.Config
+	build := c.Build
+
+	// Builds made without the Makefile still record the commit they were built from
+	if build.Commit == "" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					build.Commit = setting.Value
+				}
+			}
+		}
+	}
+
+	thumbnailCache := "disk"
+	if cfg.Thumbnails.CachePrefix != "" {
+		thumbnailCache = "bucket"
+	}
+
+	return models.VersionInfo{
+		Version:   build.Version,
+		Commit:    build.Commit,
+		BuildDate: build.Date,
+		GoVersion: runtime.Version(),
+		Profile:   cfg.Profile,
+		Features: map[string]bool{
+			"uploads":        cfg.Features.Uploads,
+			"edits":          cfg.Features.Edits,
+			"deletes":        cfg.Features.Deletes,
+			"proxyDownloads": cfg.Features.ProxyDownloads,
+		},
+		Backends: models.VersionBackends{
+			S3Region:       cfg.AWS.Region,
+			ThumbnailCache: thumbnailCache,
+			Antivirus:      cfg.Antivirus.Enabled,
+			FFprobe:        cfg.Media.FFprobe.Enabled,
+			PDF:            cfg.PDF.Enabled,
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"runtime"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionInfo(t *testing.T) {
+	c := &Core{
+		Config: &config.Config{
+			Profile:    "prod",
+			AWS:        config.AWSConfig{Region: "eu-west-1"},
+			Features:   config.FeaturesConfig{Uploads: true, ProxyDownloads: true},
+			Thumbnails: config.ThumbnailConfig{CachePrefix: ".thumbnails/"},
+			PDF:        config.PDFConfig{Enabled: true},
+		},
+		Build: BuildInfo{Version: "v1.4.0", Commit: "abc1234", Date: "2025-01-02T03:04:05Z"},
+	}
+
+	info := c.VersionInfo()
+
+	assert.Equal(t, "v1.4.0", info.Version)
+	assert.Equal(t, "abc1234", info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, "prod", info.Profile)
+	assert.Equal(t, map[string]bool{"uploads": true, "edits": false, "deletes": false, "proxyDownloads": true}, info.Features)
+	assert.Equal(t, "eu-west-1", info.Backends.S3Region)
+	assert.Equal(t, "bucket", info.Backends.ThumbnailCache)
+	assert.True(t, info.Backends.PDF)
+	assert.False(t, info.Backends.Antivirus)
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"errors"
+	"path"
+	"slices"
+	"strings"
+
+	"explorer451/internal/config"
+	"explorer451/internal/models"
+)
+
+var (
+	// ErrNoWorkspace is returned for users who are not a member of any workspace
+	ErrNoWorkspace = errors.New("not a member of any workspace")
+	// ErrWorkspaceNotFound is returned for workspaces that do not exist or that the user
+	// is not a member of
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+)
+
+// WorkspaceService decides which workspaces users belong to and what each workspace
+// may see. Workspaces are defined in the workspaces setting; members are the users and
+// teams listed there, plus the users and teams created in the workspace.
+type WorkspaceService struct {
+	core *Core
+}
+
+// NewWorkspaceService creates a new WorkspaceService
+func NewWorkspaceService(core *Core) *WorkspaceService {
+	return &WorkspaceService{core: core}
+}
+
+// Enabled reports whether any workspaces are configured. Without them the deployment
+// serves a single tenant and nothing is scoped.
+func (w *WorkspaceService) Enabled() bool {
+	return len(w.core.Config.Workspaces) > 0
+}
+
+// For returns the workspaces a user is a member of, in the order they are configured
+func (w *WorkspaceService) For(user string) []models.Workspace {
+	workspaces := []models.Workspace{}
+	for _, cfg := range w.core.Config.Workspaces {
+		if w.HasUser(cfg.Name, user) {
+			workspaces = append(workspaces, workspaceModel(cfg))
+		}
+	}
+	return workspaces
+}
+
+// Resolve returns the workspace a user's request is answered in: the named one, or
+// the first the user is a member of when name is empty
+func (w *WorkspaceService) Resolve(user, name string) (models.Workspace, error) {
+	workspaces := w.For(user)
+	if name == "" {
+		if len(workspaces) == 0 {
+			return models.Workspace{}, ErrNoWorkspace
+		}
+		return workspaces[0], nil
+	}
+	for _, workspace := range workspaces {
+		if workspace.Name == name {
+			return workspace, nil
+		}
+	}
+	return models.Workspace{}, ErrWorkspaceNotFound
+}
+
+// AllowsBucket reports whether a bucket belongs to a workspace
+func (w *WorkspaceService) AllowsBucket(workspace models.Workspace, bucket string) bool {
+	for _, pattern := range workspace.Buckets {
+		if ok, _ := path.Match(pattern, bucket); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// HasUser reports whether a user is a member of a workspace: listed in it, created in
+// it, or a member of one of its teams
+func (w *WorkspaceService) HasUser(workspace, user string) bool {
+	cfg, ok := w.config(workspace)
+	if !ok {
+		return false
+	}
+	if slices.Contains(cfg.Users, user) {
+		return true
+	}
+
+	record, err := w.core.Access.User(user)
+	if err != nil {
+		return false
+	}
+	if record.Workspace == workspace {
+		return true
+	}
+	for _, name := range record.Teams {
+		if team, err := w.core.Access.Team(name); err == nil && w.HasTeam(workspace, team) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTeam reports whether a team belongs to a workspace: listed in it or created in it
+func (w *WorkspaceService) HasTeam(workspace string, team models.Team) bool {
+	if team.Workspace == workspace {
+		return true
+	}
+	cfg, ok := w.config(workspace)
+	return ok && slices.Contains(cfg.Teams, team.Name)
+}
+
+// EffectivePermissions returns the permissions a user holds in a workspace. Roles
+// assigned to the user directly apply in every workspace; roles of teams only in the
+// workspaces the team belongs to.
+func (w *WorkspaceService) EffectivePermissions(workspace, user string) (models.EffectivePermissions, error) {
+	effective, err := w.core.Access.EffectivePermissions(user)
+	if err != nil {
+		return models.EffectivePermissions{}, err
+	}
+
+	effective.Roles = slices.DeleteFunc(effective.Roles, func(grant models.RoleGrant) bool {
+		name, viaTeam := strings.CutPrefix(grant.Via, "team:")
+		if !viaTeam {
+			return false
+		}
+		team, err := w.core.Access.Team(name)
+		return err != nil || !w.HasTeam(workspace, team)
+	})
+	if !effective.Disabled {
+		effective.Permissions = permissionsOf(effective.Roles)
+	}
+	return effective, nil
+}
+
+// config returns the settings of a workspace
+func (w *WorkspaceService) config(name string) (config.WorkspaceConfig, bool) {
+	for _, cfg := range w.core.Config.Workspaces {
+		if cfg.Name == name {
+			return cfg, true
+		}
+	}
+	return config.WorkspaceConfig{}, false
+}
+
+// workspaceModel converts a workspace's settings for the API
+func workspaceModel(cfg config.WorkspaceConfig) models.Workspace {
+	buckets := slices.Clone(cfg.Buckets)
+	if buckets == nil {
+		buckets = []string{}
+	}
+	return models.Workspace{Name: cfg.Name, Description: cfg.Description, Buckets: buckets}
+}
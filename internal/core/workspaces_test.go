@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+
+	"explorer451/internal/config"
+	"explorer451/internal/logger"
+	"explorer451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceService(t *testing.T) {
+	c := &Core{
+		Config: &config.Config{Workspaces: []config.WorkspaceConfig{
+			{Name: "finance", Buckets: []string{"nb-fin-*", "nb-reports"}, Users: []string{"alice"}, Teams: []string{"controllers"}},
+			{Name: "marketing", Buckets: []string{"nb-mkt-*"}, Users: []string{"alice"}},
+		}},
+		Logger: logger.New("error", "json"),
+	}
+	c.Access = NewAccessService(c)
+	c.Workspaces = NewWorkspaceService(c)
+	w := c.Workspaces
+
+	_, err := c.Access.CreateUser(models.CreateUserRequest{Name: "bob", Roles: []string{"viewer"}})
+	require.NoError(t, err)
+	_, err = c.Access.CreateUser(models.CreateUserRequest{Name: "carol", Workspace: "marketing"})
+	require.NoError(t, err)
+	_, err = c.Access.CreateTeam(models.CreateTeamRequest{Name: "controllers", Members: []string{"bob"}, Roles: []string{"editor"}})
+	require.NoError(t, err)
+	_, err = c.Access.CreateTeam(models.CreateTeamRequest{Name: "campaigns", Members: []string{"bob"}, Roles: []string{"admin"}, Workspace: "marketing"})
+	require.NoError(t, err)
+
+	// Members are listed, created in the workspace, or members of its teams
+	assert.True(t, w.HasUser("finance", "alice"))
+	assert.True(t, w.HasUser("finance", "bob"))
+	assert.False(t, w.HasUser("finance", "carol"))
+	assert.True(t, w.HasUser("marketing", "carol"))
+	assert.False(t, w.HasUser("finance", "mallory"))
+
+	workspace, err := w.Resolve("alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, "finance", workspace.Name)
+	workspace, err = w.Resolve("alice", "marketing")
+	require.NoError(t, err)
+	assert.Equal(t, "marketing", workspace.Name)
+	_, err = w.Resolve("carol", "finance")
+	assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+	_, err = w.Resolve("mallory", "")
+	assert.ErrorIs(t, err, ErrNoWorkspace)
+
+	finance, _ := w.Resolve("alice", "finance")
+	assert.True(t, w.AllowsBucket(finance, "nb-fin-prod"))
+	assert.True(t, w.AllowsBucket(finance, "nb-reports"))
+	assert.False(t, w.AllowsBucket(finance, "nb-mkt-assets"))
+
+	// Team roles only apply in the team's workspace; direct roles apply everywhere
+	effective, err := w.EffectivePermissions("finance", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, []models.RoleGrant{{Role: "viewer", Via: "user"}, {Role: "editor", Via: "team:controllers"}}, effective.Roles)
+	assert.NotContains(t, effective.Permissions, models.PermissionAdmin)
+	effective, err = w.EffectivePermissions("marketing", "bob")
+	require.NoError(t, err)
+	assert.Contains(t, effective.Permissions, models.PermissionAdmin)
+	assert.NotContains(t, effective.Roles, models.RoleGrant{Role: "editor", Via: "team:controllers"})
+}
@@ -0,0 +1,114 @@
+package models
+
+import "time"
+
+// Permissions granted by roles
+const (
+	PermissionRead   = "read"
+	PermissionUpload = "upload"
+	PermissionEdit   = "edit"
+	PermissionDelete = "delete"
+	PermissionShare  = "share"
+	PermissionAdmin  = "admin"
+)
+
+// Role is a named set of permissions
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// User is a person known to the explorer, named as the authenticating proxy names them
+// in server.userHeader
+type User struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+	// Disabled users keep their record but have no permissions
+	Disabled bool `json:"disabled"`
+	// Roles are assigned to the user directly; teams may add more
+	Roles []string `json:"roles"`
+	// Teams lists the teams the user is a member of
+	Teams []string `json:"teams"`
+	// Workspace is the workspace the user was created in, which makes them a member
+	Workspace string    `json:"workspace,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Team is a group of users that share roles
+type Team struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Members     []string `json:"members"`
+	Roles       []string `json:"roles"`
+	// Workspace is the workspace the team was created in; its roles only apply there
+	Workspace string    `json:"workspace,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CreateUserRequest is the body of a user creation request
+type CreateUserRequest struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Roles       []string `json:"roles"`
+	// Workspace is set by the API to the caller's workspace
+	Workspace string `json:"-"`
+}
+
+// UpdateUserRequest changes the fields of a user that are set
+type UpdateUserRequest struct {
+	DisplayName *string   `json:"displayName"`
+	Disabled    *bool     `json:"disabled"`
+	Roles       *[]string `json:"roles"`
+}
+
+// CreateTeamRequest is the body of a team creation request
+type CreateTeamRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Members     []string `json:"members"`
+	Roles       []string `json:"roles"`
+	// Workspace is set by the API to the caller's workspace
+	Workspace string `json:"-"`
+}
+
+// UpdateTeamRequest changes the fields of a team that are set, and adds and removes
+// members
+type UpdateTeamRequest struct {
+	Description   *string   `json:"description"`
+	Roles         *[]string `json:"roles"`
+	AddMembers    []string  `json:"addMembers"`
+	RemoveMembers []string  `json:"removeMembers"`
+}
+
+// RoleGrant is a role a user holds, and how they got it: "user" for a direct
+// assignment or "team:<name>" through a team
+type RoleGrant struct {
+	Role string `json:"role"`
+	Via  string `json:"via"`
+}
+
+// EffectivePermissions are the permissions a user ends up with from all their roles
+type EffectivePermissions struct {
+	User        string      `json:"user"`
+	Disabled    bool        `json:"disabled"`
+	Roles       []RoleGrant `json:"roles"`
+	Permissions []string    `json:"permissions"`
+}
+
+// UsersResponse lists users
+type UsersResponse struct {
+	Users []User `json:"users"`
+}
+
+// TeamsResponse lists teams
+type TeamsResponse struct {
+	Teams []Team `json:"teams"`
+}
+
+// RolesResponse lists the roles that can be assigned
+type RolesResponse struct {
+	Roles []Role `json:"roles"`
+}
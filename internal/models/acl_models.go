@@ -0,0 +1,35 @@
+package models
+
+// ACLGrantee identifies who an ACL grant is for: a canonical user ID, a predefined group
+// URI, or an email address
+type ACLGrantee struct {
+	Type        string `json:"type"`
+	ID          string `json:"id,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	URI         string `json:"uri,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
+// ACLGrant is one permission given to a grantee
+type ACLGrant struct {
+	Grantee    ACLGrantee `json:"grantee"`
+	Permission string     `json:"permission"`
+}
+
+// ObjectACL is an object's access control list
+type ObjectACL struct {
+	Owner  ACLGrantee `json:"owner"`
+	Grants []ACLGrant `json:"grants"`
+	// ACLsEnabled is false when the bucket's object ownership setting disables ACLs, in
+	// which case the ACL only shows the owner and cannot be changed
+	ACLsEnabled bool `json:"aclsEnabled"`
+	// Warnings point out grants that make the object public
+	Warnings []string `json:"warnings"`
+}
+
+// PutObjectACLRequest replaces an object's ACL with a canned ACL, or with the given
+// grants when CannedACL is empty
+type PutObjectACLRequest struct {
+	CannedACL string     `json:"cannedAcl,omitempty"`
+	Grants    []ACLGrant `json:"grants,omitempty"`
+}
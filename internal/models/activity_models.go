@@ -0,0 +1,32 @@
+package models
+
+// ActivityPoint counts the requests to a prefix on one day
+type ActivityPoint struct {
+	// Date is the day of the requests, as YYYY-MM-DD in UTC
+	Date   string `json:"date"`
+	Reads  int64  `json:"reads"`
+	Writes int64  `json:"writes"`
+}
+
+// PrefixActivity is the request history of a prefix
+type PrefixActivity struct {
+	Prefix string `json:"prefix"`
+	// Reads and Writes total the points in the requested range
+	Reads  int64 `json:"reads"`
+	Writes int64 `json:"writes"`
+	// LastRead and LastWrite are the latest days with any, in the whole history
+	LastRead  string          `json:"lastRead,omitempty"`
+	LastWrite string          `json:"lastWrite,omitempty"`
+	Points    []ActivityPoint `json:"points"`
+}
+
+// ActivityResponse is the request history of the prefixes in a bucket, least read first
+type ActivityResponse struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	// IngestedThrough is the delivery time of the newest log file ingested
+	IngestedThrough string           `json:"ingestedThrough,omitempty"`
+	Prefixes        []PrefixActivity `json:"prefixes"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Annotation is a note attached to an object, or to a prefix when Key ends in a slash
+type Annotation struct {
+	ID        string    `json:"id"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	Text      string    `json:"text"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateAnnotationRequest is the body of an annotation creation request
+type CreateAnnotationRequest struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Text   string `json:"text"`
+}
+
+// AnnotationsResponse lists annotations
+type AnnotationsResponse struct {
+	Annotations []Annotation `json:"annotations"`
+}
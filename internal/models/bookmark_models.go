@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Bookmark is a saved location: a bucket, or a prefix within one
+type Bookmark struct {
+	ID     string `json:"id"`
+	Bucket string `json:"bucket"`
+	// Prefix is empty for the bucket root and ends in a slash otherwise
+	Prefix    string    `json:"prefix"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateBookmarkRequest is the body of a bookmark creation request
+type CreateBookmarkRequest struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix"`
+	// Name defaults to the last segment of the prefix, or the bucket name
+	Name string `json:"name"`
+}
+
+// BookmarksResponse lists a user's bookmarks
+type BookmarksResponse struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PrefixSize is the total size of everything under a prefix, as last counted by a
+// storage report or cost estimate
+type PrefixSize struct {
+	Objects   int64     `json:"objects"`
+	Bytes     int64     `json:"bytes"`
+	CountedAt time.Time `json:"countedAt"`
+}
+
+// Breadcrumb is one segment of a path, with the number of folders and objects directly
+// inside it
+type Breadcrumb struct {
+	Prefix  string `json:"prefix"`
+	Name    string `json:"name"`
+	Folders int    `json:"folders"`
+	Objects int    `json:"objects"`
+	// Truncated is set when the folder has more entries than were counted, so the
+	// counts are lower bounds
+	Truncated bool `json:"truncated,omitempty"`
+	// Size is omitted unless a recent report counted the whole prefix
+	Size *PrefixSize `json:"size,omitempty"`
+}
+
+// BreadcrumbsResponse lists the segments of a prefix, starting with the bucket itself
+type BreadcrumbsResponse struct {
+	Bucket      string       `json:"bucket"`
+	Prefix      string       `json:"prefix"`
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs"`
+}
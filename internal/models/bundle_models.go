@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DataBundle holds the data users have created in the explorer, for backup and
+// migration between deployments
+type DataBundle struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+	// Bookmarks maps user names to their bookmarks
+	Bookmarks   map[string][]Bookmark `json:"bookmarks"`
+	Shares      []Share               `json:"shares"`
+	Annotations []Annotation          `json:"annotations"`
+}
+
+// ImportResult counts the entries a bundle import added
+type ImportResult struct {
+	Bookmarks   int `json:"bookmarks"`
+	Shares      int `json:"shares"`
+	Annotations int `json:"annotations"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// ObjectChecksums are the checksums S3 stores for an object, as base64 like S3 reports
+// them, and the result of recomputing one of them when that was asked for
+type ObjectChecksums struct {
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	ETag      string `json:"etag"`
+	Size      int64  `json:"size"`
+	// Type is FULL_OBJECT for checksums of the whole content, or COMPOSITE for the
+	// checksum of the parts' checksums S3 stores for most multipart uploads
+	Type      string `json:"type,omitempty"`
+	PartCount int    `json:"partCount,omitempty"`
+	CRC32     string `json:"crc32,omitempty"`
+	CRC32C    string `json:"crc32c,omitempty"`
+	CRC64NVME string `json:"crc64nvme,omitempty"`
+	SHA1      string `json:"sha1,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+
+	Verification *ChecksumVerification `json:"verification,omitempty"`
+}
+
+// ChecksumVerification is a checksum recomputed by reading the object
+type ChecksumVerification struct {
+	Algorithm string `json:"algorithm"`
+	Computed  string `json:"computed"`
+	Stored    string `json:"stored,omitempty"`
+	// Match is left out when there is no stored checksum to compare with, for which
+	// Reason gives the cause
+	Match     *bool  `json:"match,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	BytesRead int64  `json:"bytesRead"`
+}
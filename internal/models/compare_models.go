@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// SettingDifference is one value that differs between the two buckets. Source or
+// Target is null where the value is only set on the other bucket.
+type SettingDifference struct {
+	// Path locates the value, starting with the setting, e.g.
+	// lifecycle.expire-logs.Expiration.Days for a lifecycle rule with ID expire-logs
+	Path   string `json:"path"`
+	Source any    `json:"source"`
+	Target any    `json:"target"`
+}
+
+// SettingComparison compares one configuration setting of two buckets. Source and
+// Target are null for settings that are not configured.
+type SettingComparison struct {
+	// Setting is versioning, encryption, policy, lifecycle or tags
+	Setting     string              `json:"setting"`
+	Equal       bool                `json:"equal"`
+	Source      any                 `json:"source"`
+	Target      any                 `json:"target"`
+	Differences []SettingDifference `json:"differences"`
+	// SourceError and TargetError are set when the setting could not be read, in which
+	// case it is not compared
+	SourceError string `json:"sourceError,omitempty"`
+	TargetError string `json:"targetError,omitempty"`
+}
+
+// BucketComparison compares the configuration of a bucket with another, such as a
+// replica with its source
+type BucketComparison struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Identical is set when every setting could be read and is equal
+	Identical   bool                `json:"identical"`
+	Settings    []SettingComparison `json:"settings"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// ConfigExport is the effective configuration with secrets redacted
+type ConfigExport struct {
+	Profile string         `json:"profile,omitempty"`
+	Config  map[string]any `json:"config"`
+	// Sources maps explicitly set settings to config.yml, a profile or the environment;
+	// settings that are not listed have their default value
+	Sources map[string]string `json:"sources"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// ObjectConflict is the response to an upload or copy refused because its target key
+// already exists
+type ObjectConflict struct {
+	Message string `json:"message"`
+	Bucket  string `json:"bucket"`
+	Key     string `json:"key"`
+	// Alternatives lists the conflict strategies the request could be retried with,
+	// and the key each would write
+	Alternatives []ConflictAlternative `json:"alternatives"`
+}
+
+// ConflictAlternative is a conflict strategy that lets a refused write through
+type ConflictAlternative struct {
+	Conflict string `json:"conflict"`
+	Key      string `json:"key"`
+}
@@ -0,0 +1,44 @@
+package models
+
+// CopyObjectRequest copies an object server-side to another key, in the same bucket or
+// another one
+type CopyObjectRequest struct {
+	Key string `json:"key"`
+	// TargetBucket defaults to the source bucket
+	TargetBucket string `json:"targetBucket,omitempty"`
+	TargetKey    string `json:"targetKey"`
+	KMSKeyID     string `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do when the target key exists: fail, overwrite or rename;
+	// defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// CopyFolderRequest copies every object under a prefix to another prefix, in the same
+// or another bucket
+type CopyFolderRequest struct {
+	Prefix string `json:"prefix"`
+	// TargetBucket defaults to the source bucket
+	TargetBucket string `json:"targetBucket,omitempty"`
+	TargetPrefix string `json:"targetPrefix"`
+	KMSKeyID     string `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do with each object whose target key exists: fail,
+	// overwrite or rename; defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// CopyObjectResponse describes the object a copy or rename created
+type CopyObjectResponse struct {
+	Bucket string         `json:"bucket"`
+	Object ObjectMetadata `json:"object"`
+}
+
+// RenameObjectRequest moves an object to another key, in the same bucket or another one
+type RenameObjectRequest struct {
+	// TargetBucket defaults to the object's bucket
+	TargetBucket string `json:"targetBucket,omitempty"`
+	TargetKey    string `json:"targetKey"`
+	KMSKeyID     string `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do when the target key exists: fail, overwrite or rename;
+	// defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
+}
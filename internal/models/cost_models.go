@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// CostEstimate is the estimated monthly storage cost of a bucket or prefix
+type CostEstimate struct {
+	Bucket      string  `json:"bucket"`
+	Prefix      string  `json:"prefix"`
+	Currency    string  `json:"currency"`
+	Objects     int64   `json:"objects"`
+	Bytes       int64   `json:"bytes"`
+	MonthlyCost float64 `json:"monthlyCost"`
+	// StorageClasses breaks the estimate down by storage class, largest first
+	StorageClasses []StorageClassCost `json:"storageClasses"`
+	// Truncated is set when the prefix holds more objects than reports.maxObjects, in
+	// which case the estimate covers only the first of them
+	Truncated bool `json:"truncated"`
+	// ScanLimit is the most objects listed for the estimate
+	ScanLimit   int       `json:"scanLimit"`
+	EstimatedAt time.Time `json:"estimatedAt"`
+}
+
+// StorageClassCost is the part of a cost estimate for one storage class
+type StorageClassCost struct {
+	StorageClass string  `json:"storageClass"`
+	Objects      int64   `json:"objects"`
+	Bytes        int64   `json:"bytes"`
+	PerGBMonth   float64 `json:"perGbMonth"`
+	MonthlyCost  float64 `json:"monthlyCost"`
+	// Priced is false for storage classes missing from the price table, which are
+	// left out of the total
+	Priced bool `json:"priced"`
+}
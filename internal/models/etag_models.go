@@ -0,0 +1,37 @@
+package models
+
+// PartRange is one part of a multipart upload: the byte range of the file it holds
+type PartRange struct {
+	Number int   `json:"number"`
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+}
+
+// MultipartETagRequest asks for the ETag S3 gives a multipart upload with the given
+// part MD5s, and for the parts a file of Size bytes is split into with PartSize
+type MultipartETagRequest struct {
+	// PartMD5s are the hex MD5 digests of the parts, in order
+	PartMD5s []string `json:"partMd5s,omitempty"`
+	Size     int64    `json:"size,omitempty"`
+	PartSize int64    `json:"partSize,omitempty"`
+}
+
+// MultipartETagResponse is the expected ETag and, when a size was given, the parts
+type MultipartETagResponse struct {
+	ETag      string      `json:"etag,omitempty"`
+	PartCount int         `json:"partCount"`
+	Parts     []PartRange `json:"parts,omitempty"`
+}
+
+// ObjectParts describes how an object was uploaded, so a local file can be hashed the
+// same way to compare it with the object's ETag
+type ObjectParts struct {
+	ETag string `json:"etag"`
+	Size int64  `json:"size"`
+	// Multipart is false for objects uploaded in one request, whose ETag is the MD5 of
+	// the content unless they are encrypted with SSE-KMS or SSE-C
+	Multipart bool        `json:"multipart"`
+	PartCount int         `json:"partCount"`
+	PartSize  int64       `json:"partSize,omitempty"`
+	Parts     []PartRange `json:"parts,omitempty"`
+}
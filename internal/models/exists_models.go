@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ObjectExistence tells whether an object exists, with its size, ETag and last
+// modification time when it does
+type ObjectExistence struct {
+	Bucket       string     `json:"bucket"`
+	Key          string     `json:"key"`
+	VersionID    string     `json:"versionId,omitempty"`
+	Exists       bool       `json:"exists"`
+	Size         int64      `json:"size,omitempty"`
+	ETag         string     `json:"etag,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+}
+
+// PresignedHeadURL is a presigned URL for a HEAD request on an object, which lets the
+// holder check the object's presence and metadata without downloading it
+type PresignedHeadURL struct {
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
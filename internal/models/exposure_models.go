@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Levels of exposure findings
+const (
+	// ExposurePublic marks settings that make data readable or writable by anyone
+	ExposurePublic = "public"
+	// ExposureWarning marks settings that allow data to be made public
+	ExposureWarning = "warning"
+	// ExposureUnknown marks settings that could not be read
+	ExposureUnknown = "unknown"
+)
+
+// ExposureFinding is one setting that exposes, or could expose, a bucket or object
+type ExposureFinding struct {
+	Level string `json:"level"`
+	// Source is the setting the finding comes from: publicAccessBlock, bucketPolicy,
+	// bucketAcl or objectAcl
+	Source  string `json:"source"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+// PublicAccessBlock is a bucket's Block Public Access configuration
+type PublicAccessBlock struct {
+	Configured            bool `json:"configured"`
+	BlockPublicAcls       bool `json:"blockPublicAcls"`
+	IgnorePublicAcls      bool `json:"ignorePublicAcls"`
+	BlockPublicPolicy     bool `json:"blockPublicPolicy"`
+	RestrictPublicBuckets bool `json:"restrictPublicBuckets"`
+}
+
+// ExposureReport lists what makes a bucket, or objects under a prefix, effectively
+// public
+type ExposureReport struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+	// Public is set when any finding makes data public
+	Public            bool              `json:"public"`
+	PublicAccessBlock PublicAccessBlock `json:"publicAccessBlock"`
+	ACLsEnabled       bool              `json:"aclsEnabled"`
+	Findings          []ExposureFinding `json:"findings"`
+	// ObjectsChecked counts the object ACLs read; zero when objects were not checked or
+	// their ACLs cannot make them public
+	ObjectsChecked int `json:"objectsChecked"`
+	// Truncated is set when more objects were listed than could be checked
+	Truncated bool `json:"truncated"`
+	// ScanLimit is the most object ACLs read for the report, reports.maxAclChecks
+	ScanLimit   int       `json:"scanLimit,omitempty"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// ExposureSummary is the bucket-level exposure of every bucket
+type ExposureSummary struct {
+	Buckets     []ExposureReport `json:"buckets"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+}
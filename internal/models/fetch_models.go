@@ -0,0 +1,15 @@
+package models
+
+// FetchObjectRequest is the body of a request to import an object from a remote URL
+type FetchObjectRequest struct {
+	// URL is the HTTPS URL the server downloads the object from
+	URL string `json:"url"`
+	Key string `json:"key"`
+	// ContentType overrides the content type the remote server reports
+	ContentType string `json:"contentType,omitempty"`
+	// KMSKeyID encrypts the object with SSE-KMS using this key
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do when the key exists: fail, overwrite or rename;
+	// defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// GarbageReport lists what a bucket, or a prefix within it, holds that nobody uses any
+// more: multipart uploads never completed, whose parts are still billed, folder markers
+// with nothing left under them, and share links that expired or were revoked
+type GarbageReport struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+	// MinAge is how old uploads and folder markers must be to be listed
+	MinAge            string             `json:"minAge"`
+	IncompleteUploads []IncompleteUpload `json:"incompleteUploads"`
+	FolderMarkers     []FolderMarker     `json:"folderMarkers"`
+	EndedShares       []Share            `json:"endedShares"`
+	// Truncated is set when the listing for folder markers stopped at ScanLimit objects;
+	// markers whose folder was not fully listed are then left out
+	Truncated   bool      `json:"truncated"`
+	ScanLimit   int       `json:"scanLimit"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// IncompleteUpload is a multipart upload that was neither completed nor aborted
+type IncompleteUpload struct {
+	Key       string    `json:"key"`
+	UploadID  string    `json:"uploadId"`
+	Initiated time.Time `json:"initiated"`
+}
+
+// FolderMarker is an empty object standing for a folder with nothing else under it
+type FolderMarker struct {
+	Key          string    `json:"key"`
+	LastModified time.Time `json:"lastModified"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Actions in an object's history
+const (
+	HistoryUploaded   = "uploaded"
+	HistoryModified   = "modified"
+	HistoryDeleted    = "deleted"
+	HistoryDownloaded = "downloaded"
+	HistoryShared     = "shared"
+)
+
+// Sources of object history entries
+const (
+	// HistorySourceEvents is the explorer's feed of the changes made through it
+	HistorySourceEvents = "events"
+	// HistorySourceRecent is the users' recent files
+	HistorySourceRecent = "recent"
+	// HistorySourceShares is share links and their accesses
+	HistorySourceShares = "shares"
+	// HistorySourceS3 is the object's own metadata, for changes made outside the explorer
+	HistorySourceS3 = "s3"
+)
+
+// ObjectHistoryEntry is one thing that happened to an object
+type ObjectHistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// User is who uploaded or downloaded the object through the explorer, when known
+	User string `json:"user,omitempty"`
+	// ShareToken is the share link the object was shared or downloaded with
+	ShareToken string `json:"shareToken,omitempty"`
+	// IP is the address a share link was opened from
+	IP     string `json:"ip,omitempty"`
+	Source string `json:"source"`
+}
+
+// ObjectHistory is what is known about an object's provenance, most recent first
+type ObjectHistory struct {
+	Bucket  string               `json:"bucket"`
+	Key     string               `json:"key"`
+	Exists  bool                 `json:"exists"`
+	Entries []ObjectHistoryEntry `json:"entries"`
+}
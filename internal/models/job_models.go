@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Job statuses
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+	// JobStatusInterrupted marks a job stopped by a server shutdown before it finished
+	JobStatusInterrupted = "interrupted"
+)
+
+// Job represents a long-running background operation
+type Job struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Status     string            `json:"status"`
+	Params     map[string]string `json:"params,omitempty"`
+	Progress   JobProgress       `json:"progress"`
+	Error      string            `json:"error,omitempty"`
+	Results    []JobItemResult   `json:"results,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	StartedAt  *time.Time        `json:"startedAt,omitempty"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+}
+
+// JobProgress tracks how many items a job has processed
+type JobProgress struct {
+	Total     int64 `json:"total"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// JobItemResult records the outcome for a single item processed by a job
+type JobItemResult struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ListJobsResponse is the response for listing jobs
+type ListJobsResponse struct {
+	Jobs       []Job      `json:"jobs"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// ExtractArchiveRequest represents the request body for extracting an archive object
+type ExtractArchiveRequest struct {
+	TargetBucket string `json:"targetBucket,omitempty"`
+	TargetPrefix string `json:"targetPrefix"`
+	// KMSKeyID encrypts the extracted objects with SSE-KMS using this key
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+}
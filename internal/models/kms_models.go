@@ -0,0 +1,15 @@
+package models
+
+// KMSKey is a KMS key alias that uploads can be encrypted with
+type KMSKey struct {
+	Alias string `json:"alias"`
+	KeyID string `json:"keyId"`
+	ARN   string `json:"arn"`
+	// AWSManaged marks aliases managed by AWS, such as alias/aws/s3
+	AWSManaged bool `json:"awsManaged"`
+}
+
+// KMSKeysResponse is the response for listing KMS keys
+type KMSKeysResponse struct {
+	Keys []KMSKey `json:"keys"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// ProtectedObject is an object version that Object Lock keeps from being deleted
+type ProtectedObject struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	// Mode is the retention mode, GOVERNANCE or COMPLIANCE, while the retention lasts
+	Mode        string     `json:"mode,omitempty"`
+	RetainUntil *time.Time `json:"retainUntil,omitempty"`
+	LegalHold   bool       `json:"legalHold"`
+}
+
+// DeletePreflight lists the objects of a prospective delete that are protected by Object
+// Lock retention or a legal hold
+type DeletePreflight struct {
+	// LockedBuckets are the buckets with Object Lock enabled; objects in other buckets
+	// cannot be protected and are not checked
+	LockedBuckets  []string          `json:"lockedBuckets"`
+	ObjectsChecked int               `json:"objectsChecked"`
+	Protected      []ProtectedObject `json:"protected"`
+	// Unreadable counts the objects whose Object Lock settings could not be read
+	Unreadable int `json:"unreadable"`
+	// Truncated is set when more objects were listed than could be checked
+	Truncated bool `json:"truncated"`
+	// ScanLimit is the most objects checked for the preflight, reports.maxLockChecks
+	ScanLimit   int       `json:"scanLimit,omitempty"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// RetentionReport is Object Lock evidence for the objects under a prefix, e.g. for a
+// WORM audit: the retention and legal hold of each object's current version
+type RetentionReport struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+	// ObjectLockEnabled is false for buckets without Object Lock, whose objects cannot
+	// be protected and are not listed
+	ObjectLockEnabled bool `json:"objectLockEnabled"`
+	// DefaultRetention is the retention the bucket applies to new objects, if any
+	DefaultRetention *DefaultRetention `json:"defaultRetention,omitempty"`
+	Objects          []ObjectRetention `json:"objects"`
+	Summary          RetentionSummary  `json:"summary"`
+	// Truncated is set when the prefix holds more objects than ScanLimit,
+	// reports.maxLockChecks
+	Truncated   bool      `json:"truncated"`
+	ScanLimit   int       `json:"scanLimit"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// DefaultRetention is a bucket's default Object Lock retention, in days or years
+type DefaultRetention struct {
+	Mode  string `json:"mode"`
+	Days  int32  `json:"days,omitempty"`
+	Years int32  `json:"years,omitempty"`
+}
+
+// ObjectRetention is the Object Lock state of an object's current version
+type ObjectRetention struct {
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	// Mode and RetainUntil are the retention set on the version, even when it has
+	// lapsed; Retained tells whether it was still in effect when the report was made
+	Mode        string     `json:"mode,omitempty"`
+	RetainUntil *time.Time `json:"retainUntil,omitempty"`
+	Retained    bool       `json:"retained"`
+	LegalHold   bool       `json:"legalHold"`
+	// Error is set when the object's settings could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// RetentionSummary counts the objects of a retention report by protection
+type RetentionSummary struct {
+	Objects    int `json:"objects"`
+	Compliance int `json:"compliance"`
+	Governance int `json:"governance"`
+	LegalHolds int `json:"legalHolds"`
+	// Unprotected counts objects neither retained nor on legal hold
+	Unprotected int `json:"unprotected"`
+	Unreadable  int `json:"unreadable"`
+}
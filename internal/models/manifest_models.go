@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// UploadManifestRequest lists the files of a local folder to upload, so every upload
+// target can be presigned in one request
+type UploadManifestRequest struct {
+	// Prefix is the folder the files are uploaded into
+	Prefix string         `json:"prefix,omitempty"`
+	Files  []ManifestFile `json:"files"`
+	// ExpiresInSeconds is the lifetime of every target, 15 minutes by default
+	ExpiresInSeconds int64 `json:"expiresInSeconds,omitempty"`
+	// Policy names a configured upload policy every file is uploaded under; files are
+	// then always posted in one request
+	Policy   string            `json:"policy,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	KMSKeyID string            `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do when a key exists: fail, overwrite or rename;
+	// defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// ManifestFile is one file of an upload manifest
+type ManifestFile struct {
+	// Path is the file's path relative to the folder, with / or \ separators
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// ContentType defaults to the type of the file's extension
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// UploadManifestResponse holds an upload target for every file of a manifest, in the
+// order of the manifest, all expiring at the same time
+type UploadManifestResponse struct {
+	Targets   []UploadTarget `json:"targets"`
+	ExpiresAt time.Time      `json:"expiresAt"`
+}
+
+// UploadTarget is where to upload one file: a presigned POST for files uploaded in one
+// request, or a multipart upload with presigned part URLs for large files. Multipart
+// uploads are completed with the part ETags through the multipart upload endpoints.
+type UploadTarget struct {
+	Path string `json:"path"`
+	Key  string `json:"key"`
+
+	Post *PresignedPostURLResponse `json:"post,omitempty"`
+
+	UploadID string    `json:"uploadId,omitempty"`
+	PartSize int64     `json:"partSize,omitempty"`
+	Parts    []PartURL `json:"parts,omitempty"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// CreateMultipartUploadRequest starts a multipart upload, whose parts the client uploads
+// straight to S3 with presigned URLs
+type CreateMultipartUploadRequest struct {
+	Key         string `json:"key"`
+	ContentType string `json:"contentType,omitempty"`
+	KMSKeyID    string `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do when the key exists: fail, overwrite or rename;
+	// defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// MultipartUpload identifies a multipart upload in progress
+type MultipartUpload struct {
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key"`
+	UploadID string `json:"uploadId"`
+}
+
+// PartURLsRequest asks for presigned URLs to upload parts of a multipart upload
+type PartURLsRequest struct {
+	Key              string  `json:"key"`
+	PartNumbers      []int32 `json:"partNumbers"`
+	ExpiresInSeconds int64   `json:"expiresInSeconds,omitempty"`
+}
+
+// PartURL is a presigned URL to PUT one part to
+type PartURL struct {
+	PartNumber int32  `json:"partNumber"`
+	URL        string `json:"url"`
+}
+
+// PartURLsResponse holds presigned part URLs, all expiring at the same time
+type PartURLsResponse struct {
+	UploadID  string    `json:"uploadId"`
+	Parts     []PartURL `json:"parts"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// UploadedPart is a part S3 has received
+type UploadedPart struct {
+	PartNumber   int32     `json:"partNumber"`
+	ETag         string    `json:"etag"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// ListPartsResponse lists the parts of a multipart upload received so far, so an
+// interrupted upload can resume with the missing ones
+type ListPartsResponse struct {
+	UploadID string         `json:"uploadId"`
+	Key      string         `json:"key"`
+	Parts    []UploadedPart `json:"parts"`
+}
+
+// CompletedPart names a part to assemble, with the ETag S3 returned when it was uploaded
+type CompletedPart struct {
+	PartNumber int32  `json:"partNumber"`
+	ETag       string `json:"etag"`
+}
+
+// CompleteMultipartUploadRequest assembles the uploaded parts into the object
+type CompleteMultipartUploadRequest struct {
+	Key   string          `json:"key"`
+	Parts []CompletedPart `json:"parts"`
+}
+
+// CompleteMultipartUploadResponse describes the object a multipart upload created
+type CompleteMultipartUploadResponse struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	ETag      string `json:"etag"`
+	VersionID string `json:"versionId,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// NotificationTest is the result of writing and deleting a canary object to check a
+// bucket's event notifications
+type NotificationTest struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	// Destinations lists the bucket's notification destinations and which of the
+	// canary's events each is configured to receive
+	Destinations []NotificationDestination `json:"destinations"`
+	// EventBridge is set when the bucket sends all its events to EventBridge
+	EventBridge bool `json:"eventBridge"`
+}
+
+// NotificationDestination is an SQS queue, SNS topic or Lambda function that a bucket
+// sends events to
+type NotificationDestination struct {
+	// Type is queue, topic or lambda
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	ARN  string `json:"arn"`
+	// Created and Removed are set when the destination's event types and key filters
+	// match the canary's s3:ObjectCreated:Put and s3:ObjectRemoved:Delete events
+	Created bool `json:"created"`
+	Removed bool `json:"removed"`
+}
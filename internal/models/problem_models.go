@@ -0,0 +1,15 @@
+package models
+
+// ProblemContentType is the media type of Problem responses
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details response
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RequestID identifies the request in the server logs
+	RequestID string `json:"requestId,omitempty"`
+}
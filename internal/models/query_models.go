@@ -0,0 +1,26 @@
+package models
+
+// ObjectQueryRequest runs an S3 Select SQL expression over a CSV, JSON or Parquet object
+type ObjectQueryRequest struct {
+	// Expression is the SQL, e.g. SELECT s.id FROM S3Object s WHERE s.total > '100'
+	Expression string `json:"expression"`
+	// Format is csv, json or parquet; by default that of the key's extension
+	Format string `json:"format,omitempty"`
+	// Compression is none, gzip or bzip2; by default that of the key's extension
+	Compression string `json:"compression,omitempty"`
+	// CSVHeader is use (the default), ignore or none: whether the first line names the
+	// columns that the expression may refer to
+	CSVHeader string `json:"csvHeader,omitempty"`
+	// Delimiter separates CSV fields, a comma by default and a tab for .tsv keys
+	Delimiter string `json:"delimiter,omitempty"`
+	// JSONType is lines for one document per line, the default for .jsonl and
+	// .ndjson keys, or document
+	JSONType string `json:"jsonType,omitempty"`
+}
+
+// ObjectQueryStats are the bytes S3 read and returned for a query
+type ObjectQueryStats struct {
+	BytesScanned   int64 `json:"bytesScanned"`
+	BytesProcessed int64 `json:"bytesProcessed"`
+	BytesReturned  int64 `json:"bytesReturned"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RecentLocation is a bucket, or a prefix within one, that a user recently visited
+type RecentLocation struct {
+	Bucket string `json:"bucket"`
+	// Prefix is empty for the bucket root and ends in a slash otherwise
+	Prefix    string    `json:"prefix"`
+	VisitedAt time.Time `json:"visitedAt"`
+}
+
+// RecentFile is an object that a user recently downloaded or uploaded
+type RecentFile struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	// Action is "download" or "upload"
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+}
+
+// RecentResponse lists a user's recent locations and files, most recent first
+type RecentResponse struct {
+	Locations []RecentLocation `json:"locations"`
+	Files     []RecentFile     `json:"files"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// StorageReport breaks down the objects of a bucket or prefix by storage class and age
+type StorageReport struct {
+	Bucket  string `json:"bucket"`
+	Prefix  string `json:"prefix"`
+	Objects int64  `json:"objects"`
+	Bytes   int64  `json:"bytes"`
+	// StorageClasses lists the storage classes in use, largest first
+	StorageClasses []StorageClassUsage `json:"storageClasses"`
+	// Ages groups all objects by time since last modification, youngest first
+	Ages []AgeGroupUsage `json:"ages"`
+	// Truncated is set when the prefix holds more objects than reports.maxObjects, in
+	// which case the report covers only the first of them
+	Truncated bool `json:"truncated"`
+	// ScanLimit is the most objects listed for the report
+	ScanLimit   int       `json:"scanLimit"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// StorageClassUsage is the part of a storage report for one storage class
+type StorageClassUsage struct {
+	StorageClass string          `json:"storageClass"`
+	Objects      int64           `json:"objects"`
+	Bytes        int64           `json:"bytes"`
+	Ages         []AgeGroupUsage `json:"ages"`
+}
+
+// AgeGroupUsage counts the objects last modified between MinDays and MaxDays ago
+type AgeGroupUsage struct {
+	// Label reads like "30-90d", or "365d+" for the oldest group
+	Label   string `json:"label"`
+	MinDays int    `json:"minDays"`
+	// MaxDays is omitted for the oldest group, which has no upper bound
+	MaxDays int   `json:"maxDays,omitempty"`
+	Objects int64 `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+}
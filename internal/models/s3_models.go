@@ -1,11 +1,23 @@
 package models
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 // Bucket represents an S3 bucket
 type Bucket struct {
 	Name         string    `json:"name"`
 	CreationDate time.Time `json:"creationDate"`
+	// DisplayName, Description, Group and Tags come from the buckets setting
+	DisplayName string   `json:"displayName,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Group       string   `json:"group,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// ObjectLambda marks a read-only view through an S3 Object Lambda access point
+	ObjectLambda bool `json:"objectLambda,omitempty"`
+	// Anonymous marks a public bucket read without credentials
+	Anonymous bool `json:"anonymous,omitempty"`
 }
 
 // ObjectInfo represents an S3 object or prefix (folder)
@@ -18,14 +30,42 @@ type ObjectInfo struct {
 	LastModified time.Time `json:"lastModified"`
 	StorageClass string    `json:"storageClass"`
 	ETag         string    `json:"etag"`
+	// VersionID is the version listed, in listings as of a past time
+	VersionID string       `json:"versionId,omitempty"`
+	Links     *ObjectLinks `json:"links,omitempty"`
+}
+
+// ObjectLinks holds ready-to-use API URLs related to a listed object or folder
+type ObjectLinks struct {
+	Metadata     string `json:"metadata,omitempty"`
+	PresignedURL string `json:"presignedUrl,omitempty"`
+	Children     string `json:"children,omitempty"`
+}
+
+// Pagination is the cursor-based pagination envelope shared by all list endpoints
+type Pagination struct {
+	Cursor  string `json:"cursor,omitempty"`
+	HasMore bool   `json:"hasMore"`
+	// PageSize is the page size applied, after capping the one asked for
+	PageSize int `json:"pageSize"`
+	// MaxPageSize is the largest page size the endpoint accepts
+	MaxPageSize   int    `json:"maxPageSize,omitempty"`
+	TotalEstimate *int64 `json:"totalEstimate,omitempty"`
 }
 
 // ListObjectsResponse is the response for listing objects in a bucket
 type ListObjectsResponse struct {
 	Objects     []ObjectInfo `json:"objects"`
-	IsTruncated bool         `json:"isTruncated"`
 	ItemsInPage int          `json:"itemsInPage"`
-	PageSize    int          `json:"pageSize"`
+	Pagination  Pagination   `json:"pagination"`
+	Links       *ListLinks   `json:"links,omitempty"`
+}
+
+// ListLinks holds navigation URLs for a listing response
+type ListLinks struct {
+	Self   string `json:"self"`
+	Next   string `json:"next,omitempty"`
+	Parent string `json:"parent,omitempty"`
 }
 
 // CreateFolderRequest represents the request body for creating a folder
@@ -34,12 +74,29 @@ type CreateFolderRequest struct {
 	Type string `json:"type" validate:"required,eq=folder"`
 }
 
+// DownloadZipRequest represents the request body for downloading selected objects as a ZIP
+type DownloadZipRequest struct {
+	Keys []string `json:"keys"`
+	Name string   `json:"name,omitempty"`
+}
+
 // PresignedPostURLRequest represents the request body for generating a presigned POST URL
 type PresignedPostURLRequest struct {
 	Key              string `json:"key" validate:"required"`
 	ContentType      string `json:"contentType" validate:"required"`
 	ExpiresInSeconds int64  `json:"expiresInSeconds,omitempty"`
 	MaxSizeBytes     int64  `json:"maxSizeBytes,omitempty"`
+	// Policy names a configured upload policy, whose limits replace ExpiresInSeconds
+	// and MaxSizeBytes
+	Policy string `json:"policy,omitempty"`
+	// Tags gives values for the tags the policy requires
+	Tags map[string]string `json:"tags,omitempty"`
+	// KMSKeyID encrypts the upload with SSE-KMS using this key, unless the policy
+	// sets the encryption
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do when the key exists: fail, overwrite or rename;
+	// defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
 }
 
 // PresignedPostURLResponse represents the response for generating a presigned POST URL
@@ -53,6 +110,14 @@ type BucketDetail struct {
 	Name         string    `json:"name"`
 	Region       string    `json:"region"`
 	CreationDate time.Time `json:"creationDate"`
+	ObjectLambda bool      `json:"objectLambda,omitempty"`
+	Anonymous    bool      `json:"anonymous,omitempty"`
+}
+
+// DownloadLink tells a client where to download an object from
+type DownloadLink struct {
+	URL  string `json:"url"`
+	Mode string `json:"mode"`
 }
 
 // ObjectMetadata represents detailed metadata for an S3 object
@@ -65,5 +130,291 @@ type ObjectMetadata struct {
 	StorageClass         string            `json:"storageClass"`
 	UserMetadata         map[string]string `json:"userMetadata,omitempty"`
 	ServerSideEncryption string            `json:"serverSideEncryption,omitempty"`
-	VersionId            string            `json:"versionId,omitempty"`
+	// SSECustomerAlgorithm is set for objects encrypted with a customer-provided key
+	SSECustomerAlgorithm string `json:"sseCustomerAlgorithm,omitempty"`
+	VersionId            string `json:"versionId,omitempty"`
+	// Tags are left out when they cannot be read
+	Tags map[string]string `json:"tags,omitempty"`
+	// Grants are the object's effective ACL grants, left out when they cannot be read;
+	// Public is set when one of them gives everyone or any AWS account access
+	Grants []ACLGrant `json:"grants,omitempty"`
+	Public bool       `json:"public,omitempty"`
+}
+
+// BatchOperation describes a single read operation inside a batch request
+type BatchOperation struct {
+	ID        string `json:"id,omitempty"`
+	Op        string `json:"op" validate:"required,oneof=head list presign"`
+	Bucket    string `json:"bucket" validate:"required"`
+	Key       string `json:"key,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Delimiter string `json:"delimiter,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	PageSize  int32  `json:"pageSize,omitempty"`
+	ExpiresIn int64  `json:"expiresIn,omitempty"`
+	Fields    string `json:"fields,omitempty"`
+}
+
+// BatchRequest represents the request body for executing several read operations at once
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" validate:"required"`
+}
+
+// BatchResult holds the outcome of a single batch operation
+type BatchResult struct {
+	ID     string      `json:"id,omitempty"`
+	Op     string      `json:"op"`
+	Status int         `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// BatchResponse is the response for a batch request, with results in request order
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// ChangeEvent describes a change to an object observed by the explorer
+type ChangeEvent struct {
+	Sequence int64     `json:"sequence"`
+	Type     string    `json:"type"`
+	Bucket   string    `json:"bucket"`
+	Key      string    `json:"key"`
+	Time     time.Time `json:"time"`
+}
+
+// ChangesResponse is the response for long-polling bucket changes
+type ChangesResponse struct {
+	Events []ChangeEvent `json:"events"`
+	Cursor string        `json:"cursor"`
+	Resync bool          `json:"resync"`
+}
+
+// ObjectPreview holds the leading part of a text-like object for inline display
+type ObjectPreview struct {
+	Key          string `json:"key"`
+	ContentType  string `json:"contentType"`
+	Charset      string `json:"charset"`
+	Size         int64  `json:"size"`
+	BytesRead    int64  `json:"bytesRead"`
+	Truncated    bool   `json:"truncated"`
+	Decompressed bool   `json:"decompressed,omitempty"`
+	Content      string `json:"content"`
+}
+
+// FormattedPreview holds a validated, pretty-printed JSON or YAML object
+type FormattedPreview struct {
+	Key     string        `json:"key"`
+	Format  string        `json:"format"`
+	Size    int64         `json:"size"`
+	Valid   bool          `json:"valid"`
+	Errors  []SyntaxError `json:"errors"`
+	Content string        `json:"content"`
+}
+
+// SyntaxError describes where a structured document failed to parse
+type SyntaxError struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ObjectTail holds the end of a text object, starting at a line boundary
+type ObjectTail struct {
+	Key         string `json:"key"`
+	ContentType string `json:"contentType"`
+	Charset     string `json:"charset"`
+	Size        int64  `json:"size"`
+	Offset      int64  `json:"offset"`
+	BytesRead   int64  `json:"bytesRead"`
+	Truncated   bool   `json:"truncated"`
+	Content     string `json:"content"`
+}
+
+// ObjectBytes holds a byte range of an object, as a hex dump or as text
+type ObjectBytes struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	// Offset is where the range starts; Length is the number of bytes read, less than
+	// asked for when the object ends first
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Mode   string `json:"mode"`
+	// DetectedType is the content type the first bytes suggest, for ranges at the start
+	DetectedType string `json:"detectedType,omitempty"`
+	Content      string `json:"content"`
+}
+
+// ObjectContent holds the full text of an object loaded for editing
+type ObjectContent struct {
+	Key         string `json:"key"`
+	ContentType string `json:"contentType"`
+	ETag        string `json:"etag"`
+	Content     string `json:"content"`
+}
+
+// SaveObjectContentRequest represents the request body for saving an edited text object
+type SaveObjectContentRequest struct {
+	Content string `json:"content"`
+}
+
+// UpdateObjectMetadataRequest changes the headers and user metadata of an object. Nil
+// fields keep their current value; Metadata, when set, replaces all user metadata and
+// an empty CacheControl removes the header.
+type UpdateObjectMetadataRequest struct {
+	ContentType  *string           `json:"contentType,omitempty"`
+	CacheControl *string           `json:"cacheControl,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// Thumbnail holds an encoded thumbnail image
+type Thumbnail struct {
+	ContentType string
+	Data        []byte
+}
+
+// ObjectStream holds an object body being streamed to a client together with its
+// response metadata. Callers must close Body.
+type ObjectStream struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+	ContentRange  string
+	ETag          string
+	LastModified  time.Time
+	// Decompressed is set when a gzip-compressed object is being served decompressed
+	Decompressed bool
+}
+
+// TablePreview holds the leading rows of a delimited text object parsed into columns
+type TablePreview struct {
+	Key       string     `json:"key"`
+	Delimiter string     `json:"delimiter"`
+	Columns   []string   `json:"columns"`
+	Rows      [][]string `json:"rows"`
+	RowCount  int        `json:"rowCount"`
+	Truncated bool       `json:"truncated"`
+}
+
+// ArchiveEntry describes a single file or directory inside an archive object
+type ArchiveEntry struct {
+	Name           string    `json:"name"`
+	Size           int64     `json:"size"`
+	CompressedSize int64     `json:"compressedSize,omitempty"`
+	Modified       time.Time `json:"modified"`
+	IsDir          bool      `json:"isDir"`
+}
+
+// ArchiveListing is the response for listing the contents of an archive object
+type ArchiveListing struct {
+	Key        string         `json:"key"`
+	Format     string         `json:"format"`
+	Entries    []ArchiveEntry `json:"entries"`
+	EntryCount int            `json:"entryCount"`
+	Truncated  bool           `json:"truncated"`
+}
+
+// ImageMetadata holds the dimensions and EXIF details of an image object
+type ImageMetadata struct {
+	Key         string          `json:"key"`
+	ContentType string          `json:"contentType"`
+	Size        int64           `json:"size"`
+	Format      string          `json:"format,omitempty"`
+	Width       int             `json:"width,omitempty"`
+	Height      int             `json:"height,omitempty"`
+	TakenAt     *time.Time      `json:"takenAt,omitempty"`
+	CameraMake  string          `json:"cameraMake,omitempty"`
+	CameraModel string          `json:"cameraModel,omitempty"`
+	Orientation int             `json:"orientation,omitempty"`
+	GPS         *GPSCoordinates `json:"gps,omitempty"`
+	GPSHidden   bool            `json:"gpsHidden,omitempty"`
+}
+
+// GPSCoordinates is a location in decimal degrees
+type GPSCoordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// MediaMetadata holds the container and stream details of an audio or video object
+type MediaMetadata struct {
+	Key         string        `json:"key"`
+	ContentType string        `json:"contentType"`
+	Size        int64         `json:"size"`
+	Format      string        `json:"format"`
+	Duration    float64       `json:"duration"`
+	BitRate     int64         `json:"bitRate,omitempty"`
+	Width       int           `json:"width,omitempty"`
+	Height      int           `json:"height,omitempty"`
+	Streams     []MediaStream `json:"streams"`
+}
+
+// MediaStream describes a single audio, video or subtitle stream
+type MediaStream struct {
+	Index      int     `json:"index"`
+	Type       string  `json:"type"`
+	Codec      string  `json:"codec"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	FrameRate  float64 `json:"frameRate,omitempty"`
+	SampleRate int     `json:"sampleRate,omitempty"`
+	Channels   int     `json:"channels,omitempty"`
+	Duration   float64 `json:"duration,omitempty"`
+}
+
+// PDFMetadata holds the document information and page count of a PDF object
+type PDFMetadata struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size"`
+	Pages     int    `json:"pages"`
+	Title     string `json:"title,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	Creator   string `json:"creator,omitempty"`
+	Producer  string `json:"producer,omitempty"`
+	Created   string `json:"created,omitempty"`
+	Modified  string `json:"modified,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// ScanResult holds the outcome of an antivirus scan
+type ScanResult struct {
+	Key       string    `json:"key"`
+	Status    string    `json:"status"`
+	Signature string    `json:"signature,omitempty"`
+	ScannedAt time.Time `json:"scannedAt"`
+}
+
+// LegacyBucketsResponse is the bucket list returned by the original API
+type LegacyBucketsResponse struct {
+	Buckets []string `json:"buckets"`
+}
+
+// LegacyListObjectsResponse is the object listing returned by the original API
+type LegacyListObjectsResponse struct {
+	Items                 []LegacyObjectItem `json:"items"`
+	NextContinuationToken string             `json:"nextContinuationToken,omitempty"`
+	IsTruncated           bool               `json:"isTruncated"`
+	TotalItems            int32              `json:"totalItems"`
+	PageSize              int32              `json:"pageSize"`
+}
+
+// LegacyObjectItem is an object or folder in a LegacyListObjectsResponse
+type LegacyObjectItem struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	LastModified string `json:"lastModified,omitempty"`
+	IsFolder     bool   `json:"isFolder"`
+	Type         string `json:"type"` // "folder" or "file"
+	ContentType  string `json:"contentType,omitempty"`
+}
+
+// ListingRecord is one object in a listing export
+type ListingRecord struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	StorageClass string    `json:"storageClass"`
+	ETag         string    `json:"etag"`
 }
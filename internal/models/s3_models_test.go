@@ -260,3 +260,49 @@ func TestObjectInfo_ExistingModel(t *testing.T) {
 	assert.Equal(t, obj.StorageClass, unmarshaled.StorageClass)
 	assert.Equal(t, obj.ETag, unmarshaled.ETag)
 }
+
+func TestBatchRequest_JSON(t *testing.T) {
+	body := `{"operations":[{"id":"1","op":"head","bucket":"test-bucket","key":"a.txt"},{"op":"list","bucket":"test-bucket","prefix":"docs/","pageSize":50}]}`
+
+	var req BatchRequest
+	err := json.Unmarshal([]byte(body), &req)
+	assert.NoError(t, err)
+	assert.Len(t, req.Operations, 2)
+	assert.Equal(t, "head", req.Operations[0].Op)
+	assert.Equal(t, "a.txt", req.Operations[0].Key)
+	assert.Equal(t, "docs/", req.Operations[1].Prefix)
+	assert.Equal(t, int32(50), req.Operations[1].PageSize)
+
+	result := BatchResult{ID: "1", Op: "head", Status: 404, Error: "Object not found"}
+	jsonBytes, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","op":"head","status":404,"error":"Object not found"}`, string(jsonBytes))
+}
+
+func TestPagination_JSON(t *testing.T) {
+	total := int64(42)
+	tests := []struct {
+		name     string
+		page     Pagination
+		expected string
+	}{
+		{
+			name:     "more pages",
+			page:     Pagination{Cursor: "eyJ0IjoiYWJjIn0", HasMore: true, PageSize: 100},
+			expected: `{"cursor":"eyJ0IjoiYWJjIn0","hasMore":true,"pageSize":100}`,
+		},
+		{
+			name:     "last page with total",
+			page:     Pagination{HasMore: false, PageSize: 100, TotalEstimate: &total},
+			expected: `{"hasMore":false,"pageSize":100,"totalEstimate":42}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBytes, err := json.Marshal(tt.page)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(jsonBytes))
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Selection is a set of objects and prefixes, possibly from several buckets, that
+// operations are applied to as a whole
+type Selection struct {
+	ID        string          `json:"id"`
+	Items     []SelectionItem `json:"items"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// SelectionItem is an object, or every object under a prefix when Key ends in a slash
+type SelectionItem struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+// CreateSelectionRequest is the body of a selection creation request
+type CreateSelectionRequest struct {
+	Items []SelectionItem `json:"items"`
+}
+
+// UpdateSelectionRequest adds items to and removes items from a selection
+type UpdateSelectionRequest struct {
+	Add    []SelectionItem `json:"add"`
+	Remove []SelectionItem `json:"remove"`
+}
+
+// CopySelectionRequest is the body of a request to copy or move a selection
+type CopySelectionRequest struct {
+	TargetBucket string `json:"targetBucket"`
+	TargetPrefix string `json:"targetPrefix"`
+	// KMSKeyID encrypts the copies with SSE-KMS using this key
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+	// Conflict is what to do with each object whose target key exists: fail,
+	// overwrite or rename; defaults to uploads.conflict
+	Conflict string `json:"conflict,omitempty"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Share is a short link that redirects to a freshly presigned URL for an object
+type Share struct {
+	Token     string `json:"token"`
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	// Size is the size of the object when the link was created
+	Size int64 `json:"size"`
+	// URL is the link to hand out; filled in by the API layer
+	URL string `json:"url,omitempty"`
+	// CreatedBy is the user who created the link, empty when not identified; only they
+	// and admins may see its statistics or revoke it
+	CreatedBy string     `json:"createdBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreateShareRequest is the body of a share creation request
+type CreateShareRequest struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId"`
+	// ExpiresIn is the link's lifetime in seconds; zero uses the configured default
+	ExpiresIn int64 `json:"expiresIn"`
+}
+
+// SharesResponse lists share links
+type SharesResponse struct {
+	Shares []Share `json:"shares"`
+}
+
+// ShareAccess records one use of a share link
+type ShareAccess struct {
+	Time      time.Time `json:"time"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	// Bytes is the size of the object when the link was created, Share.Size, not what
+	// was downloaded: downloads go straight to S3, so neither the object's current size
+	// nor whether the client fetched all of it is known
+	Bytes int64 `json:"bytes"`
+}
+
+// ShareStats summarises the use of a share link
+type ShareStats struct {
+	Token    string `json:"token"`
+	Accesses int64  `json:"accesses"`
+	// Bytes sums the Bytes of every access: the object's size at creation once per use
+	Bytes        int64      `json:"bytes"`
+	LastAccessAt *time.Time `json:"lastAccessAt,omitempty"`
+	// Recent lists the latest accesses, newest first
+	Recent []ShareAccess `json:"recent"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// BulkTaggingRequest changes the tags of every object under a prefix, or of a list of
+// objects. Tags in Set are added or overwritten and tags named in Remove are deleted;
+// an object's other tags are kept.
+type BulkTaggingRequest struct {
+	// Prefix selects every object under it, "" for the whole bucket, unless Keys is set
+	Prefix string            `json:"prefix,omitempty"`
+	Keys   []string          `json:"keys,omitempty"`
+	Set    map[string]string `json:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// ObjectTags are the tags of an object
+type ObjectTags struct {
+	Key       string            `json:"key"`
+	VersionID string            `json:"versionId,omitempty"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// PutObjectTagsRequest replaces all tags of an object; an empty set removes them
+type PutObjectTagsRequest struct {
+	Tags map[string]string `json:"tags"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// TreeNode is a folder in a bucket tree, with the number of folders and objects
+// directly inside it
+type TreeNode struct {
+	Prefix  string `json:"prefix"`
+	Name    string `json:"name"`
+	Folders int    `json:"folders"`
+	Objects int    `json:"objects"`
+	// Truncated is set when the folder has more entries than were counted, so the
+	// counts are lower bounds
+	Truncated bool `json:"truncated,omitempty"`
+	// Children holds the subfolders, or is omitted when the tree stops at this folder
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// TreeResponse is a folder tree of a bucket, starting at a prefix
+type TreeResponse struct {
+	Bucket string    `json:"bucket"`
+	Depth  int       `json:"depth"`
+	Root   *TreeNode `json:"root"`
+	// Truncated is set when the tree has more folders than are returned in one response
+	Truncated bool `json:"truncated"`
+	// MaxFolders is the most folders returned in one response
+	MaxFolders int `json:"maxFolders"`
+}
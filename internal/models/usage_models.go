@@ -0,0 +1,32 @@
+package models
+
+// Sources of usage history
+const (
+	// UsageSourceReport marks sizes counted by a storage report or cost estimate of a
+	// whole bucket
+	UsageSourceReport = "report"
+	// UsageSourceStorageLens marks sizes read from S3 Storage Lens exports
+	UsageSourceStorageLens = "storageLens"
+)
+
+// UsagePoint is the size of a bucket on one day
+type UsagePoint struct {
+	// Date is the day the size applies to, as YYYY-MM-DD in UTC
+	Date    string `json:"date"`
+	Objects int64  `json:"objects"`
+	Bytes   int64  `json:"bytes"`
+	Source  string `json:"source"`
+}
+
+// UsageSeries is the size history of a bucket, oldest first
+type UsageSeries struct {
+	Bucket string       `json:"bucket"`
+	Points []UsagePoint `json:"points"`
+}
+
+// UsageResponse holds the size history of every bucket with any recorded
+type UsageResponse struct {
+	From    string        `json:"from,omitempty"`
+	To      string        `json:"to,omitempty"`
+	Buckets []UsageSeries `json:"buckets"`
+}
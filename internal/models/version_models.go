@@ -0,0 +1,23 @@
+package models
+
+// VersionInfo describes the running build and what it is configured to use
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	Profile   string `json:"profile,omitempty"`
+	// Features lists the endpoint groups from the features config and whether they are on
+	Features map[string]bool `json:"features"`
+	Backends VersionBackends `json:"backends"`
+}
+
+// VersionBackends describes the services and tools the server is configured to use
+type VersionBackends struct {
+	S3Region string `json:"s3Region"`
+	// ThumbnailCache is "disk" or "bucket"
+	ThumbnailCache string `json:"thumbnailCache"`
+	Antivirus      bool   `json:"antivirus"`
+	FFprobe        bool   `json:"ffprobe"`
+	PDF            bool   `json:"pdf"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ObjectVersion is one version of an object in a versioned bucket, or a delete marker
+// left where an object was deleted
+type ObjectVersion struct {
+	Key       string `json:"key"`
+	VersionID string `json:"versionId"`
+	// IsLatest is set on the current version of a key; when that is a delete marker the
+	// key looks deleted in plain listings
+	IsLatest     bool      `json:"isLatest"`
+	DeleteMarker bool      `json:"deleteMarker"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	StorageClass string    `json:"storageClass,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// ListVersionsResponse is the response for listing object versions, newest first per
+// key
+type ListVersionsResponse struct {
+	Versions []ObjectVersion `json:"versions"`
+	// Folders lists the prefixes grouped by the delimiter, when one was given
+	Folders    []string   `json:"folders,omitempty"`
+	Pagination Pagination `json:"pagination"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// Workspace is a tenant of a shared deployment. Its members only see its buckets and
+// the share links, jobs, annotations, users and teams that belong to it.
+type Workspace struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Buckets are bucket names or patterns such as logs-*
+	Buckets []string `json:"buckets"`
+}
+
+// WorkspacesResponse lists the workspaces the current user is a member of
+type WorkspacesResponse struct {
+	// Current is the workspace the request was answered in
+	Current    string      `json:"current"`
+	Workspaces []Workspace `json:"workspaces"`
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the pgx driver
+	_ "modernc.org/sqlite"             // registers the sqlite driver
+)
+
+// migrations are applied in order, each once, and recorded in schema_migrations. They
+// must work on both SQLite and PostgreSQL; append new ones, never edit applied ones.
+var migrations = []string{
+	`CREATE TABLE documents (
+		name       TEXT PRIMARY KEY,
+		data       TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`,
+	`ALTER TABLE documents ADD COLUMN version BIGINT NOT NULL DEFAULT 1`,
+}
+
+// sqlStore keeps documents in a SQL database
+type sqlStore struct {
+	db *sql.DB
+	// postgres selects $n placeholders instead of ?
+	postgres bool
+}
+
+// openSQL opens a database and applies pending migrations
+func openSQL(ctx context.Context, driver, dsn string) (*sqlStore, error) {
+	s := &sqlStore{postgres: driver == DriverPostgres}
+
+	name := "sqlite"
+	if s.postgres {
+		name = "pgx"
+	} else {
+		// Wait for, rather than fail on, a database locked by another connection
+		dsn = sqliteDSN(dsn)
+	}
+
+	db, err := sql.Open(name, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if !s.postgres {
+		// SQLite allows one writer at a time
+		db.SetMaxOpenConns(1)
+	}
+	s.db = db
+
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load decodes the document saved under name into v and returns its version
+func (s *sqlStore) Load(ctx context.Context, name string, v any) (int64, error) {
+	var data string
+	var version int64
+	err := s.db.QueryRowContext(ctx, s.query(`SELECT data, version FROM documents WHERE name = ?`), name).Scan(&data, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version, json.Unmarshal([]byte(data), v)
+}
+
+// Version returns the version of the document saved under name, or ErrNotFound
+func (s *sqlStore) Version(ctx context.Context, name string) (int64, error) {
+	var version int64
+	err := s.db.QueryRowContext(ctx, s.query(`SELECT version FROM documents WHERE name = ?`), name).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return version, err
+}
+
+// Save replaces the document saved under name with v if it is still at version, 0 for
+// a document never saved, and returns its new version. The version check and the
+// write are one statement, so of two instances saving the same version one gets
+// ErrConflict.
+func (s *sqlStore) Save(ctx context.Context, name string, v any, version int64) (int64, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	var result sql.Result
+	if version == 0 {
+		result, err = s.db.ExecContext(ctx, s.query(`INSERT INTO documents (name, data, updated_at, version) VALUES (?, ?, ?, 1)
+			ON CONFLICT (name) DO NOTHING`), name, string(data), now)
+	} else {
+		result, err = s.db.ExecContext(ctx, s.query(`UPDATE documents SET data = ?, updated_at = ?, version = version + 1
+			WHERE name = ? AND version = ?`), string(data), now, name, version)
+	}
+	if err != nil {
+		return 0, err
+	}
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if saved == 0 {
+		return 0, ErrConflict
+	}
+	return version + 1, nil
+}
+
+// Close closes the database
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// migrate applies the migrations the database has not seen yet, each in its own
+// transaction
+func (s *sqlStore) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	var applied int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&applied); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	for version := applied + 1; version <= len(migrations); version++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, s.query(`INSERT INTO schema_migrations (version) VALUES (?)`), version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// query rewrites ? placeholders as $1, $2, ... for PostgreSQL
+func (s *sqlStore) query(q string) string {
+	if !s.postgres {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqliteDSN adds a busy timeout to a SQLite file name or URI unless it sets one
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "busy_timeout") {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=busy_timeout(5000)"
+}
@@ -0,0 +1,60 @@
+// Package store keeps the data the explorer owns, as opposed to the objects it browses:
+// share links, bookmarks, annotations, recent history and job history. Each kind of
+// data is saved as one JSON document under a name, in SQLite by default or in
+// PostgreSQL for deployments whose instances share their state. Documents are
+// versioned, so an instance saving over a document another instance changed since it
+// last read it is told so instead of overwriting it.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"explorer451/internal/config"
+)
+
+// Drivers selectable with store.driver
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	// DriverNone keeps data in memory only, so it is lost on restart
+	DriverNone = "none"
+)
+
+var (
+	// ErrNotFound is returned by Load when nothing was saved under a name
+	ErrNotFound = errors.New("not found in store")
+	// ErrConflict is returned by Save when the document is no longer at the version
+	// the caller last read
+	ErrConflict = errors.New("changed in store since it was read")
+)
+
+// Store saves and loads named, versioned JSON documents
+type Store interface {
+	// Load decodes the document saved under name into v and returns its version
+	Load(ctx context.Context, name string, v any) (int64, error)
+	// Version returns the version of the document saved under name without reading it
+	Version(ctx context.Context, name string) (int64, error)
+	// Save replaces the document saved under name with v if it is still at version, 0
+	// for a document never saved, and returns its new version
+	Save(ctx context.Context, name string, v any, version int64) (int64, error)
+	Close() error
+}
+
+// Open connects to the configured store and brings its schema up to date. It returns
+// nil, without error, for DriverNone.
+func Open(ctx context.Context, cfg config.StoreConfig) (Store, error) {
+	switch cfg.Driver {
+	case DriverNone:
+		return nil, nil
+	case DriverSQLite, DriverPostgres:
+		s, err := openSQL(ctx, cfg.Driver, cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown store driver %q", cfg.Driver)
+	}
+}
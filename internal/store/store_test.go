@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"explorer451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.StoreConfig{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "explorer451.db")}
+
+	s, err := Open(ctx, cfg)
+	require.NoError(t, err)
+
+	var got map[string]int
+	_, err = s.Load(ctx, "counts", &got)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Version(ctx, "counts")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	version, err := s.Save(ctx, "counts", map[string]int{"a": 1}, 0)
+	require.NoError(t, err)
+	version, err = s.Save(ctx, "counts", map[string]int{"a": 2, "b": 3}, version)
+	require.NoError(t, err)
+	loaded, err := s.Load(ctx, "counts", &got)
+	require.NoError(t, err)
+	assert.Equal(t, version, loaded)
+	assert.Equal(t, map[string]int{"a": 2, "b": 3}, got)
+	current, err := s.Version(ctx, "counts")
+	require.NoError(t, err)
+	assert.Equal(t, version, current)
+
+	// Saving over a version another writer replaced, or creating a document that
+	// exists, conflicts and leaves the document alone
+	_, err = s.Save(ctx, "counts", map[string]int{}, version-1)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = s.Save(ctx, "counts", map[string]int{}, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+	require.NoError(t, s.Close())
+
+	// Reopening applies no migration twice and keeps the data
+	s, err = Open(ctx, cfg)
+	require.NoError(t, err)
+	defer s.Close()
+	got = nil
+	_, err = s.Load(ctx, "counts", &got)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got["b"])
+}
+
+func TestOpen_None(t *testing.T) {
+	s, err := Open(context.Background(), config.StoreConfig{Driver: DriverNone})
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	_, err = Open(context.Background(), config.StoreConfig{Driver: "mysql"})
+	assert.Error(t, err)
+}
+
+func TestQueryPlaceholders(t *testing.T) {
+	s := &sqlStore{postgres: true}
+	assert.Equal(t, "SELECT data FROM documents WHERE name = $1 AND x = $2", s.query("SELECT data FROM documents WHERE name = ? AND x = ?"))
+	assert.Equal(t, "file.db?_pragma=busy_timeout(5000)", sqliteDSN("file.db"))
+	assert.Equal(t, "file:x.db?mode=rw&_pragma=busy_timeout(5000)", sqliteDSN("file:x.db?mode=rw"))
+}
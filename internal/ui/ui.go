@@ -0,0 +1,26 @@
+// Package ui embeds the web frontend so the server can be deployed as a single binary.
+//
+// The frontend build is copied into dist by `make build-frontend` before the Go build.
+// A binary built without it serves the API only.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var assets embed.FS
+
+// Assets returns the embedded frontend build, or nil when the binary was built
+// without one
+func Assets() fs.FS {
+	dist, err := fs.Sub(assets, "dist")
+	if err != nil {
+		return nil
+	}
+	if _, err := fs.Stat(dist, "index.html"); err != nil {
+		return nil
+	}
+	return dist
+}